@@ -16,6 +16,7 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 
+	"github.com/rudderlabs/rudder-server/admin"
 	"github.com/rudderlabs/rudder-server/app"
 	"github.com/rudderlabs/rudder-server/app/cluster"
 	"github.com/rudderlabs/rudder-server/archiver"
@@ -187,6 +188,7 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 		jobsdb.WithSkipMaintenanceErr(config.GetBool("Router.jobsDB.skipMaintenanceError", false)),
 		jobsdb.WithStats(statsFactory),
 		jobsdb.WithDBHandle(dbPool),
+		jobsdb.WithReporting(reporting, types.ROUTER),
 	)
 	defer routerDB.Close()
 	batchRouterDB := jobsdb.NewForReadWrite(
@@ -196,6 +198,7 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 		jobsdb.WithSkipMaintenanceErr(config.GetBool("BatchRouter.jobsDB.skipMaintenanceError", false)),
 		jobsdb.WithStats(statsFactory),
 		jobsdb.WithDBHandle(dbPool),
+		jobsdb.WithReporting(reporting, types.BATCH_ROUTER),
 	)
 	defer batchRouterDB.Close()
 
@@ -302,6 +305,8 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 	if err != nil {
 		return fmt.Errorf("failed to create rt throttler factory: %w", err)
 	}
+	routerAdmin := router.NewAdmin()
+	admin.RegisterAdminHandler("Router", routerAdmin)
 	rtFactory := &router.Factory{
 		Logger:        routerLogger,
 		Reporting:     reporting,
@@ -318,6 +323,7 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 		Debugger:                   destinationHandle,
 		AdaptiveLimit:              adaptiveLimit,
 		PendingEventsRegistry:      pendingEventsRegistry,
+		Admin:                      routerAdmin,
 	}
 	brtFactory := &batchrouter.Factory{
 		Reporting:     reporting,
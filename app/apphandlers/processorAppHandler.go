@@ -15,6 +15,7 @@ import (
 	kithttputil "github.com/rudderlabs/rudder-go-kit/httputil"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-server/admin"
 	"github.com/rudderlabs/rudder-server/app"
 	"github.com/rudderlabs/rudder-server/app/cluster"
 	"github.com/rudderlabs/rudder-server/archiver"
@@ -29,6 +30,7 @@ import (
 	"github.com/rudderlabs/rudder-server/router/throttler"
 	schema_forwarder "github.com/rudderlabs/rudder-server/schema-forwarder"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
+	"github.com/rudderlabs/rudder-server/services/debugger/livestream"
 	transformationdebugger "github.com/rudderlabs/rudder-server/services/debugger/transformation"
 	"github.com/rudderlabs/rudder-server/services/fileuploader"
 	"github.com/rudderlabs/rudder-server/services/rmetrics"
@@ -175,6 +177,7 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 		jobsdb.WithSkipMaintenanceErr(config.GetBool("Router.jobsDB.skipMaintenanceError", false)),
 		jobsdb.WithStats(statsFactory),
 		jobsdb.WithDBHandle(dbPool),
+		jobsdb.WithReporting(reporting, types.ROUTER),
 	)
 	defer routerDB.Close()
 	batchRouterDB := jobsdb.NewForReadWrite(
@@ -184,6 +187,7 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 		jobsdb.WithSkipMaintenanceErr(config.GetBool("BatchRouter.jobsDB.skipMaintenanceError", false)),
 		jobsdb.WithStats(statsFactory),
 		jobsdb.WithDBHandle(dbPool),
+		jobsdb.WithReporting(reporting, types.BATCH_ROUTER),
 	)
 	defer batchRouterDB.Close()
 	errorDBForRead := jobsdb.NewForRead(
@@ -298,6 +302,8 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 	if err != nil {
 		return fmt.Errorf("failed to create throttler factory: %w", err)
 	}
+	routerAdmin := router.NewAdmin()
+	admin.RegisterAdminHandler("Router", routerAdmin)
 	rtFactory := &router.Factory{
 		Logger:        routerLogger,
 		Reporting:     reporting,
@@ -314,6 +320,7 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 		Debugger:                   destinationHandle,
 		AdaptiveLimit:              adaptiveLimit,
 		PendingEventsRegistry:      pendingEventsRegistry,
+		Admin:                      routerAdmin,
 	}
 	brtFactory := &batchrouter.Factory{
 		Reporting:     reporting,
@@ -383,6 +390,14 @@ func (a *processorApp) startHealthWebHandler(ctx context.Context, db *jobsdb.Han
 	srvMux := chi.NewMux()
 	srvMux.HandleFunc("/health", app.LivenessHandler(db))
 	srvMux.HandleFunc("/", app.LivenessHandler(db))
+	srvMux.HandleFunc("/metrics", rmetrics.SeriesHandler(stats.Default))
+	if livestream.Enabled(config.Default) {
+		if livestream.Authenticated(config.Default) {
+			srvMux.Handle(livestream.Path, livestream.NewHandler(ctx, livestream.Default, config.Default, a.log))
+		} else {
+			a.log.Warnn("Not serving the live debugger stream on the processor's port, since Debugger.LiveStream.token is not configured")
+		}
+	}
 	srv := &http.Server{
 		Addr:              ":" + strconv.Itoa(a.config.http.webPort),
 		Handler:           crash.Handler(srvMux),
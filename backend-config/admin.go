@@ -0,0 +1,32 @@
+package backendconfig
+
+import (
+	"fmt"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+// NewAdmin returns a new backend config admin, exposing admin functions of the provided backend config
+func NewAdmin(bc BackendConfig) *Admin {
+	return &Admin{bc: bc}
+}
+
+// Admin exposes backend config admin functions over the admin rpc interface
+type Admin struct {
+	bc BackendConfig
+}
+
+// ConfigChanges returns the last n backend config diffs, most recent first.
+// All diffs kept in the change log are returned if n is not positive.
+func (a *Admin) ConfigChanges(n int, reply *string) error {
+	changeLogger, ok := a.bc.(interface{ configChanges(n int) []ConfigDiff })
+	if !ok {
+		return fmt.Errorf("backend config %T doesn't keep a change log", a.bc)
+	}
+	formattedOutput, err := jsonrs.MarshalIndent(changeLogger.configChanges(n), "", "  ")
+	if err != nil {
+		return err
+	}
+	*reply = string(formattedOutput)
+	return nil
+}
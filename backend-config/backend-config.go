@@ -10,6 +10,7 @@ import (
 	"net/url"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -40,6 +41,7 @@ var (
 	configFromFile              bool
 	configEnvReplacementEnabled bool
 	dbCacheEnabled              bool
+	changeLogSize               int
 
 	LastSync           string
 	LastRegulationSync string
@@ -91,6 +93,7 @@ type backendConfigImpl struct {
 	curSourceJSONLock sync.RWMutex
 	usingCache        bool
 	cache             cache.Cache
+	changes           changeLog
 }
 
 func loadConfig() {
@@ -102,6 +105,7 @@ func loadConfig() {
 	configEnvReplacementEnabled = config.GetBoolVar(true, "BackendConfig.envReplacementEnabled")
 	incrementalConfigUpdates = config.GetBoolVar(false, "BackendConfig.incrementalConfigUpdates")
 	dbCacheEnabled = config.GetBoolVar(true, "BackendConfig.dbCacheEnabled")
+	changeLogSize = config.GetIntVar(100, 1, "BackendConfig.changeLogSize")
 }
 
 func Init() {
@@ -228,11 +232,16 @@ func (bc *backendConfigImpl) configUpdate(ctx context.Context) {
 			}
 		}
 		filteredSourcesJSON := filterProcessorEnabledWorkspaceConfig(sourceJSON)
+		diff := ConfigDiff{Timestamp: time.Now(), Changes: DiffConfigs(bc.curSourceJSON, sourceJSON)}
+		initial := bc.curSourceJSON == nil
 		bc.curSourceJSON = sourceJSON
 		bc.curSourceJSONLock.Unlock()
 		LastSync = time.Now().Format(time.RFC3339) // TODO fix concurrent access
 		bc.eb.Publish(string(TopicBackendConfig), sourceJSON)
 		bc.eb.Publish(string(TopicProcessConfig), filteredSourcesJSON)
+		if len(diff.Changes) > 0 {
+			bc.recordChanges(diff, initial)
+		}
 	} else {
 		bc.curSourceJSONLock.Unlock()
 	}
@@ -242,7 +251,40 @@ func (bc *backendConfigImpl) configUpdate(ctx context.Context) {
 	bc.initializedLock.Unlock()
 }
 
+// recordChanges adds the diff to the change log, logs its changes and publishes it to the config changes topic.
+// Changes of the initial config are not logged one by one, since they would include every single entity.
+func (bc *backendConfigImpl) recordChanges(diff ConfigDiff, initial bool) {
+	bc.changes.add(diff, changeLogSize)
+	pkgLogger.Infon("Backend config diff",
+		logger.NewIntField("changes", int64(len(diff.Changes))),
+		logger.NewBoolField("initial", initial),
+	)
+	if !initial {
+		for _, change := range diff.Changes {
+			pkgLogger.Infon("Backend config entity changed",
+				obskit.WorkspaceID(change.WorkspaceID),
+				logger.NewStringField("entity", string(change.Entity)),
+				logger.NewStringField("id", change.ID),
+				logger.NewStringField("name", change.Name),
+				logger.NewStringField("sourceId", change.SourceID),
+				logger.NewStringField("changeType", string(change.Type)),
+				logger.NewStringField("configKeys", strings.Join(change.ConfigKeys, ",")),
+			)
+		}
+	}
+	bc.eb.Publish(string(TopicConfigChanges), diff)
+}
+
+// configChanges returns the last n config diffs, most recent first
+func (bc *backendConfigImpl) configChanges(n int) []ConfigDiff {
+	return bc.changes.last(n)
+}
+
 func (bc *backendConfigImpl) pollConfigUpdate(ctx context.Context) {
+	var changes <-chan struct{}
+	if watcher, ok := bc.workspaceConfig.(configWatcher); ok {
+		changes = watcher.Watch(ctx)
+	}
 	for {
 		bc.configUpdate(ctx)
 
@@ -250,6 +292,7 @@ func (bc *backendConfigImpl) pollConfigUpdate(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-time.After(pollInterval.Load()):
+		case <-changes:
 		}
 	}
 }
@@ -268,6 +311,8 @@ Available topics are:
 - TopicProcessConfig: Will receive only backend configuration of processor enabled destinations
 
 - TopicRegulations: Will receive all regulations
+
+- TopicConfigChanges: Will receive a ConfigDiff with the changes of each update
 */
 func (bc *backendConfigImpl) Subscribe(ctx context.Context, topic Topic) pubsub.DataChannel {
 	return bc.eb.Subscribe(ctx, string(topic))
@@ -0,0 +1,212 @@
+package backendconfig
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+// EntityType is the type of a backend config entity affected by a change
+type EntityType string
+
+// ChangeType is the type of a change affecting a backend config entity
+type ChangeType string
+
+const (
+	EntityWorkspace   EntityType = "workspace"
+	EntitySource      EntityType = "source"
+	EntityDestination EntityType = "destination"
+	EntityConnection  EntityType = "connection"
+
+	ChangeAdded         ChangeType = "added"
+	ChangeRemoved       ChangeType = "removed"
+	ChangeEnabled       ChangeType = "enabled"
+	ChangeDisabled      ChangeType = "disabled"
+	ChangeConfigChanged ChangeType = "configChanged"
+)
+
+// Change is a single change of a backend config entity between two consecutive backend config updates
+type Change struct {
+	WorkspaceID string     `json:"workspaceId"`
+	Entity      EntityType `json:"entity"`
+	ID          string     `json:"id"`
+	Name        string     `json:"name,omitempty"`
+	// SourceID is the id of the source a destination is connected to, destinations being diffed per source
+	SourceID string     `json:"sourceId,omitempty"`
+	Type     ChangeType `json:"type"`
+	// ConfigKeys are the top-level config keys which were added, removed or modified, if Type is [ChangeConfigChanged]
+	ConfigKeys []string `json:"configKeys,omitempty"`
+}
+
+// ConfigDiff contains all changes between two consecutive backend config updates
+type ConfigDiff struct {
+	Timestamp time.Time `json:"timestamp"`
+	Changes   []Change  `json:"changes"`
+}
+
+// DiffConfigs computes the changes of sources, destinations and connections between the previous and the current backend config.
+// Changes are sorted by workspace, source, destination and connection id, so that the same configs always result in the same diff.
+func DiffConfigs(prev, cur map[string]ConfigT) []Change {
+	workspaceIDs := lo.Union(lo.Keys(prev), lo.Keys(cur))
+	sort.Strings(workspaceIDs)
+	var changes []Change
+	for _, workspaceID := range workspaceIDs {
+		prevConfig, inPrev := prev[workspaceID]
+		curConfig, inCur := cur[workspaceID]
+		switch {
+		case !inPrev:
+			changes = append(changes, Change{WorkspaceID: workspaceID, Entity: EntityWorkspace, ID: workspaceID, Type: ChangeAdded})
+		case !inCur:
+			changes = append(changes, Change{WorkspaceID: workspaceID, Entity: EntityWorkspace, ID: workspaceID, Type: ChangeRemoved})
+		}
+		changes = append(changes, diffWorkspace(workspaceID, prevConfig, curConfig)...)
+	}
+	return changes
+}
+
+func diffWorkspace(workspaceID string, prev, cur ConfigT) []Change {
+	var changes []Change
+	change := func(entity EntityType, id, name, sourceID string, changeType ChangeType, configKeys []string) {
+		changes = append(changes, Change{
+			WorkspaceID: workspaceID,
+			Entity:      entity,
+			ID:          id,
+			Name:        name,
+			SourceID:    sourceID,
+			Type:        changeType,
+			ConfigKeys:  configKeys,
+		})
+	}
+	enabledChange := func(prevEnabled, curEnabled bool) (ChangeType, bool) {
+		if prevEnabled == curEnabled {
+			return "", false
+		}
+		return lo.Ternary(curEnabled, ChangeEnabled, ChangeDisabled), true
+	}
+
+	prevSources := lo.SliceToMap(prev.Sources, func(s SourceT) (string, SourceT) { return s.ID, s })
+	curSources := lo.SliceToMap(cur.Sources, func(s SourceT) (string, SourceT) { return s.ID, s })
+	sourceIDs := lo.Union(lo.Keys(prevSources), lo.Keys(curSources))
+	sort.Strings(sourceIDs)
+	for _, sourceID := range sourceIDs {
+		prevSource, inPrev := prevSources[sourceID]
+		curSource, inCur := curSources[sourceID]
+		switch {
+		case !inPrev:
+			change(EntitySource, sourceID, curSource.Name, "", ChangeAdded, nil)
+		case !inCur:
+			change(EntitySource, sourceID, prevSource.Name, "", ChangeRemoved, nil)
+		default:
+			if changeType, ok := enabledChange(prevSource.Enabled, curSource.Enabled); ok {
+				change(EntitySource, sourceID, curSource.Name, "", changeType, nil)
+			}
+			if keys, ok := changedRawConfigKeys(prevSource.Config, curSource.Config); ok {
+				change(EntitySource, sourceID, curSource.Name, "", ChangeConfigChanged, keys)
+			}
+		}
+
+		prevDestinations := lo.SliceToMap(prevSource.Destinations, func(d DestinationT) (string, DestinationT) { return d.ID, d })
+		curDestinations := lo.SliceToMap(curSource.Destinations, func(d DestinationT) (string, DestinationT) { return d.ID, d })
+		destinationIDs := lo.Union(lo.Keys(prevDestinations), lo.Keys(curDestinations))
+		sort.Strings(destinationIDs)
+		for _, destinationID := range destinationIDs {
+			prevDestination, inPrev := prevDestinations[destinationID]
+			curDestination, inCur := curDestinations[destinationID]
+			switch {
+			case !inPrev:
+				change(EntityDestination, destinationID, curDestination.Name, sourceID, ChangeAdded, nil)
+			case !inCur:
+				change(EntityDestination, destinationID, prevDestination.Name, sourceID, ChangeRemoved, nil)
+			default:
+				if changeType, ok := enabledChange(prevDestination.Enabled, curDestination.Enabled); ok {
+					change(EntityDestination, destinationID, curDestination.Name, sourceID, changeType, nil)
+				}
+				if keys := changedConfigKeys(prevDestination.Config, curDestination.Config); len(keys) > 0 {
+					change(EntityDestination, destinationID, curDestination.Name, sourceID, ChangeConfigChanged, keys)
+				}
+			}
+		}
+	}
+
+	connectionIDs := lo.Union(lo.Keys(prev.Connections), lo.Keys(cur.Connections))
+	sort.Strings(connectionIDs)
+	for _, connectionID := range connectionIDs {
+		prevConnection, inPrev := prev.Connections[connectionID]
+		curConnection, inCur := cur.Connections[connectionID]
+		switch {
+		case !inPrev:
+			change(EntityConnection, connectionID, "", curConnection.SourceID, ChangeAdded, nil)
+		case !inCur:
+			change(EntityConnection, connectionID, "", prevConnection.SourceID, ChangeRemoved, nil)
+		default:
+			if changeType, ok := enabledChange(prevConnection.Enabled, curConnection.Enabled); ok {
+				change(EntityConnection, connectionID, "", curConnection.SourceID, changeType, nil)
+			}
+			if keys := changedConfigKeys(prevConnection.Config, curConnection.Config); len(keys) > 0 {
+				change(EntityConnection, connectionID, "", curConnection.SourceID, ChangeConfigChanged, keys)
+			}
+		}
+	}
+	return changes
+}
+
+// changedConfigKeys returns the sorted top-level keys which were added, removed or modified between the two configs
+func changedConfigKeys(prev, cur map[string]interface{}) []string {
+	var keys []string
+	for _, key := range lo.Union(lo.Keys(prev), lo.Keys(cur)) {
+		prevValue, inPrev := prev[key]
+		curValue, inCur := cur[key]
+		if inPrev != inCur || !reflect.DeepEqual(prevValue, curValue) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// changedRawConfigKeys is like changedConfigKeys for raw JSON configs, returning false if the configs are equal.
+// If the configs are not JSON objects, they are reported as changed without any keys.
+func changedRawConfigKeys(prev, cur []byte) ([]string, bool) {
+	var prevConfig, curConfig map[string]interface{}
+	if len(prev) > 0 && jsonrs.Unmarshal(prev, &prevConfig) != nil ||
+		len(cur) > 0 && jsonrs.Unmarshal(cur, &curConfig) != nil {
+		return nil, string(prev) != string(cur)
+	}
+	keys := changedConfigKeys(prevConfig, curConfig)
+	return keys, len(keys) > 0
+}
+
+// changeLog keeps the most recent config diffs in memory
+type changeLog struct {
+	mu    sync.RWMutex
+	diffs []ConfigDiff
+}
+
+// add appends a diff to the log, evicting the oldest diffs once the log holds more than size diffs
+func (l *changeLog) add(diff ConfigDiff, size int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.diffs = append(l.diffs, diff)
+	if over := len(l.diffs) - size; over > 0 {
+		l.diffs = append([]ConfigDiff(nil), l.diffs[over:]...)
+	}
+}
+
+// last returns the last n diffs, most recent first. All diffs are returned if n is not positive.
+func (l *changeLog) last(n int) []ConfigDiff {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if n <= 0 || n > len(l.diffs) {
+		n = len(l.diffs)
+	}
+	diffs := make([]ConfigDiff, 0, n)
+	for i := len(l.diffs) - 1; i >= len(l.diffs)-n; i-- {
+		diffs = append(diffs, l.diffs[i])
+	}
+	return diffs
+}
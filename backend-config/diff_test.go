@@ -0,0 +1,148 @@
+package backendconfig
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-server/backend-config/internal/cache"
+	"github.com/rudderlabs/rudder-server/utils/pubsub"
+)
+
+func TestDiffConfigs(t *testing.T) {
+	prev := map[string]ConfigT{
+		"workspace-1": {
+			WorkspaceID: "workspace-1",
+			Sources: []SourceT{
+				{ID: "source-1", Name: "web", Enabled: true, Config: json.RawMessage(`{"a":1,"b":2}`), Destinations: []DestinationT{
+					{ID: "dest-1", Name: "webhook", Enabled: true, Config: map[string]interface{}{"url": "https://a", "timeout": 10}},
+					{ID: "dest-2", Name: "old", Enabled: true},
+				}},
+				{ID: "source-2", Name: "removed"},
+			},
+			Connections: map[string]Connection{
+				"conn-1": {SourceID: "source-1", DestinationID: "dest-1", Enabled: true, Config: map[string]interface{}{"sync": "full"}},
+				"conn-2": {SourceID: "source-1", DestinationID: "dest-2"},
+			},
+		},
+		"workspace-2": {WorkspaceID: "workspace-2"},
+	}
+	cur := map[string]ConfigT{
+		"workspace-1": {
+			WorkspaceID: "workspace-1",
+			Sources: []SourceT{
+				{ID: "source-1", Name: "web", Enabled: false, Config: json.RawMessage(`{"b":3,"a":1,"c":true}`), Destinations: []DestinationT{
+					{ID: "dest-1", Name: "webhook", Enabled: true, Config: map[string]interface{}{"url": "https://b", "timeout": 10}},
+					{ID: "dest-3", Name: "new"},
+				}},
+				{ID: "source-3", Name: "added"},
+			},
+			Connections: map[string]Connection{
+				"conn-1": {SourceID: "source-1", DestinationID: "dest-1", Enabled: false, Config: map[string]interface{}{"sync": "incremental"}},
+				"conn-3": {SourceID: "source-3", DestinationID: "dest-3"},
+			},
+		},
+		"workspace-3": {WorkspaceID: "workspace-3", Sources: []SourceT{{ID: "source-4", Name: "other"}}},
+	}
+
+	require.Equal(t, []Change{
+		{WorkspaceID: "workspace-1", Entity: EntitySource, ID: "source-1", Name: "web", Type: ChangeDisabled},
+		{WorkspaceID: "workspace-1", Entity: EntitySource, ID: "source-1", Name: "web", Type: ChangeConfigChanged, ConfigKeys: []string{"b", "c"}},
+		{WorkspaceID: "workspace-1", Entity: EntityDestination, ID: "dest-1", Name: "webhook", SourceID: "source-1", Type: ChangeConfigChanged, ConfigKeys: []string{"url"}},
+		{WorkspaceID: "workspace-1", Entity: EntityDestination, ID: "dest-2", Name: "old", SourceID: "source-1", Type: ChangeRemoved},
+		{WorkspaceID: "workspace-1", Entity: EntityDestination, ID: "dest-3", Name: "new", SourceID: "source-1", Type: ChangeAdded},
+		{WorkspaceID: "workspace-1", Entity: EntitySource, ID: "source-2", Name: "removed", Type: ChangeRemoved},
+		{WorkspaceID: "workspace-1", Entity: EntitySource, ID: "source-3", Name: "added", Type: ChangeAdded},
+		{WorkspaceID: "workspace-1", Entity: EntityConnection, ID: "conn-1", SourceID: "source-1", Type: ChangeDisabled},
+		{WorkspaceID: "workspace-1", Entity: EntityConnection, ID: "conn-1", SourceID: "source-1", Type: ChangeConfigChanged, ConfigKeys: []string{"sync"}},
+		{WorkspaceID: "workspace-1", Entity: EntityConnection, ID: "conn-2", SourceID: "source-1", Type: ChangeRemoved},
+		{WorkspaceID: "workspace-1", Entity: EntityConnection, ID: "conn-3", SourceID: "source-3", Type: ChangeAdded},
+		{WorkspaceID: "workspace-2", Entity: EntityWorkspace, ID: "workspace-2", Type: ChangeRemoved},
+		{WorkspaceID: "workspace-3", Entity: EntityWorkspace, ID: "workspace-3", Type: ChangeAdded},
+		{WorkspaceID: "workspace-3", Entity: EntitySource, ID: "source-4", Name: "other", Type: ChangeAdded},
+	}, DiffConfigs(prev, cur))
+
+	require.Empty(t, DiffConfigs(cur, cur))
+}
+
+func TestChangeLog(t *testing.T) {
+	var l changeLog
+	require.Empty(t, l.last(0))
+	for i := 0; i < 5; i++ {
+		l.add(ConfigDiff{Changes: []Change{{ID: string(rune('a' + i))}}}, 3)
+	}
+	ids := func(diffs []ConfigDiff) []string {
+		var ids []string
+		for _, d := range diffs {
+			ids = append(ids, d.Changes[0].ID)
+		}
+		return ids
+	}
+	require.Equal(t, []string{"e", "d", "c"}, ids(l.last(0)))
+	require.Equal(t, []string{"e", "d"}, ids(l.last(2)))
+	require.Equal(t, []string{"e", "d", "c"}, ids(l.last(10)))
+}
+
+func TestConfigUpdateChanges(t *testing.T) {
+	initBackendConfig()
+
+	var (
+		ctrl        = gomock.NewController(t)
+		ctx, cancel = context.WithCancel(context.Background())
+		workspaceID = "foo"
+	)
+	defer cancel()
+
+	updated := sampleBackendConfig
+	updated.Sources = append([]SourceT{}, sampleBackendConfig.Sources...)
+	updated.Sources[0].Enabled = true
+
+	wc := NewMockworkspaceConfig(ctrl)
+	gomock.InOrder(
+		wc.EXPECT().Get(gomock.Eq(ctx)).Return(map[string]ConfigT{workspaceID: sampleBackendConfig}, nil).Times(1),
+		wc.EXPECT().Get(gomock.Eq(ctx)).Return(map[string]ConfigT{workspaceID: sampleBackendConfig}, nil).Times(1),
+		wc.EXPECT().Get(gomock.Eq(ctx)).Return(map[string]ConfigT{workspaceID: updated}, nil).Times(1),
+	)
+
+	var pubSub pubsub.PublishSubscriber
+	bc := &backendConfigImpl{
+		eb:              &pubSub,
+		workspaceConfig: wc,
+		cache:           cache.NewMockCache(ctrl),
+	}
+	chChanges := pubSub.Subscribe(ctx, string(TopicConfigChanges))
+
+	bc.configUpdate(ctx)
+	initial := (<-chChanges).Data.(ConfigDiff)
+	require.Equal(t, Change{WorkspaceID: workspaceID, Entity: EntityWorkspace, ID: workspaceID, Type: ChangeAdded}, initial.Changes[0])
+
+	bc.configUpdate(ctx) // unchanged config
+	require.Len(t, bc.configChanges(0), 1)
+
+	bc.configUpdate(ctx)
+	diff := (<-chChanges).Data.(ConfigDiff)
+	require.WithinDuration(t, time.Now(), diff.Timestamp, time.Minute)
+	require.Equal(t, []Change{{
+		WorkspaceID: workspaceID,
+		Entity:      EntitySource,
+		ID:          updated.Sources[0].ID,
+		Name:        updated.Sources[0].Name,
+		Type:        ChangeEnabled,
+	}}, diff.Changes)
+
+	var reply string
+	require.NoError(t, NewAdmin(bc).ConfigChanges(1, &reply))
+	var diffs []ConfigDiff
+	require.NoError(t, jsonrs.Unmarshal([]byte(reply), &diffs))
+	require.Len(t, diffs, 1)
+	require.Equal(t, diff.Changes, diffs[0].Changes)
+
+	require.NoError(t, NewAdmin(bc).ConfigChanges(0, &reply))
+	require.NoError(t, jsonrs.Unmarshal([]byte(reply), &diffs))
+	require.Len(t, diffs, 2)
+}
@@ -0,0 +1,93 @@
+package dynamicconfig
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Regular expression to match secret references in the format {{ secret "name" }}
+var secretReferenceRegex = regexp.MustCompile(`\{\{\s*secret\s+"([^"]+)"\s*\}\}`)
+
+// ContainsSecretReference checks if a map or any of its nested maps and arrays contains
+// at least one secret reference in the format {{ secret "name" }}.
+func ContainsSecretReference(data map[string]any) bool {
+	found := false
+	_ = walkStrings(data, func(s string) (string, error) {
+		if !found && secretReferenceRegex.MatchString(s) {
+			found = true
+		}
+		return s, nil
+	})
+	return found
+}
+
+// ResolveSecretReferences replaces all secret references in the format {{ secret "name" }} found in the string values
+// of a map, including its nested maps and arrays, with the values returned by lookup. References can either make up
+// a whole value or be embedded in a larger string, e.g. "Bearer {{ secret "api-token" }}". The map is modified in place.
+// It returns the number of references resolved, or an error if any of the secrets could not be looked up.
+func ResolveSecretReferences(data map[string]any, lookup func(name string) (string, error)) (int, error) {
+	var resolved int
+	err := walkStrings(data, func(s string) (string, error) {
+		var lookupErr error
+		result := secretReferenceRegex.ReplaceAllStringFunc(s, func(reference string) string {
+			if lookupErr != nil {
+				return reference
+			}
+			name := secretReferenceRegex.FindStringSubmatch(reference)[1]
+			value, err := lookup(name)
+			if err != nil {
+				lookupErr = fmt.Errorf("resolving secret %q: %w", name, err)
+				return reference
+			}
+			resolved++
+			return value
+		})
+		return result, lookupErr
+	})
+	return resolved, err
+}
+
+// walkStrings recursively traverses the map, replacing every string value, including the ones of nested maps and arrays,
+// with the value returned by fn. Values are only written back if fn changed them.
+func walkStrings(data map[string]any, fn func(string) (string, error)) error {
+	for key, value := range data {
+		if s, ok := value.(string); ok {
+			replaced, err := fn(s)
+			if err != nil {
+				return err
+			}
+			if replaced != s {
+				data[key] = replaced
+			}
+			continue
+		}
+		if err := walkNested(value, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkNested(value any, fn func(string) (string, error)) error {
+	switch v := value.(type) {
+	case map[string]any:
+		return walkStrings(v, fn)
+	case []any:
+		for i, item := range v {
+			if s, ok := item.(string); ok {
+				replaced, err := fn(s)
+				if err != nil {
+					return err
+				}
+				if replaced != s {
+					v[i] = replaced
+				}
+				continue
+			}
+			if err := walkNested(item, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package dynamicconfig_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/backend-config/dynamicconfig"
+)
+
+func TestResolveSecretReferences(t *testing.T) {
+	secrets := map[string]string{"password": "p@ss", "api-token": "t0ken"}
+	lookup := func(name string) (string, error) {
+		if value, ok := secrets[name]; ok {
+			return value, nil
+		}
+		return "", errors.New("not found")
+	}
+
+	t.Run("resolves references", func(t *testing.T) {
+		data := map[string]any{
+			"password": `{{ secret "password" }}`,
+			"header":   `Bearer {{secret "api-token"}}`,
+			"dynamic":  `{{ message.traits.key || "default" }}`,
+			"port":     5432,
+			"nested": map[string]any{
+				"list": []any{`{{ secret "password" }}`, "plain", map[string]any{"token": `{{ secret "api-token" }}`}},
+			},
+		}
+		require.True(t, dynamicconfig.ContainsSecretReference(data))
+		resolved, err := dynamicconfig.ResolveSecretReferences(data, lookup)
+		require.NoError(t, err)
+		require.Equal(t, 4, resolved)
+		require.Equal(t, map[string]any{
+			"password": "p@ss",
+			"header":   "Bearer t0ken",
+			"dynamic":  `{{ message.traits.key || "default" }}`,
+			"port":     5432,
+			"nested": map[string]any{
+				"list": []any{"p@ss", "plain", map[string]any{"token": "t0ken"}},
+			},
+		}, data)
+		require.False(t, dynamicconfig.ContainsSecretReference(data))
+	})
+
+	t.Run("unknown secret", func(t *testing.T) {
+		data := map[string]any{"key": `{{ secret "unknown" }}`}
+		_, err := dynamicconfig.ResolveSecretReferences(data, lookup)
+		require.ErrorContains(t, err, `resolving secret "unknown": not found`)
+	})
+
+	t.Run("no references", func(t *testing.T) {
+		require.False(t, dynamicconfig.ContainsSecretReference(nil))
+		resolved, err := dynamicconfig.ResolveSecretReferences(map[string]any{"key": "secret"}, lookup)
+		require.NoError(t, err)
+		require.Zero(t, resolved)
+	})
+}
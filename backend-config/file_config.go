@@ -0,0 +1,193 @@
+package backendconfig
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+)
+
+// configWatcher is implemented by workspace configs which can notify about configuration changes as soon as they happen, without waiting for the next poll
+type configWatcher interface {
+	// Watch returns a channel receiving a signal whenever the configuration might have changed, or nil if changes cannot be watched
+	Watch(ctx context.Context) <-chan struct{}
+}
+
+// readConfigFiles reads the workspace config found at the given path as JSON.
+//
+// The path can either be a single JSON or YAML file, or a directory containing multiple JSON and YAML files (*.json, *.yaml, *.yml),
+// each one holding a part of the workspace config. Files of a directory are merged in lexical order: arrays (e.g. sources) are concatenated,
+// objects (e.g. connections) are merged, rejecting duplicate keys, while scalar values (e.g. workspaceId) must not conflict.
+func readConfigFiles(path string) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		if !isYAMLFile(path) {
+			return IoUtil.ReadFile(path)
+		}
+		doc, err := readConfigDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		return jsonrs.Marshal(doc)
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || !isConfigFile(entry.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(path, entry.Name()))
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no config files found in directory %q", path)
+	}
+	sort.Strings(files)
+
+	merged := make(map[string]interface{})
+	for _, file := range files {
+		doc, err := readConfigDocument(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := mergeConfigDocument(merged, doc, ""); err != nil {
+			return nil, fmt.Errorf("merging %q: %w", file, err)
+		}
+	}
+	return jsonrs.Marshal(merged)
+}
+
+func readConfigDocument(file string) (map[string]interface{}, error) {
+	data, err := IoUtil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if isYAMLFile(file) {
+		err = yaml.Unmarshal(data, &doc)
+	} else {
+		err = jsonrs.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %q: %w", file, err)
+	}
+	return doc, nil
+}
+
+// mergeConfigDocument merges src into dst
+func mergeConfigDocument(dst, src map[string]interface{}, path string) error {
+	for key, srcValue := range src {
+		keyPath := key
+		if path != "" {
+			keyPath = path + "." + key
+		}
+		dstValue, ok := dst[key]
+		if !ok {
+			dst[key] = srcValue
+			continue
+		}
+		switch srcValue := srcValue.(type) {
+		case []interface{}:
+			dstSlice, ok := dstValue.([]interface{})
+			if !ok {
+				return fmt.Errorf("%s: cannot merge an array with a %T", keyPath, dstValue)
+			}
+			dst[key] = append(dstSlice, srcValue...)
+		case map[string]interface{}:
+			dstMap, ok := dstValue.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: cannot merge an object with a %T", keyPath, dstValue)
+			}
+			for k := range srcValue {
+				if _, ok := dstMap[k]; ok && path == "" {
+					// top-level objects are keyed by id (e.g. connections, credentials), a duplicate key is most probably a mistake
+					return fmt.Errorf("%s: duplicate key %q", keyPath, k)
+				}
+			}
+			if err := mergeConfigDocument(dstMap, srcValue, keyPath); err != nil {
+				return err
+			}
+		default:
+			if fmt.Sprint(dstValue) != fmt.Sprint(srcValue) {
+				return fmt.Errorf("%s: conflicting values %v and %v", keyPath, dstValue, srcValue)
+			}
+		}
+	}
+	return nil
+}
+
+func isConfigFile(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".json") || isYAMLFile(name)
+}
+
+func isYAMLFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// Watch watches the config path for changes if the config is read from file. Directories are watched instead of files,
+// so that changes performed through atomic renames or symlink swaps (e.g. kubernetes config maps) are also detected.
+func (wc *singleWorkspaceConfig) Watch(ctx context.Context) <-chan struct{} {
+	if !configFromFile {
+		return nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		wc.logger.Warnn("Unable to watch backend config file for changes, falling back to polling", obskit.Error(err))
+		return nil
+	}
+	dir := wc.configJSONPath
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		dir = filepath.Dir(dir)
+	}
+	if err := watcher.Add(dir); err != nil {
+		_ = watcher.Close()
+		wc.logger.Warnn("Unable to watch backend config file for changes, falling back to polling",
+			logger.NewStringField("path", dir), obskit.Error(err),
+		)
+		return nil
+	}
+
+	changes := make(chan struct{}, 1)
+	go func() {
+		defer func() { _ = watcher.Close() }()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op == fsnotify.Chmod {
+					continue
+				}
+				select {
+				case changes <- struct{}{}:
+				default: // a change is already pending
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				wc.logger.Warnn("Error watching backend config file for changes", obskit.Error(err))
+			}
+		}
+	}()
+	return changes
+}
@@ -0,0 +1,173 @@
+package backendconfig
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConfigFiles(t *testing.T) {
+	t.Run("yaml file", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "workspaceConfig.yaml")
+		require.NoError(t, os.WriteFile(path, []byte(`
+workspaceId: workspace-1
+sources:
+  - id: source-1
+    writeKey: wk-1
+    enabled: true
+    config:
+      key: value
+    destinations:
+      - id: dest-1
+        config:
+          apiKey: secret
+`), 0o600))
+		wc := &singleWorkspaceConfig{token: "token", configJSONPath: path}
+		require.NoError(t, wc.SetUp())
+		conf, err := wc.getFromFile(context.Background())
+		require.NoError(t, err)
+		require.Len(t, conf["workspace-1"].Sources, 1)
+		source := conf["workspace-1"].Sources[0]
+		require.Equal(t, "wk-1", source.WriteKey)
+		require.JSONEq(t, `{"key":"value"}`, string(source.Config))
+		require.Equal(t, "secret", source.Destinations[0].Config["apiKey"])
+	})
+
+	t.Run("directory with multiple files", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "01-workspace.yaml"), []byte("workspaceId: workspace-1\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "02-sources.json"), []byte(`{"workspaceId":"workspace-1","sources":[{"id":"source-1","destinations":[{"id":"dest-1"}]}]}`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "03-sources.yml"), []byte("sources:\n  - id: source-2\n"), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "04-connections.json"), []byte(`{"connections":{"conn-1":{"sourceId":"source-2","destinationId":"dest-1"}}}`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0o600))
+
+		wc := &singleWorkspaceConfig{token: "token", configJSONPath: dir}
+		require.NoError(t, wc.SetUp())
+		conf, err := wc.getFromFile(context.Background())
+		require.NoError(t, err)
+		c := conf["workspace-1"]
+		require.Len(t, c.Sources, 2)
+		require.Equal(t, "source-1", c.Sources[0].ID)
+		require.Equal(t, "source-2", c.Sources[1].ID)
+		require.Equal(t, "dest-1", c.Connections["conn-1"].DestinationID)
+	})
+
+	t.Run("conflicting files", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"workspaceId":"workspace-1","connections":{"conn-1":{}}}`), 0o600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"workspaceId":"workspace-2"}`), 0o600))
+		_, err := readConfigFiles(dir)
+		require.ErrorContains(t, err, "workspaceId: conflicting values")
+
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"connections":{"conn-1":{}}}`), 0o600))
+		_, err = readConfigFiles(dir)
+		require.ErrorContains(t, err, `connections: duplicate key "conn-1"`)
+	})
+
+	t.Run("empty directory", func(t *testing.T) {
+		_, err := readConfigFiles(t.TempDir())
+		require.ErrorContains(t, err, "no config files found")
+	})
+}
+
+func TestGetFromFileValidation(t *testing.T) {
+	initBackendConfig()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "workspaceConfig.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"workspaceId":"workspace-1","sources":[{"id":"source-1"}]}`), 0o600))
+	wc := &singleWorkspaceConfig{token: "token", configJSONPath: path}
+	require.NoError(t, wc.SetUp())
+	conf, err := wc.getFromFile(context.Background())
+	require.NoError(t, err)
+	require.Len(t, conf["workspace-1"].Sources, 1)
+
+	again, err := wc.getFromFile(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, conf, again, "unchanged file should return the same config")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"workspaceId":"workspace-1","sources":[{"id":"source-1"},{"id":"source-1"}]}`), 0o600))
+	_, err = wc.getFromFile(context.Background())
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Contains(t, err.Error(), "duplicate source id")
+}
+
+func TestWatchConfigFile(t *testing.T) {
+	initBackendConfig()
+	configFromFile = true
+	t.Cleanup(func() { configFromFile = false })
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "workspaceConfig.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"workspaceId":"workspace-1"}`), 0o600))
+	wc := &singleWorkspaceConfig{token: "token", configJSONPath: path}
+	require.NoError(t, wc.SetUp())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := wc.Watch(ctx)
+	require.NotNil(t, changes)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"workspaceId":"workspace-1","sources":[{"id":"source-1"}]}`), 0o600))
+	select {
+	case <-changes:
+	case <-time.After(10 * time.Second):
+		t.Fatal("no change detected")
+	}
+
+	// an atomic rename should be detected as well
+	tmp := filepath.Join(dir, ".tmp")
+	require.NoError(t, os.WriteFile(tmp, []byte(`{"workspaceId":"workspace-1"}`), 0o600))
+	for len(changes) > 0 {
+		<-changes
+	}
+	require.NoError(t, os.Rename(tmp, path))
+	select {
+	case <-changes:
+	case <-time.After(10 * time.Second):
+		t.Fatal("no change detected")
+	}
+}
+
+func TestGetFromFileSecretReferences(t *testing.T) {
+	initBackendConfig()
+	t.Setenv("RUDDER_SECRET_WAREHOUSE_PASSWORD", "p@ss")
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "workspaceConfig.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"workspaceId":"workspace-1","sources":[{"id":"source-1","destinations":[
+		{"id":"dest-1","config":{"password":"{{ secret \"warehouse-password\" }}","user":"admin"}}
+	]}]}`), 0o600))
+	wc := &singleWorkspaceConfig{token: "token", configJSONPath: path}
+	require.NoError(t, wc.SetUp())
+	conf, err := wc.getFromFile(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"password": "p@ss", "user": "admin"}, conf["workspace-1"].Sources[0].Destinations[0].Config)
+
+	// only the destinations referencing secrets which cannot be resolved get disabled
+	require.NoError(t, os.WriteFile(path, []byte(`{"workspaceId":"workspace-1","sources":[{"id":"source-1","destinations":[
+		{"id":"dest-1","enabled":true,"config":{"password":"{{ secret \"unknown\" }}"}},
+		{"id":"dest-2","enabled":true,"config":{"password":"{{ secret \"warehouse-password\" }}"}},
+		{"id":"dest-3","enabled":true,"config":{"user":"admin"}}
+	]}]}`), 0o600))
+	conf, err = wc.getFromFile(context.Background())
+	require.NoError(t, err)
+	destinations := conf["workspace-1"].Sources[0].Destinations
+	require.False(t, destinations[0].Enabled)
+	require.True(t, destinations[1].Enabled)
+	require.Equal(t, "p@ss", destinations[1].Config["password"])
+	require.True(t, destinations[2].Enabled)
+
+	// a config referencing secrets is not cached, so that destinations get enabled once their secrets become resolvable
+	t.Setenv("RUDDER_SECRET_UNKNOWN", "known")
+	conf, err = wc.getFromFile(context.Background())
+	require.NoError(t, err)
+	require.True(t, conf["workspace-1"].Sources[0].Destinations[0].Enabled)
+	require.Equal(t, "known", conf["workspace-1"].Sources[0].Destinations[0].Config["password"])
+}
@@ -0,0 +1,40 @@
+package backendconfig
+
+import (
+	"context"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/backend-config/dynamicconfig"
+)
+
+// secretResolver resolves secrets referenced by destination configs
+type secretResolver interface {
+	Resolve(ctx context.Context, name string) (string, error)
+}
+
+// resolveSecretReferences replaces all {{ secret "name" }} references found in the configs of the sources' destinations
+// with the secrets' values. Destinations referencing secrets which cannot be resolved are disabled and logged, so that
+// they don't prevent the rest of the config from being published. An error is returned only if ctx is done while resolving secrets.
+func resolveSecretReferences(ctx context.Context, sources []SourceT, resolver secretResolver, log logger.Logger) error {
+	for i := range sources {
+		for j := range sources[i].Destinations {
+			dest := &sources[i].Destinations[j]
+			_, err := dynamicconfig.ResolveSecretReferences(dest.Config, func(name string) (string, error) {
+				return resolver.Resolve(ctx, name)
+			})
+			if err == nil {
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Errorn("Disabling destination referencing secrets which cannot be resolved",
+				obskit.SourceID(sources[i].ID), obskit.DestinationID(dest.ID), obskit.Error(err),
+			)
+			dest.Enabled = false
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Package secrets resolves secrets referenced by destination configs as {{ secret "name" }},
+// looking them up from pluggable providers: environment variables, files (e.g. mounted by kubernetes) and Vault-compatible HTTP APIs.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+)
+
+// ErrNotFound is returned by providers which don't hold the requested secret
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up secrets by name
+type Provider interface {
+	// Get returns the value of the secret, or [ErrNotFound] if the provider doesn't hold it
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// NewResolver returns a resolver looking up secrets from the given providers, in order.
+// Resolved secrets are cached for ttl, so that providers are not queried on every backend config poll.
+func NewResolver(ttl time.Duration, providers ...Provider) *Resolver {
+	return &Resolver{
+		providers: providers,
+		ttl:       ttl,
+		now:       time.Now,
+		cache:     make(map[string]cachedSecret),
+	}
+}
+
+// NewResolverFromConfig returns a resolver using the providers listed by BackendConfig.Secrets.providers, in order:
+//
+//   - env: environment variables named after the secret, prefixed with BackendConfig.Secrets.env.prefix, e.g. RUDDER_SECRET_WAREHOUSE_PASSWORD for "warehouse-password"
+//   - file: files named after the secret in the BackendConfig.Secrets.file.dir directory
+//   - vault: secrets of a Vault-compatible KV v2 API at BackendConfig.Secrets.vault.address, named as "path#key"
+func NewResolverFromConfig(conf *config.Config) (*Resolver, error) {
+	var providers []Provider
+	for _, name := range conf.GetStringSlice("BackendConfig.Secrets.providers", []string{"env", "file"}) {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "env":
+			providers = append(providers, NewEnvProvider(conf.GetString("BackendConfig.Secrets.env.prefix", "RUDDER_SECRET_")))
+		case "file":
+			providers = append(providers, NewFileProvider(conf.GetString("BackendConfig.Secrets.file.dir", "/etc/rudderstack/secrets")))
+		case "vault":
+			address := conf.GetString("BackendConfig.Secrets.vault.address", "")
+			if address == "" {
+				return nil, errors.New("vault secrets provider: BackendConfig.Secrets.vault.address is required")
+			}
+			providers = append(providers, NewVaultProvider(
+				address,
+				conf.GetString("BackendConfig.Secrets.vault.token", ""),
+				conf.GetString("BackendConfig.Secrets.vault.mount", "secret"),
+				&http.Client{Timeout: conf.GetDuration("BackendConfig.Secrets.vault.timeout", 10, time.Second)},
+			))
+		default:
+			return nil, fmt.Errorf("unknown secrets provider %q", name)
+		}
+	}
+	return NewResolver(conf.GetDuration("BackendConfig.Secrets.cacheTTL", 60, time.Second), providers...), nil
+}
+
+// Resolver resolves secrets from a list of providers
+type Resolver struct {
+	providers []Provider
+	ttl       time.Duration
+	now       func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedSecret
+}
+
+type cachedSecret struct {
+	value     string
+	expiresAt time.Time
+}
+
+// Resolve returns the value of the secret held by the first provider having it, or [ErrNotFound] if no provider holds it
+func (r *Resolver) Resolve(ctx context.Context, name string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cached, ok := r.cache[name]; ok && r.now().Before(cached.expiresAt) {
+		return cached.value, nil
+	}
+	for _, provider := range r.providers {
+		value, err := provider.Get(ctx, name)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if r.ttl > 0 {
+			r.cache[name] = cachedSecret{value: value, expiresAt: r.now().Add(r.ttl)}
+		}
+		return value, nil
+	}
+	return "", ErrNotFound
+}
+
+// NewEnvProvider returns a provider looking up secrets from environment variables named after the upper-cased secret name,
+// with all characters other than letters and digits replaced by underscores, e.g. RUDDER_SECRET_API_KEY for "api-key"
+func NewEnvProvider(prefix string) Provider {
+	return envProvider{prefix: prefix}
+}
+
+type envProvider struct {
+	prefix string
+}
+
+func (p envProvider) Get(_ context.Context, name string) (string, error) {
+	envName := p.prefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+	value, ok := os.LookupEnv(envName)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// NewFileProvider returns a provider reading secrets from files named after the secret in the given directory,
+// as kubernetes does when mounting secrets as volumes. A single trailing newline is trimmed from the file's content.
+func NewFileProvider(dir string) Provider {
+	return fileProvider{dir: dir}
+}
+
+type fileProvider struct {
+	dir string
+}
+
+func (p fileProvider) Get(_ context.Context, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid secret name %q for file provider", name)
+	}
+	data, err := os.ReadFile(filepath.Join(p.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
@@ -0,0 +1,141 @@
+package secrets_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-server/backend-config/secrets"
+	"github.com/rudderlabs/rudder-server/testhelper/vault"
+)
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv("TEST_SECRET_WAREHOUSE_PASSWORD", "p@ss")
+	p := secrets.NewEnvProvider("TEST_SECRET_")
+
+	value, err := p.Get(context.Background(), "warehouse-password")
+	require.NoError(t, err)
+	require.Equal(t, "p@ss", value)
+
+	_, err = p.Get(context.Background(), "unknown")
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+}
+
+func TestFileProvider(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "api-key"), []byte("k3y\n"), 0o600))
+	p := secrets.NewFileProvider(dir)
+
+	value, err := p.Get(context.Background(), "api-key")
+	require.NoError(t, err)
+	require.Equal(t, "k3y", value)
+
+	_, err = p.Get(context.Background(), "unknown")
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+
+	_, err = p.Get(context.Background(), "../api-key")
+	require.ErrorContains(t, err, "invalid secret name")
+}
+
+func TestVaultProvider(t *testing.T) {
+	srv := vault.NewServer("root-token", "secret")
+	defer srv.Close()
+	srv.Put("warehouse/snowflake", map[string]interface{}{"password": "p@ss", "value": "default", "port": 443})
+
+	p := secrets.NewVaultProvider(srv.Server.URL, "root-token", "secret", nil)
+	value, err := p.Get(context.Background(), "warehouse/snowflake#password")
+	require.NoError(t, err)
+	require.Equal(t, "p@ss", value)
+
+	value, err = p.Get(context.Background(), "warehouse/snowflake")
+	require.NoError(t, err)
+	require.Equal(t, "default", value)
+
+	value, err = p.Get(context.Background(), "warehouse/snowflake#port")
+	require.NoError(t, err)
+	require.Equal(t, "443", value)
+
+	_, err = p.Get(context.Background(), "warehouse/snowflake#user")
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+	_, err = p.Get(context.Background(), "warehouse/redshift#password")
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+
+	_, err = secrets.NewVaultProvider(srv.Server.URL, "wrong-token", "secret", nil).Get(context.Background(), "warehouse/snowflake")
+	require.ErrorContains(t, err, "failed with 403")
+}
+
+type providerFunc func(ctx context.Context, name string) (string, error)
+
+func (f providerFunc) Get(ctx context.Context, name string) (string, error) { return f(ctx, name) }
+
+func TestResolver(t *testing.T) {
+	var calls int
+	first := providerFunc(func(_ context.Context, name string) (string, error) {
+		calls++
+		if name == "first" {
+			return "from-first", nil
+		}
+		return "", secrets.ErrNotFound
+	})
+	second := providerFunc(func(_ context.Context, name string) (string, error) {
+		switch name {
+		case "second":
+			return "from-second", nil
+		case "failing":
+			return "", errors.New("provider unavailable")
+		}
+		return "", secrets.ErrNotFound
+	})
+
+	r := secrets.NewResolver(time.Hour, first, second)
+	value, err := r.Resolve(context.Background(), "first")
+	require.NoError(t, err)
+	require.Equal(t, "from-first", value)
+	value, err = r.Resolve(context.Background(), "second")
+	require.NoError(t, err)
+	require.Equal(t, "from-second", value)
+	_, err = r.Resolve(context.Background(), "failing")
+	require.ErrorContains(t, err, "provider unavailable")
+	_, err = r.Resolve(context.Background(), "unknown")
+	require.ErrorIs(t, err, secrets.ErrNotFound)
+
+	calls = 0
+	_, err = r.Resolve(context.Background(), "first")
+	require.NoError(t, err)
+	require.Zero(t, calls, "resolved secrets should be cached")
+}
+
+func TestNewResolverFromConfig(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "shared"), []byte("from-file"), 0o600))
+	t.Setenv("RUDDER_SECRET_SHARED", "from-env")
+	srv := vault.NewServer("root-token", "kv")
+	defer srv.Close()
+	srv.Put("app", map[string]interface{}{"token": "from-vault"})
+
+	c := config.New()
+	c.Set("BackendConfig.Secrets.providers", []string{"file", "env", "vault"})
+	c.Set("BackendConfig.Secrets.file.dir", dir)
+	c.Set("BackendConfig.Secrets.vault.address", srv.Server.URL)
+	c.Set("BackendConfig.Secrets.vault.token", "root-token")
+	c.Set("BackendConfig.Secrets.vault.mount", "kv")
+	r, err := secrets.NewResolverFromConfig(c)
+	require.NoError(t, err)
+
+	value, err := r.Resolve(context.Background(), "shared")
+	require.NoError(t, err)
+	require.Equal(t, "from-file", value)
+	value, err = r.Resolve(context.Background(), "app#token")
+	require.NoError(t, err)
+	require.Equal(t, "from-vault", value)
+
+	c.Set("BackendConfig.Secrets.providers", []string{"unknown"})
+	_, err = secrets.NewResolverFromConfig(c)
+	require.ErrorContains(t, err, `unknown secrets provider "unknown"`)
+}
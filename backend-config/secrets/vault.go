@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	kithttputil "github.com/rudderlabs/rudder-go-kit/httputil"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+// defaultVaultKey is the key of a vault secret looked up when the secret name doesn't specify one
+const defaultVaultKey = "value"
+
+// NewVaultProvider returns a provider reading secrets from the KV v2 secrets engine of a Vault-compatible HTTP API,
+// mounted at the given mount path. Secret names have the form "path#key", the key defaulting to "value" if omitted,
+// e.g. "warehouse/snowflake#password" reads the password key of the secret at <mount>/data/warehouse/snowflake.
+func NewVaultProvider(address, token, mount string, client *http.Client) Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &vaultProvider{
+		address: strings.TrimSuffix(address, "/"),
+		token:   token,
+		mount:   strings.Trim(mount, "/"),
+		client:  client,
+	}
+}
+
+type vaultProvider struct {
+	address string
+	token   string
+	mount   string
+	client  *http.Client
+}
+
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+}
+
+func (p *vaultProvider) Get(ctx context.Context, name string) (string, error) {
+	path, key, ok := strings.Cut(name, "#")
+	if !ok {
+		key = defaultVaultKey
+	}
+	path = strings.Trim(path, "/")
+	if path == "" || key == "" {
+		return "", fmt.Errorf("invalid vault secret name %q", name)
+	}
+	segments := strings.Split(path, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	u := fmt.Sprintf("%s/v1/%s/data/%s", p.address, p.mount, strings.Join(segments, "/"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	if p.token != "" {
+		req.Header.Set("X-Vault-Token", p.token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request: %w", err)
+	}
+	defer func() { kithttputil.CloseResponse(resp) }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading vault response: %w", err)
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("vault request for %q failed with %d", path, resp.StatusCode)
+	}
+	var kv vaultKVResponse
+	if err := jsonrs.Unmarshal(body, &kv); err != nil {
+		return "", fmt.Errorf("parsing vault response: %w", err)
+	}
+	value, ok := kv.Data.Data[key]
+	if !ok {
+		return "", ErrNotFound
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(value), nil
+}
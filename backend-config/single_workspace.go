@@ -18,6 +18,7 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 	"github.com/rudderlabs/rudder-server/backend-config/dynamicconfig"
+	"github.com/rudderlabs/rudder-server/backend-config/secrets"
 	"github.com/rudderlabs/rudder-server/services/controlplane/identity"
 	"github.com/rudderlabs/rudder-server/utils/types"
 )
@@ -33,6 +34,9 @@ type singleWorkspaceConfig struct {
 	workspaceID     string
 
 	dynamicConfigCache dynamicconfig.Cache
+	secrets            secretResolver
+
+	fileMu sync.Mutex // serializes reading the config from file, which shares the dynamic config cache
 
 	logger               logger.Logger
 	httpCallsStat        stats.Counter
@@ -47,6 +51,13 @@ func (wc *singleWorkspaceConfig) SetUp() error {
 	if wc.logger == nil {
 		wc.logger = logger.NewLogger().Child("backend-config").Withn(obskit.WorkspaceID(wc.workspaceID))
 	}
+	if wc.secrets == nil {
+		resolver, err := secrets.NewResolverFromConfig(config.Default)
+		if err != nil {
+			return fmt.Errorf("single workspace: setting up secrets resolver: %w", err)
+		}
+		wc.secrets = resolver
+	}
 
 	if configFromFile {
 		if wc.configJSONPath == "" {
@@ -73,7 +84,7 @@ func (wc *singleWorkspaceConfig) AccessToken() string {
 // Get returns sources from the workspace
 func (wc *singleWorkspaceConfig) Get(ctx context.Context) (map[string]ConfigT, error) {
 	if configFromFile {
-		return wc.getFromFile()
+		return wc.getFromFile(ctx)
 	} else {
 		return wc.getFromAPI(ctx)
 	}
@@ -123,6 +134,10 @@ func (wc *singleWorkspaceConfig) getFromAPI(ctx context.Context) (map[string]Con
 	}
 	sourcesJSON.ApplyReplaySources()
 	sourcesJSON.processAccountAssociations()
+	if err = resolveSecretReferences(ctx, sourcesJSON.Sources, wc.secrets, wc.logger); err != nil {
+		wc.logger.Errorn("Unable to resolve secrets referenced by backend config", obskit.Error(err))
+		return conf, err
+	}
 
 	// Process dynamic config with the instance cache
 	ProcessDestinationsInSources(sourcesJSON.Sources, wc.dynamicConfigCache)
@@ -136,18 +151,26 @@ func (wc *singleWorkspaceConfig) getFromAPI(ctx context.Context) (map[string]Con
 	return conf, nil
 }
 
-// getFromFile reads the workspace config from JSON file
-func (wc *singleWorkspaceConfig) getFromFile() (map[string]ConfigT, error) {
-	wc.logger.Debugn("Reading workspace config from JSON file")
+// getFromFile reads the workspace config from the JSON or YAML file(s) found at configJSONPath.
+// Configs which fail validation are rejected, so that they never get published to subscribers, whereas destinations referencing
+// secrets which cannot be resolved get disabled.
+func (wc *singleWorkspaceConfig) getFromFile(ctx context.Context) (map[string]ConfigT, error) {
+	wc.logger.Debugn("Reading workspace config from file")
 
 	conf := make(map[string]ConfigT)
-	data, err := IoUtil.ReadFile(wc.configJSONPath)
+	data, err := readConfigFiles(wc.configJSONPath)
 	if err != nil {
 		wc.logger.Errorn("Unable to read backend config from file",
 			logger.NewStringField("path", wc.configJSONPath), obskit.Error(err),
 		)
 		return conf, err
 	}
+
+	// the config is parsed on every read, even if the files haven't changed, since the returned config is owned by the caller
+	// which may modify it, e.g. sorting its sources
+	wc.fileMu.Lock()
+	defer wc.fileMu.Unlock()
+
 	var configJSON ConfigT
 	if err = jsonrs.Unmarshal(data, &configJSON); err != nil {
 		wc.logger.Errorn("Unable to parse backend config from file",
@@ -155,12 +178,24 @@ func (wc *singleWorkspaceConfig) getFromFile() (map[string]ConfigT, error) {
 		)
 		return conf, err
 	}
+	if err = configJSON.Validate(); err != nil {
+		wc.logger.Errorn("Invalid backend config in file, ignoring it",
+			logger.NewStringField("path", wc.configJSONPath), obskit.Error(err),
+		)
+		return conf, err
+	}
 	workspaceID := configJSON.WorkspaceID
 	wc.workspaceIDOnce.Do(func() {
-		wc.logger.Infon("Read workspace config from JSON file")
+		wc.logger.Infon("Read workspace config from file")
 		wc.workspaceID = workspaceID
 	})
 	configJSON.processAccountAssociations()
+	if err = resolveSecretReferences(ctx, configJSON.Sources, wc.secrets, wc.logger); err != nil {
+		wc.logger.Errorn("Unable to resolve secrets referenced by backend config in file",
+			logger.NewStringField("path", wc.configJSONPath), obskit.Error(err),
+		)
+		return conf, err
+	}
 
 	// Process dynamic config with the instance cache
 	ProcessDestinationsInSources(configJSON.Sources, wc.dynamicConfigCache)
@@ -161,7 +161,7 @@ func TestSingleWorkspaceGetFromFile(t *testing.T) {
 			configJSONPath: "invalid-path",
 		}
 		require.NoError(t, wc.SetUp())
-		conf, err := wc.getFromFile()
+		conf, err := wc.getFromFile(context.Background())
 		require.Error(t, err)
 		require.Equal(t, map[string]ConfigT{}, conf)
 	})
@@ -177,7 +177,7 @@ func TestSingleWorkspaceGetFromFile(t *testing.T) {
 			configJSONPath: tmpFile.Name(),
 		}
 		require.NoError(t, wc.SetUp())
-		conf, err := wc.getFromFile()
+		conf, err := wc.getFromFile(context.Background())
 		require.Error(t, err)
 		require.Equal(t, map[string]ConfigT{}, conf)
 	})
@@ -227,7 +227,7 @@ func TestSingleWorkspaceGetFromFile(t *testing.T) {
 			configJSONPath: tmpFile.Name(),
 		}
 		require.NoError(t, wc.SetUp())
-		conf, err := wc.getFromFile()
+		conf, err := wc.getFromFile(context.Background())
 		require.NoError(t, err)
 		require.Equal(t, map[string]ConfigT{sampleWorkspaceID: sampleBackendConfig}, conf)
 	})
@@ -272,7 +272,7 @@ func TestSingleWorkspaceGetFromFile(t *testing.T) {
 			configJSONPath: tmpFile.Name(),
 		}
 		require.NoError(t, wc.SetUp())
-		conf, err := wc.getFromFile()
+		conf, err := wc.getFromFile(context.Background())
 		require.NoError(t, err)
 		require.Len(t, conf, 1)
 
@@ -24,6 +24,10 @@ const (
 	/*TopicProcessConfig topic provides updates on backend config of processor enabled destinations, via Subscribe function */
 	TopicProcessConfig Topic = "processConfig"
 
+	/*TopicConfigChanges topic provides the ConfigDiff of each backend config update, via Subscribe function.
+	Like all topics, slow subscribers only receive the latest diff; the complete history is kept by the admin's change log */
+	TopicConfigChanges Topic = "configChanges"
+
 	/*RegulationSuppress refers to Suppress Regulation */
 	RegulationSuppress Regulation = "Suppress"
 
@@ -186,6 +190,13 @@ type Settings struct {
 	DataRetention     DataRetention `json:"dataRetention"`
 	EventAuditEnabled bool          `json:"eventAuditEnabled"`
 	EventBlocking     EventBlocking `json:"eventBlocking"`
+	Priority          Priority      `json:"priority"`
+}
+
+// Priority defines the priority tier (low, medium, high) and weight of a workspace when delivering events
+type Priority struct {
+	Tier   string `json:"tier"`
+	Weight int    `json:"weight"`
 }
 
 type DataRetention struct {
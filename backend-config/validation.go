@@ -0,0 +1,105 @@
+package backendconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// ValidationError lists all the problems found while validating a workspace config
+type ValidationError struct {
+	WorkspaceID string
+	Problems    []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid workspace config %q: %s", e.WorkspaceID, strings.Join(e.Problems, "; "))
+}
+
+// Validate checks the workspace config for missing identifiers, duplicate sources and dangling references,
+// returning a [*ValidationError] describing every problem found, or nil if the config is valid.
+func (c *ConfigT) Validate() error {
+	v := &ValidationError{WorkspaceID: c.WorkspaceID}
+	problemf := func(format string, args ...interface{}) {
+		v.Problems = append(v.Problems, fmt.Sprintf(format, args...))
+	}
+
+	if c.WorkspaceID == "" {
+		problemf("workspaceId is required")
+	}
+
+	sourceIDs := make(map[string]int)
+	writeKeys := make(map[string]string)
+	destinationIDs := make(map[string]struct{})
+	for i := range c.Sources {
+		source := &c.Sources[i]
+		sourceRef := fmt.Sprintf("sources[%d]", i)
+		if source.ID == "" {
+			problemf("%s: id is required", sourceRef)
+		} else {
+			sourceRef = fmt.Sprintf("sources[%d] (id %q)", i, source.ID)
+			if j, ok := sourceIDs[source.ID]; ok {
+				problemf("%s: duplicate source id, already defined by sources[%d]", sourceRef, j)
+			}
+			sourceIDs[source.ID] = i
+		}
+		if source.WriteKey != "" {
+			if otherSourceID, ok := writeKeys[source.WriteKey]; ok {
+				problemf("%s: writeKey is already used by source %q", sourceRef, otherSourceID)
+			}
+			writeKeys[source.WriteKey] = source.ID
+		}
+		if len(source.Config) > 0 && !isJSONObject(source.Config) {
+			problemf("%s: config must be an object", sourceRef)
+		}
+
+		for j := range source.Destinations {
+			destination := &source.Destinations[j]
+			destinationRef := fmt.Sprintf("%s.destinations[%d]", sourceRef, j)
+			if destination.ID == "" {
+				problemf("%s: id is required", destinationRef)
+				continue
+			}
+			destinationRef = fmt.Sprintf("%s (id %q)", destinationRef, destination.ID)
+			destinationIDs[destination.ID] = struct{}{}
+			for k, transformation := range destination.Transformations {
+				transformationRef := fmt.Sprintf("%s.transformations[%d]", destinationRef, k)
+				if transformation.ID == "" {
+					problemf("%s: id is required", transformationRef)
+				}
+				if transformation.VersionID == "" {
+					problemf("%s: versionId is required", transformationRef)
+				}
+			}
+		}
+	}
+
+	connectionIDs := lo.Keys(c.Connections)
+	sort.Strings(connectionIDs)
+	for _, connectionID := range connectionIDs {
+		connection := c.Connections[connectionID]
+		connectionRef := fmt.Sprintf("connections[%q]", connectionID)
+		if connection.SourceID == "" {
+			problemf("%s: sourceId is required", connectionRef)
+		} else if _, ok := sourceIDs[connection.SourceID]; !ok {
+			problemf("%s: sourceId %q doesn't match any source", connectionRef, connection.SourceID)
+		}
+		if connection.DestinationID == "" {
+			problemf("%s: destinationId is required", connectionRef)
+		} else if _, ok := destinationIDs[connection.DestinationID]; !ok {
+			problemf("%s: destinationId %q doesn't match any destination", connectionRef, connection.DestinationID)
+		}
+	}
+
+	if len(v.Problems) > 0 {
+		return v
+	}
+	return nil
+}
+
+func isJSONObject(raw []byte) bool {
+	trimmed := strings.TrimSpace(string(raw))
+	return trimmed == "null" || strings.HasPrefix(trimmed, "{")
+}
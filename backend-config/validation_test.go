@@ -0,0 +1,59 @@
+package backendconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		require.NoError(t, sampleBackendConfig.Validate())
+
+		c := ConfigT{
+			WorkspaceID: "workspace-1",
+			Sources: []SourceT{
+				{ID: "source-1", WriteKey: "wk-1", Destinations: []DestinationT{{ID: "dest-1"}}},
+				{ID: "source-2", WriteKey: "wk-2", Destinations: []DestinationT{{ID: "dest-1"}}},
+			},
+			Connections: map[string]Connection{
+				"conn-1": {SourceID: "source-1", DestinationID: "dest-1"},
+			},
+		}
+		require.NoError(t, c.Validate())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		c := ConfigT{
+			Sources: []SourceT{
+				{ID: "source-1", WriteKey: "wk-1", Config: []byte(`[]`)},
+				{ID: "source-1", WriteKey: "wk-1"},
+				{Destinations: []DestinationT{
+					{},
+					{ID: "dest-1", Transformations: []TransformationT{{ID: "tr-1"}}},
+				}},
+			},
+			Connections: map[string]Connection{
+				"conn-2": {SourceID: "source-2", DestinationID: "dest-1"},
+				"conn-1": {DestinationID: "dest-2"},
+			},
+		}
+		err := c.Validate()
+		require.Error(t, err)
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Equal(t, []string{
+			"workspaceId is required",
+			`sources[0] (id "source-1"): config must be an object`,
+			`sources[1] (id "source-1"): duplicate source id, already defined by sources[0]`,
+			`sources[1] (id "source-1"): writeKey is already used by source "source-1"`,
+			"sources[2]: id is required",
+			"sources[2].destinations[0]: id is required",
+			`sources[2].destinations[1] (id "dest-1").transformations[0]: versionId is required`,
+			`connections["conn-1"]: sourceId is required`,
+			`connections["conn-1"]: destinationId "dest-2" doesn't match any destination`,
+			`connections["conn-2"]: sourceId "source-2" doesn't match any source`,
+		}, validationErr.Problems)
+		require.Contains(t, err.Error(), "invalid workspace config")
+	})
+}
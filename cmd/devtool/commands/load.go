@@ -0,0 +1,505 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/time/rate"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+
+	"github.com/rudderlabs/rudder-server/utils/httputil"
+)
+
+func init() {
+	DefaultList = append(DefaultList, LOAD())
+}
+
+// loadProfile describes the traffic generated by the load command
+type loadProfile struct {
+	mix              map[string]int // event type => weight
+	users            int            // number of distinct users
+	propertySizeMean int            // mean size in bytes of the properties of events, exponentially distributed
+	outOfOrderRatio  float64        // ratio of events with timestamps in the past
+	maxSkew          time.Duration  // maximum age of out of order timestamps
+	duplicateRatio   float64        // ratio of events reusing the messageId of a previous event
+}
+
+var loadProfiles = map[string]loadProfile{
+	// browsers, mostly page views of many anonymous visitors
+	"web": {
+		mix:              map[string]int{"page": 60, "track": 35, "identify": 5},
+		users:            100000,
+		propertySizeMean: 256,
+		outOfOrderRatio:  0.01,
+		maxSkew:          time.Minute,
+		duplicateRatio:   0.001,
+	},
+	// mobile apps, which queue events while offline and retry them
+	"mobile": {
+		mix:              map[string]int{"track": 80, "screen": 15, "identify": 5},
+		users:            50000,
+		propertySizeMean: 512,
+		outOfOrderRatio:  0.1,
+		maxSkew:          24 * time.Hour,
+		duplicateRatio:   0.01,
+	},
+	// backend services, sending fewer but larger events of known users
+	"server": {
+		mix:              map[string]int{"track": 90, "identify": 10},
+		users:            10000,
+		propertySizeMean: 2048,
+		maxSkew:          time.Second,
+	},
+}
+
+func LOAD() *cli.Command {
+	return &cli.Command{
+		Name:   "load",
+		Usage:  "generate realistic traffic against rudder-server's gateway at a target rate, reporting latencies and errors",
+		Action: Load,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "endpoint",
+				Usage:   "HTTP endpoint for rudder-server",
+				Value:   "http://localhost:8080",
+				Aliases: []string{"e"},
+			},
+			&cli.StringFlag{
+				Name:     "write-key",
+				Usage:    "source write key",
+				Required: true,
+				Aliases:  []string{"w"},
+			},
+			&cli.StringFlag{
+				Name:    "profile",
+				Usage:   "traffic profile, one of web, mobile or server; overridden by the flags below",
+				Value:   "web",
+				Aliases: []string{"p"},
+			},
+			&cli.Float64Flag{
+				Name:    "rps",
+				Usage:   "target number of requests per second",
+				Value:   100,
+				Aliases: []string{"r"},
+			},
+			&cli.DurationFlag{
+				Name:    "duration",
+				Usage:   "duration of the test, including the ramp-up",
+				Value:   time.Minute,
+				Aliases: []string{"d"},
+			},
+			&cli.DurationFlag{
+				Name:  "ramp-up",
+				Usage: "duration of the linear increase of the rate up to the target one",
+			},
+			&cli.IntFlag{
+				Name:    "batch-size",
+				Usage:   "number of events per request, sent to /v1/batch",
+				Value:   1,
+				Aliases: []string{"b"},
+			},
+			&cli.IntFlag{
+				Name:  "concurrency",
+				Usage: "maximum number of requests in flight, requests exceeding it are skipped",
+				Value: 200,
+			},
+			&cli.StringFlag{
+				Name:  "mix",
+				Usage: "weights of event types, e.g. track=70,identify=10,page=20",
+			},
+			&cli.IntFlag{
+				Name:  "users",
+				Usage: "number of distinct users",
+			},
+			&cli.IntFlag{
+				Name:  "property-size",
+				Usage: "mean size in bytes of the properties of events, exponentially distributed",
+			},
+			&cli.Float64Flag{
+				Name:  "out-of-order",
+				Usage: "ratio of events with timestamps in the past, from 0 to 1",
+			},
+			&cli.DurationFlag{
+				Name:  "max-skew",
+				Usage: "maximum age of the timestamps of out of order events",
+			},
+			&cli.Float64Flag{
+				Name:  "duplicates",
+				Usage: "ratio of events reusing the messageId of a previous event, from 0 to 1",
+			},
+		},
+	}
+}
+
+func Load(c *cli.Context) error {
+	profile, ok := loadProfiles[c.String("profile")]
+	if !ok {
+		return fmt.Errorf("unknown profile %q", c.String("profile"))
+	}
+	if c.IsSet("mix") {
+		mix, err := parseMix(c.String("mix"))
+		if err != nil {
+			return err
+		}
+		profile.mix = mix
+	}
+	if c.IsSet("users") {
+		profile.users = c.Int("users")
+	}
+	if c.IsSet("property-size") {
+		profile.propertySizeMean = c.Int("property-size")
+	}
+	if c.IsSet("out-of-order") {
+		profile.outOfOrderRatio = c.Float64("out-of-order")
+	}
+	if c.IsSet("max-skew") {
+		profile.maxSkew = c.Duration("max-skew")
+	}
+	if c.IsSet("duplicates") {
+		profile.duplicateRatio = c.Float64("duplicates")
+	}
+	if profile.users < 1 {
+		return fmt.Errorf("users must be positive")
+	}
+	targetRPS, duration, rampUp := c.Float64("rps"), c.Duration("duration"), c.Duration("ramp-up")
+	if targetRPS <= 0 {
+		return fmt.Errorf("rps must be positive")
+	}
+
+	ctx, cancel := context.WithTimeout(c.Context, duration)
+	defer cancel()
+	gen := newLoadGenerator(profile)
+	url := c.String("endpoint") + "/v1/batch"
+	writeKey, batchSize, concurrency := c.String("write-key"), c.Int("batch-size"), c.Int("concurrency")
+	client := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: concurrency, MaxConnsPerHost: concurrency},
+	}
+	res := &loadResults{}
+
+	limiter := rate.NewLimiter(rampedLimit(targetRPS, rampUp, 0), 1)
+	start := time.Now()
+	if rampUp > 0 {
+		go func() {
+			ticker := time.NewTicker(100 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					elapsed := time.Since(start)
+					limiter.SetLimit(rampedLimit(targetRPS, rampUp, elapsed))
+					if elapsed >= rampUp {
+						return
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fmt.Println(res.progress(time.Since(start)))
+			}
+		}
+	}()
+
+	fmt.Printf("sending %s traffic to %s at up to %g requests/s for %s\n", c.String("profile"), url, targetRPS, duration)
+	var wg sync.WaitGroup
+	inFlight := make(chan struct{}, concurrency)
+	for waitLimiter(ctx, limiter) == nil {
+		body, anonymousID, err := gen.batch(batchSize, time.Now())
+		if err != nil {
+			return err
+		}
+		select {
+		case inFlight <- struct{}{}:
+		default:
+			res.skipped.Add(1)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-inFlight; wg.Done() }()
+			res.record(sendLoadRequest(client, url, writeKey, anonymousID, body))
+		}()
+	}
+	wg.Wait()
+	fmt.Println(res.summary(time.Since(start)))
+	return nil
+}
+
+// waitLimiter waits until the limiter allows a request. Long waits are split so that the limit is checked again at least every
+// 100ms, thus requests waiting while the rate ramps up are sent at the increased rate, rather than at the one they started waiting at.
+func waitLimiter(ctx context.Context, limiter *rate.Limiter) error {
+	const maxWait = 100 * time.Millisecond
+	for {
+		r := limiter.Reserve()
+		delay, reserved := r.Delay(), true
+		if delay > maxWait {
+			r.Cancel()
+			delay, reserved = maxWait, false
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if reserved {
+				r.Cancel()
+			}
+			return ctx.Err()
+		case <-timer.C:
+			if reserved {
+				return nil
+			}
+		}
+	}
+}
+
+// rampedLimit returns the rate of requests after elapsed time, increasing linearly from 1% of the target rate up to it during the ramp-up
+func rampedLimit(targetRPS float64, rampUp, elapsed time.Duration) rate.Limit {
+	if rampUp <= 0 {
+		return rate.Limit(targetRPS)
+	}
+	progress := float64(elapsed) / float64(rampUp)
+	return rate.Limit(targetRPS * math.Max(0.01, math.Min(1, progress)))
+}
+
+// sendLoadRequest sends a batch request, returning its status code, 0 if it failed, and its latency
+func sendLoadRequest(client *http.Client, url, writeKey, anonymousID string, body []byte) (int, time.Duration) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, 0
+	}
+	req.SetBasicAuth(writeKey, "")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("AnonymousId", anonymousID)
+	start := time.Now()
+	resp, err := client.Do(req)
+	latency := time.Since(start)
+	if err != nil {
+		return 0, latency
+	}
+	httputil.CloseResponse(resp)
+	return resp.StatusCode, latency
+}
+
+func parseMix(value string) (map[string]int, error) {
+	mix := make(map[string]int)
+	for _, part := range strings.Split(value, ",") {
+		eventType, weight, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix %q, expected type=weight", part)
+		}
+		w, err := strconv.Atoi(weight)
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid weight of %q: %q", eventType, weight)
+		}
+		switch eventType = strings.TrimSpace(eventType); eventType {
+		case "track", "identify", "page", "screen", "group", "alias":
+			mix[eventType] = w
+		default:
+			return nil, fmt.Errorf("unsupported event type %q", eventType)
+		}
+	}
+	return mix, nil
+}
+
+// loadGenerator generates events according to a profile
+type loadGenerator struct {
+	profile     loadProfile
+	types       []string
+	totalWeight int
+
+	mu         sync.Mutex
+	rand       *rand.Rand
+	messageIDs []string // ring of recent message ids, for generating duplicates
+	next       int
+}
+
+func newLoadGenerator(profile loadProfile) *loadGenerator {
+	g := &loadGenerator{
+		profile:    profile,
+		rand:       rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+		messageIDs: make([]string, 0, 1000),
+	}
+	for eventType, weight := range profile.mix {
+		g.types = append(g.types, eventType)
+		g.totalWeight += weight
+	}
+	sort.Strings(g.types)
+	return g
+}
+
+// batch returns the body of a batch request with n events of the same user, along with the user's anonymous id
+func (g *loadGenerator) batch(n int, now time.Time) ([]byte, string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	user := g.rand.IntN(g.profile.users)
+	anonymousID := uuid.NewSHA1(uuid.NameSpaceOID, []byte(strconv.Itoa(user))).String()
+	events := make([]map[string]any, n)
+	for i := range events {
+		events[i] = g.event(user, anonymousID, now)
+	}
+	body, err := jsonrs.Marshal(map[string]any{"batch": events, "sentAt": now.Format(time.RFC3339Nano)})
+	return body, anonymousID, err
+}
+
+func (g *loadGenerator) event(user int, anonymousID string, now time.Time) map[string]any {
+	eventType := g.eventType()
+	timestamp := now
+	if g.rand.Float64() < g.profile.outOfOrderRatio && g.profile.maxSkew > 0 {
+		timestamp = now.Add(-time.Duration(g.rand.Int64N(int64(g.profile.maxSkew))))
+	}
+	event := map[string]any{
+		"type":              eventType,
+		"messageId":         g.messageID(),
+		"anonymousId":       anonymousID,
+		"userId":            "user-" + strconv.Itoa(user),
+		"originalTimestamp": timestamp.Format(time.RFC3339Nano),
+		"sentAt":            now.Format(time.RFC3339Nano),
+		"channel":           "devtool",
+		"context":           map[string]any{"library": map[string]any{"name": "rudder-devtool-load"}},
+	}
+	properties := map[string]any{"padding": g.padding()}
+	switch eventType {
+	case "track":
+		event["event"] = []string{"Product Viewed", "Product Added", "Checkout Started", "Order Completed"}[g.rand.IntN(4)]
+		properties["price"] = math.Round(g.rand.Float64()*10000) / 100
+		event["properties"] = properties
+	case "page", "screen":
+		event["name"] = []string{"Home", "Search", "Product", "Cart"}[g.rand.IntN(4)]
+		event["properties"] = properties
+	case "identify", "group":
+		event["context"].(map[string]any)["traits"] = properties
+		if eventType == "group" {
+			event["groupId"] = "group-" + strconv.Itoa(user%100)
+		}
+	case "alias":
+		event["previousId"] = anonymousID
+	}
+	return event
+}
+
+func (g *loadGenerator) eventType() string {
+	if g.totalWeight == 0 {
+		return "track"
+	}
+	n := g.rand.IntN(g.totalWeight)
+	for _, eventType := range g.types {
+		if n -= g.profile.mix[eventType]; n < 0 {
+			return eventType
+		}
+	}
+	return g.types[len(g.types)-1]
+}
+
+// messageID returns a new message id, or a recent one to simulate duplicates
+func (g *loadGenerator) messageID() string {
+	if len(g.messageIDs) > 0 && g.rand.Float64() < g.profile.duplicateRatio {
+		return g.messageIDs[g.rand.IntN(len(g.messageIDs))]
+	}
+	id := uuid.NewString()
+	if len(g.messageIDs) < cap(g.messageIDs) {
+		g.messageIDs = append(g.messageIDs, id)
+	} else {
+		g.messageIDs[g.next] = id
+		g.next = (g.next + 1) % len(g.messageIDs)
+	}
+	return id
+}
+
+// padding returns a string of exponentially distributed size, capped to 32KB
+func (g *loadGenerator) padding() string {
+	size := int(math.Min(g.rand.ExpFloat64()*float64(g.profile.propertySizeMean), 32*1024))
+	return strings.Repeat("x", size)
+}
+
+// loadResults collects the outcome of load requests
+type loadResults struct {
+	skipped atomic.Int64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	statuses  map[string]int64 // 2xx, 4xx, 5xx or error
+}
+
+func (r *loadResults) record(statusCode int, latency time.Duration) {
+	class := "error"
+	if statusCode > 0 {
+		class = strconv.Itoa(statusCode/100) + "xx"
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.statuses == nil {
+		r.statuses = make(map[string]int64)
+	}
+	r.statuses[class]++
+	if statusCode > 0 {
+		r.latencies = append(r.latencies, latency)
+	}
+}
+
+func (r *loadResults) progress(elapsed time.Duration) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sent int64
+	for _, count := range r.statuses {
+		sent += count
+	}
+	return fmt.Sprintf("%s: %d requests (%.1f/s) %s, %d skipped",
+		elapsed.Round(time.Second), sent, float64(sent)/elapsed.Seconds(), r.statusesString(), r.skipped.Load())
+}
+
+func (r *loadResults) summary(elapsed time.Duration) string {
+	r.mu.Lock()
+	latencies := slices.Clone(r.latencies)
+	r.mu.Unlock()
+	slices.Sort(latencies)
+	percentile := func(p float64) time.Duration {
+		if len(latencies) == 0 {
+			return 0
+		}
+		return latencies[int(math.Ceil(p/100*float64(len(latencies))))-1]
+	}
+	var max time.Duration
+	if len(latencies) > 0 {
+		max = latencies[len(latencies)-1]
+	}
+	return fmt.Sprintf("%s\nlatency: p50=%s p90=%s p95=%s p99=%s max=%s",
+		r.progress(elapsed), percentile(50), percentile(90), percentile(95), percentile(99), max)
+}
+
+// statusesString returns the counts of requests by status class, must be called with the lock held
+func (r *loadResults) statusesString() string {
+	classes := make([]string, 0, len(r.statuses))
+	for class := range r.statuses {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	parts := make([]string, len(classes))
+	for i, class := range classes {
+		parts[i] = fmt.Sprintf("%s=%d", class, r.statuses[class])
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
@@ -0,0 +1,260 @@
+package commands
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/time/rate"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+type loadBatch struct {
+	SentAt string           `json:"sentAt"`
+	Batch  []map[string]any `json:"batch"`
+}
+
+func generateBatches(t *testing.T, profile loadProfile, batches, batchSize int, now time.Time) ([]loadBatch, []string) {
+	t.Helper()
+	gen := newLoadGenerator(profile)
+	generated := make([]loadBatch, batches)
+	anonymousIDs := make([]string, batches)
+	for i := range generated {
+		body, anonymousID, err := gen.batch(batchSize, now)
+		require.NoError(t, err)
+		require.NoError(t, jsonrs.Unmarshal(body, &generated[i]))
+		require.Len(t, generated[i].Batch, batchSize)
+		anonymousIDs[i] = anonymousID
+	}
+	return generated, anonymousIDs
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := parseMix("track=70, identify=10,page=20")
+	require.NoError(t, err)
+	require.Equal(t, map[string]int{"track": 70, "identify": 10, "page": 20}, mix)
+
+	for _, invalid := range []string{"track", "track=-1", "track=many", "purchase=10", ""} {
+		_, err := parseMix(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestLoadGenerator(t *testing.T) {
+	now := time.Now()
+
+	t.Run("events", func(t *testing.T) {
+		batches, anonymousIDs := generateBatches(t, loadProfile{mix: map[string]int{"track": 1}, users: 10, propertySizeMean: 16}, 10, 5, now)
+		for i, b := range batches {
+			require.Equal(t, now.Format(time.RFC3339Nano), b.SentAt)
+			userID := b.Batch[0]["userId"]
+			for _, event := range b.Batch {
+				require.Equal(t, "track", event["type"])
+				require.NotEmpty(t, event["event"])
+				require.NotEmpty(t, event["messageId"])
+				require.Equal(t, anonymousIDs[i], event["anonymousId"], "events of a batch should be of the same user")
+				require.Equal(t, userID, event["userId"])
+				require.Equal(t, now.Format(time.RFC3339Nano), event["originalTimestamp"])
+				require.Contains(t, event["properties"], "padding")
+			}
+		}
+	})
+
+	t.Run("mix", func(t *testing.T) {
+		batches, _ := generateBatches(t, loadProfile{mix: map[string]int{"track": 80, "identify": 20, "alias": 0}, users: 10}, 1000, 10, now)
+		counts := make(map[string]int)
+		for _, b := range batches {
+			for _, event := range b.Batch {
+				counts[event["type"].(string)]++
+			}
+		}
+		require.Zero(t, counts["alias"], "event types of no weight shouldn't be generated")
+		require.InDelta(t, 8000, counts["track"], 400)
+		require.InDelta(t, 2000, counts["identify"], 400)
+
+		batches, _ = generateBatches(t, loadProfile{users: 1}, 1, 1, now)
+		require.Equal(t, "track", batches[0].Batch[0]["type"], "track events should be generated without a mix")
+	})
+
+	t.Run("users", func(t *testing.T) {
+		_, anonymousIDs := generateBatches(t, loadProfile{users: 5}, 1000, 1, now)
+		distinct := make(map[string]struct{})
+		for _, anonymousID := range anonymousIDs {
+			distinct[anonymousID] = struct{}{}
+		}
+		require.Len(t, distinct, 5)
+
+		_, again := generateBatches(t, loadProfile{users: 1}, 1, 1, now)
+		_, other := generateBatches(t, loadProfile{users: 1}, 1, 1, now)
+		require.Equal(t, again, other, "anonymous ids should be stable across runs")
+	})
+
+	t.Run("out of order timestamps", func(t *testing.T) {
+		batches, _ := generateBatches(t, loadProfile{users: 1, outOfOrderRatio: 0.5, maxSkew: time.Hour}, 2000, 1, now)
+		var outOfOrder int
+		for _, b := range batches {
+			timestamp, err := time.Parse(time.RFC3339Nano, b.Batch[0]["originalTimestamp"].(string))
+			require.NoError(t, err)
+			require.False(t, timestamp.After(now))
+			require.Less(t, now.Sub(timestamp), time.Hour)
+			if timestamp.Before(now) {
+				outOfOrder++
+			}
+		}
+		require.InDelta(t, 1000, outOfOrder, 150)
+	})
+
+	t.Run("duplicates", func(t *testing.T) {
+		count := func(profile loadProfile) int {
+			batches, _ := generateBatches(t, profile, 2000, 1, now)
+			seen := make(map[string]struct{})
+			var duplicates int
+			for _, b := range batches {
+				messageID := b.Batch[0]["messageId"].(string)
+				if _, ok := seen[messageID]; ok {
+					duplicates++
+				}
+				seen[messageID] = struct{}{}
+			}
+			return duplicates
+		}
+		require.Zero(t, count(loadProfile{users: 1}))
+		require.InDelta(t, 200, count(loadProfile{users: 1, duplicateRatio: 0.1}), 60)
+	})
+
+	t.Run("property size", func(t *testing.T) {
+		gen := newLoadGenerator(loadProfile{users: 1, propertySizeMean: 1000})
+		var total int
+		for range 10000 {
+			size := len(gen.padding())
+			require.LessOrEqual(t, size, 32*1024)
+			total += size
+		}
+		require.InDelta(t, 1000, total/10000, 100, "sizes should be exponentially distributed around the mean")
+		require.Empty(t, newLoadGenerator(loadProfile{users: 1}).padding())
+	})
+}
+
+func TestRampedLimit(t *testing.T) {
+	require.Equal(t, rate.Limit(100), rampedLimit(100, 0, 0), "the target rate should be used without a ramp-up")
+	require.Equal(t, rate.Limit(1), rampedLimit(100, time.Minute, 0), "the ramp-up should start at 1% of the target rate")
+	require.InDelta(t, 50, float64(rampedLimit(100, time.Minute, 30*time.Second)), 0.001)
+	require.Equal(t, rate.Limit(100), rampedLimit(100, time.Minute, time.Minute))
+	require.Equal(t, rate.Limit(100), rampedLimit(100, time.Minute, time.Hour))
+}
+
+func TestLoadResults(t *testing.T) {
+	var res loadResults
+	for i := 1; i <= 100; i++ {
+		res.record(http.StatusOK, time.Duration(i)*time.Millisecond)
+	}
+	res.record(http.StatusTooManyRequests, time.Second)
+	res.record(0, time.Minute)
+	res.skipped.Add(2)
+	require.Equal(t,
+		"10s: 102 requests (10.2/s) [2xx=100 4xx=1 error=1], 2 skipped\nlatency: p50=51ms p90=91ms p95=96ms p99=100ms max=1s",
+		res.summary(10*time.Second),
+		"latencies of requests which got no response shouldn't be accounted for",
+	)
+	require.Contains(t, (&loadResults{}).summary(time.Second), "p50=0s")
+}
+
+func TestLoad(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+		events   int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeKey, _, _ := r.BasicAuth()
+		body, err := io.ReadAll(r.Body)
+		if r.URL.Path != "/v1/batch" || writeKey != "write-key" || r.Header.Get("AnonymousId") == "" || err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var b loadBatch
+		if err := jsonrs.Unmarshal(body, &b); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		requests++
+		events += len(b.Batch)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	run := func(t *testing.T, args ...string) {
+		t.Helper()
+		app := cli.NewApp()
+		app.Commands = []*cli.Command{LOAD()}
+		require.NoError(t, app.Run(append([]string{"devtool", "load", "--endpoint", srv.URL, "--write-key", "write-key"}, args...)))
+	}
+
+	t.Run("rate", func(t *testing.T) {
+		requests, events = 0, 0
+		run(t, "--rps", "50", "--duration", "2s", "--batch-size", "3", "--profile", "mobile")
+		require.InDelta(t, 100, requests, 15, "requests should be sent at the target rate")
+		require.Equal(t, 3*requests, events)
+	})
+
+	t.Run("ramp-up", func(t *testing.T) {
+		requests = 0
+		run(t, "--rps", "50", "--duration", "2s", "--ramp-up", "2s")
+		require.InDelta(t, 50, requests, 15, "the rate should increase linearly during the ramp-up")
+	})
+
+	t.Run("invalid flags", func(t *testing.T) {
+		app := cli.NewApp()
+		app.Commands = []*cli.Command{LOAD()}
+		base := []string{"devtool", "load", "--endpoint", srv.URL, "--write-key", "write-key", "--duration", "10ms"}
+		require.Error(t, app.Run(append(base, "--profile", "desktop")))
+		require.Error(t, app.Run(append(base, "--rps", "0")))
+		require.Error(t, app.Run(append(base, "--users", "0")))
+		require.Error(t, app.Run(append(base, "--mix", "purchase=1")))
+	})
+
+	t.Run("wait limiter", func(t *testing.T) {
+		limiter := rate.NewLimiter(1, 1)
+		require.NoError(t, waitLimiter(context.Background(), limiter))
+		time.AfterFunc(200*time.Millisecond, func() { limiter.SetLimit(100) })
+		start := time.Now()
+		require.NoError(t, waitLimiter(context.Background(), limiter))
+		require.Less(t, time.Since(start), 500*time.Millisecond, "waits should follow limit increases")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, waitLimiter(ctx, rate.NewLimiter(0.1, 0)), context.DeadlineExceeded)
+	})
+
+	t.Run("concurrency", func(t *testing.T) {
+		var inFlight, maxInFlight atomic.Int64
+		block := make(chan struct{})
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				current := maxInFlight.Load()
+				if n <= current || maxInFlight.CompareAndSwap(current, n) {
+					break
+				}
+			}
+			<-block
+		}))
+		defer slow.Close()
+		time.AfterFunc(time.Second, func() { close(block) })
+
+		app := cli.NewApp()
+		app.Commands = []*cli.Command{LOAD()}
+		require.NoError(t, app.Run([]string{"devtool", "load", "--endpoint", slow.URL, "--write-key", "write-key", "--rps", "100", "--duration", "500ms", "--concurrency", "2"}))
+		require.EqualValues(t, 2, maxInFlight.Load(), "requests exceeding the concurrency should be skipped")
+	})
+}
@@ -2,10 +2,15 @@ package commands
 
 import (
 	"fmt"
+	"hash/fnv"
 	"io"
 	"log"
+	"math/rand/v2"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/urfave/cli/v2"
@@ -24,7 +29,7 @@ func WEBHOOK() *cli.Command {
 		Subcommands: []*cli.Command{
 			{
 				Name:   "run",
-				Usage:  "switch between normal and degraded mode of rudder-server",
+				Usage:  "run a mock webhook destination, optionally failing requests for testing retries, ordering and throttling",
 				Action: WebhookRun,
 				Flags: []cli.Flag{
 					&cli.IntFlag{
@@ -38,6 +43,52 @@ func WEBHOOK() *cli.Command {
 						Usage:   "print more",
 						Value:   false,
 					},
+					&cli.DurationFlag{
+						Name:  "latency",
+						Usage: "delay every response by this duration",
+					},
+					&cli.DurationFlag{
+						Name:  "latency-jitter",
+						Usage: "delay every response by a random duration up to this one, on top of --latency",
+					},
+					&cli.Float64Flag{
+						Name:  "throttle-ratio",
+						Usage: "ratio of requests to respond to with 429, from 0 to 1",
+					},
+					&cli.IntFlag{
+						Name:  "retry-after",
+						Usage: "seconds of the Retry-After header of 429 responses, not set if 0",
+					},
+					&cli.Float64Flag{
+						Name:  "error-ratio",
+						Usage: "ratio of requests to respond to with --error-status, from 0 to 1",
+					},
+					&cli.IntFlag{
+						Name:  "error-status",
+						Usage: "status code of failed requests",
+						Value: http.StatusInternalServerError,
+					},
+					&cli.DurationFlag{
+						Name:  "burst-every",
+						Usage: "start a burst of --error-status responses to every request at this interval",
+					},
+					&cli.DurationFlag{
+						Name:  "burst-duration",
+						Usage: "duration of each burst of errors",
+						Value: 10 * time.Second,
+					},
+					&cli.Float64Flag{
+						Name:  "timeout-ratio",
+						Usage: "ratio of requests to never respond to, until the client gives up, from 0 to 1",
+					},
+					&cli.StringSliceFlag{
+						Name:  "fail-users",
+						Usage: "user ids (or anonymous ids) whose events always fail with --error-status",
+					},
+					&cli.Float64Flag{
+						Name:  "fail-users-ratio",
+						Usage: "ratio of users whose events always fail with --error-status, from 0 to 1",
+					},
 				},
 			},
 		},
@@ -49,35 +100,121 @@ func WEBHOOK() *cli.Command {
 func WebhookRun(c *cli.Context) error {
 	port := c.Int("port")
 
+	wh := newWebhook(webhookConfig{
+		Latency:        c.Duration("latency"),
+		LatencyJitter:  c.Duration("latency-jitter"),
+		ThrottleRatio:  c.Float64("throttle-ratio"),
+		RetryAfter:     c.Int("retry-after"),
+		ErrorRatio:     c.Float64("error-ratio"),
+		ErrorStatus:    c.Int("error-status"),
+		BurstEvery:     c.Duration("burst-every"),
+		BurstDuration:  c.Duration("burst-duration"),
+		TimeoutRatio:   c.Float64("timeout-ratio"),
+		FailUsers:      c.StringSlice("fail-users"),
+		FailUsersRatio: c.Float64("fail-users-ratio"),
+	})
+	wh.Verbose = c.Bool("verbose")
+
 	fmt.Printf("listening on: http://localhost:%d \n", port)
 	httpWebServer := &http.Server{
-		Addr: ":" + strconv.Itoa(port),
-		Handler: &webhook{
-			Verbose: c.Bool("verbose"),
-		},
+		Addr:              ":" + strconv.Itoa(port),
+		Handler:           wh.handler(),
 		ReadTimeout:       0 * time.Second,
 		ReadHeaderTimeout: 0 * time.Second,
-		WriteTimeout:      10 * time.Second,
 		IdleTimeout:       720 * time.Second,
 		MaxHeaderBytes:    524288,
 	}
 	return httpWebServer.ListenAndServe()
 }
 
+// webhookConfig are the failure modes of the webhook, which can be changed while it is running through /_mock/config
+type webhookConfig struct {
+	Latency        time.Duration `json:"latency"`
+	LatencyJitter  time.Duration `json:"latencyJitter"`
+	ThrottleRatio  float64       `json:"throttleRatio"`
+	RetryAfter     int           `json:"retryAfter"`
+	ErrorRatio     float64       `json:"errorRatio"`
+	ErrorStatus    int           `json:"errorStatus"`
+	BurstEvery     time.Duration `json:"burstEvery"`
+	BurstDuration  time.Duration `json:"burstDuration"`
+	TimeoutRatio   float64       `json:"timeoutRatio"`
+	FailUsers      []string      `json:"failUsers"`
+	FailUsersRatio float64       `json:"failUsersRatio"`
+}
+
+// failsUser returns true if all events of the user should fail
+func (c *webhookConfig) failsUser(user string) bool {
+	if slices.Contains(c.FailUsers, user) {
+		return true
+	}
+	if c.FailUsersRatio <= 0 || user == "" {
+		return false
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(user))
+	return float64(h.Sum32()%10000) < c.FailUsersRatio*10000
+}
+
 type webhook struct {
 	Verbose bool
+
+	mu      sync.Mutex
+	config  webhookConfig
+	started time.Time
+	users   map[string]*webhookUser
+	stats   webhookStats
 }
 
-type payload struct {
-	SentAt string
+// webhookUser are the events received for a user
+type webhookUser struct {
+	Events []receivedEvent `json:"events"`
+	// Pending is the message id of the last event which failed with a retryable status. Until it is received again,
+	// any other event of the user is delivered out of order.
+	Pending string `json:"pending,omitempty"`
 }
 
-func (*webhook) computeTime(b []byte) {
-	p := payload{}
-	err := jsonrs.Unmarshal(b, &p)
-	if err != nil {
-		return
+type receivedEvent struct {
+	MessageID  string    `json:"messageId"`
+	Status     int       `json:"status"` // 0 if the request timed out
+	ReceivedAt time.Time `json:"receivedAt"`
+}
+
+type webhookStats struct {
+	Requests          int64            `json:"requests"`
+	Statuses          map[string]int64 `json:"statuses"`
+	Users             int              `json:"users"`
+	OrderViolations   int64            `json:"orderViolations"`
+	LastViolation     string           `json:"lastViolation,omitempty"`
+	DuplicateMessages int64            `json:"duplicateMessages"`
+}
+
+func newWebhook(config webhookConfig) *webhook {
+	return &webhook{
+		config:  config,
+		started: time.Now(),
+		users:   make(map[string]*webhookUser),
 	}
+}
+
+// handler returns the handler of the webhook's events, along with its /_mock endpoints
+func (wh *webhook) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/_mock/config", wh.serveConfig)
+	mux.HandleFunc("/_mock/stats", wh.serveStats)
+	mux.HandleFunc("/_mock/events", wh.serveEvents)
+	mux.HandleFunc("/_mock/reset", wh.serveReset)
+	mux.Handle("/", wh)
+	return mux
+}
+
+type payload struct {
+	SentAt      string
+	MessageID   string `json:"messageId"`
+	UserID      string `json:"userId"`
+	AnonymousID string `json:"anonymousId"`
+}
+
+func (*webhook) computeTime(p payload) {
 	sentAt, err := time.Parse(time.RFC3339, p.SentAt)
 	if err != nil {
 		log.Println(err)
@@ -94,9 +231,169 @@ func (wh *webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	var p payload
+	_ = jsonrs.Unmarshal(b, &p)
+	user := p.UserID
+	if user == "" {
+		user = p.AnonymousID
+	}
+
+	wh.mu.Lock()
+	config := wh.config
+	wh.mu.Unlock()
+
+	wh.computeTime(p)
+	if delay := config.Latency; delay > 0 || config.LatencyJitter > 0 {
+		if config.LatencyJitter > 0 {
+			delay += rand.N(config.LatencyJitter)
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+	}
+
+	status := http.StatusOK
+	switch {
+	case config.failsUser(user):
+		status = config.ErrorStatus
+	case wh.inBurst(config):
+		status = config.ErrorStatus
+	case rand.Float64() < config.TimeoutRatio:
+		status = 0
+	case rand.Float64() < config.ThrottleRatio:
+		status = http.StatusTooManyRequests
+	case rand.Float64() < config.ErrorRatio:
+		status = config.ErrorStatus
+	}
+	wh.record(user, p.MessageID, status)
+	if wh.Verbose {
+		log.Printf("responding to %q of user %q with %d", p.MessageID, user, status)
+	}
+
+	switch status {
+	case 0:
+		<-r.Context().Done()
+	case http.StatusOK:
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("OK"))
+	default:
+		if status == http.StatusTooManyRequests && config.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(config.RetryAfter))
+		}
+		http.Error(w, http.StatusText(status), status)
+	}
+}
+
+// inBurst returns true if errors are being burst at the moment
+func (wh *webhook) inBurst(config webhookConfig) bool {
+	if config.BurstEvery <= 0 {
+		return false
+	}
+	return time.Since(wh.started)%config.BurstEvery < config.BurstDuration
+}
+
+// record records an event of a user, checking that it isn't delivered while an earlier failed event of the user is
+// still pending. This is the ordering guarantee the router provides, unless the failed event gets aborted.
+func (wh *webhook) record(user, messageID string, status int) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	wh.stats.Requests++
+	if wh.stats.Statuses == nil {
+		wh.stats.Statuses = make(map[string]int64)
+	}
+	if status == 0 {
+		wh.stats.Statuses["timeout"]++
+	} else {
+		wh.stats.Statuses[strconv.Itoa(status)]++
+	}
+	if user == "" || messageID == "" {
+		return
+	}
+	u, ok := wh.users[user]
+	if !ok {
+		u = &webhookUser{}
+		wh.users[user] = u
+	}
+	for _, e := range u.Events {
+		if e.MessageID == messageID && e.Status == http.StatusOK {
+			wh.stats.DuplicateMessages++
+			break
+		}
+	}
+	if u.Pending != "" && u.Pending != messageID {
+		wh.stats.OrderViolations++
+		wh.stats.LastViolation = fmt.Sprintf("user %q: received %q while %q is pending", user, messageID, u.Pending)
+		log.Println("order violation:", wh.stats.LastViolation)
+	}
+	u.Events = append(u.Events, receivedEvent{MessageID: messageID, Status: status, ReceivedAt: time.Now()})
+	switch {
+	case status == http.StatusOK:
+		if u.Pending == messageID {
+			u.Pending = ""
+		}
+	case status == 0 || status == http.StatusTooManyRequests || status >= 500:
+		u.Pending = messageID
+	}
+}
+
+// serveConfig returns the current failure modes on GET, and replaces them with the ones in the body on PUT or POST
+func (wh *webhook) serveConfig(w http.ResponseWriter, r *http.Request) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	if r.Method == http.MethodPut || r.Method == http.MethodPost {
+		config := webhookConfig{ErrorStatus: http.StatusInternalServerError}
+		if err := jsonrs.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		wh.config = config
+		wh.started = time.Now()
+		log.Printf("updated config: %+v", config)
+	}
+	writeJSON(w, wh.config)
+}
+
+// serveStats returns the counts of requests by status along with the detected order violations
+func (wh *webhook) serveStats(w http.ResponseWriter, _ *http.Request) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	stats := wh.stats
+	stats.Users = len(wh.users)
+	writeJSON(w, stats)
+}
 
-	wh.computeTime(b)
+// serveEvents returns the events received for the users in the userId query parameter, or for all users if not set
+func (wh *webhook) serveEvents(w http.ResponseWriter, r *http.Request) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	users := wh.users
+	if ids := r.URL.Query().Get("userId"); ids != "" {
+		users = make(map[string]*webhookUser)
+		for _, id := range strings.Split(ids, ",") {
+			if u, ok := wh.users[id]; ok {
+				users[id] = u
+			}
+		}
+	}
+	writeJSON(w, users)
+}
 
+// serveReset forgets all received events and stats
+func (wh *webhook) serveReset(w http.ResponseWriter, _ *http.Request) {
+	wh.mu.Lock()
+	defer wh.mu.Unlock()
+	wh.users = make(map[string]*webhookUser)
+	wh.stats = webhookStats{}
 	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write([]byte("OK"))
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	b, err := jsonrs.MarshalIndent(v, "", "  ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
 }
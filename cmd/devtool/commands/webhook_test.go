@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+
+	"github.com/rudderlabs/rudder-server/utils/httputil"
+)
+
+func startWebhook(t *testing.T, config webhookConfig) (*httptest.Server, func(userID, messageID string) int) {
+	t.Helper()
+	if config.ErrorStatus == 0 {
+		config.ErrorStatus = http.StatusInternalServerError
+	}
+	srv := httptest.NewServer(newWebhook(config).handler())
+	t.Cleanup(srv.Close)
+	client := &http.Client{Timeout: 200 * time.Millisecond}
+	send := func(userID, messageID string) int {
+		body := fmt.Sprintf(`{"userId":%q,"messageId":%q,"SentAt":%q}`, userID, messageID, time.Now().Format(time.RFC3339))
+		resp, err := client.Post(srv.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			return 0
+		}
+		httputil.CloseResponse(resp)
+		return resp.StatusCode
+	}
+	return srv, send
+}
+
+func getJSON(t *testing.T, url string, v any) {
+	t.Helper()
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer func() { httputil.CloseResponse(resp) }()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, jsonrs.NewDecoder(resp.Body).Decode(v))
+}
+
+func putConfig(t *testing.T, srv *httptest.Server, config string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPut, srv.URL+"/_mock/config", strings.NewReader(config))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	httputil.CloseResponse(resp)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func webhookStatsOf(t *testing.T, srv *httptest.Server) webhookStats {
+	t.Helper()
+	var stats webhookStats
+	getJSON(t, srv.URL+"/_mock/stats", &stats)
+	return stats
+}
+
+func TestWebhookFailureModes(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		srv, send := startWebhook(t, webhookConfig{})
+		require.Equal(t, http.StatusOK, send("u1", "m1"))
+		require.Equal(t, webhookStats{Requests: 1, Statuses: map[string]int64{"200": 1}, Users: 1}, webhookStatsOf(t, srv))
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		_, send := startWebhook(t, webhookConfig{Latency: 100 * time.Millisecond, LatencyJitter: 50 * time.Millisecond})
+		start := time.Now()
+		require.Equal(t, http.StatusOK, send("u1", "m1"))
+		require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+		require.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("throttling", func(t *testing.T) {
+		srv, _ := startWebhook(t, webhookConfig{ThrottleRatio: 1, RetryAfter: 5})
+		resp, err := http.Post(srv.URL, "application/json", strings.NewReader(`{"userId":"u1","messageId":"m1"}`))
+		require.NoError(t, err)
+		httputil.CloseResponse(resp)
+		require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		require.Equal(t, "5", resp.Header.Get("Retry-After"))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		srv, send := startWebhook(t, webhookConfig{ErrorRatio: 1, ErrorStatus: http.StatusBadGateway})
+		require.Equal(t, http.StatusBadGateway, send("u1", "m1"))
+		require.Equal(t, map[string]int64{"502": 1}, webhookStatsOf(t, srv).Statuses)
+	})
+
+	t.Run("bursts", func(t *testing.T) {
+		_, send := startWebhook(t, webhookConfig{BurstEvery: time.Second, BurstDuration: 300 * time.Millisecond})
+		require.Equal(t, http.StatusInternalServerError, send("u1", "m1"), "requests should fail during a burst")
+		time.Sleep(500 * time.Millisecond)
+		require.Equal(t, http.StatusOK, send("u1", "m1"), "requests should succeed between bursts")
+	})
+
+	t.Run("timeouts", func(t *testing.T) {
+		srv, send := startWebhook(t, webhookConfig{TimeoutRatio: 1})
+		require.Zero(t, send("u1", "m1"), "requests should never be responded to")
+		require.Equal(t, map[string]int64{"timeout": 1}, webhookStatsOf(t, srv).Statuses)
+	})
+
+	t.Run("failing users", func(t *testing.T) {
+		_, send := startWebhook(t, webhookConfig{FailUsers: []string{"u1"}})
+		require.Equal(t, http.StatusInternalServerError, send("u1", "m1"))
+		require.Equal(t, http.StatusOK, send("u2", "m2"))
+
+		_, send = startWebhook(t, webhookConfig{FailUsersRatio: 0.5})
+		var failed int
+		for i := range 1000 {
+			status := send(fmt.Sprintf("user-%d", i), "m1")
+			require.Equal(t, status, send(fmt.Sprintf("user-%d", i), "m1"), "users should always fail or always succeed")
+			if status != http.StatusOK {
+				failed++
+			}
+		}
+		require.InDelta(t, 500, failed, 100)
+	})
+
+	t.Run("config", func(t *testing.T) {
+		srv, send := startWebhook(t, webhookConfig{ErrorRatio: 1})
+		require.Equal(t, http.StatusInternalServerError, send("u1", "m1"))
+
+		putConfig(t, srv, `{"errorRatio":1,"errorStatus":503}`)
+		var config webhookConfig
+		getJSON(t, srv.URL+"/_mock/config", &config)
+		require.Equal(t, webhookConfig{ErrorRatio: 1, ErrorStatus: http.StatusServiceUnavailable}, config)
+		require.Equal(t, http.StatusServiceUnavailable, send("u1", "m2"))
+
+		putConfig(t, srv, `{"errorRatio":1}`)
+		require.Equal(t, http.StatusInternalServerError, send("u1", "m3"), "the error status should default to 500")
+
+		resp, err := http.Post(srv.URL+"/_mock/config", "application/json", bytes.NewReader([]byte(`{"errorRatio":`)))
+		require.NoError(t, err)
+		httputil.CloseResponse(resp)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestWebhookOrdering(t *testing.T) {
+	srv, send := startWebhook(t, webhookConfig{})
+	failNext := func(status int) {
+		putConfig(t, srv, fmt.Sprintf(`{"errorRatio":1,"errorStatus":%d}`, status))
+	}
+	succeedNext := func() { putConfig(t, srv, `{}`) }
+
+	// events of a user received while an earlier one is pending a retry are out of order
+	failNext(http.StatusInternalServerError)
+	require.Equal(t, http.StatusInternalServerError, send("u1", "m1"))
+	succeedNext()
+	require.Equal(t, http.StatusOK, send("u2", "m1"), "events of other users aren't affected")
+	require.Equal(t, http.StatusOK, send("u1", "m2"))
+	stats := webhookStatsOf(t, srv)
+	require.EqualValues(t, 1, stats.OrderViolations)
+	require.Equal(t, `user "u1": received "m2" while "m1" is pending`, stats.LastViolation)
+
+	// retrying the pending event successfully restores the order
+	require.Equal(t, http.StatusOK, send("u1", "m1"))
+	require.Equal(t, http.StatusOK, send("u1", "m3"))
+	require.EqualValues(t, 1, webhookStatsOf(t, srv).OrderViolations)
+
+	// throttled and timed out events are retried too, unlike events failing with a 4xx which get aborted
+	failNext(http.StatusTooManyRequests)
+	require.Equal(t, http.StatusTooManyRequests, send("u1", "m4"))
+	succeedNext()
+	require.Equal(t, http.StatusOK, send("u1", "m5"))
+	require.EqualValues(t, 2, webhookStatsOf(t, srv).OrderViolations)
+	require.Equal(t, http.StatusOK, send("u1", "m4"))
+
+	putConfig(t, srv, `{"timeoutRatio":1}`)
+	require.Zero(t, send("u1", "m6"))
+	succeedNext()
+	require.Equal(t, http.StatusOK, send("u1", "m7"))
+	require.EqualValues(t, 3, webhookStatsOf(t, srv).OrderViolations)
+	require.Equal(t, http.StatusOK, send("u1", "m6"))
+
+	failNext(http.StatusBadRequest)
+	require.Equal(t, http.StatusBadRequest, send("u1", "m8"))
+	succeedNext()
+	require.Equal(t, http.StatusOK, send("u1", "m9"))
+	require.EqualValues(t, 3, webhookStatsOf(t, srv).OrderViolations)
+
+	// events delivered successfully more than once are duplicates
+	require.Equal(t, http.StatusOK, send("u1", "m9"))
+	stats = webhookStatsOf(t, srv)
+	require.EqualValues(t, 1, stats.DuplicateMessages)
+	require.Equal(t, 2, stats.Users)
+
+	var events map[string]webhookUser
+	getJSON(t, srv.URL+"/_mock/events?userId=u2", &events)
+	require.Len(t, events, 1)
+	require.Len(t, events["u2"].Events, 1)
+	require.Equal(t, "m1", events["u2"].Events[0].MessageID)
+	require.Equal(t, http.StatusOK, events["u2"].Events[0].Status)
+
+	resp, err := http.Post(srv.URL+"/_mock/reset", "", nil)
+	require.NoError(t, err)
+	httputil.CloseResponse(resp)
+	require.Equal(t, webhookStats{}, webhookStatsOf(t, srv))
+	events = nil
+	getJSON(t, srv.URL+"/_mock/events", &events)
+	require.Empty(t, events)
+}
@@ -0,0 +1,327 @@
+package jobsdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/client"
+)
+
+// exportPageSize is the number of jobs exported per call to the server
+const exportPageSize = 1000
+
+type DatasetsArg struct {
+	Prefix string
+}
+
+type CountsArg struct {
+	Prefix  string
+	Dataset string
+	GroupBy []string
+}
+
+type FindJobArg struct {
+	Prefix    string
+	JobID     int64
+	MessageID string
+}
+
+type ExportArg struct {
+	Prefix        string
+	State         string
+	WorkspaceID   string
+	DestinationID string
+	CustomVal     string
+	AfterJobID    int64
+	Limit         int
+}
+
+type UpdateJobStatesArg struct {
+	Prefix        string
+	Action        string
+	States        []string
+	WorkspaceID   string
+	DestinationID string
+	CustomVal     string
+	ErrorCode     string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Reason        string
+	DryRun        bool
+}
+
+func prefixFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name:     "prefix",
+		Usage:    "Specify the table prefix of the jobsdb, e.g. gw, rt, batch_rt or proc_error",
+		Aliases:  []string{"p"},
+		Required: true,
+	}
+}
+
+// Command returns the jobsdb command group
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "jobsdb",
+		Usage: "Inspect the datasets and jobs of jobsdb",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "datasets",
+				Usage: "Lists the datasets of a jobsdb in order, with the range of their job ids",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "prefix",
+						Usage:   "Specify the table prefix of the jobsdb, all jobsdbs if not set",
+						Aliases: []string{"p"},
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return call("JobsDB.Datasets", DatasetsArg{Prefix: c.String("prefix")})
+				},
+			},
+			{
+				Name:  "counts",
+				Usage: "Counts the jobs of a jobsdb by their last state, workspace, destination or custom value",
+				Flags: []cli.Flag{
+					prefixFlag(),
+					&cli.StringFlag{
+						Name:    "dataset",
+						Usage:   "Specify the index of the dataset to count jobs of, all datasets if not set",
+						Aliases: []string{"d"},
+					},
+					&cli.StringFlag{
+						Name:    "group-by",
+						Usage:   "Specify a comma-separated list of state, workspace, destination and customVal",
+						Aliases: []string{"g"},
+						Value:   "state",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return call("JobsDB.Counts", CountsArg{
+						Prefix:  c.String("prefix"),
+						Dataset: c.String("dataset"),
+						GroupBy: strings.Split(c.String("group-by"), ","),
+					})
+				},
+			},
+			{
+				Name:  "job",
+				Usage: "Finds a job by its id, or the jobs of a message id, across datasets and prints their full status history",
+				Flags: []cli.Flag{
+					prefixFlag(),
+					&cli.Int64Flag{
+						Name:    "id",
+						Usage:   "Specify the id of the job",
+						Aliases: []string{"j"},
+					},
+					&cli.StringFlag{
+						Name:    "message-id",
+						Usage:   "Specify the message id of the jobs. There is no index on message ids, so all jobs are scanned, and only the latest jobs of the latest datasets are scanned when payloads are stored as bytea",
+						Aliases: []string{"m"},
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if !c.IsSet("id") && !c.IsSet("message-id") {
+						return fmt.Errorf("either --id or --message-id is required")
+					}
+					return call("JobsDB.FindJob", FindJobArg{
+						Prefix:    c.String("prefix"),
+						JobID:     c.Int64("id"),
+						MessageID: c.String("message-id"),
+					})
+				},
+			},
+			{
+				Name:  "export",
+				Usage: "Exports the jobs of a jobsdb matching the given filters as NDJSON, along with their last status",
+				Flags: []cli.Flag{
+					prefixFlag(),
+					&cli.StringFlag{
+						Name:    "state",
+						Usage:   "Specify the last state of the jobs, e.g. failed, aborted or not_picked_yet",
+						Aliases: []string{"s"},
+					},
+					&cli.StringFlag{
+						Name:    "workspace",
+						Usage:   "Specify the workspace id of the jobs",
+						Aliases: []string{"w"},
+					},
+					&cli.StringFlag{
+						Name:    "dest",
+						Usage:   "Specify the destination id of the jobs",
+						Aliases: []string{"d"},
+					},
+					&cli.StringFlag{
+						Name:    "custom-val",
+						Usage:   "Specify the custom value of the jobs, e.g. the destination type",
+						Aliases: []string{"c"},
+					},
+					&cli.IntFlag{
+						Name:    "limit",
+						Usage:   "Specify the maximum number of jobs to export, all matching jobs if not set",
+						Aliases: []string{"n"},
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Usage:   "Specify the file to export jobs to, stdout if not set",
+						Aliases: []string{"o"},
+					},
+				},
+				Action: Export,
+			},
+			{
+				Name:  "update",
+				Usage: "Aborts, retries or skips the jobs of a jobsdb matching the given filters, only counting them unless --apply is set",
+				Flags: []cli.Flag{
+					prefixFlag(),
+					&cli.StringFlag{
+						Name:     "action",
+						Usage:    "Specify abort, retry or skip. Unprocessed, failed or waiting jobs can be aborted or skipped, aborted or failed jobs can be retried",
+						Aliases:  []string{"a"},
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:    "state",
+						Usage:   "Specify a comma-separated list of the last states of the jobs, all the states the action applies to if not set",
+						Aliases: []string{"s"},
+					},
+					&cli.StringFlag{
+						Name:    "workspace",
+						Usage:   "Specify the workspace id of the jobs",
+						Aliases: []string{"w"},
+					},
+					&cli.StringFlag{
+						Name:    "dest",
+						Usage:   "Specify the destination id of the jobs",
+						Aliases: []string{"d"},
+					},
+					&cli.StringFlag{
+						Name:    "custom-val",
+						Usage:   "Specify the custom value of the jobs, e.g. the destination type",
+						Aliases: []string{"c"},
+					},
+					&cli.StringFlag{
+						Name:    "error-code",
+						Usage:   "Specify the error code of the last status of the jobs",
+						Aliases: []string{"e"},
+					},
+					&cli.TimestampFlag{
+						Name:   "created-after",
+						Usage:  "Specify the time the jobs were created at or after, e.g. 2024-01-02T15:04:05Z",
+						Layout: time.RFC3339,
+					},
+					&cli.TimestampFlag{
+						Name:   "created-before",
+						Usage:  "Specify the time the jobs were created before, e.g. 2024-01-02T15:04:05Z",
+						Layout: time.RFC3339,
+					},
+					&cli.StringFlag{
+						Name:    "reason",
+						Usage:   "Specify the reason of the update, recorded in the new job statuses",
+						Aliases: []string{"r"},
+					},
+					&cli.BoolFlag{
+						Name:  "apply",
+						Usage: "Update the jobs instead of only counting them",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					arg := UpdateJobStatesArg{
+						Prefix:        c.String("prefix"),
+						Action:        c.String("action"),
+						WorkspaceID:   c.String("workspace"),
+						DestinationID: c.String("dest"),
+						CustomVal:     c.String("custom-val"),
+						ErrorCode:     c.String("error-code"),
+						Reason:        c.String("reason"),
+						DryRun:        !c.Bool("apply"),
+					}
+					if c.IsSet("state") {
+						arg.States = strings.Split(c.String("state"), ",")
+					}
+					if t := c.Timestamp("created-after"); t != nil {
+						arg.CreatedAfter = *t
+					}
+					if t := c.Timestamp("created-before"); t != nil {
+						arg.CreatedBefore = *t
+					}
+					return call("JobsDB.UpdateJobStates", arg)
+				},
+			},
+		},
+	}
+}
+
+// Export exports matching jobs page by page, until all of them or limit jobs are exported
+func Export(c *cli.Context) error {
+	var w io.Writer = os.Stdout
+	if c.IsSet("output") {
+		f, err := os.Create(c.String("output"))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	arg := ExportArg{
+		Prefix:        c.String("prefix"),
+		State:         c.String("state"),
+		WorkspaceID:   c.String("workspace"),
+		DestinationID: c.String("dest"),
+		CustomVal:     c.String("custom-val"),
+	}
+	remaining := c.Int("limit")
+	for {
+		arg.Limit = exportPageSize
+		if remaining > 0 && remaining < exportPageSize {
+			arg.Limit = remaining
+		}
+		var reply string
+		if err := client.GetUDSClient().Call("JobsDB.Export", arg, &reply); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, reply); err != nil {
+			return err
+		}
+		lines := strings.Split(strings.TrimSuffix(reply, "\n"), "\n")
+		if reply == "" || len(lines) < arg.Limit {
+			return nil
+		}
+		if remaining > 0 {
+			if remaining -= len(lines); remaining == 0 {
+				return nil
+			}
+		}
+		lastJobID, err := jobIDOf(lines[len(lines)-1])
+		if err != nil {
+			return err
+		}
+		arg.AfterJobID = lastJobID
+	}
+}
+
+// jobIDOf returns the job id of an exported job
+func jobIDOf(line string) (int64, error) {
+	var job struct {
+		JobID int64 `json:"jobId"`
+	}
+	if err := json.Unmarshal([]byte(line), &job); err != nil {
+		return 0, fmt.Errorf("invalid exported job: %w", err)
+	}
+	return job.JobID, nil
+}
+
+func call(method string, arg any) error {
+	var reply string
+	err := client.GetUDSClient().Call(method, arg, &reply)
+	if err == nil {
+		fmt.Println(reply)
+	}
+	return err
+}
@@ -9,6 +9,9 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/client"
+	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/jobsdb"
+	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/reporting"
+	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/trace"
 	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/warehouse"
 )
 
@@ -111,6 +114,47 @@ func main() {
 				return err
 			},
 		},
+		{
+			Name:  "circuit-breakers",
+			Usage: "Gets the status of router destination circuit breakers",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:    "dest-type",
+					Usage:   `Specify destination type to filter circuit breakers, e.g. WEBHOOK`,
+					Aliases: []string{"t"},
+				},
+			},
+			Action: func(c *cli.Context) error {
+				var reply string
+				err := client.GetUDSClient().Call("Router.CircuitBreakers", c.String("dest-type"), &reply)
+				if err == nil {
+					fmt.Println(reply)
+				}
+				return err
+			},
+		},
+		{
+			Name:  "config-changes",
+			Usage: "Gets the most recent backend config changes",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:    "last",
+					Usage:   "Specify the number of most recent config diffs to get, all kept diffs if not set",
+					Aliases: []string{"n"},
+				},
+			},
+			Action: func(c *cli.Context) error {
+				var reply string
+				err := client.GetUDSClient().Call("BackendConfig.ConfigChanges", c.Int("last"), &reply)
+				if err == nil {
+					fmt.Println(reply)
+				}
+				return err
+			},
+		},
+		jobsdb.Command(),
+		trace.Command(),
+		reporting.Command(),
 		{
 			Name:  "logging-config",
 			Usage: "Gets Logging Configuration",
@@ -0,0 +1,113 @@
+package reporting
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/client"
+)
+
+type ReportsFilter struct {
+	WorkspaceID   string
+	SourceID      string
+	DestinationID string
+	PU            string
+	From          time.Time
+	To            time.Time
+}
+
+type CountsArg struct {
+	ReportsFilter
+	GroupBy  []string
+	Interval string
+}
+
+type ErrorSamplesArg struct {
+	ReportsFilter
+	Limit int
+}
+
+// Command returns the reporting command, querying the reports stored locally by the postgres reporting sink
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:  "reporting",
+		Usage: "Queries the reports stored locally, when the postgres reporting sink is enabled",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "counts",
+				Usage: "Prints the counts of events per status, for the last 24 hours by default",
+				Flags: append(filterFlags(),
+					&cli.StringSliceFlag{
+						Name:    "group-by",
+						Usage:   "Specify the columns to group counts by: workspace, source, destination, pu, status, status_code, event_name, event_type or error_type",
+						Aliases: []string{"g"},
+						Value:   cli.NewStringSlice("status"),
+					},
+					&cli.StringFlag{
+						Name:    "interval",
+						Usage:   "Specify the time interval to bucket counts by: minute, hour or day",
+						Aliases: []string{"i"},
+					},
+				),
+				Action: func(c *cli.Context) error {
+					return call("Reporting.Counts", CountsArg{
+						ReportsFilter: filter(c),
+						GroupBy:       c.StringSlice("group-by"),
+						Interval:      c.String("interval"),
+					})
+				},
+			},
+			{
+				Name:  "errors",
+				Usage: "Prints the latest error samples, along with the events which caused them",
+				Flags: append(filterFlags(),
+					&cli.IntFlag{
+						Name:    "limit",
+						Usage:   "Specify the number of error samples to print",
+						Aliases: []string{"n"},
+						Value:   20,
+					},
+				),
+				Action: func(c *cli.Context) error {
+					return call("Reporting.ErrorSamples", ErrorSamplesArg{
+						ReportsFilter: filter(c),
+						Limit:         c.Int("limit"),
+					})
+				},
+			},
+		},
+	}
+}
+
+func filterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{Name: "workspace-id", Usage: "Specify the workspace id to filter reports by", Aliases: []string{"w"}},
+		&cli.StringFlag{Name: "source-id", Usage: "Specify the source id to filter reports by", Aliases: []string{"s"}},
+		&cli.StringFlag{Name: "destination-id", Usage: "Specify the destination id to filter reports by", Aliases: []string{"d"}},
+		&cli.StringFlag{Name: "pu", Usage: "Specify the processing unit to filter reports by, e.g. router"},
+		&cli.DurationFlag{Name: "since", Usage: "Specify how far back to query reports", Value: 24 * time.Hour},
+	}
+}
+
+func filter(c *cli.Context) ReportsFilter {
+	to := time.Now()
+	return ReportsFilter{
+		WorkspaceID:   c.String("workspace-id"),
+		SourceID:      c.String("source-id"),
+		DestinationID: c.String("destination-id"),
+		PU:            c.String("pu"),
+		From:          to.Add(-c.Duration("since")),
+		To:            to,
+	}
+}
+
+func call(method string, arg any) error {
+	var reply string
+	err := client.GetUDSClient().Call(method, arg, &reply)
+	if err == nil {
+		fmt.Println(reply)
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package trace
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/client"
+)
+
+type TraceArg struct {
+	MessageID string
+	UserID    string
+}
+
+type WatchArg struct {
+	MessageID string
+	UserID    string
+	TTL       time.Duration
+}
+
+// Command returns the trace command, along with its watch subcommand
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:      "trace",
+		Usage:     "Prints the stages an event went through, from the gateway up to its delivery by the router, if it was traced",
+		ArgsUsage: "<messageId>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "user-id",
+				Usage:   "Specify a user id to print the traces of all its traced events, instead of a message id",
+				Aliases: []string{"u"},
+			},
+		},
+		Action: func(c *cli.Context) error {
+			arg := TraceArg{MessageID: c.Args().First(), UserID: c.String("user-id")}
+			if arg.MessageID == "" && arg.UserID == "" {
+				return fmt.Errorf("either a message id or --user-id is required")
+			}
+			return call("Lineage.Trace", arg)
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:  "watch",
+				Usage: "Traces the events of a message id or user id from now on, regardless of sampling",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "message-id",
+						Usage:   "Specify the message id of the events to trace",
+						Aliases: []string{"m"},
+					},
+					&cli.StringFlag{
+						Name:    "user-id",
+						Usage:   "Specify the user id, or anonymous id, of the events to trace",
+						Aliases: []string{"u"},
+					},
+					&cli.DurationFlag{
+						Name:  "ttl",
+						Usage: "Specify for how long to trace the events, 0s to stop tracing them",
+						Value: time.Hour,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					arg := WatchArg{MessageID: c.String("message-id"), UserID: c.String("user-id"), TTL: c.Duration("ttl")}
+					if arg.MessageID == "" && arg.UserID == "" {
+						return fmt.Errorf("either --message-id or --user-id is required")
+					}
+					if arg.TTL == 0 {
+						arg.TTL = -1 // the server defaults zero ttls
+					}
+					return call("Lineage.Watch", arg)
+				},
+			},
+		},
+	}
+}
+
+func call(method string, arg any) error {
+	var reply string
+	err := client.GetUDSClient().Call(method, arg, &reply)
+	if err == nil {
+		fmt.Println(reply)
+	}
+	return err
+}
@@ -0,0 +1,237 @@
+package reporting
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+const (
+	// defaultQueryWindow is the window of local reports queried, if not specified
+	defaultQueryWindow = 24 * time.Hour
+	// defaultErrorSamplesLimit is the number of error samples returned, if not specified
+	defaultErrorSamplesLimit = 20
+	adminQueryTimeout        = time.Minute
+)
+
+// groupByColumns are the columns local report counts can be grouped by
+var groupByColumns = map[string]string{
+	"workspace":   "workspace_id",
+	"source":      "source_id",
+	"destination": "destination_id",
+	"pu":          "pu",
+	"status":      "status",
+	"status_code": "status_code",
+	"event_name":  "event_name",
+	"event_type":  "event_type",
+	"error_type":  "error_type",
+}
+
+// intervals are the time intervals local report counts can be bucketed by
+var intervals = map[string]string{
+	"minute": "minute",
+	"hour":   "hour",
+	"day":    "day",
+}
+
+// Admin queries the reports stored locally by the postgres sink
+type Admin struct {
+	db *sql.DB
+}
+
+// NewAdmin returns the admin handler of the local reports of a reports database, to be registered with the admin server
+func NewAdmin(db *sql.DB) *Admin {
+	return &Admin{db: db}
+}
+
+// ReportsFilter filters local reports by connection and time, the time window defaulting to the last 24 hours
+type ReportsFilter struct {
+	WorkspaceID   string
+	SourceID      string
+	DestinationID string
+	PU            string
+	From          time.Time
+	To            time.Time
+}
+
+type CountsArg struct {
+	ReportsFilter
+	GroupBy  []string
+	Interval string
+}
+
+type ErrorSamplesArg struct {
+	ReportsFilter
+	Limit int
+}
+
+type reportCount struct {
+	Bucket         *time.Time        `json:"bucket,omitempty"`
+	Group          map[string]string `json:"group,omitempty"`
+	Count          int64             `json:"count"`
+	ViolationCount int64             `json:"violationCount"`
+}
+
+type errorSample struct {
+	ReportedAt     time.Time       `json:"reportedAt"`
+	WorkspaceID    string          `json:"workspaceId"`
+	SourceID       string          `json:"sourceId"`
+	DestinationID  string          `json:"destinationId"`
+	PU             string          `json:"pu"`
+	Status         string          `json:"status"`
+	StatusCode     int             `json:"statusCode"`
+	ErrorType      string          `json:"errorType,omitempty"`
+	EventName      string          `json:"eventName,omitempty"`
+	EventType      string          `json:"eventType,omitempty"`
+	Count          int64           `json:"count"`
+	SampleResponse string          `json:"sampleResponse"`
+	SampleEvent    json.RawMessage `json:"sampleEvent,omitempty"`
+}
+
+// Counts returns the counts of events of local reports, grouped by the requested columns and time interval
+func (a *Admin) Counts(arg CountsArg, reply *string) error {
+	var selects, groups []string
+	if arg.Interval != "" {
+		interval, ok := intervals[arg.Interval]
+		if !ok {
+			return fmt.Errorf("unsupported interval %q, supported intervals are minute, hour and day", arg.Interval)
+		}
+		selects = append(selects, fmt.Sprintf("date_trunc('%s', reported_at)", interval))
+		groups = append(groups, "1")
+	}
+	for _, groupBy := range arg.GroupBy {
+		column, ok := groupByColumns[groupBy]
+		if !ok {
+			return fmt.Errorf("unsupported group by %q", groupBy)
+		}
+		selects = append(selects, column+"::TEXT")
+		groups = append(groups, strconv.Itoa(len(groups)+1))
+	}
+	where, args := arg.where()
+	query := `SELECT ` + strings.Join(append(selects, "SUM(count)", "SUM(violation_count)"), ", ") +
+		` FROM ` + LocalReportsTable + ` WHERE ` + where
+	if len(groups) > 0 {
+		query += ` GROUP BY ` + strings.Join(groups, ", ") + ` ORDER BY ` + strings.Join(groups, ", ")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), adminQueryTimeout)
+	defer cancel()
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("querying local reports: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	counts := make([]reportCount, 0)
+	for rows.Next() {
+		var (
+			bucket                sql.NullTime
+			values                = make([]sql.NullString, len(arg.GroupBy))
+			count, violationCount sql.NullInt64
+			dest                  []any
+		)
+		if arg.Interval != "" {
+			dest = append(dest, &bucket)
+		}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(append(dest, &count, &violationCount)...); err != nil {
+			return fmt.Errorf("scanning local reports: %w", err)
+		}
+		c := reportCount{Count: count.Int64, ViolationCount: violationCount.Int64}
+		if bucket.Valid {
+			c.Bucket = &bucket.Time
+		}
+		if len(arg.GroupBy) > 0 {
+			c.Group = make(map[string]string, len(arg.GroupBy))
+			for i, groupBy := range arg.GroupBy {
+				c.Group[groupBy] = values[i].String
+			}
+		}
+		counts = append(counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating local reports: %w", err)
+	}
+	return marshalReply(counts, reply)
+}
+
+// ErrorSamples returns the latest local reports having a sample response, along with their sample event
+func (a *Admin) ErrorSamples(arg ErrorSamplesArg, reply *string) error {
+	limit := arg.Limit
+	if limit <= 0 {
+		limit = defaultErrorSamplesLimit
+	}
+	where, args := arg.where()
+	query := `SELECT reported_at, workspace_id, source_id, destination_id, pu, status, status_code, error_type, event_name, event_type, count, sample_response, sample_event
+		FROM ` + LocalReportsTable + ` WHERE ` + where + ` AND sample_response <> ''
+		ORDER BY reported_at DESC, id DESC LIMIT ` + strconv.Itoa(limit)
+
+	ctx, cancel := context.WithTimeout(context.Background(), adminQueryTimeout)
+	defer cancel()
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("querying local reports: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	samples := make([]errorSample, 0)
+	for rows.Next() {
+		var (
+			s           errorSample
+			sampleEvent string
+		)
+		if err := rows.Scan(&s.ReportedAt, &s.WorkspaceID, &s.SourceID, &s.DestinationID, &s.PU, &s.Status, &s.StatusCode,
+			&s.ErrorType, &s.EventName, &s.EventType, &s.Count, &s.SampleResponse, &sampleEvent); err != nil {
+			return fmt.Errorf("scanning local reports: %w", err)
+		}
+		if json.Valid([]byte(sampleEvent)) && sampleEvent != "{}" {
+			s.SampleEvent = json.RawMessage(sampleEvent)
+		}
+		samples = append(samples, s)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterating local reports: %w", err)
+	}
+	return marshalReply(samples, reply)
+}
+
+// where returns the conditions of the filter, along with their arguments
+func (f ReportsFilter) where() (string, []any) {
+	to := f.To
+	if to.IsZero() {
+		to = time.Now()
+	}
+	from := f.From
+	if from.IsZero() {
+		from = to.Add(-defaultQueryWindow)
+	}
+	conditions := []string{"reported_at >= $1", "reported_at < $2"}
+	args := []any{from.UTC(), to.UTC()}
+	for _, filter := range []struct{ column, value string }{
+		{"workspace_id", f.WorkspaceID},
+		{"source_id", f.SourceID},
+		{"destination_id", f.DestinationID},
+		{"pu", f.PU},
+	} {
+		if filter.value != "" {
+			args = append(args, filter.value)
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", filter.column, len(args)))
+		}
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func marshalReply(v any, reply *string) error {
+	formattedOutput, err := jsonrs.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	*reply = string(formattedOutput)
+	return nil
+}
@@ -0,0 +1,194 @@
+package reporting
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+const LocalReportsTable = "local_reports"
+
+// Sinks of aggregated reports, configured through Reporting.sinks
+const (
+	// SinkService sends aggregated reports to the hosted reporting service
+	SinkService = "service"
+	// SinkPostgres stores aggregated reports in the local reports table, for querying them with the admin API
+	SinkPostgres = "postgres"
+	// SinkOTLP emits aggregated report counts as metrics, exported like the rest of the server's metrics
+	SinkOTLP = "otlp"
+)
+
+// StatReportingEvents is the metric aggregated report counts are emitted as by [SinkOTLP]
+const StatReportingEvents = "reporting_events"
+
+// deliveredSinks keeps track of the sinks the aggregated reports of a bucket have been delivered to,
+// so that retrying a bucket only delivers it to the sinks which failed
+type deliveredSinks struct {
+	bucket int64
+	sinks  map[string]struct{}
+}
+
+// deliver delivers the bucket to the sink using deliverFn, unless it has already been delivered to it
+func (d *deliveredSinks) deliver(bucket int64, sink string, deliverFn func() error) error {
+	if d.sinks == nil || d.bucket != bucket {
+		d.bucket, d.sinks = bucket, make(map[string]struct{})
+	}
+	if _, ok := d.sinks[sink]; ok {
+		return nil
+	}
+	if err := deliverFn(); err != nil {
+		return err
+	}
+	d.sinks[sink] = struct{}{}
+	return nil
+}
+
+// localSink stores the aggregated reports of a reports database in its local reports table
+type localSink struct {
+	db          *sql.DB
+	log         logger.Logger
+	retention   config.ValueLoader[time.Duration]
+	cleanedUpAt time.Time
+}
+
+func newLocalSink(db *sql.DB, conf *config.Config, log logger.Logger) *localSink {
+	return &localSink{
+		db:        db,
+		log:       log,
+		retention: conf.GetReloadableDurationVar(720, time.Hour, "Reporting.localSink.retention"),
+	}
+}
+
+// store stores the aggregated reports of the bucket [bucketStart, bucketEnd), in minutes, replacing any reports of the
+// bucket already stored, so that a bucket can be stored again if sending it to another sink fails
+func (s *localSink) store(ctx context.Context, bucketStart, bucketEnd int64, metrics []*types.Metric) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+LocalReportsTable+` WHERE reported_at >= $1 AND reported_at < $2`,
+		time.Unix(bucketStart*60, 0).UTC(), time.Unix(bucketEnd*60, 0).UTC()); err != nil {
+		return fmt.Errorf("deleting reports of bucket: %w", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(LocalReportsTable,
+		"reported_at",
+		"workspace_id", "namespace", "instance_id",
+		"source_definition_id",
+		"source_category",
+		"source_id",
+		"destination_definition_id",
+		"destination_id",
+		"source_task_run_id",
+		"source_job_id",
+		"source_job_run_id",
+		"transformation_id",
+		"transformation_version_id",
+		"tracking_plan_id",
+		"tracking_plan_version",
+		"in_pu", "pu",
+		"terminal_state", "initial_state",
+		"status",
+		"status_code",
+		"event_name", "event_type",
+		"error_type",
+		"count", "violation_count",
+		"sample_response", "sample_event",
+	))
+	if err != nil {
+		return fmt.Errorf("preparing statement: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+	for _, metric := range metrics {
+		reportedAt := time.UnixMilli(metric.ReportedAt).UTC()
+		for _, sd := range metric.StatusDetails {
+			if _, err := stmt.ExecContext(ctx,
+				reportedAt,
+				metric.WorkspaceID, metric.Namespace, metric.InstanceID,
+				metric.SourceDefinitionID,
+				metric.SourceCategory,
+				metric.SourceID,
+				metric.DestinationDefinitionID,
+				metric.DestinationID,
+				metric.SourceTaskRunID,
+				metric.SourceJobID,
+				metric.SourceJobRunID,
+				metric.TransformationID,
+				metric.TransformationVersionID,
+				metric.TrackingPlanID,
+				metric.TrackingPlanVersion,
+				metric.InPU, metric.PU,
+				metric.TerminalPU, metric.InitialPU,
+				sd.Status,
+				sd.StatusCode,
+				sd.EventName, sd.EventType,
+				sd.ErrorType,
+				sd.Count, sd.ViolationCount,
+				sd.SampleResponse, string(sd.SampleEvent),
+			); err != nil {
+				return fmt.Errorf("copying report: %w", err)
+			}
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("executing copy: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+	s.cleanup(ctx)
+	return nil
+}
+
+// cleanup deletes the reports older than the retention period, at most once per hour
+func (s *localSink) cleanup(ctx context.Context) {
+	if time.Since(s.cleanedUpAt) < time.Hour {
+		return
+	}
+	res, err := s.db.ExecContext(ctx, `DELETE FROM `+LocalReportsTable+` WHERE reported_at < $1`, time.Now().Add(-s.retention.Load()))
+	if err != nil {
+		s.log.Errorn(`[ Reporting ]: Error deleting expired local reports`, obskit.Error(err))
+		return
+	}
+	s.cleanedUpAt = time.Now()
+	if deleted, _ := res.RowsAffected(); deleted > 0 {
+		s.log.Infon(`[ Reporting ]: Deleted expired local reports`, logger.NewIntField("count", deleted))
+	}
+}
+
+// emitReportingEvents emits the counts of aggregated reports as metrics.
+// Tags are kept to bounded values only, status codes being bucketed in classes (e.g. 2xx), whereas per connection counts
+// are available through the local reports of [SinkPostgres].
+func emitReportingEvents(s stats.Stats, metrics []*types.Metric) {
+	for _, metric := range metrics {
+		for _, sd := range metric.StatusDetails {
+			s.NewTaggedStat(StatReportingEvents, stats.CountType, stats.Tags{
+				"sourceCategory":          metric.SourceCategory,
+				"destinationDefinitionId": metric.DestinationDefinitionID,
+				"pu":                      metric.PU,
+				"terminal":                strconv.FormatBool(metric.TerminalPU),
+				"status":                  sd.Status,
+				"statusCodeClass":         statusCodeClass(sd.StatusCode),
+			}).Count(int(sd.Count))
+		}
+	}
+}
+
+// statusCodeClass returns the class of an HTTP status code, e.g. 2xx, or unknown for codes which are not valid HTTP status codes
+func statusCodeClass(statusCode int) string {
+	if statusCode < 100 || statusCode > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(statusCode/100) + "xx"
+}
@@ -0,0 +1,152 @@
+package reporting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
+
+	migrator "github.com/rudderlabs/rudder-server/services/sql-migrator"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+func localSinkMetric(reportedAt time.Time, destinationID string, statusDetails ...*types.StatusDetail) *types.Metric {
+	return &types.Metric{
+		InstanceDetails:   types.InstanceDetails{WorkspaceID: "w1", InstanceID: "1"},
+		ConnectionDetails: types.ConnectionDetails{SourceID: "s1", SourceCategory: "webhook", DestinationID: destinationID},
+		PUDetails:         types.PUDetails{InPU: "dest_transformer", PU: "router", TerminalPU: true},
+		ReportMetadata:    types.ReportMetadata{ReportedAt: reportedAt.UnixMilli()},
+		StatusDetails:     statusDetails,
+	}
+}
+
+func TestLocalSink(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	postgresContainer, err := postgres.Setup(pool, t)
+	require.NoError(t, err)
+	db := postgresContainer.DB
+	require.NoError(t, (&migrator.Migrator{Handle: db, MigrationsTable: "reports_migrations"}).Migrate("reports"))
+
+	ctx := context.Background()
+	sink := newLocalSink(db, config.New(), logger.NOP)
+	bucket := time.Now().UTC().Truncate(time.Minute)
+	bucketStart, bucketEnd := bucket.Unix()/60, bucket.Unix()/60+1
+	metrics := []*types.Metric{
+		localSinkMetric(bucket, "d1",
+			&types.StatusDetail{Status: "succeeded", StatusCode: 200, Count: 10},
+			&types.StatusDetail{Status: "aborted", StatusCode: 400, Count: 2, SampleResponse: "bad request", SampleEvent: []byte(`{"event":"e1"}`), ErrorType: "rejected"},
+		),
+		localSinkMetric(bucket, "d2", &types.StatusDetail{Status: "succeeded", StatusCode: 200, Count: 5}),
+	}
+	require.NoError(t, sink.store(ctx, bucketStart, bucketEnd, metrics))
+	require.NoError(t, sink.store(ctx, bucketStart, bucketEnd, metrics), "storing a bucket again should replace its reports")
+
+	a := NewAdmin(db)
+	var reply string
+	require.NoError(t, a.Counts(CountsArg{}, &reply))
+	require.JSONEq(t, `[{"count":17,"violationCount":0}]`, reply)
+
+	require.NoError(t, a.Counts(CountsArg{GroupBy: []string{"destination", "status"}}, &reply))
+	require.JSONEq(t, `[
+		{"group":{"destination":"d1","status":"aborted"},"count":2,"violationCount":0},
+		{"group":{"destination":"d1","status":"succeeded"},"count":10,"violationCount":0},
+		{"group":{"destination":"d2","status":"succeeded"},"count":5,"violationCount":0}
+	]`, reply)
+
+	require.NoError(t, a.Counts(CountsArg{ReportsFilter: ReportsFilter{DestinationID: "d2"}, Interval: "hour"}, &reply))
+	var counts []reportCount
+	require.NoError(t, jsonrs.Unmarshal([]byte(reply), &counts))
+	require.Len(t, counts, 1)
+	require.EqualValues(t, 5, counts[0].Count)
+	require.True(t, bucket.Truncate(time.Hour).Equal(*counts[0].Bucket))
+
+	require.Error(t, a.Counts(CountsArg{GroupBy: []string{"unknown"}}, &reply))
+	require.Error(t, a.Counts(CountsArg{Interval: "week"}, &reply))
+
+	require.NoError(t, a.ErrorSamples(ErrorSamplesArg{}, &reply))
+	var samples []errorSample
+	require.NoError(t, jsonrs.Unmarshal([]byte(reply), &samples))
+	require.Len(t, samples, 1)
+	require.Equal(t, "d1", samples[0].DestinationID)
+	require.Equal(t, "bad request", samples[0].SampleResponse)
+	require.Equal(t, "rejected", samples[0].ErrorType)
+	require.JSONEq(t, `{"event":"e1"}`, string(samples[0].SampleEvent))
+
+	t.Run("expired reports are deleted", func(t *testing.T) {
+		c := config.New()
+		c.Set("Reporting.localSink.retention", "1h")
+		sink := newLocalSink(db, c, logger.NOP)
+		old := bucket.Add(-2 * time.Hour)
+		require.NoError(t, sink.store(ctx, old.Unix()/60, old.Unix()/60+1, []*types.Metric{
+			localSinkMetric(old, "d1", &types.StatusDetail{Status: "succeeded", StatusCode: 200, Count: 1}),
+		}))
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM `+LocalReportsTable+` WHERE reported_at < $1`, bucket).Scan(&count))
+		require.Zero(t, count)
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM `+LocalReportsTable).Scan(&count))
+		require.Equal(t, 3, count)
+	})
+}
+
+func TestEmitReportingEvents(t *testing.T) {
+	store, err := memstats.New()
+	require.NoError(t, err)
+	emitReportingEvents(store, []*types.Metric{
+		localSinkMetric(time.Now(), "d1",
+			&types.StatusDetail{Status: "succeeded", StatusCode: 200, Count: 10},
+			&types.StatusDetail{Status: "aborted", StatusCode: 400, Count: 2},
+			&types.StatusDetail{Status: "aborted", StatusCode: 404, Count: 1},
+			&types.StatusDetail{Status: "filtered", StatusCode: 0, Count: 4},
+		),
+		localSinkMetric(time.Now(), "d2", &types.StatusDetail{Status: "succeeded", StatusCode: 201, Count: 5}),
+	})
+	tags := func(status, statusCodeClass string) stats.Tags {
+		return stats.Tags{
+			"sourceCategory":          "webhook",
+			"destinationDefinitionId": "",
+			"pu":                      "router",
+			"terminal":                "true",
+			"status":                  status,
+			"statusCodeClass":         statusCodeClass,
+		}
+	}
+	require.EqualValues(t, 15, store.Get(StatReportingEvents, tags("succeeded", "2xx")).LastValue())
+	require.EqualValues(t, 3, store.Get(StatReportingEvents, tags("aborted", "4xx")).LastValue())
+	require.EqualValues(t, 4, store.Get(StatReportingEvents, tags("filtered", "unknown")).LastValue())
+	require.Len(t, store.GetByName(StatReportingEvents), 3, "connections should not have series of their own")
+}
+
+func TestDeliveredSinks(t *testing.T) {
+	var d deliveredSinks
+	calls := make(map[string]int)
+	deliverFn := func(sink string, err error) func() error {
+		return func() error {
+			calls[sink]++
+			return err
+		}
+	}
+
+	require.NoError(t, d.deliver(1, SinkPostgres, deliverFn(SinkPostgres, nil)))
+	require.Error(t, d.deliver(1, SinkService, deliverFn(SinkService, errors.New("service unavailable"))))
+
+	// retrying the bucket only delivers it to the failed sink
+	require.NoError(t, d.deliver(1, SinkPostgres, deliverFn(SinkPostgres, nil)))
+	require.NoError(t, d.deliver(1, SinkService, deliverFn(SinkService, nil)))
+	require.Equal(t, map[string]int{SinkPostgres: 1, SinkService: 2}, calls)
+
+	// a new bucket is delivered to all sinks
+	require.NoError(t, d.deliver(2, SinkPostgres, deliverFn(SinkPostgres, nil)))
+	require.NoError(t, d.deliver(2, SinkService, deliverFn(SinkService, nil)))
+	require.Equal(t, map[string]int{SinkPostgres: 2, SinkService: 3}, calls)
+}
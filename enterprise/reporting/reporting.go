@@ -27,6 +27,7 @@ import (
 	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 
 	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-server/admin"
 	"github.com/rudderlabs/rudder-server/enterprise/reporting/client"
 	"github.com/rudderlabs/rudder-server/enterprise/reporting/event_sampler"
 	migrator "github.com/rudderlabs/rudder-server/services/sql-migrator"
@@ -63,6 +64,7 @@ type DefaultReporter struct {
 
 	instanceID                           string
 	whActionsOnly                        bool
+	sinks                                []string
 	region                               string
 	sleepInterval                        config.ValueLoader[time.Duration]
 	mainLoopSleepInterval                config.ValueLoader[time.Duration]
@@ -112,6 +114,8 @@ func NewDefaultReporter(ctx context.Context, conf *config.Config, log logger.Log
 		log.Infon("REPORTING_WH_ACTIONS_ONLY enabled.only sending reports relevant to wh actions.")
 	}
 
+	sinks := config.GetStringSliceVar([]string{SinkService}, "Reporting.sinks")
+
 	if eventSamplingEnabled.Load() {
 		var err error
 		eventSampler, err = event_sampler.NewEventSampler(ctx, eventSamplingDuration, eventSamplerType, eventSamplingCardinality, event_sampler.MetricsReporting, conf, log, stats)
@@ -132,6 +136,7 @@ func NewDefaultReporter(ctx context.Context, conf *config.Config, log logger.Log
 		namespace:                            config.GetKubeNamespace(),
 		instanceID:                           config.GetString("INSTANCE_ID", "1"),
 		whActionsOnly:                        whActionsOnly,
+		sinks:                                sinks,
 		sleepInterval:                        sleepInterval,
 		mainLoopSleepInterval:                mainLoopSleepInterval,
 		vacuumFull:                           config.GetReloadableBoolVar(false, "Reporting.vacuumFull"),
@@ -189,6 +194,9 @@ func (r *DefaultReporter) DatabaseSyncer(c types.SyncerConfig) types.ReportingSy
 		panic(fmt.Errorf("could not run reports_always migrations: %w", err))
 	}
 	r.syncers[c.ConnInfo] = &types.SyncSource{SyncerConfig: c, DbHandle: dbHandle}
+	if c.Label == types.CoreReportingLabel && r.hasSink(SinkPostgres) {
+		admin.RegisterAdminHandler("Reporting", NewAdmin(dbHandle))
+	}
 
 	if !config.GetBool("Reporting.syncer.enabled", true) {
 		return func() {}
@@ -447,7 +455,16 @@ func (r *DefaultReporter) mainLoop(ctx context.Context, c types.SyncerConfig) {
 	})
 
 	g.Go(func() error {
+		dbHandle, err := r.getDBHandle(c.ConnInfo)
+		if err != nil {
+			return err
+		}
+		var localSink *localSink
+		if r.hasSink(SinkPostgres) {
+			localSink = newLocalSink(dbHandle, config.Default, r.log)
+		}
 		var (
+			delivered                  deliveredSinks
 			deletedRows                int
 			vacuumDeletedRowsThreshold = config.GetReloadableIntVar(100000, 1, "Reporting.vacuumThresholdDeletedRows")
 			lastVacuum                 time.Time
@@ -503,36 +520,30 @@ func (r *DefaultReporter) mainLoop(ctx context.Context, c types.SyncerConfig) {
 			getAggregatedReportsTimer.Since(getAggregatedReportsStart)
 			getAggregatedReportsCount.Observe(float64(len(metrics)))
 
-			errGroup, errCtx := errgroup.WithContext(ctx)
-			for _, metric := range metrics {
-				if r.whActionsOnly && metric.SourceCategory != "warehouse" {
-					// if whActionsOnly is true, we only send reports for wh actions sources
-					// we silently drop all other reports
-					continue
+			// Use the same aggregationIntervalMin value that was used to query the reports in getReports()
+			bucketStart, bucketEnd := GetAggregationBucketMinute(reportedAt, aggregationIntervalMin)
+			// sinks are delivered to independently: a bucket is only deleted once all sinks succeeded, and is retried only for the sinks which failed
+			var sinkErrs []error
+			if localSink != nil {
+				if err := delivered.deliver(bucketStart, SinkPostgres, func() error {
+					return localSink.store(ctx, bucketStart, bucketEnd, metrics)
+				}); err != nil {
+					r.log.Errorn(`[ Reporting ]: Error storing metrics in local reports`, obskit.Error(err))
+					sinkErrs = append(sinkErrs, err)
 				}
-				metricToSend := metric
-				requestChan <- struct{}{}
-				if errCtx.Err() != nil {
-					// if any of errGroup's goroutines fail - don't send anymore requests for this batch
-					break
+			}
+			if r.hasSink(SinkService) {
+				if err := delivered.deliver(bucketStart, SinkService, func() error {
+					return r.sendMetrics(ctx, netClient, c.Label, metrics, requestChan)
+				}); err != nil {
+					r.log.Errorn(`[ Reporting ]: Error sending metrics to service`, obskit.Error(err))
+					sinkErrs = append(sinkErrs, err)
 				}
-				errGroup.Go(func() error {
-					err := r.sendMetric(errCtx, netClient, c.Label, metricToSend)
-					<-requestChan
-					return err
-				})
 			}
-
-			err = errGroup.Wait()
-			if err != nil {
-				r.log.Errorn(`[ Reporting ]: Error sending metrics to service`, obskit.Error(err))
-			} else {
-				dbHandle, err := r.getDBHandle(c.ConnInfo)
-				if err != nil {
-					return err
+			if len(sinkErrs) == 0 {
+				if r.hasSink(SinkOTLP) {
+					emitReportingEvents(r.stats, metrics)
 				}
-				// Use the same aggregationIntervalMin value that was used to query the reports in getReports()
-				bucketStart, bucketEnd := GetAggregationBucketMinute(reportedAt, aggregationIntervalMin)
 				_, err = dbHandle.Exec(`DELETE FROM `+ReportsTable+` WHERE reported_at >= $1 and reported_at < $2`, bucketStart, bucketEnd)
 				if err != nil {
 					r.log.Errorn(`[ Reporting ]: Error deleting local reports from table`,
@@ -583,6 +594,35 @@ func (r *DefaultReporter) mainLoop(ctx context.Context, c types.SyncerConfig) {
 	}
 }
 
+// sendMetrics sends the aggregated reports to the hosted reporting service, stopping at the first failure
+func (r *DefaultReporter) sendMetrics(ctx context.Context, netClient *http.Client, label string, metrics []*types.Metric, requestChan chan struct{}) error {
+	errGroup, errCtx := errgroup.WithContext(ctx)
+	for _, metric := range metrics {
+		if r.whActionsOnly && metric.SourceCategory != "warehouse" {
+			// if whActionsOnly is true, we only send reports for wh actions sources
+			// we silently drop all other reports
+			continue
+		}
+		metricToSend := metric
+		requestChan <- struct{}{}
+		if errCtx.Err() != nil {
+			// if any of errGroup's goroutines fail - don't send anymore requests for this batch
+			break
+		}
+		errGroup.Go(func() error {
+			err := r.sendMetric(errCtx, netClient, label, metricToSend)
+			<-requestChan
+			return err
+		})
+	}
+	return errGroup.Wait()
+}
+
+// hasSink returns true if aggregated reports are configured to be sent to the sink
+func (r *DefaultReporter) hasSink(sink string) bool {
+	return slices.Contains(r.sinks, sink)
+}
+
 func (r *DefaultReporter) vacuum(ctx context.Context, db *sql.DB, tags stats.Tags) error {
 	defer r.stats.NewTaggedStat(StatReportingVacuumDuration, stats.TimerType, tags).RecordDuration()()
 	var (
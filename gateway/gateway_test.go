@@ -196,6 +196,7 @@ func (c *testContext) Setup() {
 	c.mockRateLimiter = mockGateway.NewMockThrottler(c.mockCtrl)
 	c.mockWebhook = mockGateway.NewMockWebhookRequestHandler(c.mockCtrl)
 	c.mockWebhook.EXPECT().Shutdown().AnyTimes()
+	c.mockWebhook.EXPECT().UpdateMappings(gomock.Any()).AnyTimes()
 	c.mockBackendConfig.EXPECT().Subscribe(gomock.Any(), backendconfig.TopicProcessConfig).
 		DoAndReturn(func(ctx context.Context, topic backendconfig.Topic) pubsub.DataChannel {
 			ch := make(chan pubsub.DataEvent, 1)
@@ -2781,6 +2782,7 @@ func createTestGatewayWithLeakyUploader(t *testing.T, endpoint, accessKeyID, sec
 	mockRateLimiter := mockGateway.NewMockThrottler(mockCtrl)
 	mockWebhook := mockGateway.NewMockWebhookRequestHandler(mockCtrl)
 	mockWebhook.EXPECT().Shutdown().AnyTimes()
+	mockWebhook.EXPECT().UpdateMappings(gomock.Any()).AnyTimes()
 	mockBackendConfig.EXPECT().Subscribe(gomock.Any(), backendconfig.TopicProcessConfig).
 		DoAndReturn(func(ctx context.Context, topic backendconfig.Topic) pubsub.DataChannel {
 			ch := make(chan pubsub.DataEvent, 1)
@@ -43,6 +43,7 @@ import (
 	"github.com/rudderlabs/rudder-server/gateway/webhook"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	sourcedebugger "github.com/rudderlabs/rudder-server/services/debugger/source"
+	"github.com/rudderlabs/rudder-server/services/lineage"
 	"github.com/rudderlabs/rudder-server/services/rsources"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/utils/types"
@@ -67,6 +68,7 @@ type Handle struct {
 	versionHandler  func(w http.ResponseWriter, r *http.Request)
 	rsourcesService rsources.JobService
 	sourcehandle    sourcedebugger.SourceDebugger
+	lineage         *lineage.Recorder
 
 	// statistic measurements initialised during Setup
 
@@ -218,6 +220,7 @@ func (gw *Handle) userWebRequestWorkerProcess(userWebRequestWorker *userWebReque
 			sourceStats[sourceTag].Version = jobData.version
 			sourceStats[sourceTag].RequestEventsBot(jobData.botEvents)
 			if err != nil {
+				gw.recordLineage(req.requestPayload, arctx.SourceID, req.traceParent, err)
 				switch {
 				case errors.Is(err, errRequestDropped):
 					req.done <- response.TooManyRequests
@@ -262,12 +265,22 @@ func (gw *Handle) userWebRequestWorkerProcess(userWebRequestWorker *userWebReque
 		for _, batch := range jobBatches {
 			err, found := errorMessagesMap[batch[0].UUID]
 			sourceTag := jobSourceTagMap[batch[0].UUID]
+			req := jobIDReqMap[batch[0].UUID]
 			if found {
 				sourceStats[sourceTag].RequestEventsFailed(len(batch), "storeFailed")
 				jobIDReqMap[batch[0].UUID].errors = append(jobIDReqMap[batch[0].UUID].errors, err)
 			} else {
 				sourceStats[sourceTag].RequestEventsSucceeded(len(batch))
 			}
+			if gw.lineage.Active() {
+				var storeErr error
+				if found {
+					storeErr = errors.New(err)
+				}
+				for _, job := range batch {
+					gw.recordLineage(job.EventPayload, req.authContext.SourceID, req.traceParent, storeErr)
+				}
+			}
 			jobIDReqMap[batch[0].UUID].done <- err
 		}
 		// Sending events to config backend
@@ -731,6 +744,7 @@ func (gw *Handle) internalBatchHandlerFunc() http.HandlerFunc {
 				for _, jwm := range jobsWithMetadata {
 					jwm.stat.EventsFailed(1, "storeFailed")
 					jwm.stat.Report(gw.stats)
+					gw.recordLineage(jwm.job.EventPayload, jwm.stat.SourceID, gjson.GetBytes(jwm.job.Parameters, "traceparent").String(), err)
 				}
 				stat.RequestFailed("storeFailed")
 				stat.Report(gw.stats)
@@ -739,6 +753,7 @@ func (gw *Handle) internalBatchHandlerFunc() http.HandlerFunc {
 			for _, jwm := range jobsWithMetadata {
 				jwm.stat.EventsSuccess(1)
 				jwm.stat.Report(gw.stats)
+				gw.recordLineage(jwm.job.EventPayload, jwm.stat.SourceID, gjson.GetBytes(jwm.job.Parameters, "traceparent").String(), nil)
 				// Sending events to config backend
 				if jwm.stat.WriteKey == "" {
 					gw.logger.Errorn("writeKey not found in event payload")
@@ -1170,3 +1185,37 @@ func (gw *Handle) storeJobs(ctx context.Context, jobs []*jobsdb.JobT) error {
 		return nil
 	})
 }
+
+// recordLineage records the gateway stage of the traced events of a payload, either a batch or a single event,
+// as stored or rejected with err
+func (gw *Handle) recordLineage(payload []byte, sourceID, traceParent string, err error) {
+	if !gw.lineage.Active() {
+		return
+	}
+	status, errorMessage := "stored", ""
+	if err != nil {
+		status, errorMessage = "rejected", err.Error()
+	}
+	events := gjson.GetBytes(payload, "batch").Array()
+	if len(events) == 0 {
+		events = []gjson.Result{gjson.ParseBytes(payload)}
+	}
+	for _, event := range events {
+		messageID, userID, anonymousID := event.Get("messageId").String(), event.Get("userId").String(), event.Get("anonymousId").String()
+		if !gw.lineage.Traced(messageID, userID, anonymousID) {
+			continue
+		}
+		if userID == "" {
+			userID = anonymousID
+		}
+		gw.lineage.Record(lineage.Record{
+			Stage:       lineage.StageGateway,
+			Status:      status,
+			Error:       errorMessage,
+			MessageID:   messageID,
+			UserID:      userID,
+			SourceID:    sourceID,
+			TraceParent: traceParent,
+		})
+	}
+}
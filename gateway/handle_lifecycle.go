@@ -43,8 +43,10 @@ import (
 	"github.com/rudderlabs/rudder-server/gateway/webhook"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	"github.com/rudderlabs/rudder-server/middleware"
+	"github.com/rudderlabs/rudder-server/services/debugger/livestream"
 	sourcedebugger "github.com/rudderlabs/rudder-server/services/debugger/source"
 	"github.com/rudderlabs/rudder-server/services/diagnostics"
+	"github.com/rudderlabs/rudder-server/services/lineage"
 	"github.com/rudderlabs/rudder-server/services/rsources"
 	rsources_http "github.com/rudderlabs/rudder-server/services/rsources/http"
 	"github.com/rudderlabs/rudder-server/services/transformer"
@@ -88,6 +90,7 @@ func (gw *Handle) Setup(
 	gw.versionHandler = versionHandler
 	gw.rsourcesService = rsourcesService
 	gw.sourcehandle = sourcehandle
+	gw.lineage = lineage.Default()
 	gw.inFlightRequests = new(sync.WaitGroup)
 
 	// Port where GW is running
@@ -183,7 +186,10 @@ func (gw *Handle) Setup(
 				return nil, auth.ErrSourceNotFound
 			}
 			return authCtx, nil
-		})
+		},
+		auth.WithSignatureVerifiers(auth.NewSignatureVerifiers(gw.config.GetReloadableDurationVar(5, time.Minute, "Gateway.webhook.signatureTolerance"))),
+		auth.WithMaxRequestSize(gw.conf.maxReqSize),
+	)
 
 	// new bg ctx for leaky logger
 	// we don't want to cancel the main context.
@@ -383,6 +389,7 @@ func (gw *Handle) processBackendConfig(configData map[string]backendconfig.Confi
 		}
 	}
 
+	gw.webhook.UpdateMappings(sourceIDSourceMap)
 	gw.configSubscriberLock.Lock()
 	gw.writeKeysSourceMap = writeKeysSourceMap
 	gw.sourceIDSourceMap = sourceIDSourceMap
@@ -663,9 +670,22 @@ func (gw *Handle) StartWebHandler(ctx context.Context) error {
 			diagnostics.ServerStarted: time.Now(),
 		})
 	}
+	handler := c.Handler(srvMux)
+	if livestream.Enabled(gw.config) {
+		if livestream.Authenticated(gw.config) {
+			// the live debugger stream is long-lived, so it is kept out of the middlewares tracking requests,
+			// and out of CORS, since it is not meant to be consumed by browsers
+			rootMux := chi.NewRouter()
+			rootMux.Handle(livestream.Path, livestream.NewHandler(ctx, livestream.Default, gw.config, gw.logger))
+			rootMux.Mount("/", handler)
+			handler = rootMux
+		} else {
+			gw.logger.Warnn("Not serving the live debugger stream on the gateway's port, since Debugger.LiveStream.token is not configured")
+		}
+	}
 	srv := &http.Server{
 		Addr:              ":" + strconv.Itoa(gw.conf.webPort),
-		Handler:           c.Handler(crash.Handler(srvMux)),
+		Handler:           crash.Handler(handler),
 		ReadTimeout:       gw.conf.ReadTimeout,
 		ReadHeaderTimeout: gw.conf.ReadHeaderTimeout,
 		WriteTimeout:      gw.conf.WriteTimeout,
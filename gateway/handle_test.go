@@ -69,6 +69,7 @@ func createTestGateway(t *testing.T, eventBlockingSettings backendconfig.EventBl
 	t.Cleanup(mockCtrl.Finish)
 	mockWebhook := mocks_gateway.NewMockWebhookRequestHandler(mockCtrl)
 	mockWebhook.EXPECT().Register(gomock.Any()).AnyTimes() // Allow any number of Register calls
+	mockWebhook.EXPECT().UpdateMappings(gomock.Any()).AnyTimes()
 
 	gw := &Handle{
 		stats:   statsStore,
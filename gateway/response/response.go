@@ -78,6 +78,12 @@ const (
 	NoDestinationIDInHeader = "failed to read destination id from header"
 	// ErrAuthenticatingWebhookRequest = "error occurred while authenticating the webhook request"
 	ErrAuthenticatingWebhookRequest = "error occurred while authenticating the webhook request"
+	// InvalidWebhookMapping - webhook source has no valid mapping in its config
+	InvalidWebhookMapping = "invalid webhook mapping in source config"
+	// WebhookMappingFailed - webhook payload could not be mapped to events
+	WebhookMappingFailed = "webhook payload could not be mapped to events"
+	// InvalidWebhookSignature - webhook request signature is missing, invalid or expired
+	InvalidWebhookSignature = "invalid webhook signature"
 
 	transPixelResponse = "\x47\x49\x46\x38\x39\x61\x01\x00\x01\x00\x80\x00\x00\x00\x00\x00\x00\x00\x00\x21\xF9\x04" +
 		"\x01\x00\x00\x00\x00\x2C\x00\x00\x00\x00\x01\x00\x01\x00\x00\x02\x02\x44\x01\x00\x3B"
@@ -123,6 +129,9 @@ var statusMap = map[string]status{
 	GatewayTimeout:                                 {message: GatewayTimeout, code: http.StatusGatewayTimeout},
 	ServiceUnavailable:                             {message: ServiceUnavailable, code: http.StatusServiceUnavailable},
 	ErrAuthenticatingWebhookRequest:                {message: ErrAuthenticatingWebhookRequest, code: http.StatusInternalServerError},
+	InvalidWebhookSignature:                        {message: InvalidWebhookSignature, code: http.StatusUnauthorized},
+	InvalidWebhookMapping:                          {message: InvalidWebhookMapping, code: http.StatusBadRequest},
+	WebhookMappingFailed:                           {message: WebhookMappingFailed, code: http.StatusBadRequest},
 }
 
 // status holds the gateway response status message and code
@@ -1,10 +1,16 @@
 package auth
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"io"
 	"net/http"
 
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+
 	gwtypes "github.com/rudderlabs/rudder-server/gateway/types"
 
 	"github.com/rudderlabs/rudder-server/gateway/response"
@@ -15,16 +21,39 @@ var ErrSourceNotFound = errors.New("source not found")
 type WebhookAuth struct {
 	onFailure             func(w http.ResponseWriter, r *http.Request, errorMessage string, authCtx *gwtypes.AuthRequestContext)
 	authReqCtxForWriteKey func(writeKey string) (*gwtypes.AuthRequestContext, error)
+	signatureVerifiers    *SignatureVerifiers
+	maxRequestSize        config.ValueLoader[int]
+}
+
+type OptFn func(wa *WebhookAuth)
+
+// WithSignatureVerifiers enables signature verification of webhook requests for sources having a signing secret in their config
+func WithSignatureVerifiers(signatureVerifiers *SignatureVerifiers) OptFn {
+	return func(wa *WebhookAuth) {
+		wa.signatureVerifiers = signatureVerifiers
+	}
+}
+
+// WithMaxRequestSize limits the size in bytes of the bodies read for verifying signatures, rejecting larger requests
+func WithMaxRequestSize(maxRequestSize config.ValueLoader[int]) OptFn {
+	return func(wa *WebhookAuth) {
+		wa.maxRequestSize = maxRequestSize
+	}
 }
 
 func NewWebhookAuth(
 	onFailure func(w http.ResponseWriter, r *http.Request, errorMessage string, authCtx *gwtypes.AuthRequestContext),
 	authReqCtxForWriteKey func(writeKey string) (*gwtypes.AuthRequestContext, error),
+	opts ...OptFn,
 ) *WebhookAuth {
-	return &WebhookAuth{
+	wa := &WebhookAuth{
 		onFailure:             onFailure,
 		authReqCtxForWriteKey: authReqCtxForWriteKey,
 	}
+	for _, opt := range opts {
+		opt(wa)
+	}
+	return wa
 }
 
 func (wa *WebhookAuth) AuthHandler(next http.HandlerFunc) http.HandlerFunc {
@@ -58,6 +87,41 @@ func (wa *WebhookAuth) AuthHandler(next http.HandlerFunc) http.HandlerFunc {
 			wa.onFailure(w, r, response.SourceDisabled, arctx)
 			return
 		}
+		if errorMessage := wa.verifySignature(w, r, arctx); errorMessage != "" {
+			wa.onFailure(w, r, errorMessage, arctx)
+			return
+		}
 		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), gwtypes.CtxParamAuthRequestContext, arctx)))
 	}
 }
+
+// verifySignature verifies the signature of the request if the source has a signing secret and a signature verifier exists for its source definition.
+// The body is read for verifying it, up to the max request size. It returns an error message if the request should be rejected.
+func (wa *WebhookAuth) verifySignature(w http.ResponseWriter, r *http.Request, arctx *gwtypes.AuthRequestContext) string {
+	if wa.signatureVerifiers == nil {
+		return ""
+	}
+	if _, ok := wa.signatureVerifiers.Get(arctx.SourceDefName); !ok {
+		return ""
+	}
+	secret := gjson.GetBytes(arctx.SourceDetails.Config, SigningSecretConfigKey).String()
+	if secret == "" {
+		return ""
+	}
+	if wa.maxRequestSize != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(wa.maxRequestSize.Load()))
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		if maxBytesErr := (*http.MaxBytesError)(nil); errors.As(err, &maxBytesErr) {
+			return response.RequestBodyTooLarge
+		}
+		return response.RequestBodyReadFailed
+	}
+	_ = r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err := wa.signatureVerifiers.Verify(arctx.SourceDefName, r.Header, body, []byte(secret)); err != nil {
+		return response.InvalidWebhookSignature
+	}
+	return ""
+}
@@ -1,15 +1,20 @@
 package auth
 
 import (
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/rudderlabs/rudder-go-kit/config"
+
 	"github.com/rudderlabs/rudder-server/gateway/response"
 
 	gwtypes "github.com/rudderlabs/rudder-server/gateway/types"
@@ -141,3 +146,84 @@ func TestNewWebhookAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestWebhookAuthSignatureVerification(t *testing.T) {
+	const (
+		secret = "shpss_test"
+		body   = `{"id":1}`
+	)
+	webhookAuth := NewWebhookAuth(
+		func(w http.ResponseWriter, r *http.Request, errorMessage string, _ *gwtypes.AuthRequestContext) {
+			http.Error(w, errorMessage, http.StatusUnauthorized)
+		},
+		func(writeKey string) (*gwtypes.AuthRequestContext, error) {
+			arctx := &gwtypes.AuthRequestContext{
+				SourceCategory: "webhook",
+				SourceEnabled:  true,
+				SourceDefName:  "Shopify",
+			}
+			if writeKey == "signed-source" {
+				arctx.SourceDetails.Config = []byte(`{"webhookSigningSecret":"` + secret + `"}`)
+			}
+			return arctx, nil
+		},
+		WithSignatureVerifiers(NewSignatureVerifiers(config.SingleValueLoader(5*time.Minute))),
+		WithMaxRequestSize(config.SingleValueLoader(1024)),
+	)
+	server := httptest.NewServer(webhookAuth.AuthHandler(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(payload)
+	}))
+	defer server.Close()
+
+	sendBody := func(t *testing.T, writeKey, signature, body string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(body))
+		require.NoError(t, err)
+		req.SetBasicAuth(writeKey, "")
+		if signature != "" {
+			req.Header.Set("X-Shopify-Hmac-Sha256", signature)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		respBody, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(respBody)
+	}
+	send := func(t *testing.T, writeKey, signature string) (int, string) {
+		t.Helper()
+		return sendBody(t, writeKey, signature, body)
+	}
+	validSignature := base64.StdEncoding.EncodeToString(sign(secret, body))
+
+	t.Run("valid signature", func(t *testing.T) {
+		code, respBody := send(t, "signed-source", validSignature)
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, body, respBody, "body should still be readable after verification")
+	})
+
+	t.Run("unsigned request", func(t *testing.T) {
+		code, respBody := send(t, "signed-source", "")
+		require.Equal(t, http.StatusUnauthorized, code)
+		require.Equal(t, response.InvalidWebhookSignature+"\n", respBody)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		code, _ := send(t, "signed-source", base64.StdEncoding.EncodeToString(sign("wrong", body)))
+		require.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("request too large", func(t *testing.T) {
+		large := `{"id":"` + strings.Repeat("x", 1024) + `"}`
+		code, respBody := sendBody(t, "signed-source", base64.StdEncoding.EncodeToString(sign(secret, large)), large)
+		require.Equal(t, http.StatusUnauthorized, code)
+		require.Equal(t, response.RequestBodyTooLarge+"\n", respBody, "bodies shouldn't be read beyond the max request size")
+	})
+
+	t.Run("source without signing secret", func(t *testing.T) {
+		code, respBody := send(t, "unsigned-source", "")
+		require.Equal(t, http.StatusOK, code)
+		require.Equal(t, body, respBody)
+	})
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+)
+
+// SigningSecretConfigKey is the key of a source's config containing the secret used for verifying webhook signatures.
+// Signatures are only verified for sources having a signing secret and a signature verifier registered for their source definition.
+// Replayed requests are only rejected for providers signing a timestamp along with the body, i.e. Stripe and Slack, and only once
+// their timestamp is out of the tolerance window: requests of other providers carry a valid signature no matter how often they are sent.
+const SigningSecretConfigKey = "webhookSigningSecret"
+
+var (
+	ErrMissingSignature     = errors.New("missing signature")
+	ErrInvalidSignature     = errors.New("invalid signature")
+	ErrTimestampOutOfBounds = errors.New("timestamp outside of the tolerance window")
+	ErrMalformedSignature   = errors.New("malformed signature")
+)
+
+// SignatureVerifier verifies the signature of a webhook request, as sent by a webhook provider
+type SignatureVerifier interface {
+	// Verify returns an error if the request's headers don't carry a valid signature of the body for the given secret.
+	// Verifiers of providers signing a timestamp along with the body must reject timestamps further than tolerance away from now.
+	Verify(header http.Header, body, secret []byte, now time.Time, tolerance time.Duration) error
+}
+
+// SignatureVerifierFunc is an adapter allowing the use of ordinary functions as signature verifiers
+type SignatureVerifierFunc func(header http.Header, body, secret []byte, now time.Time, tolerance time.Duration) error
+
+func (f SignatureVerifierFunc) Verify(header http.Header, body, secret []byte, now time.Time, tolerance time.Duration) error {
+	return f(header, body, secret, now, tolerance)
+}
+
+// NewSignatureVerifiers returns a registry of signature verifiers, containing verifiers for the following source definitions:
+//
+//   - Stripe: Stripe-Signature header (t=<timestamp>,v1=<hex hmac-sha256 of "<timestamp>.<body>">)
+//   - Shopify: X-Shopify-Hmac-Sha256 header (base64 hmac-sha256 of the body)
+//   - GitHub: X-Hub-Signature-256 header (sha256=<hex hmac-sha256 of the body>)
+//   - Slack: X-Slack-Signature & X-Slack-Request-Timestamp headers (v0=<hex hmac-sha256 of "v0:<timestamp>:<body>">)
+//
+// Only Stripe and Slack sign a timestamp, thus Shopify and GitHub requests get no replay protection.
+func NewSignatureVerifiers(tolerance config.ValueLoader[time.Duration]) *SignatureVerifiers {
+	v := &SignatureVerifiers{
+		verifiers: make(map[string]SignatureVerifier),
+		tolerance: tolerance,
+		now:       time.Now,
+	}
+	v.Register("Stripe", SignatureVerifierFunc(verifyStripe))
+	v.Register("Shopify", SignatureVerifierFunc(verifyShopify))
+	v.Register("GitHub", SignatureVerifierFunc(verifyGitHub))
+	v.Register("Slack", SignatureVerifierFunc(verifySlack))
+	return v
+}
+
+// SignatureVerifiers is a registry of signature verifiers keyed by source definition name (case-insensitive)
+type SignatureVerifiers struct {
+	mu        sync.RWMutex
+	verifiers map[string]SignatureVerifier
+	tolerance config.ValueLoader[time.Duration]
+	now       func() time.Time
+}
+
+// Register registers a signature verifier for the given source definition, replacing any existing one
+func (v *SignatureVerifiers) Register(sourceDefName string, verifier SignatureVerifier) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.verifiers[strings.ToLower(sourceDefName)] = verifier
+}
+
+// Get returns the signature verifier registered for the given source definition, if any
+func (v *SignatureVerifiers) Get(sourceDefName string) (SignatureVerifier, bool) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	verifier, ok := v.verifiers[strings.ToLower(sourceDefName)]
+	return verifier, ok
+}
+
+// Verify verifies the signature of a webhook request's body using the verifier registered for the given source definition.
+// Requests of source definitions without a registered verifier are considered valid.
+func (v *SignatureVerifiers) Verify(sourceDefName string, header http.Header, body, secret []byte) error {
+	verifier, ok := v.Get(sourceDefName)
+	if !ok {
+		return nil
+	}
+	return verifier.Verify(header, body, secret, v.now(), v.tolerance.Load())
+}
+
+func verifyStripe(header http.Header, body, secret []byte, now time.Time, tolerance time.Duration) error {
+	value := header.Get("Stripe-Signature")
+	if value == "" {
+		return ErrMissingSignature
+	}
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			timestamp = v
+		case "v1":
+			signatures = append(signatures, v)
+		}
+	}
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrMalformedSignature
+	}
+	if err := checkTimestamp(timestamp, now, tolerance); err != nil {
+		return err
+	}
+	expected := hmacSHA256(secret, []byte(timestamp), []byte("."), body)
+	for _, signature := range signatures {
+		if equalHex(signature, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func verifyShopify(header http.Header, body, secret []byte, _ time.Time, _ time.Duration) error {
+	value := header.Get("X-Shopify-Hmac-Sha256")
+	if value == "" {
+		return ErrMissingSignature
+	}
+	signature, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return ErrMalformedSignature
+	}
+	if !hmac.Equal(signature, hmacSHA256(secret, body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func verifyGitHub(header http.Header, body, secret []byte, _ time.Time, _ time.Duration) error {
+	value := header.Get("X-Hub-Signature-256")
+	if value == "" {
+		return ErrMissingSignature
+	}
+	signature, ok := strings.CutPrefix(value, "sha256=")
+	if !ok {
+		return ErrMalformedSignature
+	}
+	if !equalHex(signature, hmacSHA256(secret, body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func verifySlack(header http.Header, body, secret []byte, now time.Time, tolerance time.Duration) error {
+	value, timestamp := header.Get("X-Slack-Signature"), header.Get("X-Slack-Request-Timestamp")
+	if value == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+	signature, ok := strings.CutPrefix(value, "v0=")
+	if !ok {
+		return ErrMalformedSignature
+	}
+	if err := checkTimestamp(timestamp, now, tolerance); err != nil {
+		return err
+	}
+	if !equalHex(signature, hmacSHA256(secret, []byte("v0:"+timestamp+":"), body)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// checkTimestamp rejects unix timestamps further than tolerance away from now, protecting against replayed requests
+func checkTimestamp(timestamp string, now time.Time, tolerance time.Duration) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrMalformedSignature
+	}
+	if tolerance <= 0 {
+		return nil
+	}
+	if diff := now.Sub(time.Unix(seconds, 0)).Abs(); diff > tolerance {
+		return ErrTimestampOutOfBounds
+	}
+	return nil
+}
+
+func hmacSHA256(secret []byte, parts ...[]byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	for _, part := range parts {
+		mac.Write(part)
+	}
+	return mac.Sum(nil)
+}
+
+func equalHex(signature string, expected []byte) bool {
+	decoded, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(decoded, expected)
+}
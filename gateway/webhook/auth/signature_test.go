@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+)
+
+func sign(secret string, parts ...string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	for _, part := range parts {
+		mac.Write([]byte(part))
+	}
+	return mac.Sum(nil)
+}
+
+func TestSignatureVerifiers(t *testing.T) {
+	const (
+		secret = "whsec_test"
+		body   = `{"id":"evt_1","type":"charge.succeeded"}`
+	)
+	now := time.Unix(1700000000, 0)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+
+	verifiers := NewSignatureVerifiers(config.SingleValueLoader(5 * time.Minute))
+	verifiers.now = func() time.Time { return now }
+
+	tests := []struct {
+		name          string
+		sourceDefName string
+		header        http.Header
+		expectedErr   error
+	}{
+		{
+			name:          "stripe valid",
+			sourceDefName: "Stripe",
+			header:        http.Header{"Stripe-Signature": {"t=" + ts + ",v1=deadbeef,v1=" + hex.EncodeToString(sign(secret, ts, ".", body))}},
+		},
+		{
+			name:          "stripe invalid",
+			sourceDefName: "stripe",
+			header:        http.Header{"Stripe-Signature": {"t=" + ts + ",v1=" + hex.EncodeToString(sign("other", ts, ".", body))}},
+			expectedErr:   ErrInvalidSignature,
+		},
+		{
+			name:          "stripe replayed",
+			sourceDefName: "Stripe",
+			header:        http.Header{"Stripe-Signature": {"t=" + stale + ",v1=" + hex.EncodeToString(sign(secret, stale, ".", body))}},
+			expectedErr:   ErrTimestampOutOfBounds,
+		},
+		{
+			name:          "stripe malformed",
+			sourceDefName: "Stripe",
+			header:        http.Header{"Stripe-Signature": {"v1=abc"}},
+			expectedErr:   ErrMalformedSignature,
+		},
+		{
+			name:          "stripe unsigned",
+			sourceDefName: "Stripe",
+			header:        http.Header{},
+			expectedErr:   ErrMissingSignature,
+		},
+		{
+			name:          "shopify valid",
+			sourceDefName: "Shopify",
+			header:        http.Header{"X-Shopify-Hmac-Sha256": {base64.StdEncoding.EncodeToString(sign(secret, body))}},
+		},
+		{
+			name:          "shopify invalid",
+			sourceDefName: "Shopify",
+			header:        http.Header{"X-Shopify-Hmac-Sha256": {base64.StdEncoding.EncodeToString(sign(secret, "tampered"))}},
+			expectedErr:   ErrInvalidSignature,
+		},
+		{
+			name:          "github valid",
+			sourceDefName: "GitHub",
+			header:        http.Header{"X-Hub-Signature-256": {"sha256=" + hex.EncodeToString(sign(secret, body))}},
+		},
+		{
+			name:          "github malformed",
+			sourceDefName: "GitHub",
+			header:        http.Header{"X-Hub-Signature-256": {hex.EncodeToString(sign(secret, body))}},
+			expectedErr:   ErrMalformedSignature,
+		},
+		{
+			name:          "slack valid",
+			sourceDefName: "Slack",
+			header: http.Header{
+				"X-Slack-Signature":         {"v0=" + hex.EncodeToString(sign(secret, "v0:", ts, ":", body))},
+				"X-Slack-Request-Timestamp": {ts},
+			},
+		},
+		{
+			name:          "slack replayed",
+			sourceDefName: "Slack",
+			header: http.Header{
+				"X-Slack-Signature":         {"v0=" + hex.EncodeToString(sign(secret, "v0:", stale, ":", body))},
+				"X-Slack-Request-Timestamp": {stale},
+			},
+			expectedErr: ErrTimestampOutOfBounds,
+		},
+		{
+			name:          "source definition without verifier",
+			sourceDefName: "webhook",
+			header:        http.Header{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifiers.Verify(tt.sourceDefName, tt.header, []byte(body), []byte(secret))
+			if tt.expectedErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+
+	t.Run("custom verifier", func(t *testing.T) {
+		verifiers := NewSignatureVerifiers(config.SingleValueLoader(time.Minute))
+		verifiers.Register("Custom", SignatureVerifierFunc(func(header http.Header, _, secret []byte, _ time.Time, _ time.Duration) error {
+			if header.Get("X-Token") != string(secret) {
+				return ErrInvalidSignature
+			}
+			return nil
+		}))
+		require.NoError(t, verifiers.Verify("custom", http.Header{"X-Token": {secret}}, nil, []byte(secret)))
+		require.ErrorIs(t, verifiers.Verify("custom", http.Header{}, nil, []byte(secret)), ErrInvalidSignature)
+	})
+}
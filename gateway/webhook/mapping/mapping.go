@@ -0,0 +1,177 @@
+package mapping
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+// SourceDefinitionName is the name of the generic webhook source definition, whose webhooks are mapped to events in-process
+// using the mapping declared in the source's config, instead of being sent to the source transformer.
+const SourceDefinitionName = "GenericWebhook"
+
+// configKey is the key of a source's config containing its mapping. A mapping is a template of the resulting event, e.g.
+//
+//	"mapping": {
+//	  "type": "track",
+//	  "event": "$.action",
+//	  "userId": "$.user.id",
+//	  "properties": {"amount": "$.order.total", "currency": "$.order.currency", "origin": "billing"}
+//	}
+//
+// String values starting with "$." are JSON paths into the webhook payload ("$" being the whole payload), while all other values are used as-is.
+// A leading "$$" escapes a literal "$". Paths not found in the payload are omitted from the resulting event.
+const configKey = "mapping"
+
+var (
+	ErrMissingMapping = errors.New("source config has no mapping")
+	ErrInvalidPayload = errors.New("webhook payload is not a JSON object or array")
+)
+
+// Supports returns true if webhooks of the given source definition are mapped in-process
+func Supports(sourceDefName string) bool {
+	return strings.EqualFold(sourceDefName, SourceDefinitionName)
+}
+
+// Mapping maps webhook payloads to rudder events
+type Mapping struct {
+	template map[string]interface{}
+}
+
+// FromSourceConfig parses the mapping declared in a source's config
+func FromSourceConfig(sourceConfig json.RawMessage) (*Mapping, error) {
+	raw := gjson.GetBytes(sourceConfig, configKey)
+	if !raw.Exists() {
+		return nil, ErrMissingMapping
+	}
+	var template map[string]interface{}
+	if err := jsonrs.Unmarshal([]byte(raw.Raw), &template); err != nil {
+		return nil, fmt.Errorf("invalid mapping: %w", err)
+	}
+	if template == nil {
+		return nil, ErrMissingMapping
+	}
+	if t, ok := template["type"]; ok {
+		if _, ok := t.(string); !ok {
+			return nil, errors.New("invalid mapping: type must be a string")
+		}
+	}
+	return &Mapping{template: template}, nil
+}
+
+// Map maps a webhook payload to events. A JSON object payload results in a single event, whereas
+// every element of a JSON array payload results in an event of its own.
+func (m *Mapping) Map(payload []byte) ([]map[string]interface{}, error) {
+	if !gjson.ValidBytes(payload) {
+		return nil, ErrInvalidPayload
+	}
+	root := gjson.ParseBytes(payload)
+	var elements []gjson.Result
+	switch {
+	case root.IsObject():
+		elements = []gjson.Result{root}
+	case root.IsArray():
+		elements = root.Array()
+	default:
+		return nil, ErrInvalidPayload
+	}
+	events := make([]map[string]interface{}, 0, len(elements))
+	for i, element := range elements {
+		event, err := m.mapElement(element)
+		if err != nil {
+			if len(elements) > 1 {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (m *Mapping) mapElement(element gjson.Result) (map[string]interface{}, error) {
+	event, _ := evaluate(m.template, element).(map[string]interface{})
+	if event == nil {
+		event = make(map[string]interface{})
+	}
+	eventType, _ := event["type"].(string)
+	if eventType == "" {
+		eventType = "track"
+		event["type"] = eventType
+	}
+	if eventName, _ := event["event"].(string); eventType == "track" && eventName == "" {
+		return nil, errors.New("mapped track event has no event name")
+	}
+	for _, key := range []string{"userId", "anonymousId"} {
+		if id := stringify(event[key]); id != "" {
+			event[key] = id
+		} else {
+			delete(event, key)
+		}
+	}
+	if event["userId"] == nil && event["anonymousId"] == nil {
+		event["anonymousId"] = uuid.New().String()
+	}
+	if _, ok := event["messageId"]; !ok {
+		event["messageId"] = uuid.New().String()
+	}
+	return event, nil
+}
+
+// evaluate resolves all JSON path expressions of the template against the provided element
+func evaluate(template interface{}, element gjson.Result) interface{} {
+	switch t := template.(type) {
+	case string:
+		switch {
+		case strings.HasPrefix(t, "$$"):
+			return t[1:]
+		case t == "$":
+			return element.Value()
+		case strings.HasPrefix(t, "$."):
+			value := element.Get(t[2:])
+			if !value.Exists() {
+				return nil
+			}
+			return value.Value()
+		default:
+			return t
+		}
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			if value := evaluate(v, element); value != nil {
+				result[k] = value
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(t))
+		for _, v := range t {
+			result = append(result, evaluate(v, element))
+		}
+		return result
+	default:
+		return t
+	}
+}
+
+// stringify converts identifiers to strings, e.g. numeric user ids
+func stringify(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
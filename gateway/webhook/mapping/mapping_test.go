@@ -0,0 +1,95 @@
+package mapping_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/gateway/webhook/mapping"
+)
+
+func TestSupports(t *testing.T) {
+	require.True(t, mapping.Supports("GenericWebhook"))
+	require.True(t, mapping.Supports("genericwebhook"))
+	require.False(t, mapping.Supports("Shopify"))
+}
+
+func TestFromSourceConfig(t *testing.T) {
+	_, err := mapping.FromSourceConfig([]byte(`{}`))
+	require.ErrorIs(t, err, mapping.ErrMissingMapping)
+
+	_, err = mapping.FromSourceConfig([]byte(`{"mapping":"$.event"}`))
+	require.Error(t, err)
+
+	_, err = mapping.FromSourceConfig([]byte(`{"mapping":{"type":1}}`))
+	require.Error(t, err)
+
+	_, err = mapping.FromSourceConfig([]byte(`{"mapping":{"event":"$.action"}}`))
+	require.NoError(t, err)
+}
+
+func TestMap(t *testing.T) {
+	m, err := mapping.FromSourceConfig([]byte(`{"mapping":{
+		"event": "$.action",
+		"userId": "$.user.id",
+		"properties": {"amount": "$.order.total", "tags": ["$.order.tag", "static"], "origin": "billing", "price": "$$10", "missing": "$.nope"},
+		"context": {"raw": "$"}
+	}}`))
+	require.NoError(t, err)
+
+	t.Run("object payload", func(t *testing.T) {
+		events, err := m.Map([]byte(`{"action":"Order Completed","user":{"id":42},"order":{"total":9.5,"tag":"vip"}}`))
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		event := events[0]
+		require.Equal(t, "track", event["type"])
+		require.Equal(t, "Order Completed", event["event"])
+		require.Equal(t, "42", event["userId"])
+		require.NotContains(t, event, "anonymousId")
+		require.NotEmpty(t, event["messageId"])
+		require.Equal(t, map[string]interface{}{
+			"amount": 9.5,
+			"tags":   []interface{}{"vip", "static"},
+			"origin": "billing",
+			"price":  "$10",
+		}, event["properties"])
+		require.Equal(t, map[string]interface{}{
+			"raw": map[string]interface{}{
+				"action": "Order Completed",
+				"user":   map[string]interface{}{"id": float64(42)},
+				"order":  map[string]interface{}{"total": 9.5, "tag": "vip"},
+			},
+		}, event["context"])
+	})
+
+	t.Run("array payload", func(t *testing.T) {
+		events, err := m.Map([]byte(`[{"action":"a"},{"action":"b","user":{"id":"u-1"}}]`))
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Equal(t, "a", events[0]["event"])
+		require.NotEmpty(t, events[0]["anonymousId"], "anonymousId should be generated for unidentified events")
+		require.Equal(t, "b", events[1]["event"])
+		require.Equal(t, "u-1", events[1]["userId"])
+	})
+
+	t.Run("track without event name", func(t *testing.T) {
+		_, err := m.Map([]byte(`{"user":{"id":42}}`))
+		require.Error(t, err)
+	})
+
+	t.Run("invalid payload", func(t *testing.T) {
+		_, err := m.Map([]byte(`not json`))
+		require.ErrorIs(t, err, mapping.ErrInvalidPayload)
+		_, err = m.Map([]byte(`"a string"`))
+		require.ErrorIs(t, err, mapping.ErrInvalidPayload)
+	})
+
+	t.Run("identify", func(t *testing.T) {
+		m, err := mapping.FromSourceConfig([]byte(`{"mapping":{"type":"identify","userId":"$.id","traits":{"email":"$.email"}}}`))
+		require.NoError(t, err)
+		events, err := m.Map([]byte(`{"id":"u-1","email":"a@b.c"}`))
+		require.NoError(t, err)
+		require.Equal(t, "identify", events[0]["type"])
+		require.Equal(t, map[string]interface{}{"email": "a@b.c"}, events[0]["traits"])
+	})
+}
@@ -24,8 +24,10 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/retryablehttp"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/gateway/response"
 	gwtypes "github.com/rudderlabs/rudder-server/gateway/types"
+	"github.com/rudderlabs/rudder-server/gateway/webhook/mapping"
 	"github.com/rudderlabs/rudder-server/gateway/webhook/model"
 	"github.com/rudderlabs/rudder-server/services/transformer"
 	"github.com/rudderlabs/rudder-server/utils/misc"
@@ -51,6 +53,8 @@ type WebhookRequestHandler interface {
 	RequestHandler(w http.ResponseWriter, r *http.Request)
 	// Register registers a new webhook source type and starts a goroutine to process requests for that source type
 	Register(name string)
+	// UpdateMappings parses the mappings of the generic webhook sources among the given ones, keyed by source id, replacing the previous ones
+	UpdateMappings(sources map[string]backendconfig.SourceT)
 	// Shutdown shuts down the webhook handler, closing all channels and waiting for goroutines to finish
 	Shutdown() error
 }
@@ -79,6 +83,7 @@ type HandleT struct {
 	}
 	statReporterCreator StatReporterCreator
 	httpClient          retryablehttp.HttpClient
+	mappings            atomic.Pointer[map[string]sourceMapping] // source id => parsed mapping of generic webhook sources
 }
 
 type webhookSourceStatT struct {
@@ -319,6 +324,10 @@ func (webhook *HandleT) batchRequests(sourceDef string, requestQ chan *webhookT)
 // TODO : return back immediately for blank request body. its waiting till timeout
 func (bt *batchWebhookTransformerT) batchTransformLoop() {
 	for breq := range bt.webhook.batchRequestQ {
+		if mapping.Supports(breq.sourceType) {
+			bt.mapBatch(breq)
+			continue
+		}
 		// If unable to fetch features from transformer, send GatewayTimeout to all requests
 		// TODO: Remove timeout from here after timeout handler is added in gateway
 		ctx, cancel := context.WithTimeout(context.Background(), config.GetDurationVar(10, time.Second, "WriteTimeout", "WriteTimeOutInSec"))
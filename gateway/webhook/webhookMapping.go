@@ -0,0 +1,125 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/gateway/response"
+	gwtypes "github.com/rudderlabs/rudder-server/gateway/types"
+	"github.com/rudderlabs/rudder-server/gateway/webhook/mapping"
+	"github.com/rudderlabs/rudder-server/gateway/webhook/model"
+)
+
+// mapBatch maps the requests of a generic webhook source batch to events in-process, using the mapping declared in each source's config,
+// and enqueues them in the gateway, bypassing the source transformer altogether
+func (bt *batchWebhookTransformerT) mapBatch(breq *batchWebhookT) {
+	if _, ok := bt.stats.sourceStats[breq.sourceType]; !ok {
+		bt.stats.sourceStats[breq.sourceType] = bt.newWebhookStat(breq.sourceType)
+	}
+	sourceStat := bt.stats.sourceStats[breq.sourceType]
+	sourceStat.numEvents.Count(len(breq.batchRequest))
+
+	mappingStart := time.Now()
+	failedWebhookPayloads := make([]*model.FailedWebhookPayload, 0)
+	for _, req := range breq.batchRequest {
+		payload, events, errMessage, reason := bt.mapRequest(req)
+		if errMessage == "" && len(events) == 0 {
+			// an empty array payload carries no events, there is nothing to enqueue
+			req.done <- transformerResponse{StatusCode: http.StatusOK}
+			continue
+		}
+		if errMessage == "" {
+			sourceStat.numOutputEvents.Count(len(events))
+			var batchPayload []byte
+			var err error
+			if batchPayload, err = jsonrs.Marshal(map[string]interface{}{"batch": events}); err != nil {
+				errMessage, reason = response.ErrorInMarshal, "marshal error"
+			} else if errMessage = bt.webhook.enqueueInGateway(req, batchPayload); errMessage != "" {
+				reason = bt.getWebhookFailureReason(errMessage, "enqueueInGateway failed")
+			}
+		}
+		if errMessage != "" {
+			bt.webhook.logger.Errorn("webhook mapping failed",
+				obskit.SourceType(breq.sourceType),
+				obskit.SourceID(req.sourceID),
+				logger.NewStringField("errorMessage", errMessage))
+			bt.webhook.countWebhookErrors(breq.sourceType, req.authContext, reason, response.GetErrorStatusCode(errMessage), 1)
+			if payload != nil {
+				failedWebhookPayloads = append(failedWebhookPayloads, &model.FailedWebhookPayload{RequestContext: req.authContext, Payload: payload, SourceType: breq.sourceType, Reason: errMessage})
+			}
+			req.done <- bt.markResponseFail(errMessage)
+			continue
+		}
+		req.done <- transformerResponse{StatusCode: http.StatusOK}
+	}
+	sourceStat.sourceTransform.Since(mappingStart)
+
+	if len(failedWebhookPayloads) > 0 {
+		if err := bt.webhook.gwHandle.SaveWebhookFailures(failedWebhookPayloads); err != nil {
+			bt.webhook.logger.Errorn("Saving webhook failures of sourceType", obskit.SourceType(breq.sourceType), obskit.Error(err))
+		}
+	}
+}
+
+// mapRequest reads the request's payload and maps it to events, returning an error message and a failure reason if mapping fails
+func (bt *batchWebhookTransformerT) mapRequest(req *webhookT) (payload []byte, events []map[string]interface{}, errMessage, reason string) {
+	defer func() {
+		if req.request.Body != nil {
+			_ = req.request.Body.Close()
+		}
+	}()
+	if req.request.Body == nil {
+		return nil, nil, response.RequestBodyNil, "empty body"
+	}
+	payload, err := io.ReadAll(req.request.Body)
+	if err != nil {
+		return nil, nil, response.RequestBodyReadFailed, "read body error"
+	}
+	if len(payload) > bt.webhook.config.maxReqSize.Load() {
+		return nil, nil, response.RequestBodyTooLarge, response.RequestBodyTooLarge
+	}
+	m, err := bt.webhook.mappingOf(req.authContext)
+	if err != nil {
+		bt.webhook.logger.Warnn("invalid webhook mapping", obskit.SourceID(req.sourceID), obskit.Error(err))
+		return payload, nil, response.InvalidWebhookMapping, "invalid mapping"
+	}
+	if events, err = m.Map(payload); err != nil {
+		bt.webhook.logger.Debugn("webhook payload could not be mapped", obskit.SourceID(req.sourceID), obskit.Error(err))
+		return payload, nil, response.WebhookMappingFailed, "mapping failed"
+	}
+	return payload, events, "", ""
+}
+
+// sourceMapping is the mapping of a generic webhook source, parsed once per config update along with its parsing error
+type sourceMapping struct {
+	mapping *mapping.Mapping
+	err     error
+}
+
+func (webhook *HandleT) UpdateMappings(sources map[string]backendconfig.SourceT) {
+	mappings := make(map[string]sourceMapping)
+	for sourceID, source := range sources {
+		if !mapping.Supports(source.SourceDefinition.Name) {
+			continue
+		}
+		m, err := mapping.FromSourceConfig(source.Config)
+		mappings[sourceID] = sourceMapping{mapping: m, err: err}
+	}
+	webhook.mappings.Store(&mappings)
+}
+
+// mappingOf returns the mapping of the request's source, as parsed on the last config update. Mappings of sources
+// unknown as of the last config update are parsed from the request's auth context instead.
+func (webhook *HandleT) mappingOf(arctx *gwtypes.AuthRequestContext) (*mapping.Mapping, error) {
+	if mappings := webhook.mappings.Load(); mappings != nil {
+		if m, ok := (*mappings)[arctx.SourceID]; ok {
+			return m.mapping, m.err
+		}
+	}
+	return mapping.FromSourceConfig(arctx.SourceDetails.Config)
+}
@@ -21,6 +21,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
@@ -28,9 +29,11 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
 
 	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	gwStats "github.com/rudderlabs/rudder-server/gateway/internal/stats"
 	mockWebhook "github.com/rudderlabs/rudder-server/gateway/mocks"
 	"github.com/rudderlabs/rudder-server/gateway/response"
+	"github.com/rudderlabs/rudder-server/gateway/webhook/model"
 	mock_features "github.com/rudderlabs/rudder-server/mocks/services/transformer"
 	"github.com/rudderlabs/rudder-server/services/transformer"
 	"github.com/rudderlabs/rudder-server/utils/misc"
@@ -725,3 +728,161 @@ func newMockTransformerServer(successAfter int, successRespBody, failureRespBody
 	mockServer.Server = handler
 	return mockServer
 }
+
+func TestWebhookRequestHandlerWithMapping(t *testing.T) {
+	initWebhook()
+	const genericWebhookSourceDefName = "GenericWebhook"
+
+	newHandler := func(t *testing.T, mockGW *mockWebhook.MockGateway, statsStore stats.Stats) *HandleT {
+		webhookHandler := Setup(mockGW, transformer.NewNoOpService(), statsStore, config.Default, newSourceStatReporter, func(bt *batchWebhookTransformerT) {
+			bt.sourceTransformAdapter = func(ctx context.Context) (sourceTransformAdapter, error) {
+				t.Error("source transformer shouldn't be used for generic webhook sources")
+				return nil, errors.New("unexpected")
+			}
+		})
+		webhookHandler.Register(genericWebhookSourceDefName)
+		return webhookHandler
+	}
+	send := func(webhookHandler *HandleT, arctx *gwtypes.AuthRequestContext, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/webhook", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		ctx := context.WithValue(req.Context(), gwtypes.CtxParamCallType, "webhook")
+		ctx = context.WithValue(ctx, gwtypes.CtxParamAuthRequestContext, arctx)
+		webhookHandler.RequestHandler(w, req.WithContext(ctx))
+		return w
+	}
+
+	t.Run("mapped in-process", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockGW := mockWebhook.NewMockGateway(ctrl)
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		webhookHandler := newHandler(t, mockGW, statsStore)
+		defer func() { _ = webhookHandler.Shutdown() }()
+
+		arctx := &gwtypes.AuthRequestContext{WriteKey: sampleWriteKey, SourceDefName: genericWebhookSourceDefName}
+		arctx.SourceDetails.Config = []byte(`{"mapping":{"event":"$.action","userId":"$.user","properties":{"total":"$.total"}}}`)
+
+		mockGW.EXPECT().TrackRequestMetrics("").Times(1)
+		mockGW.EXPECT().ProcessTransformedWebhookRequest(gomock.Any(), gomock.Any(), "batch", gomock.Any(), arctx).Times(1).
+			DoAndReturn(func(_ *http.ResponseWriter, _ *http.Request, _ string, payload []byte, _ *gwtypes.AuthRequestContext) string {
+				var batch struct {
+					Batch []map[string]interface{} `json:"batch"`
+				}
+				require.NoError(t, jsonrs.Unmarshal(payload, &batch))
+				require.Len(t, batch.Batch, 1)
+				require.Equal(t, "track", batch.Batch[0]["type"])
+				require.Equal(t, "Order Completed", batch.Batch[0]["event"])
+				require.Equal(t, "u-1", batch.Batch[0]["userId"])
+				require.Equal(t, map[string]interface{}{"total": float64(10)}, batch.Batch[0]["properties"])
+				return ""
+			})
+
+		w := send(webhookHandler, arctx, `{"action":"Order Completed","user":"u-1","total":10}`)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+		require.Equal(t, response.Ok, strings.TrimSpace(w.Body.String()))
+		require.EqualValues(t, 1, statsStore.Get("webhook_num_output_events", stats.Tags{"sourceType": genericWebhookSourceDefName}).LastValue())
+	})
+
+	t.Run("mapping failure", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockGW := mockWebhook.NewMockGateway(ctrl)
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		webhookHandler := newHandler(t, mockGW, statsStore)
+		defer func() { _ = webhookHandler.Shutdown() }()
+
+		arctx := &gwtypes.AuthRequestContext{WriteKey: sampleWriteKey, SourceID: "source-1", WorkspaceID: "workspace-1", SourceDefName: genericWebhookSourceDefName}
+		arctx.SourceDetails.Config = []byte(`{"mapping":{"event":"$.action"}}`)
+
+		mockGW.EXPECT().TrackRequestMetrics(response.WebhookMappingFailed).Times(1)
+		mockGW.EXPECT().SaveWebhookFailures(gomock.Any()).Times(1).DoAndReturn(func(failures []*model.FailedWebhookPayload) error {
+			require.Len(t, failures, 1)
+			require.Equal(t, response.WebhookMappingFailed, failures[0].Reason)
+			require.Equal(t, `{"user":"u-1"}`, string(failures[0].Payload))
+			return nil
+		})
+
+		w := send(webhookHandler, arctx, `{"user":"u-1"}`)
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		require.Equal(t, response.WebhookMappingFailed, strings.TrimSpace(w.Body.String()))
+		require.EqualValues(t, 1, statsStore.Get("webhook_num_errors", stats.Tags{
+			"writeKey":    sampleWriteKey,
+			"workspaceId": "workspace-1",
+			"sourceID":    "source-1",
+			"statusCode":  "400",
+			"sourceType":  genericWebhookSourceDefName,
+			"reason":      "mapping failed",
+		}).LastValue())
+	})
+
+	t.Run("empty batch", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockGW := mockWebhook.NewMockGateway(ctrl)
+		webhookHandler := newHandler(t, mockGW, stats.NOP)
+		defer func() { _ = webhookHandler.Shutdown() }()
+
+		arctx := &gwtypes.AuthRequestContext{WriteKey: sampleWriteKey, SourceDefName: genericWebhookSourceDefName}
+		arctx.SourceDetails.Config = []byte(`{"mapping":{"event":"$.action"}}`)
+		mockGW.EXPECT().TrackRequestMetrics("").Times(1)
+
+		w := send(webhookHandler, arctx, `[]`)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode, "an empty batch should be accepted without being enqueued")
+	})
+
+	t.Run("mapping of config update", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockGW := mockWebhook.NewMockGateway(ctrl)
+		webhookHandler := newHandler(t, mockGW, stats.NOP)
+		defer func() { _ = webhookHandler.Shutdown() }()
+
+		webhookHandler.UpdateMappings(map[string]backendconfig.SourceT{
+			"source-1": {
+				ID:               "source-1",
+				SourceDefinition: backendconfig.SourceDefinitionT{Name: genericWebhookSourceDefName},
+				Config:           []byte(`{"mapping":{"event":"$.action","userId":"$.user"}}`),
+			},
+			"source-2": {
+				ID:               "source-2",
+				SourceDefinition: backendconfig.SourceDefinitionT{Name: genericWebhookSourceDefName},
+				Config:           []byte(`{}`),
+			},
+		})
+
+		// the mapping parsed on the config update is used, rather than parsing the one of the request's auth context again
+		arctx := &gwtypes.AuthRequestContext{WriteKey: sampleWriteKey, SourceID: "source-1", SourceDefName: genericWebhookSourceDefName}
+		arctx.SourceDetails.Config = []byte(`{"mapping":{"event":"$.other"}}`)
+		mockGW.EXPECT().TrackRequestMetrics("").Times(1)
+		mockGW.EXPECT().ProcessTransformedWebhookRequest(gomock.Any(), gomock.Any(), "batch", gomock.Any(), arctx).Times(1).
+			DoAndReturn(func(_ *http.ResponseWriter, _ *http.Request, _ string, payload []byte, _ *gwtypes.AuthRequestContext) string {
+				require.Equal(t, "Order Completed", gjson.GetBytes(payload, "batch.0.event").String())
+				require.Equal(t, "u-1", gjson.GetBytes(payload, "batch.0.userId").String())
+				return ""
+			})
+		w := send(webhookHandler, arctx, `{"action":"Order Completed","user":"u-1"}`)
+		require.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+		// so are its parsing errors
+		arctx = &gwtypes.AuthRequestContext{WriteKey: sampleWriteKey, SourceID: "source-2", SourceDefName: genericWebhookSourceDefName}
+		arctx.SourceDetails.Config = []byte(`{"mapping":{"event":"$.action"}}`)
+		mockGW.EXPECT().TrackRequestMetrics(response.InvalidWebhookMapping).Times(1)
+		mockGW.EXPECT().SaveWebhookFailures(gomock.Any()).Times(1).Return(nil)
+		w = send(webhookHandler, arctx, `{"action":"Order Completed"}`)
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+	})
+
+	t.Run("missing mapping", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockGW := mockWebhook.NewMockGateway(ctrl)
+		webhookHandler := newHandler(t, mockGW, stats.NOP)
+		defer func() { _ = webhookHandler.Shutdown() }()
+
+		arctx := &gwtypes.AuthRequestContext{WriteKey: sampleWriteKey, SourceDefName: genericWebhookSourceDefName}
+		mockGW.EXPECT().TrackRequestMetrics(response.InvalidWebhookMapping).Times(1)
+		mockGW.EXPECT().SaveWebhookFailures(gomock.Any()).Times(1).Return(nil)
+
+		w := send(webhookHandler, arctx, sampleJson)
+		require.Equal(t, http.StatusBadRequest, w.Result().StatusCode)
+		require.Equal(t, response.InvalidWebhookMapping, strings.TrimSpace(w.Body.String()))
+	})
+}
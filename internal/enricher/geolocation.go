@@ -4,13 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
-	"path"
+	"slices"
+	"sync"
+	"time"
 
 	"github.com/samber/lo"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
-	"github.com/rudderlabs/rudder-go-kit/filemanager"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
@@ -33,32 +33,125 @@ type Geolocation struct {
 	Postal   string `json:"postal"`
 	Location string `json:"location"`
 	Timezone string `json:"timezone"`
+
+	// Network traits, only available if ASN and connection type databases are configured
+	ASN            uint   `json:"asn,omitempty"`
+	ASOrganization string `json:"asOrganization,omitempty"`
+	ISP            string `json:"isp,omitempty"`
+	Organization   string `json:"organization,omitempty"`
+	ConnectionType string `json:"connectionType,omitempty"`
 }
 
 type geoEnricher struct {
-	fetcher geolocation.GeoFetcher
+	fetcher *geolocation.SwappableFetcher
+	dbs     []geoDB
 	logger  logger.Logger
 	stats   stats.Stats
+
+	checksums []string // of the databases currently in use
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
 }
 
+// NewGeoEnricher returns an enricher adding geolocation information to events, from the databases described in [geoDBs].
+// If Geolocation.refreshInterval is set, databases are fetched again periodically and swapped without a restart whenever they change.
 func NewGeoEnricher(conf *config.Config, log logger.Logger, statClient stats.Stats) (PipelineEnricher, error) {
 	log.Infon("Setting up new event geo enricher")
 
-	dbPath, err := downloadMaxmindDB(context.Background(), conf, log)
+	dbs, err := geoDBs(conf, log)
 	if err != nil {
-		return nil, fmt.Errorf("downloading instance of maxmind db: %w", err)
+		return nil, fmt.Errorf("configuring geolocation databases: %w", err)
+	}
+	e := &geoEnricher{
+		dbs:    dbs,
+		stats:  statClient,
+		logger: log.Child("geolocation"),
 	}
 
-	fetcher, err := geolocation.NewMaxmindDBReader(dbPath)
+	paths, checksums, err := e.fetchDBs(context.Background(), false)
+	if err != nil {
+		return nil, fmt.Errorf("downloading instance of maxmind db: %w", err)
+	}
+	fetcher, err := geolocation.NewMultiDBReader(paths...)
 	if err != nil {
 		return nil, fmt.Errorf("creating new instance of maxmind's geolocation db reader: %w", err)
 	}
+	e.fetcher = geolocation.NewSwappableFetcher(fetcher)
+	e.checksums = checksums
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	if refreshInterval := conf.GetDuration("Geolocation.refreshInterval", 0, time.Second); refreshInterval > 0 {
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			e.refreshLoop(ctx, refreshInterval)
+		}()
+	}
+	return e, nil
+}
 
-	return &geoEnricher{
-		fetcher: fetcher,
-		stats:   statClient,
-		logger:  log.Child("geolocation"),
-	}, nil
+// fetchDBs fetches all databases, returning their paths along with their checksums
+func (e *geoEnricher) fetchDBs(ctx context.Context, refresh bool) (paths, checksums []string, err error) {
+	for _, db := range e.dbs {
+		dbPath, err := db.source.fetch(ctx, refresh)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetching %s database: %w", db.name, err)
+		}
+		checksum, err := fileChecksum(dbPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s database: %w", db.name, err)
+		}
+		paths = append(paths, dbPath)
+		checksums = append(checksums, checksum)
+	}
+	return paths, checksums, nil
+}
+
+func (e *geoEnricher) refreshLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		updated, err := e.refresh(ctx)
+		status := "unchanged"
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return
+			}
+			status = "failed"
+			e.logger.Errorn("refreshing geolocation databases, keeping the current ones", obskit.Error(err))
+		case updated:
+			status = "updated"
+			e.logger.Infon("geolocation databases updated")
+		}
+		e.stats.NewTaggedStat("proc_geo_enricher_db_refresh", stats.CountType, stats.Tags{"status": status}).Increment()
+	}
+}
+
+// refresh fetches the databases again, swapping the ones in use if any of them changed
+func (e *geoEnricher) refresh(ctx context.Context) (bool, error) {
+	paths, checksums, err := e.fetchDBs(ctx, true)
+	if err != nil {
+		return false, err
+	}
+	if slices.Equal(checksums, e.checksums) {
+		return false, nil
+	}
+	fetcher, err := geolocation.NewMultiDBReader(paths...)
+	if err != nil {
+		return false, fmt.Errorf("opening updated databases: %w", err)
+	}
+	if err := e.fetcher.Swap(fetcher); err != nil {
+		e.logger.Warnn("closing previous geolocation databases", obskit.Error(err))
+	}
+	e.checksums = checksums
+	return true, nil
 }
 
 // Enrich function runs on a request of GatewayBatchRequest which contains
@@ -141,6 +234,8 @@ func (e *geoEnricher) Enrich(source *backendconfig.SourceT, request *types.Gatew
 
 func (e *geoEnricher) Close() error {
 	e.logger.Infon("closing the geolocation enricher")
+	e.cancel()
+	e.wg.Wait()
 
 	if err := e.fetcher.Close(); err != nil {
 		return fmt.Errorf("closing the geo enricher: %w", err)
@@ -148,84 +243,6 @@ func (e *geoEnricher) Close() error {
 	return nil
 }
 
-// downloadMaxmindDB downloads database file from upstream s3 and stores it in
-// a specified location. Download is skipped if the file already exists in the expected path.
-func downloadMaxmindDB(ctx context.Context, conf *config.Config, log logger.Logger) (string, error) {
-	var (
-		dbKey            = conf.GetString("Geolocation.db.key", "geolite2City.mmdb")
-		bucket           = conf.GetString("Geolocation.db.storage.bucket", "rudderstack-geolocation")
-		region           = conf.GetString("Geolocation.db.storage.region", "us-east-1")
-		endpoint         = conf.GetString("Geolocation.db.storage.endpoint", "")
-		accessKeyID      = conf.GetString("Geolocation.db.storage.accessKey", "")
-		secretAccessKey  = conf.GetString("Geolocation.db.storage.secretAccessKey", "")
-		s3ForcePathStyle = conf.GetBool("Geolocation.db.storage.s3ForcePathStyle", false)
-		disableSSL       = conf.GetBool("Geolocation.db.storage.disableSSL", false)
-	)
-
-	var (
-		baseDIR      = path.Join(conf.GetString("RUDDER_TMPDIR", "."), "geolocation")
-		downloadPath = path.Join(baseDIR, dbKey)
-	)
-
-	// If the filepath exists return
-	if _, err := os.Stat(downloadPath); err == nil {
-		return downloadPath, nil
-	}
-
-	log.Infon("downloading new geolocation db from key", logger.NewStringField("key", dbKey))
-
-	if err := os.MkdirAll(baseDIR, os.ModePerm); err != nil {
-		return "", fmt.Errorf("creating directory for storing db: %w", err)
-	}
-
-	f, err := os.CreateTemp(baseDIR, "geodb-*.mmdb")
-	if err != nil {
-		return "", fmt.Errorf("creating a temporary file: %w", err)
-	}
-
-	defer func() {
-		_ = f.Close()
-		_ = os.Remove(f.Name())
-	}()
-
-	manager, err := filemanager.New(&filemanager.Settings{
-		Provider: "S3",
-		Config: map[string]interface{}{
-			"bucketName":       bucket,
-			"region":           region,
-			"endpoint":         endpoint,
-			"accessKeyID":      accessKeyID,
-			"secretAccessKey":  secretAccessKey,
-			"s3ForcePathStyle": s3ForcePathStyle,
-			"disableSSL":       disableSSL,
-		},
-		Conf: conf,
-	})
-	if err != nil {
-		return "", fmt.Errorf("creating a new s3 manager client: %w", err)
-	}
-
-	if err := manager.Download(ctx, f, dbKey); err != nil {
-		return "", fmt.Errorf("downloading file with key: %s from bucket: %s and region: %s, err: %w",
-			dbKey,
-			bucket,
-			region,
-			err)
-	}
-
-	// before renaming, we need to sync data to the disk
-	if err := f.Sync(); err != nil {
-		return "", fmt.Errorf("syncing file to disk: %w", err)
-	}
-
-	// Finally move the downloaded file from previous temp location to new location
-	if err := os.Rename(f.Name(), downloadPath); err != nil {
-		return "", fmt.Errorf("renaming file: %w", err)
-	}
-
-	return downloadPath, nil
-}
-
 func extractGeolocationData(ip string, geoCity geolocation.GeoInfo) Geolocation {
 	toReturn := Geolocation{
 		IP:       ip,
@@ -233,6 +250,12 @@ func extractGeolocationData(ip string, geoCity geolocation.GeoInfo) Geolocation
 		Country:  geoCity.Country.ISOCode,
 		Postal:   geoCity.Postal.Code,
 		Timezone: geoCity.Location.Timezone,
+
+		ASN:            geoCity.ASN,
+		ASOrganization: geoCity.ASOrganization,
+		ISP:            geoCity.ISP,
+		Organization:   geoCity.Organization,
+		ConnectionType: geoCity.ConnectionType,
 	}
 
 	if len(geoCity.Subdivisions) > 0 {
@@ -0,0 +1,323 @@
+package enricher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	kithttputil "github.com/rudderlabs/rudder-go-kit/httputil"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+)
+
+const (
+	dbProviderS3   = "s3"
+	dbProviderFile = "file"
+	dbProviderHTTP = "http"
+	dbProviderNone = "none"
+)
+
+// geoDB is a geolocation database along with where to fetch it from
+type geoDB struct {
+	name   string
+	source dbSource
+}
+
+// dbSource provides the latest version of a database
+type dbSource interface {
+	// fetch returns the path of the database, downloading it again if refresh is true and the source supports it
+	fetch(ctx context.Context, refresh bool) (string, error)
+}
+
+// geoDBs returns the databases to be used for enrichment:
+//
+//   - Geolocation.db: the city database, downloaded from s3 by default
+//   - Geolocation.asnDb: an optional ASN or ISP database
+//   - Geolocation.connectionTypeDb: an optional connection type database
+//
+// Each database is fetched according to its provider (<prefix>.provider) which can be either of:
+//
+//   - s3: downloaded from <prefix>.storage.bucket using <prefix>.key
+//   - file: read from <prefix>.file.path
+//   - http: downloaded from <prefix>.http.url, verified against the sha256 checksum found in <prefix>.http.sha256 or at <prefix>.http.checksumUrl
+//   - none: not used
+//
+// Any MaxMind DB file can be used, e.g. MaxMind, DB-IP or IP2Location ones, as long as they follow the GeoIP2 schemas.
+func geoDBs(conf *config.Config, log logger.Logger) ([]geoDB, error) {
+	var dbs []geoDB
+	for _, db := range []struct {
+		name, prefix, defaultProvider string
+	}{
+		{name: "city", prefix: "Geolocation.db", defaultProvider: dbProviderS3},
+		{name: "asn", prefix: "Geolocation.asnDb", defaultProvider: dbProviderNone},
+		{name: "connectionType", prefix: "Geolocation.connectionTypeDb", defaultProvider: dbProviderNone},
+	} {
+		var source dbSource
+		switch provider := strings.ToLower(conf.GetString(db.prefix+".provider", db.defaultProvider)); provider {
+		case dbProviderS3:
+			source = &s3DBSource{conf: conf, log: log, prefix: db.prefix}
+		case dbProviderFile:
+			dbPath := conf.GetString(db.prefix+".file.path", "")
+			if dbPath == "" {
+				return nil, fmt.Errorf("%s: file path is required for file provider", db.prefix)
+			}
+			source = &fileDBSource{path: dbPath}
+		case dbProviderHTTP:
+			url := conf.GetString(db.prefix+".http.url", "")
+			if url == "" {
+				return nil, fmt.Errorf("%s: url is required for http provider", db.prefix)
+			}
+			source = &httpDBSource{
+				log:         log,
+				url:         url,
+				sha256:      strings.ToLower(conf.GetString(db.prefix+".http.sha256", "")),
+				checksumURL: conf.GetString(db.prefix+".http.checksumUrl", ""),
+				path:        path.Join(conf.GetString("RUDDER_TMPDIR", "."), "geolocation", db.name+".mmdb"),
+				client:      &http.Client{Timeout: conf.GetDuration(db.prefix+".http.timeout", 5, time.Minute)},
+			}
+		case dbProviderNone, "":
+			continue
+		default:
+			return nil, fmt.Errorf("%s: unknown provider %q", db.prefix, provider)
+		}
+		dbs = append(dbs, geoDB{name: db.name, source: source})
+	}
+	return dbs, nil
+}
+
+type s3DBSource struct {
+	conf   *config.Config
+	log    logger.Logger
+	prefix string
+}
+
+func (s *s3DBSource) fetch(ctx context.Context, refresh bool) (string, error) {
+	return downloadS3DB(ctx, s.conf, s.log, s.prefix, refresh)
+}
+
+// fileDBSource reads a database from a local path, which should be replaced atomically (e.g. renamed) when updated
+type fileDBSource struct {
+	path string
+}
+
+func (s *fileDBSource) fetch(context.Context, bool) (string, error) {
+	if _, err := os.Stat(s.path); err != nil {
+		return "", fmt.Errorf("reading database file: %w", err)
+	}
+	return s.path, nil
+}
+
+// httpDBSource downloads a database over http, verifying its checksum, if any.
+// Downloads are skipped if the server reports that the database hasn't changed since the last one.
+type httpDBSource struct {
+	log         logger.Logger
+	url         string
+	sha256      string
+	checksumURL string
+	path        string
+	client      *http.Client
+
+	etag         string
+	lastModified string
+}
+
+func (s *httpDBSource) fetch(ctx context.Context, refresh bool) (string, error) {
+	if _, err := os.Stat(s.path); err == nil && !refresh {
+		return s.path, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(s.path); err == nil {
+		if s.etag != "" {
+			req.Header.Set("If-None-Match", s.etag)
+		}
+		if s.lastModified != "" {
+			req.Header.Set("If-Modified-Since", s.lastModified)
+		}
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("downloading database: %w", err)
+	}
+	defer func() { kithttputil.CloseResponse(resp) }()
+	if resp.StatusCode == http.StatusNotModified {
+		return s.path, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading database: unexpected status code %d", resp.StatusCode)
+	}
+
+	expectedChecksum, err := s.expectedChecksum(ctx)
+	if err != nil {
+		return "", err
+	}
+	s.log.Infon("downloading new geolocation db", logger.NewStringField("url", s.url))
+	err = writeAtomically(s.path, func(f *os.File) error {
+		h := sha256.New()
+		if _, err := io.Copy(io.MultiWriter(f, h), resp.Body); err != nil {
+			return fmt.Errorf("downloading database: %w", err)
+		}
+		if checksum := hex.EncodeToString(h.Sum(nil)); expectedChecksum != "" && checksum != expectedChecksum {
+			return fmt.Errorf("database checksum mismatch: expected %s, got %s", expectedChecksum, checksum)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	s.etag, s.lastModified = resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	return s.path, nil
+}
+
+// expectedChecksum returns the configured sha256 checksum, or the one found at the checksum url, as formatted by sha256sum
+func (s *httpDBSource) expectedChecksum(ctx context.Context) (string, error) {
+	if s.sha256 != "" || s.checksumURL == "" {
+		return s.sha256, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.checksumURL, http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("downloading database checksum: %w", err)
+	}
+	defer func() { kithttputil.CloseResponse(resp) }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("downloading database checksum: unexpected status code %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return "", fmt.Errorf("downloading database checksum: %w", err)
+	}
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 {
+		return "", errors.New("empty database checksum")
+	}
+	return strings.ToLower(fields[0]), nil
+}
+
+// writeAtomically writes a file using write, in a temporary file which is then renamed to dst,
+// so that readers of dst (e.g. memory mapped databases) are never exposed to partial content
+func writeAtomically(dst string, write func(f *os.File) error) error {
+	if err := os.MkdirAll(path.Dir(dst), os.ModePerm); err != nil {
+		return fmt.Errorf("creating directory for storing db: %w", err)
+	}
+	f, err := os.CreateTemp(path.Dir(dst), "geodb-*.mmdb")
+	if err != nil {
+		return fmt.Errorf("creating a temporary file: %w", err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+	if err := write(f); err != nil {
+		return err
+	}
+	// before renaming, we need to sync data to the disk
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("syncing file to disk: %w", err)
+	}
+	// Finally move the downloaded file from previous temp location to new location
+	if err := os.Rename(f.Name(), dst); err != nil {
+		return fmt.Errorf("renaming file: %w", err)
+	}
+	return nil
+}
+
+// downloadMaxmindDB downloads the city database file from upstream s3 and stores it in
+// a specified location. Download is skipped if the file already exists in the expected path.
+func downloadMaxmindDB(ctx context.Context, conf *config.Config, log logger.Logger) (string, error) {
+	return downloadS3DB(ctx, conf, log, "Geolocation.db", false)
+}
+
+// downloadS3DB downloads the database file configured under prefix from upstream s3 and stores it in
+// a specified location. Download is skipped if the file already exists in the expected path, unless refresh is true.
+func downloadS3DB(ctx context.Context, conf *config.Config, log logger.Logger, prefix string, refresh bool) (string, error) {
+	defaultKey := ""
+	if prefix == "Geolocation.db" {
+		defaultKey = "geolite2City.mmdb"
+	}
+	var (
+		dbKey            = conf.GetString(prefix+".key", defaultKey)
+		bucket           = conf.GetString(prefix+".storage.bucket", "rudderstack-geolocation")
+		region           = conf.GetString(prefix+".storage.region", "us-east-1")
+		endpoint         = conf.GetString(prefix+".storage.endpoint", "")
+		accessKeyID      = conf.GetString(prefix+".storage.accessKey", "")
+		secretAccessKey  = conf.GetString(prefix+".storage.secretAccessKey", "")
+		s3ForcePathStyle = conf.GetBool(prefix+".storage.s3ForcePathStyle", false)
+		disableSSL       = conf.GetBool(prefix+".storage.disableSSL", false)
+	)
+	if dbKey == "" {
+		return "", fmt.Errorf("%s: key is required for s3 provider", prefix)
+	}
+
+	var (
+		baseDIR      = path.Join(conf.GetString("RUDDER_TMPDIR", "."), "geolocation")
+		downloadPath = path.Join(baseDIR, dbKey)
+	)
+
+	// If the filepath exists return
+	if _, err := os.Stat(downloadPath); err == nil && !refresh {
+		return downloadPath, nil
+	}
+
+	log.Infon("downloading new geolocation db from key", logger.NewStringField("key", dbKey))
+
+	manager, err := filemanager.New(&filemanager.Settings{
+		Provider: "S3",
+		Config: map[string]interface{}{
+			"bucketName":       bucket,
+			"region":           region,
+			"endpoint":         endpoint,
+			"accessKeyID":      accessKeyID,
+			"secretAccessKey":  secretAccessKey,
+			"s3ForcePathStyle": s3ForcePathStyle,
+			"disableSSL":       disableSSL,
+		},
+		Conf: conf,
+	})
+	if err != nil {
+		return "", fmt.Errorf("creating a new s3 manager client: %w", err)
+	}
+
+	err = writeAtomically(downloadPath, func(f *os.File) error {
+		if err := manager.Download(ctx, f, dbKey); err != nil {
+			return fmt.Errorf("downloading file with key: %s from bucket: %s and region: %s, err: %w",
+				dbKey,
+				bucket,
+				region,
+				err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return downloadPath, nil
+}
+
+// fileChecksum returns the sha256 checksum of a file, used for detecting database updates
+func fileChecksum(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
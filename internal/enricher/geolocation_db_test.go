@@ -0,0 +1,194 @@
+package enricher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/types"
+)
+
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	require.NoError(t, err)
+	tmp := dst + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, data, 0o644))
+	require.NoError(t, os.Rename(tmp, dst))
+}
+
+func enrichIP(t *testing.T, e PipelineEnricher, ip string) Geolocation {
+	t.Helper()
+	source := NewSourceBuilder("source-id").WithGeoEnrichment(true).Build()
+	request := &types.GatewayBatchRequest{
+		RequestIP: ip,
+		Batch:     []types.SingularEventT{{"context": map[string]interface{}{}}},
+	}
+	require.NoError(t, e.Enrich(source, request, &types.EventParams{}))
+	return request.Batch[0]["context"].(map[string]interface{})["geo"].(Geolocation)
+}
+
+func TestGeoDBs(t *testing.T) {
+	for name, settings := range map[string]map[string]interface{}{
+		"unknown provider":      {"Geolocation.db.provider": "unknown"},
+		"file without path":     {"Geolocation.db.provider": "file"},
+		"http without url":      {"Geolocation.asnDb.provider": "http"},
+		"s3 without key on asn": {"Geolocation.asnDb.provider": "s3"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := config.New()
+			c.Set("RUDDER_TMPDIR", t.TempDir())
+			for k, v := range settings {
+				c.Set(k, v)
+			}
+			_, err := NewGeoEnricher(c, logger.NOP, stats.NOP)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestGeoEnricherFileProvider(t *testing.T) {
+	c := config.New()
+	c.Set("Geolocation.db.provider", "file")
+	c.Set("Geolocation.db.file.path", "./testdata/geolocation/city_test.mmdb")
+	c.Set("Geolocation.asnDb.provider", "file")
+	c.Set("Geolocation.asnDb.file.path", "./testdata/geolocation/isp_test.mmdb")
+	c.Set("Geolocation.connectionTypeDb.provider", "file")
+	c.Set("Geolocation.connectionTypeDb.file.path", "./testdata/geolocation/connection_type_test.mmdb")
+
+	e, err := NewGeoEnricher(c, logger.NOP, stats.NOP)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, e.Close()) }()
+
+	geo := enrichIP(t, e, "2.125.160.216")
+	require.Equal(t, "Boxford", geo.City)
+	require.Equal(t, "GB", geo.Country)
+	require.EqualValues(t, 5607, geo.ASN)
+	require.Equal(t, "Sky UK Limited", geo.ASOrganization)
+	require.Equal(t, "Sky UK", geo.ISP)
+	require.Equal(t, "Sky Broadband", geo.Organization)
+	require.Equal(t, "Cable/DSL", geo.ConnectionType)
+
+	c.Set("Geolocation.db.file.path", "./testdata/geolocation/missing.mmdb")
+	_, err = NewGeoEnricher(c, logger.NOP, stats.NOP)
+	require.Error(t, err)
+}
+
+func TestGeoEnricherHTTPProvider(t *testing.T) {
+	city, err := os.ReadFile("./testdata/geolocation/city_test.mmdb")
+	require.NoError(t, err)
+	sum := sha256.Sum256(city)
+	checksum := hex.EncodeToString(sum[:])
+
+	var downloads atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/city.mmdb":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			downloads.Add(1)
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write(city)
+		case "/city.mmdb.sha256":
+			_, _ = w.Write([]byte(checksum + "  city.mmdb\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	newConf := func() *config.Config {
+		c := config.New()
+		c.Set("RUDDER_TMPDIR", t.TempDir())
+		c.Set("Geolocation.db.provider", "http")
+		c.Set("Geolocation.db.http.url", srv.URL+"/city.mmdb")
+		return c
+	}
+
+	t.Run("checksum url", func(t *testing.T) {
+		c := newConf()
+		c.Set("Geolocation.db.http.checksumUrl", srv.URL+"/city.mmdb.sha256")
+		e, err := NewGeoEnricher(c, logger.NOP, stats.NOP)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, e.Close()) }()
+		require.Equal(t, "Boxford", enrichIP(t, e, "2.125.160.216").City)
+
+		// unchanged databases are neither downloaded again nor swapped
+		before := downloads.Load()
+		updated, err := e.(*geoEnricher).refresh(t.Context())
+		require.NoError(t, err)
+		require.False(t, updated)
+		require.Equal(t, before, downloads.Load())
+	})
+
+	t.Run("configured checksum", func(t *testing.T) {
+		c := newConf()
+		c.Set("Geolocation.db.http.sha256", checksum)
+		e, err := NewGeoEnricher(c, logger.NOP, stats.NOP)
+		require.NoError(t, err)
+		require.NoError(t, e.Close())
+	})
+
+	t.Run("checksum mismatch", func(t *testing.T) {
+		c := newConf()
+		c.Set("Geolocation.db.http.sha256", "0000")
+		_, err := NewGeoEnricher(c, logger.NOP, stats.NOP)
+		require.ErrorContains(t, err, "checksum mismatch")
+	})
+
+	t.Run("download failure", func(t *testing.T) {
+		c := newConf()
+		c.Set("Geolocation.db.http.url", srv.URL+"/missing.mmdb")
+		_, err := NewGeoEnricher(c, logger.NOP, stats.NOP)
+		require.ErrorContains(t, err, "unexpected status code 404")
+	})
+}
+
+func TestGeoEnricherHotSwap(t *testing.T) {
+	dir := t.TempDir()
+	asnPath := filepath.Join(dir, "asn.mmdb")
+	copyFile(t, "./testdata/geolocation/connection_type_test.mmdb", asnPath)
+
+	c := config.New()
+	c.Set("Geolocation.db.provider", "file")
+	c.Set("Geolocation.db.file.path", "./testdata/geolocation/city_test.mmdb")
+	c.Set("Geolocation.asnDb.provider", "file")
+	c.Set("Geolocation.asnDb.file.path", asnPath)
+	c.Set("Geolocation.refreshInterval", "10ms")
+
+	e, err := NewGeoEnricher(c, logger.NOP, stats.NOP)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, e.Close()) }()
+	require.Zero(t, enrichIP(t, e, "2.125.160.216").ASN)
+
+	// replacing the database is picked up without restarting, while enrichment keeps working
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		source := &backendconfig.SourceT{ID: "source-id", GeoEnrichment: struct{ Enabled bool }{Enabled: true}}
+		for i := 0; i < 100; i++ {
+			request := &types.GatewayBatchRequest{RequestIP: "2.125.160.216", Batch: []types.SingularEventT{{}}}
+			_ = e.Enrich(source, request, &types.EventParams{})
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	copyFile(t, "./testdata/geolocation/isp_test.mmdb", asnPath)
+	require.Eventually(t, func() bool {
+		return enrichIP(t, e, "2.125.160.216").ASN == 5607
+	}, 5*time.Second, 10*time.Millisecond)
+	<-done
+}
@@ -0,0 +1,566 @@
+package jobsdb
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+
+	"github.com/rudderlabs/rudder-server/jobsdb/internal/dsindex"
+	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
+)
+
+// inspectableHandles keeps the started handles by table prefix, so that they can be inspected through [Admin]
+var inspectableHandles sync.Map // table prefix => *Handle
+
+// maxExportLimit bounds the jobs exported in a single call
+const maxExportLimit = 10000
+
+// NewAdmin returns a new jobsdb admin, for inspecting the datasets of all started jobsdbs
+func NewAdmin() *Admin {
+	return &Admin{}
+}
+
+// Admin exposes jobsdb inspection functions over the admin rpc interface.
+// Datasets are always read from the database, so that all of them can be inspected, even by writers which only keep the latest ones in memory.
+type Admin struct{}
+
+// DatasetsArg are the arguments of [Admin.Datasets]
+type DatasetsArg struct {
+	Prefix string // table prefix of the jobsdb, all jobsdbs if empty
+}
+
+// CountsArg are the arguments of [Admin.Counts]
+type CountsArg struct {
+	Prefix  string   // table prefix of the jobsdb
+	Dataset string   // index of the dataset to count jobs of, all datasets if empty
+	GroupBy []string // any of state, workspace, destination and customVal, state if empty
+}
+
+// FindJobArg are the arguments of [Admin.FindJob]
+type FindJobArg struct {
+	Prefix    string // table prefix of the jobsdb
+	JobID     int64  // id of the job to find
+	MessageID string // message id of the jobs to find, if no job id is given
+}
+
+// ExportArg are the arguments of [Admin.Export]
+type ExportArg struct {
+	Prefix        string // table prefix of the jobsdb
+	State         string // last state of the jobs to export, e.g. failed or not_picked_yet
+	WorkspaceID   string
+	DestinationID string
+	CustomVal     string
+	AfterJobID    int64 // only jobs after this one are exported, for paginating
+	Limit         int
+}
+
+type inspectedDataset struct {
+	Prefix         string `json:"prefix"`
+	Index          string `json:"index"`
+	Level          int    `json:"level"`
+	JobTable       string `json:"jobTable"`
+	JobStatusTable string `json:"jobStatusTable"`
+	MinJobID       int64  `json:"minJobId"`
+	MaxJobID       int64  `json:"maxJobId"`
+}
+
+type inspectedCount struct {
+	State         string `json:"state,omitempty"`
+	WorkspaceID   string `json:"workspaceId,omitempty"`
+	DestinationID string `json:"destinationId,omitempty"`
+	CustomVal     string `json:"customVal,omitempty"`
+	Count         int64  `json:"count"`
+}
+
+type inspectedStatus struct {
+	JobState      string          `json:"jobState"`
+	AttemptNum    int64           `json:"attemptNum"`
+	ExecTime      time.Time       `json:"execTime"`
+	RetryTime     time.Time       `json:"retryTime"`
+	ErrorCode     string          `json:"errorCode"`
+	ErrorResponse json.RawMessage `json:"errorResponse,omitempty"`
+	Parameters    json.RawMessage `json:"parameters,omitempty"`
+}
+
+type inspectedJob struct {
+	Dataset      string            `json:"dataset"`
+	JobID        int64             `json:"jobId"`
+	UUID         string            `json:"uuid"`
+	UserID       string            `json:"userId"`
+	WorkspaceID  string            `json:"workspaceId"`
+	CustomVal    string            `json:"customVal"`
+	EventCount   int               `json:"eventCount"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	ExpireAt     time.Time         `json:"expireAt"`
+	Parameters   json.RawMessage   `json:"parameters"`
+	EventPayload json.RawMessage   `json:"eventPayload"`
+	LastStatus   *inspectedStatus  `json:"lastStatus,omitempty"`
+	Statuses     []inspectedStatus `json:"statuses,omitempty"`
+}
+
+// Datasets returns the datasets of a jobsdb in order, along with the range of their job ids
+func (a *Admin) Datasets(arg DatasetsArg, reply *string) (err error) {
+	defer recoverInspection(&err)
+	var prefixes []string
+	if arg.Prefix != "" {
+		prefixes = []string{arg.Prefix}
+	} else {
+		inspectableHandles.Range(func(key, _ any) bool {
+			prefixes = append(prefixes, key.(string))
+			return true
+		})
+		sort.Strings(prefixes)
+	}
+	datasets := []inspectedDataset{}
+	for _, prefix := range prefixes {
+		err := inspect(prefix, func(ctx context.Context, jd *Handle, tx *Tx, dsList []dataSetT) error {
+			for _, ds := range dsList {
+				idx, err := dsindex.Parse(ds.Index)
+				if err != nil {
+					return err
+				}
+				var minJobID, maxJobID sql.NullInt64
+				if err := tx.QueryRowContext(ctx, fmt.Sprintf(`select min(job_id), max(job_id) from %q`, ds.JobTable)).Scan(&minJobID, &maxJobID); err != nil {
+					return fmt.Errorf("job id range of %s: %w", ds.JobTable, err)
+				}
+				datasets = append(datasets, inspectedDataset{
+					Prefix:         prefix,
+					Index:          ds.Index,
+					Level:          idx.Length(),
+					JobTable:       ds.JobTable,
+					JobStatusTable: ds.JobStatusTable,
+					MinJobID:       minJobID.Int64,
+					MaxJobID:       maxJobID.Int64,
+				})
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return marshalReply(datasets, reply)
+}
+
+// Counts returns the number of jobs of a jobsdb grouped by their last state, workspace, destination or custom value, largest counts first
+func (a *Admin) Counts(arg CountsArg, reply *string) (err error) {
+	defer recoverInspection(&err)
+	groupBy := arg.GroupBy
+	if len(groupBy) == 0 {
+		groupBy = []string{"state"}
+	}
+	for _, key := range groupBy {
+		if !slices.Contains([]string{"state", "workspace", "destination", "customVal"}, key) {
+			return fmt.Errorf("invalid group by %q, valid values are state, workspace, destination and customVal", key)
+		}
+	}
+	grouped := make(map[inspectedCount]int64)
+	err = inspect(arg.Prefix, func(ctx context.Context, jd *Handle, tx *Tx, dsList []dataSetT) error {
+		for _, ds := range dsList {
+			if arg.Dataset != "" && ds.Index != arg.Dataset {
+				continue
+			}
+			rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+				`select coalesce(js.job_state, $1), j.workspace_id, coalesce(j.parameters->>'destination_id', ''), j.custom_val, count(*)
+				from %[1]q j left join "v_last_%[2]s" js on js.job_id = j.job_id
+				group by 1, 2, 3, 4`,
+				ds.JobTable, ds.JobStatusTable,
+			), Unprocessed.State)
+			if err != nil {
+				return fmt.Errorf("counting jobs of %s: %w", ds.JobTable, err)
+			}
+			for rows.Next() {
+				var c inspectedCount
+				var count int64
+				if err := rows.Scan(&c.State, &c.WorkspaceID, &c.DestinationID, &c.CustomVal, &count); err != nil {
+					_ = rows.Close()
+					return fmt.Errorf("scanning counts of %s: %w", ds.JobTable, err)
+				}
+				var key inspectedCount
+				for _, g := range groupBy {
+					switch g {
+					case "state":
+						key.State = c.State
+					case "workspace":
+						key.WorkspaceID = c.WorkspaceID
+					case "destination":
+						key.DestinationID = c.DestinationID
+					case "customVal":
+						key.CustomVal = c.CustomVal
+					}
+				}
+				grouped[key] += count
+			}
+			if err := rows.Err(); err != nil {
+				_ = rows.Close()
+				return fmt.Errorf("rows.Err() on counts of %s: %w", ds.JobTable, err)
+			}
+			_ = rows.Close()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	counts := make([]inspectedCount, 0, len(grouped))
+	for key, count := range grouped {
+		key.Count = count
+		counts = append(counts, key)
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return fmt.Sprint(counts[i]) < fmt.Sprint(counts[j])
+	})
+	return marshalReply(counts, reply)
+}
+
+// FindJob finds a job by its id, or the jobs of a message id, across all datasets of a jobsdb, along with their full status history.
+// Message ids are looked up in the parameters of jobs, and in the batch of payloads, like the gateway's ones. There is no index for
+// either, so all jobs of all datasets get scanned. Payloads of bytea datasets may be compressed or encrypted and are decoded for
+// matching, thus only the latest jobs of the latest bytea datasets are scanned, as configured by JobsDB.inspectMaxScannedDatasets
+// and JobsDB.inspectMaxScannedJobs.
+func (a *Admin) FindJob(arg FindJobArg, reply *string) (err error) {
+	defer recoverInspection(&err)
+	if arg.JobID == 0 && arg.MessageID == "" {
+		return fmt.Errorf("either a job id or a message id is required")
+	}
+	jobs := []inspectedJob{}
+	err = inspect(arg.Prefix, func(ctx context.Context, jd *Handle, tx *Tx, dsList []dataSetT) error {
+		scannedDatasets := jd.config.GetIntVar(3, 1, jd.configKeys("inspectMaxScannedDatasets")...)
+		for i := len(dsList) - 1; i >= 0; i-- { // latest datasets first, for scanning the latest bytea ones
+			ds := dsList[i]
+			condition, args := `j.job_id = $1`, []any{arg.JobID}
+			if arg.JobID == 0 {
+				columnType, err := jd.payloadColumnTypeOf(ctx, tx, ds.JobTable)
+				if err != nil {
+					return err
+				}
+				condition, args = messageIDCondition(columnType), []any{arg.MessageID}
+				if columnType == BYTEA {
+					var jobIDs []int64
+					if scannedDatasets > 0 {
+						scannedDatasets--
+						if jobIDs, err = jd.scanPayloadsForMessageID(ctx, tx, ds, arg.MessageID); err != nil {
+							return err
+						}
+					} else {
+						jd.logger.Warnn("Payloads of dataset not scanned for message id, too many bytea datasets",
+							logger.NewStringField("table", ds.JobTable),
+						)
+					}
+					condition, args = `(`+condition+` or j.job_id = ANY($2))`, append(args, pq.Array(jobIDs))
+				}
+			}
+			found, err := jd.inspectJobsInTx(ctx, tx, ds, condition+` order by j.job_id`, args...)
+			if err != nil {
+				return err
+			}
+			for i := range found {
+				if found[i].Statuses, err = jd.inspectStatusesInTx(ctx, tx, ds, found[i].JobID); err != nil {
+					return err
+				}
+			}
+			jobs = append(found, jobs...)
+			if arg.JobID != 0 && len(jobs) > 0 {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return marshalReply(jobs, reply)
+}
+
+// Export returns the jobs of a jobsdb matching the given filters as NDJSON, along with their last status, in job id order.
+// At most limit jobs are returned, the next ones can be exported by passing the id of the last job returned as AfterJobID.
+func (a *Admin) Export(arg ExportArg, reply *string) (err error) {
+	defer recoverInspection(&err)
+	limit := arg.Limit
+	if limit <= 0 || limit > maxExportLimit {
+		limit = maxExportLimit
+	}
+	filter := jobFilter{WorkspaceID: arg.WorkspaceID, DestinationID: arg.DestinationID, CustomVal: arg.CustomVal}
+	if arg.State != "" {
+		filter.States = []string{arg.State}
+	}
+	condition, args := filter.condition(2)
+	var buf bytes.Buffer
+	err = inspect(arg.Prefix, func(ctx context.Context, jd *Handle, tx *Tx, dsList []dataSetT) error {
+		for _, ds := range dsList {
+			jobs, err := jd.inspectJobsInTx(ctx, tx, ds,
+				`j.job_id > $1 and `+condition+fmt.Sprintf(` order by j.job_id limit $%d`, len(args)+2),
+				append(append([]any{arg.AfterJobID}, args...), limit)...,
+			)
+			if err != nil {
+				return err
+			}
+			for _, job := range jobs {
+				line, err := jsonrs.Marshal(job)
+				if err != nil {
+					return err
+				}
+				buf.Write(line)
+				buf.WriteByte('\n')
+			}
+			if limit -= len(jobs); limit == 0 {
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*reply = buf.String()
+	return nil
+}
+
+// inspect runs f against all datasets of the jobsdb with the given prefix, in a read-only transaction
+func inspect(prefix string, f func(ctx context.Context, jd *Handle, tx *Tx, dsList []dataSetT) error) error {
+	h, ok := inspectableHandles.Load(prefix)
+	if !ok {
+		return fmt.Errorf("no jobsdb with prefix %q", prefix)
+	}
+	jd := h.(*Handle)
+	ctx, cancel := context.WithTimeout(context.Background(), jd.config.GetDurationVar(60, time.Second, jd.configKeys("inspectTimeout")...))
+	defer cancel()
+	sqlTx, err := jd.dbHandle.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer func() { _ = sqlTx.Rollback() }()
+	tx := &Tx{Tx: sqlTx}
+	dsList, err := getDSList(jd, tx, prefix)
+	if err != nil {
+		return err
+	}
+	return f(ctx, jd, tx, dsList)
+}
+
+// jobFilter matches jobs by their last state and attributes, for inspecting or updating them in bulk
+type jobFilter struct {
+	States        []string // last states, unprocessed jobs being in the not_picked_yet state
+	WorkspaceID   string
+	DestinationID string
+	CustomVal     string
+	ErrorCode     string // error code of the last status
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// condition returns the condition matching the filter's jobs, along with its query arguments numbered from firstArg.
+// Jobs are expected to be aliased as j and their last status as js.
+func (f jobFilter) condition(firstArg int) (string, []any) {
+	conditions := []string{"true"}
+	var args []any
+	add := func(condition string, arg any) {
+		conditions = append(conditions, fmt.Sprintf(condition, firstArg+len(args)))
+		args = append(args, arg)
+	}
+	if len(f.States) > 0 {
+		add(`coalesce(js.job_state, '`+Unprocessed.State+`') = ANY($%d)`, pq.Array(f.States))
+	}
+	if f.WorkspaceID != "" {
+		add(`j.workspace_id = $%d`, f.WorkspaceID)
+	}
+	if f.DestinationID != "" {
+		add(`j.parameters->>'destination_id' = $%d`, f.DestinationID)
+	}
+	if f.CustomVal != "" {
+		add(`j.custom_val = $%d`, f.CustomVal)
+	}
+	if f.ErrorCode != "" {
+		add(`js.error_code = $%d`, f.ErrorCode)
+	}
+	if !f.CreatedAfter.IsZero() {
+		add(`j.created_at >= $%d`, f.CreatedAfter)
+	}
+	if !f.CreatedBefore.IsZero() {
+		add(`j.created_at < $%d`, f.CreatedBefore)
+	}
+	return strings.Join(conditions, " and "), args
+}
+
+// messageIDCondition returns the condition matching jobs of a message id, given as the first query argument.
+// Payloads of bytea datasets may be compressed or encrypted, so only the parameters of their jobs are looked up, their payloads
+// being scanned by [Handle.scanPayloadsForMessageID] instead.
+func messageIDCondition(columnType payloadColumnType) string {
+	condition := `j.parameters->>'message_id' = $1`
+	switch columnType {
+	case JSONB:
+		condition += ` or j.event_payload @> jsonb_build_object('batch', jsonb_build_array(jsonb_build_object('messageId', $1::text)))`
+	case TEXT:
+		condition += ` or strpos(j.event_payload, '"messageId":"' || $1 || '"') > 0`
+	}
+	return `(` + condition + `)`
+}
+
+// scanPayloadsForMessageID returns the ids of the latest jobs of a bytea dataset having the message id in the batch of their payload,
+// decoding payloads for matching. At most JobsDB.inspectMaxScannedJobs jobs are scanned, latest first.
+func (jd *Handle) scanPayloadsForMessageID(ctx context.Context, tx *Tx, ds dataSetT, messageID string) ([]int64, error) {
+	maxScannedJobs := jd.config.GetIntVar(100000, 1, jd.configKeys("inspectMaxScannedJobs")...)
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`select job_id, event_payload from %q order by job_id desc limit $1`, ds.JobTable), maxScannedJobs+1)
+	if err != nil {
+		return nil, fmt.Errorf("scanning payloads of %s: %w", ds.JobTable, err)
+	}
+	defer func() { _ = rows.Close() }()
+	var (
+		jobIDs  []int64
+		scanned int
+	)
+	for rows.Next() {
+		if scanned++; scanned > maxScannedJobs {
+			jd.logger.Warnn("Payloads of dataset partially scanned for message id, too many jobs",
+				logger.NewStringField("table", ds.JobTable),
+				logger.NewIntField("scannedJobs", int64(maxScannedJobs)),
+			)
+			break
+		}
+		var jobID int64
+		var payload []byte
+		if err := rows.Scan(&jobID, &payload); err != nil {
+			return nil, fmt.Errorf("scanning payloads of %s: %w", ds.JobTable, err)
+		}
+		if payload, err = jd.decodePayload(ctx, payload); err != nil {
+			return nil, fmt.Errorf("decoding payload of job %d: %w", jobID, err)
+		}
+		for _, id := range gjson.GetBytes(payload, "batch.#.messageId").Array() {
+			if id.String() == messageID {
+				jobIDs = append(jobIDs, jobID)
+				break
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() on payloads of %s: %w", ds.JobTable, err)
+	}
+	return jobIDs, nil
+}
+
+// inspectJobsInTx returns the jobs of a dataset matching the condition, along with their last status
+func (jd *Handle) inspectJobsInTx(ctx context.Context, tx *Tx, ds dataSetT, condition string, args ...any) ([]inspectedJob, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+		`select j.job_id, j.uuid, j.user_id, j.workspace_id, j.custom_val, j.event_count, j.created_at, j.expire_at, j.parameters, j.event_payload,
+		js.job_state, js.attempt, js.exec_time, js.retry_time, js.error_code, js.error_response, js.parameters
+		from %[1]q j left join "v_last_%[2]s" js on js.job_id = j.job_id
+		where `+condition,
+		ds.JobTable, ds.JobStatusTable,
+	), args...)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting jobs of %s: %w", ds.JobTable, err)
+	}
+	defer func() { _ = rows.Close() }()
+	var jobs []inspectedJob
+	for rows.Next() {
+		job := inspectedJob{Dataset: ds.Index}
+		var payload []byte
+		var status nullableStatus
+		if err := rows.Scan(&job.JobID, &job.UUID, &job.UserID, &job.WorkspaceID, &job.CustomVal, &job.EventCount, &job.CreatedAt, &job.ExpireAt, &job.Parameters, &payload,
+			&status.jobState, &status.attempt, &status.execTime, &status.retryTime, &status.errorCode, &status.errorResponse, &status.parameters); err != nil {
+			return nil, fmt.Errorf("scanning jobs of %s: %w", ds.JobTable, err)
+		}
+		if payload, err = jd.decodePayload(ctx, payload); err != nil {
+			return nil, fmt.Errorf("decoding payload of job %d: %w", job.JobID, err)
+		}
+		job.EventPayload = rawJSON(payload)
+		if status.jobState.Valid {
+			job.LastStatus = status.inspected()
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() on jobs of %s: %w", ds.JobTable, err)
+	}
+	return jobs, nil
+}
+
+// inspectStatusesInTx returns all statuses of a job, oldest first
+func (jd *Handle) inspectStatusesInTx(ctx context.Context, tx *Tx, ds dataSetT, jobID int64) ([]inspectedStatus, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+		`select job_state, attempt, exec_time, retry_time, error_code, error_response, parameters from %q where job_id = $1 order by id`,
+		ds.JobStatusTable,
+	), jobID)
+	if err != nil {
+		return nil, fmt.Errorf("inspecting statuses of job %d: %w", jobID, err)
+	}
+	defer func() { _ = rows.Close() }()
+	var statuses []inspectedStatus
+	for rows.Next() {
+		var status nullableStatus
+		if err := rows.Scan(&status.jobState, &status.attempt, &status.execTime, &status.retryTime, &status.errorCode, &status.errorResponse, &status.parameters); err != nil {
+			return nil, fmt.Errorf("scanning statuses of job %d: %w", jobID, err)
+		}
+		statuses = append(statuses, *status.inspected())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() on statuses of job %d: %w", jobID, err)
+	}
+	return statuses, nil
+}
+
+type nullableStatus struct {
+	jobState      sql.NullString
+	attempt       sql.NullInt64
+	execTime      sql.NullTime
+	retryTime     sql.NullTime
+	errorCode     sql.NullString
+	errorResponse []byte
+	parameters    []byte
+}
+
+func (s nullableStatus) inspected() *inspectedStatus {
+	return &inspectedStatus{
+		JobState:      s.jobState.String,
+		AttemptNum:    s.attempt.Int64,
+		ExecTime:      s.execTime.Time,
+		RetryTime:     s.retryTime.Time,
+		ErrorCode:     s.errorCode.String,
+		ErrorResponse: rawJSON(s.errorResponse),
+		Parameters:    rawJSON(s.parameters),
+	}
+}
+
+// rawJSON returns the value as is if it is valid json, as a json string otherwise
+func rawJSON(value []byte) json.RawMessage {
+	if len(value) == 0 {
+		return nil
+	}
+	if json.Valid(value) {
+		return value
+	}
+	quoted, _ := jsonrs.Marshal(string(value))
+	return quoted
+}
+
+func marshalReply(v any, reply *string) error {
+	formattedOutput, err := jsonrs.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	*reply = string(formattedOutput)
+	return nil
+}
+
+// recoverInspection turns panics of an inspection, e.g. failed assertions, into errors
+func recoverInspection(err *error) {
+	if r := recover(); r != nil {
+		*err = fmt.Errorf("internal Rudder server error: %v", r)
+	}
+}
@@ -0,0 +1,143 @@
+package jobsdb
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+func TestAdmin(t *testing.T) {
+	config.Reset()
+	c := config.New()
+	_ = startPostgres(t)
+	ctx := context.Background()
+
+	jd := Handle{config: c}
+	require.NoError(t, jd.Setup(ReadWrite, true, "inspect"))
+	defer jd.TearDown()
+
+	jobs := genJobs("ws-1", "WEBHOOK", 3, 1)
+	jobs[2].WorkspaceId = "ws-2"
+	jobs[2].Parameters = []byte(`{"destination_id":"dest-1","message_id":"message-1"}`)
+	require.NoError(t, jd.Store(ctx, jobs))
+	for range 2 {
+		require.NoError(t, jd.UpdateJobStatus(ctx, genJobStatuses(jobs[:1], Failed.State), []string{"WEBHOOK"}, nil))
+	}
+	require.NoError(t, jd.UpdateJobStatus(ctx, genJobStatuses(jobs[1:2], Succeeded.State), []string{"WEBHOOK"}, nil))
+
+	a := NewAdmin()
+	var reply string
+
+	t.Run("datasets", func(t *testing.T) {
+		require.NoError(t, a.Datasets(DatasetsArg{Prefix: "inspect"}, &reply))
+		var datasets []inspectedDataset
+		require.NoError(t, jsonrs.Unmarshal([]byte(reply), &datasets))
+		require.Equal(t, []inspectedDataset{{
+			Prefix: "inspect", Index: "1", Level: 1, JobTable: "inspect_jobs_1", JobStatusTable: "inspect_job_status_1", MinJobID: 1, MaxJobID: 3,
+		}}, datasets)
+
+		require.Error(t, a.Datasets(DatasetsArg{Prefix: "unknown"}, &reply))
+	})
+
+	t.Run("counts", func(t *testing.T) {
+		require.NoError(t, a.Counts(CountsArg{Prefix: "inspect"}, &reply))
+		var counts []inspectedCount
+		require.NoError(t, jsonrs.Unmarshal([]byte(reply), &counts))
+		require.ElementsMatch(t, []inspectedCount{
+			{State: Failed.State, Count: 1},
+			{State: Succeeded.State, Count: 1},
+			{State: Unprocessed.State, Count: 1},
+		}, counts)
+
+		require.NoError(t, a.Counts(CountsArg{Prefix: "inspect", GroupBy: []string{"workspace", "destination"}}, &reply))
+		require.NoError(t, jsonrs.Unmarshal([]byte(reply), &counts))
+		require.Equal(t, []inspectedCount{
+			{WorkspaceID: "ws-1", Count: 2},
+			{WorkspaceID: "ws-2", DestinationID: "dest-1", Count: 1},
+		}, counts)
+
+		require.Error(t, a.Counts(CountsArg{Prefix: "inspect", GroupBy: []string{"user"}}, &reply))
+	})
+
+	t.Run("find job", func(t *testing.T) {
+		require.NoError(t, a.FindJob(FindJobArg{Prefix: "inspect", JobID: 1}, &reply))
+		var found []inspectedJob
+		require.NoError(t, jsonrs.Unmarshal([]byte(reply), &found))
+		require.Len(t, found, 1)
+		require.Equal(t, jobs[0].UUID.String(), found[0].UUID)
+		require.JSONEq(t, string(jobs[0].EventPayload), string(found[0].EventPayload))
+		require.Len(t, found[0].Statuses, 2, "the full status history should be returned")
+		require.Equal(t, Failed.State, found[0].LastStatus.JobState)
+
+		// message ids are looked up in parameters and payloads
+		require.NoError(t, a.FindJob(FindJobArg{Prefix: "inspect", MessageID: "message-1"}, &reply))
+		require.NoError(t, jsonrs.Unmarshal([]byte(reply), &found))
+		require.Len(t, found, 1)
+		require.EqualValues(t, 3, found[0].JobID)
+		require.Nil(t, found[0].LastStatus)
+		require.NoError(t, a.FindJob(FindJobArg{Prefix: "inspect", MessageID: "b96f3d8a-7c26-4329-9671-4e3202f42f15"}, &reply))
+		require.NoError(t, jsonrs.Unmarshal([]byte(reply), &found))
+		require.Len(t, found, 3)
+
+		require.Error(t, a.FindJob(FindJobArg{Prefix: "inspect"}, &reply))
+	})
+
+	t.Run("find job of compressed payloads", func(t *testing.T) {
+		c := config.New()
+		c.Set("JobsDB.payloadCompression.codec", "zstd")
+		c.Set("JobsDB.payloadCompression.minSize", 1)
+		c.Set("JobsDB.inspectMaxScannedJobs", 2)
+		compressedJD := Handle{config: c}
+		compressedJD.conf.payloadColumnType = BYTEA
+		require.NoError(t, compressedJD.Setup(ReadWrite, true, "inspect_compressed"))
+		defer compressedJD.TearDown()
+		require.NoError(t, compressedJD.Store(ctx, genJobs("ws-1", "WEBHOOK", 3, 1)))
+
+		// payloads are decoded for matching, only the latest jobs being scanned
+		require.NoError(t, a.FindJob(FindJobArg{Prefix: "inspect_compressed", MessageID: "b96f3d8a-7c26-4329-9671-4e3202f42f15"}, &reply))
+		var found []inspectedJob
+		require.NoError(t, jsonrs.Unmarshal([]byte(reply), &found))
+		require.Len(t, found, 2)
+		require.EqualValues(t, 2, found[0].JobID)
+		require.EqualValues(t, 3, found[1].JobID)
+		require.JSONEq(t, string(jobs[0].EventPayload), string(found[0].EventPayload))
+
+		require.NoError(t, a.FindJob(FindJobArg{Prefix: "inspect_compressed", MessageID: "unknown"}, &reply))
+		require.JSONEq(t, `[]`, reply)
+	})
+
+	t.Run("export", func(t *testing.T) {
+		exported := func(arg ExportArg) []inspectedJob {
+			arg.Prefix = "inspect"
+			require.NoError(t, a.Export(arg, &reply))
+			var exported []inspectedJob
+			for _, line := range strings.Split(strings.TrimSuffix(reply, "\n"), "\n") {
+				if line == "" {
+					continue
+				}
+				var job inspectedJob
+				require.NoError(t, jsonrs.Unmarshal([]byte(line), &job))
+				exported = append(exported, job)
+			}
+			return exported
+		}
+		require.Len(t, exported(ExportArg{}), 3)
+		failed := exported(ExportArg{State: Failed.State})
+		require.Len(t, failed, 1)
+		require.EqualValues(t, 1, failed[0].JobID)
+		require.Len(t, exported(ExportArg{State: Unprocessed.State, DestinationID: "dest-1"}), 1)
+		require.Empty(t, exported(ExportArg{WorkspaceID: "ws-3"}))
+
+		// exports are paginated
+		page := exported(ExportArg{Limit: 2})
+		require.Len(t, page, 2)
+		page = exported(ExportArg{Limit: 2, AfterJobID: page[1].JobID})
+		require.Len(t, page, 1)
+		require.EqualValues(t, 3, page[0].JobID)
+	})
+}
@@ -1454,6 +1454,44 @@ func BenchmarkJobsdb(b *testing.B) {
 	}
 }
 
+// BenchmarkMatchAllParameterFilters benchmarks looking up jobs of empty partitions needing all of their parameter filters
+// to match, which is what processor partitions do in destination and connection isolation modes. Filters on all of the
+// cache parameters can be served by the no results cache, the rest hit the database every time.
+func BenchmarkMatchAllParameterFilters(b *testing.B) {
+	_ = startPostgres(b)
+	jobsDB := &Handle{config: config.New()}
+	require.NoError(b, jobsDB.Setup(ReadWrite, true, "gw"))
+	defer jobsDB.TearDown()
+
+	ctx := context.Background()
+	jobs := make([]*JobT, 1000)
+	for i := range jobs {
+		jobs[i] = &JobT{
+			WorkspaceId:  "workspace",
+			Parameters:   []byte(fmt.Sprintf(`{"source_id":"source-%d","destination_id":"destination-%d"}`, i%10, i%5)),
+			EventPayload: []byte(`{"testKey":"testValue"}`),
+			UserID:       "user",
+			UUID:         uuid.New(),
+			CustomVal:    "GW",
+			EventCount:   1,
+		}
+	}
+	require.NoError(b, jobsDB.Store(ctx, jobs))
+
+	for name, filters := range map[string][]ParameterFilterT{
+		"cached":   {{Name: "source_id", Value: "source-0"}, {Name: "destination_id", Value: "other"}},
+		"uncached": {{Name: "source_id", Value: "other"}},
+	} {
+		b.Run(name, func(b *testing.B) {
+			for range b.N {
+				res, err := jobsDB.GetUnprocessed(ctx, GetQueryParams{ParameterFilters: filters, MatchAllParameterFilters: true, JobsLimit: 100})
+				require.NoError(b, err)
+				require.Empty(b, res.Jobs)
+			}
+		})
+	}
+}
+
 func benchmarkJobsdbConcurrently(b *testing.B, jobsDB *Handle, totalJobs, pageSize, concurrency int) {
 	b.StopTimer()
 	var start, end sync.WaitGroup
@@ -0,0 +1,147 @@
+// Package payloadcodec compresses job payloads.
+//
+// Compressed payloads start with a marker made of a NUL byte followed by the codec's id, which can never be the case
+// for json payloads. Thus payloads compressed with any codec can be mixed with uncompressed ones and still be decoded.
+package payloadcodec
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// Codec is a payload compression codec
+type Codec byte
+
+const (
+	// None leaves payloads uncompressed
+	None Codec = iota
+	// Zstd compresses payloads using zstd
+	Zstd
+	// LZ4 compresses payloads using lz4 blocks
+	LZ4
+	// Snappy compresses payloads using snappy blocks
+	Snappy
+)
+
+const markerByte = 0x00
+
+// maxDecodedSize guards against corrupted lz4 payloads claiming huge sizes
+const maxDecodedSize = 1 << 30
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedDefault), zstd.WithEncoderConcurrency(1))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecodedSize))
+)
+
+// Parse returns the codec with the given name, i.e. none, zstd, lz4 or snappy
+func Parse(name string) (Codec, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "none":
+		return None, nil
+	case "zstd":
+		return Zstd, nil
+	case "lz4":
+		return LZ4, nil
+	case "snappy":
+		return Snappy, nil
+	default:
+		return None, fmt.Errorf("unknown payload codec %q", name)
+	}
+}
+
+func (c Codec) String() string {
+	switch c {
+	case None:
+		return "none"
+	case Zstd:
+		return "zstd"
+	case LZ4:
+		return "lz4"
+	case Snappy:
+		return "snappy"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(c))
+	}
+}
+
+// Encode compresses the payload, prefixing it with the codec's marker.
+// The payload is returned as is if the codec is [None] or if compressing it doesn't make it any smaller.
+func (c Codec) Encode(payload []byte) ([]byte, error) {
+	if c == None || len(payload) == 0 {
+		return payload, nil
+	}
+	encoded := []byte{markerByte, byte(c)}
+	switch c {
+	case Zstd:
+		encoded = zstdEncoder.EncodeAll(payload, encoded)
+	case LZ4:
+		encoded = binary.AppendUvarint(encoded, uint64(len(payload)))
+		header := len(encoded)
+		encoded = append(encoded, make([]byte, lz4.CompressBlockBound(len(payload)))...)
+		n, err := lz4.CompressBlock(payload, encoded[header:], nil)
+		if err != nil {
+			return nil, fmt.Errorf("lz4: %w", err)
+		}
+		if n == 0 { // incompressible
+			return payload, nil
+		}
+		encoded = encoded[:header+n]
+	case Snappy:
+		encoded = append(encoded, snappy.Encode(nil, payload)...)
+	default:
+		return nil, fmt.Errorf("unknown payload codec %d", byte(c))
+	}
+	if len(encoded) >= len(payload) {
+		return payload, nil
+	}
+	return encoded, nil
+}
+
+// CodecOf returns the codec the payload was encoded with, [None] if it isn't compressed
+func CodecOf(payload []byte) Codec {
+	if len(payload) < 2 || payload[0] != markerByte {
+		return None
+	}
+	return Codec(payload[1])
+}
+
+// Decode decompresses a payload encoded by any codec, returning uncompressed payloads as is
+func Decode(payload []byte) ([]byte, error) {
+	c := CodecOf(payload)
+	if c == None {
+		return payload, nil
+	}
+	data := payload[2:]
+	switch c {
+	case Zstd:
+		decoded, err := zstdDecoder.DecodeAll(data, nil)
+		if err != nil {
+			return nil, fmt.Errorf("zstd: %w", err)
+		}
+		return decoded, nil
+	case LZ4:
+		size, n := binary.Uvarint(data)
+		if n <= 0 || size > maxDecodedSize {
+			return nil, errors.New("lz4: invalid decoded size")
+		}
+		decoded := make([]byte, size)
+		if _, err := lz4.UncompressBlock(data[n:], decoded); err != nil {
+			return nil, fmt.Errorf("lz4: %w", err)
+		}
+		return decoded, nil
+	case Snappy:
+		decoded, err := snappy.Decode(nil, data)
+		if err != nil {
+			return nil, fmt.Errorf("snappy: %w", err)
+		}
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("unknown payload codec %d", byte(c))
+	}
+}
@@ -0,0 +1,68 @@
+package payloadcodec_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/jobsdb/internal/payloadcodec"
+)
+
+func TestCodecs(t *testing.T) {
+	payload := []byte(`{"batch":[` + string(bytes.Repeat([]byte(`{"type":"track","event":"Product Viewed","properties":{"price":10}},`), 50)) + `{}]}`)
+
+	for _, name := range []string{"zstd", "lz4", "snappy"} {
+		t.Run(name, func(t *testing.T) {
+			c, err := payloadcodec.Parse(name)
+			require.NoError(t, err)
+			require.Equal(t, name, c.String())
+
+			encoded, err := c.Encode(payload)
+			require.NoError(t, err)
+			require.Less(t, len(encoded), len(payload))
+			require.Equal(t, c, payloadcodec.CodecOf(encoded))
+
+			decoded, err := payloadcodec.Decode(encoded)
+			require.NoError(t, err)
+			require.Equal(t, payload, decoded)
+
+			// payloads which can't be compressed are kept as is
+			small := []byte(`{}`)
+			encoded, err = c.Encode(small)
+			require.NoError(t, err)
+			require.Equal(t, small, encoded)
+
+			// corrupted payloads can't be decoded
+			encoded, err = c.Encode(payload)
+			require.NoError(t, err)
+			_, err = payloadcodec.Decode(encoded[:len(encoded)/2])
+			require.Error(t, err)
+		})
+	}
+
+	t.Run("none", func(t *testing.T) {
+		c, err := payloadcodec.Parse("")
+		require.NoError(t, err)
+		require.Equal(t, payloadcodec.None, c)
+		encoded, err := c.Encode(payload)
+		require.NoError(t, err)
+		require.Equal(t, payload, encoded)
+	})
+
+	t.Run("uncompressed payloads are decoded as is", func(t *testing.T) {
+		for _, p := range [][]byte{nil, []byte(`{}`), []byte(` [1]`), payload} {
+			require.Equal(t, payloadcodec.None, payloadcodec.CodecOf(p))
+			decoded, err := payloadcodec.Decode(p)
+			require.NoError(t, err)
+			require.Equal(t, p, decoded)
+		}
+	})
+
+	t.Run("unknown codecs", func(t *testing.T) {
+		_, err := payloadcodec.Parse("gzip")
+		require.Error(t, err)
+		_, err = payloadcodec.Decode([]byte{0x00, 0x7f, 0x01})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,101 @@
+package payloadcrypto
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+// keyFile is the format of a local key file, e.g.
+//
+//	{"current": "k2", "keys": {"k1": "<base64 encoded 256-bit key>", "k2": "<base64 encoded 256-bit key>"}}
+//
+// Keys are rotated by adding a new key and making it the current one, while keeping previous keys for decrypting
+// payloads which haven't been re-encrypted yet.
+type keyFile struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// LocalKeyProvider is a [KeyProvider] keeping its key encryption keys in memory, loaded from a local key file
+type LocalKeyProvider struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewFileKeyProvider returns a [LocalKeyProvider] with the keys of the key file at path
+func NewFileKeyProvider(path string) (*LocalKeyProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+	var f keyFile
+	if err := jsonrs.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parsing key file: %w", err)
+	}
+	keys := make(map[string][]byte, len(f.Keys))
+	for id, encoded := range f.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decoding key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return NewLocalKeyProvider(f.Current, keys)
+}
+
+// NewLocalKeyProvider returns a [LocalKeyProvider] with the given 256-bit keys, wrapping data keys with the current one
+func NewLocalKeyProvider(current string, keys map[string][]byte) (*LocalKeyProvider, error) {
+	p := &LocalKeyProvider{current: current, keys: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("key %q must be 32 bytes long, got %d", id, len(key))
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		if p.keys[id], err = cipher.NewGCM(block); err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+	}
+	if _, ok := p.keys[current]; !ok {
+		return nil, fmt.Errorf("current key %q not found", current)
+	}
+	return p, nil
+}
+
+func (p *LocalKeyProvider) CurrentKeyID() string {
+	return p.current
+}
+
+func (p *LocalKeyProvider) GenerateDataKey(_ context.Context) (plaintext, wrapped []byte, keyID string, err error) {
+	plaintext = make([]byte, 32)
+	if _, err := rand.Read(plaintext); err != nil {
+		return nil, nil, "", err
+	}
+	kek := p.keys[p.current]
+	wrapped = make([]byte, kek.NonceSize(), kek.NonceSize()+len(plaintext)+kek.Overhead())
+	if _, err := rand.Read(wrapped); err != nil {
+		return nil, nil, "", err
+	}
+	wrapped = kek.Seal(wrapped, wrapped, plaintext, []byte(p.current))
+	return plaintext, wrapped, p.current, nil
+}
+
+func (p *LocalKeyProvider) DecryptDataKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	kek, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", keyID)
+	}
+	if len(wrapped) < kek.NonceSize() {
+		return nil, fmt.Errorf("wrapped data key is truncated")
+	}
+	return kek.Open(nil, wrapped[:kek.NonceSize()], wrapped[kek.NonceSize():], []byte(keyID))
+}
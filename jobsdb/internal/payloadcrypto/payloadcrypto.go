@@ -0,0 +1,224 @@
+// Package payloadcrypto encrypts job payloads at rest using envelope encryption.
+//
+// Payloads are encrypted with AES-GCM using data keys, which are generated and wrapped by a [KeyProvider] using its
+// current key encryption key. Every encrypted payload carries the id of the key encryption key along with its wrapped
+// data key, so that it can be decrypted as long as the provider still knows that key, even after the current key has
+// been rotated. Data keys are reused for a while, so that the provider isn't called for every payload.
+//
+// Encrypted payloads start with a marker made of a NUL byte followed by 0x80, which can never be the case for json payloads,
+// nor for payloads compressed by payloadcodec.
+package payloadcrypto
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// KeyProvider is a KMS-style provider of key encryption keys, generating data keys and unwrapping them
+type KeyProvider interface {
+	// CurrentKeyID returns the id of the key new data keys are wrapped with
+	CurrentKeyID() string
+	// GenerateDataKey returns a new 256-bit data key, both in plaintext and wrapped with the current key, along with the latter's id
+	GenerateDataKey(ctx context.Context) (plaintext, wrapped []byte, keyID string, err error)
+	// DecryptDataKey unwraps a data key wrapped with the key with the given id
+	DecryptDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+var marker = []byte{0x00, 0x80}
+
+// maxCachedDataKeys bounds the unwrapped data keys kept in memory
+const maxCachedDataKeys = 1000
+
+// IsEncrypted returns whether the payload is encrypted
+func IsEncrypted(payload []byte) bool {
+	return len(payload) >= len(marker) && payload[0] == marker[0] && payload[1] == marker[1]
+}
+
+// KeyID returns the id of the key encryption key the payload's data key is wrapped with
+func KeyID(payload []byte) (string, error) {
+	h, err := parseHeader(payload)
+	if err != nil {
+		return "", err
+	}
+	return h.keyID, nil
+}
+
+// KeyPrefix returns the prefix of payloads whose data key is wrapped with the key with the given id, which allows
+// finding payloads encrypted with other keys without parsing them
+func KeyPrefix(keyID string) []byte {
+	prefix := append([]byte{}, marker...)
+	prefix = binary.AppendUvarint(prefix, uint64(len(keyID)))
+	return append(prefix, keyID...)
+}
+
+// Encryptor encrypts and decrypts payloads
+type Encryptor struct {
+	provider   KeyProvider
+	dataKeyTTL time.Duration
+	now        func() time.Time
+
+	mu       sync.Mutex
+	current  *dataKey
+	dataKeys map[string]cipher.AEAD // wrapped data key => cipher
+}
+
+type dataKey struct {
+	keyID     string
+	wrapped   []byte
+	aead      cipher.AEAD
+	expiresAt time.Time
+}
+
+// New returns an encryptor using the provider's keys, renewing data keys every dataKeyTTL
+func New(provider KeyProvider, dataKeyTTL time.Duration) *Encryptor {
+	return &Encryptor{
+		provider:   provider,
+		dataKeyTTL: dataKeyTTL,
+		now:        time.Now,
+		dataKeys:   make(map[string]cipher.AEAD),
+	}
+}
+
+// CurrentKeyID returns the id of the key encryption key new payloads are encrypted with
+func (e *Encryptor) CurrentKeyID() string {
+	return e.provider.CurrentKeyID()
+}
+
+// Encrypt encrypts the payload using the current data key
+func (e *Encryptor) Encrypt(ctx context.Context, payload []byte) ([]byte, error) {
+	key, err := e.currentDataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	encrypted := make([]byte, 0, len(marker)+2*binary.MaxVarintLen16+len(key.keyID)+len(key.wrapped)+key.aead.NonceSize()+len(payload)+key.aead.Overhead())
+	encrypted = append(encrypted, marker...)
+	encrypted = binary.AppendUvarint(encrypted, uint64(len(key.keyID)))
+	encrypted = append(encrypted, key.keyID...)
+	encrypted = binary.AppendUvarint(encrypted, uint64(len(key.wrapped)))
+	encrypted = append(encrypted, key.wrapped...)
+	nonceStart := len(encrypted)
+	encrypted = encrypted[:nonceStart+key.aead.NonceSize()]
+	if _, err := rand.Read(encrypted[nonceStart:]); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	// the header is authenticated along with the payload, so that it can't be tampered with
+	return key.aead.Seal(encrypted, encrypted[nonceStart:], payload, encrypted[:nonceStart]), nil
+}
+
+// Decrypt decrypts an encrypted payload, returning payloads which aren't encrypted as is
+func (e *Encryptor) Decrypt(ctx context.Context, payload []byte) ([]byte, error) {
+	if !IsEncrypted(payload) {
+		return payload, nil
+	}
+	h, err := parseHeader(payload)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := e.dataKey(ctx, h.keyID, h.wrapped)
+	if err != nil {
+		return nil, err
+	}
+	data := payload[h.size:]
+	if len(data) < aead.NonceSize() {
+		return nil, errors.New("decrypting payload: truncated")
+	}
+	decrypted, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], payload[:h.size])
+	if err != nil {
+		return nil, fmt.Errorf("decrypting payload: %w", err)
+	}
+	return decrypted, nil
+}
+
+func (e *Encryptor) currentDataKey(ctx context.Context) (*dataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.current != nil && e.now().Before(e.current.expiresAt) && e.current.keyID == e.provider.CurrentKeyID() {
+		return e.current, nil
+	}
+	plaintext, wrapped, keyID, err := e.provider.GenerateDataKey(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("generating data key: %w", err)
+	}
+	aead, err := newAEAD(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	e.current = &dataKey{keyID: keyID, wrapped: wrapped, aead: aead, expiresAt: e.now().Add(e.dataKeyTTL)}
+	e.cacheDataKey(wrapped, aead)
+	return e.current, nil
+}
+
+func (e *Encryptor) dataKey(ctx context.Context, keyID string, wrapped []byte) (cipher.AEAD, error) {
+	e.mu.Lock()
+	aead, ok := e.dataKeys[string(wrapped)]
+	e.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+	plaintext, err := e.provider.DecryptDataKey(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting data key with key %q: %w", keyID, err)
+	}
+	if aead, err = newAEAD(plaintext); err != nil {
+		return nil, err
+	}
+	e.mu.Lock()
+	e.cacheDataKey(wrapped, aead)
+	e.mu.Unlock()
+	return aead, nil
+}
+
+// cacheDataKey caches an unwrapped data key, must be called with the lock held
+func (e *Encryptor) cacheDataKey(wrapped []byte, aead cipher.AEAD) {
+	if len(e.dataKeys) >= maxCachedDataKeys {
+		clear(e.dataKeys)
+	}
+	e.dataKeys[string(wrapped)] = aead
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+type header struct {
+	keyID   string
+	wrapped []byte
+	size    int
+}
+
+func parseHeader(payload []byte) (header, error) {
+	if !IsEncrypted(payload) {
+		return header{}, errors.New("payload isn't encrypted")
+	}
+	pos := len(marker)
+	field := func() ([]byte, error) {
+		l, n := binary.Uvarint(payload[pos:])
+		if n <= 0 || l > uint64(len(payload)-pos-n) {
+			return nil, errors.New("invalid encrypted payload header")
+		}
+		pos += n
+		v := payload[pos : pos+int(l)]
+		pos += int(l)
+		return v, nil
+	}
+	keyID, err := field()
+	if err != nil {
+		return header{}, err
+	}
+	wrapped, err := field()
+	if err != nil {
+		return header{}, err
+	}
+	return header{keyID: string(keyID), wrapped: wrapped, size: pos}, nil
+}
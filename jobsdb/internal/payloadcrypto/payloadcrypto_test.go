@@ -0,0 +1,165 @@
+package payloadcrypto_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/jobsdb/internal/payloadcrypto"
+)
+
+func newKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+// countingProvider counts the data keys generated and unwrapped by a provider
+type countingProvider struct {
+	payloadcrypto.KeyProvider
+	generated, decrypted int
+}
+
+func (p *countingProvider) GenerateDataKey(ctx context.Context) ([]byte, []byte, string, error) {
+	p.generated++
+	return p.KeyProvider.GenerateDataKey(ctx)
+}
+
+func (p *countingProvider) DecryptDataKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	p.decrypted++
+	return p.KeyProvider.DecryptDataKey(ctx, keyID, wrapped)
+}
+
+func TestEncryptor(t *testing.T) {
+	ctx := context.Background()
+	payload := []byte(`{"batch":[{"type":"track","event":"Order Completed","userId":"user@example.com"}]}`)
+	k1, k2 := newKey(t), newKey(t)
+
+	local, err := payloadcrypto.NewLocalKeyProvider("k1", map[string][]byte{"k1": k1})
+	require.NoError(t, err)
+	provider := &countingProvider{KeyProvider: local}
+	e := payloadcrypto.New(provider, time.Hour)
+
+	t.Run("encrypt and decrypt", func(t *testing.T) {
+		encrypted, err := e.Encrypt(ctx, payload)
+		require.NoError(t, err)
+		require.True(t, payloadcrypto.IsEncrypted(encrypted))
+		require.NotContains(t, string(encrypted), "user@example.com")
+		keyID, err := payloadcrypto.KeyID(encrypted)
+		require.NoError(t, err)
+		require.Equal(t, "k1", keyID)
+
+		decrypted, err := e.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		require.Equal(t, payload, decrypted)
+
+		// data keys are reused
+		_, err = e.Encrypt(ctx, payload)
+		require.NoError(t, err)
+		require.Equal(t, 1, provider.generated)
+		require.Zero(t, provider.decrypted)
+
+		// a fresh encryptor unwraps the data key once
+		fresh := payloadcrypto.New(provider, time.Hour)
+		for range 2 {
+			decrypted, err = fresh.Decrypt(ctx, encrypted)
+			require.NoError(t, err)
+			require.Equal(t, payload, decrypted)
+		}
+		require.Equal(t, 1, provider.decrypted)
+	})
+
+	t.Run("plain payloads are decrypted as is", func(t *testing.T) {
+		for _, p := range [][]byte{nil, []byte(`{}`), {0x00, 0x01, 0x02}, payload} {
+			require.False(t, payloadcrypto.IsEncrypted(p))
+			decrypted, err := e.Decrypt(ctx, p)
+			require.NoError(t, err)
+			require.Equal(t, p, decrypted)
+		}
+	})
+
+	t.Run("tampered payloads can't be decrypted", func(t *testing.T) {
+		encrypted, err := e.Encrypt(ctx, payload)
+		require.NoError(t, err)
+		tampered := append([]byte{}, encrypted...)
+		tampered[len(tampered)-1] ^= 0xff
+		_, err = e.Decrypt(ctx, tampered)
+		require.Error(t, err)
+		_, err = e.Decrypt(ctx, encrypted[:len(encrypted)/2])
+		require.Error(t, err)
+		_, err = e.Decrypt(ctx, encrypted[:3])
+		require.Error(t, err)
+	})
+
+	t.Run("key rotation", func(t *testing.T) {
+		encrypted, err := e.Encrypt(ctx, payload)
+		require.NoError(t, err)
+
+		rotated, err := payloadcrypto.NewLocalKeyProvider("k2", map[string][]byte{"k1": k1, "k2": k2})
+		require.NoError(t, err)
+		re := payloadcrypto.New(rotated, time.Hour)
+		require.Equal(t, "k2", re.CurrentKeyID())
+
+		// payloads encrypted with previous keys are still readable
+		decrypted, err := re.Decrypt(ctx, encrypted)
+		require.NoError(t, err)
+		require.Equal(t, payload, decrypted)
+
+		reencrypted, err := re.Encrypt(ctx, decrypted)
+		require.NoError(t, err)
+		keyID, err := payloadcrypto.KeyID(reencrypted)
+		require.NoError(t, err)
+		require.Equal(t, "k2", keyID)
+		require.True(t, bytes.HasPrefix(reencrypted, payloadcrypto.KeyPrefix("k2")))
+		require.False(t, bytes.HasPrefix(encrypted, payloadcrypto.KeyPrefix("k2")))
+
+		// but not once the key is gone
+		_, err = e.Decrypt(ctx, reencrypted)
+		require.Error(t, err)
+	})
+}
+
+func TestFileKeyProvider(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(newKey(t))
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "keys.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	p, err := payloadcrypto.NewFileKeyProvider(write(`{"current":"k1","keys":{"k1":"` + key + `"}}`))
+	require.NoError(t, err)
+	require.Equal(t, "k1", p.CurrentKeyID())
+	plaintext, wrapped, keyID, err := p.GenerateDataKey(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "k1", keyID)
+	unwrapped, err := p.DecryptDataKey(context.Background(), keyID, wrapped)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, unwrapped)
+	_, err = p.DecryptDataKey(context.Background(), "k2", wrapped)
+	require.Error(t, err)
+
+	for name, content := range map[string]string{
+		"missing current key": `{"current":"k2","keys":{"k1":"` + key + `"}}`,
+		"short key":           `{"current":"k1","keys":{"k1":"c2hvcnQ="}}`,
+		"invalid base64":      `{"current":"k1","keys":{"k1":"!"}}`,
+		"invalid json":        `{`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := payloadcrypto.NewFileKeyProvider(write(content))
+			require.Error(t, err)
+		})
+	}
+	_, err = payloadcrypto.NewFileKeyProvider(filepath.Join(dir, "missing.json"))
+	require.Error(t, err)
+}
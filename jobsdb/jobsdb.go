@@ -54,10 +54,13 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/jsonrs"
 	"github.com/rudderlabs/rudder-server/jobsdb/internal/cache"
 	"github.com/rudderlabs/rudder-server/jobsdb/internal/lock"
+	"github.com/rudderlabs/rudder-server/jobsdb/internal/payloadcodec"
+	"github.com/rudderlabs/rudder-server/jobsdb/internal/payloadcrypto"
 	"github.com/rudderlabs/rudder-server/services/rmetrics"
 	"github.com/rudderlabs/rudder-server/utils/crash"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
+	"github.com/rudderlabs/rudder-server/utils/types"
 )
 
 var errStaleDsList = errors.New("stale dataset list")
@@ -94,8 +97,11 @@ type GetQueryParams struct {
 	WorkspaceID                   string
 	CustomValFilters              []string
 	ParameterFilters              []ParameterFilterT
-	stateFilters                  []string
-	afterJobID                    *int64
+	// if MatchAllParameterFilters is true, ParameterFilters are combined using AND instead of OR
+	// and a filter with an empty value matches jobs that don't have the parameter at all
+	MatchAllParameterFilters bool
+	stateFilters             []string
+	afterJobID               *int64
 
 	// query limits
 
@@ -332,6 +338,9 @@ const (
 	SourceID      parameterName = "parameters->>'source_id'"
 	DestinationID parameterName = "parameters->>'destination_id'"
 	WorkspaceID   parameterName = "workspace_id"
+	// Connection is the source and destination pair of jobs bound to a destination, in the form of sourceID/destinationID.
+	// Jobs not having a destination_id parameter are not bound to a connection.
+	Connection parameterName = "(parameters->>'source_id') || '/' || (parameters->>'destination_id')"
 )
 
 /*
@@ -507,6 +516,8 @@ type Handle struct {
 	tablePrefix          string
 	logger               logger.Logger
 	stats                stats.Stats
+	reporting            types.Reporting
+	reportingPU          string
 
 	datasetList         dataSetTList
 	datasetRangeList    dataSetRangeTList
@@ -515,6 +526,7 @@ type Handle struct {
 	dsListLock          *lock.Locker
 	dsMigrationLock     *lock.Locker
 	noResultsCache      *cache.NoResultsCache[ParameterFilterT]
+	payloadColumnTypes  sync.Map // job table => payloadColumnType
 
 	// table count stats
 	statTableCount        stats.Measurement
@@ -580,10 +592,21 @@ type Handle struct {
 			jobMinRowsLeftMigrateThreshold    config.ValueLoader[float64]
 			migrateDSLoopSleepDuration        config.ValueLoader[time.Duration]
 			migrateDSTimeout                  config.ValueLoader[time.Duration]
+			recodeBatchSize                   config.ValueLoader[int]
+			recodeBatchBytes                  config.ValueLoader[int64]
 		}
 		backup struct {
 			masterBackupEnabled config.ValueLoader[bool]
 		}
+		payloadCompression struct {
+			codec   payloadcodec.Codec
+			minSize config.ValueLoader[int]
+		}
+		payloadEncryption struct {
+			enabled     bool
+			keyProvider payloadcrypto.KeyProvider
+			encryptor   *payloadcrypto.Encryptor
+		}
 	}
 }
 
@@ -778,6 +801,24 @@ func WithSkipMaintenanceErr(ignore bool) OptsFunc {
 	}
 }
 
+// WithReporting sets the reporting service which manual job state updates are reported to, as updates made by the given processing unit
+func WithReporting(reporting types.Reporting, pu string) OptsFunc {
+	return func(jd *Handle) {
+		jd.reporting = reporting
+		jd.reportingPU = pu
+	}
+}
+
+// PayloadKeyProvider provides the keys for encrypting payloads at rest, wrapping and unwrapping data keys KMS-style
+type PayloadKeyProvider = payloadcrypto.KeyProvider
+
+// WithPayloadKeyProvider sets the key provider used for encrypting and decrypting payloads, instead of the configured key file
+func WithPayloadKeyProvider(p PayloadKeyProvider) OptsFunc {
+	return func(jd *Handle) {
+		jd.conf.payloadEncryption.keyProvider = p
+	}
+}
+
 func WithJobMaxAge(jobMaxAge config.ValueLoader[time.Duration]) OptsFunc {
 	return func(jd *Handle) {
 		jd.conf.jobMaxAge = jobMaxAge
@@ -841,7 +882,7 @@ func (jd *Handle) init() {
 	}
 
 	if string(jd.conf.payloadColumnType) == "" {
-		jd.conf.payloadColumnType = TEXT
+		jd.conf.payloadColumnType = payloadColumnType(strings.ToLower(jd.config.GetStringVar(string(TEXT), jd.configKeys("payloadColumnType")...)))
 	}
 
 	if jd.stats == nil {
@@ -957,7 +998,7 @@ func (jd *Handle) workersAndAuxSetup() {
 		defaultLogCacheBranchInvalidation = true
 	}
 	jd.noResultsCache = cache.NewNoResultsCache(
-		cacheParameterFilters,
+		noResultsCacheParameters(),
 		func() time.Duration { return jd.conf.cacheExpiration.Load() },
 		cache.WithWarnOnBranchInvalidation[ParameterFilterT](
 			jd.config.GetReloadableBoolVar(defaultLogCacheBranchInvalidation, jd.configKeys("logCacheBranchInvalidation")...),
@@ -1008,10 +1049,43 @@ func (jd *Handle) loadConfig() {
 	jd.conf.migration.maxMigrateDSProbe = jd.config.GetReloadableIntVar(10, 1, jd.configKeys("maxMigrateDSProbe")...)
 	jd.conf.migration.vacuumFullStatusTableThreshold = jd.config.GetReloadableInt64Var(500*bytesize.MB, 1, jd.configKeys("vacuumFullStatusTableThreshold")...)
 	jd.conf.migration.vacuumAnalyzeStatusTableThreshold = jd.config.GetReloadableInt64Var(30000, 1, jd.configKeys("vacuumAnalyzeStatusTableThreshold")...)
+	// recodeBatchSize, recodeBatchBytes: Maximum number of jobs and of payload bytes read at once while migrating jobs whose payloads need to be recoded
+	jd.conf.migration.recodeBatchSize = jd.config.GetReloadableIntVar(10000, 1, jd.configKeys("migrateDS.recodeBatchSize")...)
+	jd.conf.migration.recodeBatchBytes = jd.config.GetReloadableInt64Var(64*bytesize.MB, 1, jd.configKeys("migrateDS.recodeBatchBytes")...)
 
 	// masterBackupEnabled = true => all the jobsdb are eligible for backup
 	jd.conf.backup.masterBackupEnabled = jd.config.GetReloadableBoolVar(true, jd.configKeys("backup.enabled")...)
 
+	// payloadCompression: Compression of payloads stored in datasets with a bytea payload column
+	codec, err := payloadcodec.Parse(jd.config.GetStringVar("none", jd.configKeys("payloadCompression.codec")...))
+	if err != nil {
+		jd.logger.Errorn("Invalid payload compression codec, payloads won't be compressed", obskit.Error(err))
+	}
+	jd.conf.payloadCompression.codec = codec
+	jd.conf.payloadCompression.minSize = jd.config.GetReloadableIntVar(256, 1, jd.configKeys("payloadCompression.minSize")...)
+
+	// payloadEncryption: Encryption of payloads stored in datasets with a bytea payload column.
+	// Payloads already encrypted can still be decrypted as long as a key provider is available, even if encryption is disabled.
+	jd.conf.payloadEncryption.enabled = jd.config.GetBoolVar(false, jd.configKeys("payloadEncryption.enabled")...)
+	if keyFile := jd.config.GetStringVar("", jd.configKeys("payloadEncryption.keyFile")...); jd.conf.payloadEncryption.keyProvider == nil && keyFile != "" {
+		keyProvider, err := payloadcrypto.NewFileKeyProvider(keyFile)
+		jd.assertError(err)
+		jd.conf.payloadEncryption.keyProvider = keyProvider
+	}
+	if jd.conf.payloadEncryption.keyProvider != nil {
+		jd.conf.payloadEncryption.encryptor = payloadcrypto.New(
+			jd.conf.payloadEncryption.keyProvider,
+			jd.config.GetDurationVar(1, time.Hour, jd.configKeys("payloadEncryption.dataKeyTTL")...),
+		)
+	} else if jd.conf.payloadEncryption.enabled {
+		panic(fmt.Errorf("payload encryption is enabled for %s but no key provider is configured", jd.tablePrefix))
+	}
+	if jd.conf.payloadEncryption.enabled && jd.conf.payloadColumnType != BYTEA {
+		jd.logger.Warnn("Payload encryption requires a bytea payload column, payloads won't be encrypted",
+			logger.NewStringField("payloadColumnType", string(jd.conf.payloadColumnType)),
+		)
+	}
+
 	// maxDSSize: Maximum size of a DS. The process which adds new DS runs in the background
 	// (every few seconds) so a DS may go beyond this size
 	// passing `maxDSSize` by reference, so it can be hot reloaded
@@ -1073,6 +1147,10 @@ func (jd *Handle) Start() error {
 	if !jd.skipSetupDBSetup {
 		jd.setUpForOwnerType(ctx, jd.ownerType)
 	}
+	// writers only keep the latest datasets in memory, so readers are preferred for inspecting and updating jobs
+	if _, loaded := inspectableHandles.LoadOrStore(jd.tablePrefix, jd); loaded && jd.ownerType != Write {
+		inspectableHandles.Store(jd.tablePrefix, jd)
+	}
 	return nil
 }
 
@@ -1173,6 +1251,7 @@ func (jd *Handle) TearDown() {
 //
 //	Noop if the connection pool is shared with the handle.
 func (jd *Handle) Close() {
+	inspectableHandles.CompareAndDelete(jd.tablePrefix, jd)
 	if !jd.sharedConnectionPool {
 		if err := jd.dbHandle.Close(); err != nil {
 			jd.logger.Errorn("error closing db connection", obskit.Error(err))
@@ -1517,6 +1596,7 @@ func (jd *Handle) createDSTablesInTx(ctx context.Context, tx *Tx, newDS dataSetT
 		expire_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW());`, newDS.JobTable)); err != nil {
 		return fmt.Errorf("creating %s: %w", newDS.JobTable, err)
 	}
+	jd.payloadColumnTypes.Store(newDS.JobTable, columnType)
 
 	if _, err := tx.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE %q (
 		id BIGSERIAL,
@@ -1687,6 +1767,7 @@ func (jd *Handle) dropDSForRecovery(ds dataSetT) {
 
 func (jd *Handle) postDropDs(ds dataSetT) {
 	jd.noResultsCache.InvalidateDataset(ds.Index)
+	jd.payloadColumnTypes.Delete(ds.JobTable)
 
 	// Tracking time interval between drop ds operations. Hence calling end before start
 	if jd.isStatDropDSPeriodInitialized {
@@ -1842,6 +1923,9 @@ func (jd *Handle) invalidateCacheForJobs(ds dataSetT, jobList []*JobT) {
 			params = append(params, key+":"+val)
 			parameterFilters = append(parameterFilters, ParameterFilterT{Name: key, Value: val})
 		}
+		if matchAll, ok := matchAllCacheParameterFilter(parameterFilters); ok {
+			parameterFilters = append(parameterFilters, matchAll)
+		}
 
 		paramsKey := strings.Join(params, "#")
 		if _, ok := cacheKeys[workspace][customVal][paramsKey]; !ok {
@@ -1878,6 +1962,31 @@ func (jd *Handle) GetToProcess(ctx context.Context, params GetQueryParams, more
 
 var cacheParameterFilters = []string{"source_id", "destination_id"}
 
+// matchAllCacheParameter is the parameter the no results cache keeps track of jobs matching all of the cache parameter filters with
+const matchAllCacheParameter = "match_all"
+
+// noResultsCacheParameters returns the parameters supported by the no results cache
+func noResultsCacheParameters() []string {
+	return append(slices.Clone(cacheParameterFilters), matchAllCacheParameter)
+}
+
+// matchAllCacheParameterFilter returns the parameter filter the no results cache keeps track of jobs matching all of the
+// given parameter filters with. This is only possible if they are filtering on all of the cache parameters.
+func matchAllCacheParameterFilter(parameterFilters []ParameterFilterT) (ParameterFilterT, bool) {
+	if len(parameterFilters) != len(cacheParameterFilters) {
+		return ParameterFilterT{}, false
+	}
+	values := make([]string, len(cacheParameterFilters))
+	for i, name := range cacheParameterFilters {
+		parameterFilter, ok := lo.Find(parameterFilters, func(pf ParameterFilterT) bool { return pf.Name == name })
+		if !ok {
+			return ParameterFilterT{}, false
+		}
+		values[i] = parameterFilter.Value
+	}
+	return ParameterFilterT{Name: matchAllCacheParameter, Value: strings.Join(values, "&")}, true
+}
+
 func (jd *Handle) GetPileUpCounts(ctx context.Context, cutoffTime time.Time, increaseFunc rmetrics.IncreasePendingEventsFunc) error {
 	// pause migration to avoid any read locks being blocked during pileup count
 	jd.migrateDSPaused.Store(true)
@@ -1960,6 +2069,10 @@ func (jd *Handle) getDistinctValuesPerDataset(
 ) (map[string][]string, error) {
 	var queries []string
 	for _, ds := range dsList {
+		if param == Connection {
+			queries = append(queries, distinctConnectionsQuery(ds, customVal))
+			continue
+		}
 		if customVal == "" {
 			queries = append(queries, fmt.Sprintf(`SELECT '%[2]s', * FROM (
 				WITH RECURSIVE t AS (
@@ -2000,13 +2113,16 @@ func (jd *Handle) getDistinctValuesPerDataset(
 	for rows.Next() {
 		var (
 			ds    string
-			value string
+			value sql.NullString
 		)
 		err := rows.Scan(&ds, &value)
 		if err != nil {
 			return nil, fmt.Errorf("couldn't scan distinct parameter-%s: %w", param.string(), err)
 		}
-		result[ds] = append(result[ds], value)
+		if !value.Valid { // jobs not having the parameter at all
+			continue
+		}
+		result[ds] = append(result[ds], value.String)
 	}
 	if err = rows.Err(); err != nil {
 		return nil, fmt.Errorf("rows.Err() on distinct parameter-%s: %w", param.string(), err)
@@ -2014,6 +2130,30 @@ func (jd *Handle) getDistinctValuesPerDataset(
 	return result, nil
 }
 
+// distinctConnectionsQuery returns the query of the distinct connections of a dataset. Distinct destinations are found
+// with a skip scan over the destination_id index, then the sources of each destination are looked up using the same index,
+// so that only jobs bound to a destination are scanned.
+func distinctConnectionsQuery(ds, customVal string) string {
+	var customValCondition string
+	if customVal != "" {
+		customValCondition = fmt.Sprintf(`custom_val = '%s' AND `, customVal)
+	}
+	return fmt.Sprintf(`SELECT '%[2]s', * FROM (
+		WITH RECURSIVE t AS (
+			(SELECT %[1]s as parameter FROM %[2]q WHERE %[3]s%[1]s IS NOT NULL ORDER BY %[1]s LIMIT 1)
+			UNION ALL
+			(
+				SELECT s.* FROM t, LATERAL(
+					SELECT %[1]s as parameter FROM %[2]q f
+					WHERE %[3]sf.%[1]s > t.parameter
+					ORDER BY %[1]s LIMIT 1
+					)s
+				)
+			)
+		SELECT DISTINCT (f.parameters->>'source_id') || '/' || t.parameter FROM t JOIN %[2]q f ON %[3]sf.%[1]s = t.parameter) a`,
+		DestinationID.string(), ds, customValCondition)
+}
+
 func (jd *Handle) GetDistinctParameterValues(ctx context.Context, parameter ParameterName, customValFilter string) ([]string, error) {
 	if !jd.dsMigrationLock.RTryLockWithCtx(ctx) {
 		return nil, fmt.Errorf("could not acquire a migration read lock: %w", ctx.Err())
@@ -2038,19 +2178,24 @@ func (jd *Handle) doStoreJobsInTx(ctx context.Context, tx *Tx, ds dataSetT, jobL
 		var stmt *sql.Stmt
 		var err error
 
+		payloads, err := jd.storablePayloads(ctx, tx, ds, jobList)
+		if err != nil {
+			return err
+		}
+
 		stmt, err = tx.PrepareContext(ctx, pq.CopyIn(ds.JobTable, "uuid", "user_id", "custom_val", "parameters", "event_payload", "event_count", "workspace_id"))
 		if err != nil {
 			return err
 		}
 
 		defer func() { _ = stmt.Close() }()
-		for _, job := range jobList {
+		for i, job := range jobList {
 			eventCount := 1
 			if job.EventCount > 1 {
 				eventCount = job.EventCount
 			}
 
-			if _, err = stmt.ExecContext(ctx, job.UUID, job.UserID, job.CustomVal, string(job.Parameters), string(job.EventPayload), eventCount, job.WorkspaceId); err != nil {
+			if _, err = stmt.ExecContext(ctx, job.UUID, job.UserID, job.CustomVal, string(job.Parameters), payloads[i], eventCount, job.WorkspaceId); err != nil {
 				return err
 			}
 		}
@@ -2112,7 +2257,17 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 	workspaceID := params.WorkspaceID
 	checkValidJobState(jd, stateFilters)
 
-	if jd.noResultsCache.Get(ds.Index, workspaceID, customValFilters, stateFilters, parameterFilters) {
+	// the no results cache keeps track of each parameter filter independently, thus filters needing to match together
+	// are tracked as a single filter, if possible
+	useCache := true
+	cacheFilters := parameterFilters
+	if params.MatchAllParameterFilters {
+		var matchAll ParameterFilterT
+		matchAll, useCache = matchAllCacheParameterFilter(parameterFilters)
+		cacheFilters = []ParameterFilterT{matchAll}
+	}
+
+	if useCache && jd.noResultsCache.Get(ds.Index, workspaceID, customValFilters, stateFilters, cacheFilters) {
 		jd.logger.Debugn("[getJobsDS] Empty cache hit for ds: %v, stateFilters: %v, customValFilters: %v, parameterFilters: %v",
 			logger.NewStringField("ds", ds.String()),
 			logger.NewStringField("stateFilters", strings.Join(stateFilters, ",")),
@@ -2129,13 +2284,13 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 	}
 
 	stateFilters = lo.Filter(stateFilters, func(state string, _ int) bool { // exclude states for which we already know that there are no jobs
-		return !jd.noResultsCache.Get(ds.Index, workspaceID, customValFilters, []string{state}, parameterFilters)
+		return !useCache || !jd.noResultsCache.Get(ds.Index, workspaceID, customValFilters, []string{state}, cacheFilters)
 	})
 
 	defer jd.getTimerStat("jobsdb_get_jobs_ds_time", &tags).RecordDuration()()
 
 	containsUnprocessed := lo.Contains(stateFilters, Unprocessed.State)
-	skipCacheResult := params.afterJobID != nil
+	skipCacheResult := params.afterJobID != nil || !useCache
 	cacheTx := map[string]*cache.NoResultTx[ParameterFilterT]{}
 	if !skipCacheResult {
 		for _, state := range stateFilters {
@@ -2146,7 +2301,7 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 			if state == Unprocessed.State && jd.ownerType == Read && lastDS {
 				continue
 			}
-			cacheTx[state] = jd.noResultsCache.StartNoResultTx(ds.Index, workspaceID, customValFilters, []string{state}, parameterFilters)
+			cacheTx[state] = jd.noResultsCache.StartNoResultTx(ds.Index, workspaceID, customValFilters, []string{state}, cacheFilters)
 		}
 	}
 
@@ -2168,7 +2323,11 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 	}
 
 	if len(parameterFilters) > 0 {
-		filterConditions = append(filterConditions, constructParameterJSONQuery("jobs", parameterFilters))
+		if params.MatchAllParameterFilters {
+			filterConditions = append(filterConditions, constructParameterJSONQueryAND("jobs", parameterFilters))
+		} else {
+			filterConditions = append(filterConditions, constructParameterJSONQuery("jobs", parameterFilters))
+		}
 	}
 
 	if workspaceID != "" {
@@ -2272,7 +2431,9 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 		if err != nil {
 			return JobsResult{}, false, err
 		}
-		job.EventPayload = payload
+		if job.EventPayload, err = jd.decodePayload(ctx, payload); err != nil {
+			return JobsResult{}, false, fmt.Errorf("decoding payload of job %d: %w", job.JobID, err)
+		}
 		if jsState.Valid {
 			resultsetStates[jsState.String] = struct{}{}
 			job.LastJobStatus.JobState = jsState.String
@@ -2369,10 +2530,15 @@ func (jd *Handle) updateJobStatusDSInTx(ctx context.Context, tx *Tx, ds dataSetT
 				updatedStates[status.WorkspaceId][status.JobState] = make(map[ParameterFilterT]struct{})
 			}
 			if status.JobParameters != nil {
+				parameterFilters := make([]ParameterFilterT, 0, len(cacheParameterFilters))
 				for _, param := range cacheParameterFilters {
 					v := gjson.GetBytes(status.JobParameters, param).Str
+					parameterFilters = append(parameterFilters, ParameterFilterT{Name: param, Value: v})
 					updatedStates[status.WorkspaceId][status.JobState][ParameterFilterT{Name: param, Value: v}] = struct{}{}
 				}
+				if matchAll, ok := matchAllCacheParameterFilter(parameterFilters); ok {
+					updatedStates[status.WorkspaceId][status.JobState][matchAll] = struct{}{}
+				}
 			}
 
 			if !utf8.ValidString(string(status.ErrorResponse)) {
@@ -2619,6 +2785,7 @@ const (
 	postMigrateDSOperation     = "POST_MIGRATE_DS_OP"
 	dropDSOperation            = "DROP_DS"
 	RawDataDestUploadOperation = "S3_DEST_UPLOAD"
+	manualUpdateOperation      = "MANUAL_JOB_STATE_UPDATE"
 )
 
 type JournalEntryT struct {
@@ -2649,7 +2816,8 @@ func (jd *Handle) JournalMarkStartInTx(tx *Tx, opType string, opPayload json.Raw
 		opType == migrateCopyOperation ||
 		opType == postMigrateDSOperation ||
 		opType == dropDSOperation ||
-		opType == RawDataDestUploadOperation, fmt.Sprintf("opType: %s is not a supported op", opType))
+		opType == RawDataDestUploadOperation ||
+		opType == manualUpdateOperation, fmt.Sprintf("opType: %s is not a supported op", opType))
 
 	sqlStatement := fmt.Sprintf(`INSERT INTO %s_journal (operation, done, operation_payload, start_time, owner)
                                        VALUES ($1, $2, $3, $4, $5) RETURNING id`, jd.tablePrefix)
@@ -3360,6 +3528,8 @@ func (jd *Handle) GetLastJob(ctx context.Context) *JobT {
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		jd.assertError(err)
 	}
+	job.EventPayload, err = jd.decodePayload(ctx, job.EventPayload)
+	jd.assertError(err)
 	return &job
 }
 
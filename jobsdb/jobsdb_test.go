@@ -1321,6 +1321,75 @@ func TestConstructParameterJSONQuery(t *testing.T) {
 	require.Equal(t, `(alias.parameters->>'name'='value')`, q)
 }
 
+func TestConstructParameterJSONQueryAND(t *testing.T) {
+	q := constructParameterJSONQueryAND("alias", []ParameterFilterT{{Name: "name", Value: "value"}, {Name: "other", Value: ""}})
+	require.Equal(t, `(alias.parameters->>'name'='value' AND alias.parameters->>'other' IS NULL)`, q)
+}
+
+func TestMatchAllCacheParameterFilter(t *testing.T) {
+	pf, ok := matchAllCacheParameterFilter([]ParameterFilterT{{Name: "destination_id", Value: "d1"}, {Name: "source_id", Value: "s1"}})
+	require.True(t, ok)
+	require.Equal(t, ParameterFilterT{Name: matchAllCacheParameter, Value: "s1&d1"}, pf)
+
+	pf, ok = matchAllCacheParameterFilter([]ParameterFilterT{{Name: "source_id", Value: "s1"}, {Name: "destination_id", Value: ""}})
+	require.True(t, ok)
+	require.Equal(t, ParameterFilterT{Name: matchAllCacheParameter, Value: "s1&"}, pf)
+
+	_, ok = matchAllCacheParameterFilter([]ParameterFilterT{{Name: "source_id", Value: "s1"}})
+	require.False(t, ok, "filters not covering all cache parameters cannot be cached")
+	_, ok = matchAllCacheParameterFilter([]ParameterFilterT{{Name: "source_id", Value: "s1"}, {Name: "other", Value: "o1"}})
+	require.False(t, ok, "filters on unsupported parameters cannot be cached")
+}
+
+func TestConnectionIsolation(t *testing.T) {
+	_ = startPostgres(t)
+	jobsDB := &Handle{config: config.New()}
+	require.NoError(t, jobsDB.Setup(ReadWrite, true, strings.ToLower(rsRand.String(5))))
+	defer jobsDB.TearDown()
+
+	ctx := context.Background()
+	job := func(parameters string) *JobT {
+		return &JobT{
+			WorkspaceId:  "ws-1",
+			Parameters:   []byte(parameters),
+			EventPayload: []byte(`{"testKey":"testValue"}`),
+			UserID:       "u1",
+			UUID:         uuid.New(),
+			CustomVal:    "MOCKDS",
+			EventCount:   1,
+		}
+	}
+	require.NoError(t, jobsDB.Store(ctx, []*JobT{
+		job(`{"source_id":"s1"}`),
+		job(`{"source_id":"s1","destination_id":"d1"}`),
+		job(`{"source_id":"s2","destination_id":"d1"}`),
+	}))
+
+	connections, err := jobsDB.GetDistinctParameterValues(ctx, Connection, "")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"s1/d1", "s2/d1"}, connections)
+	connections, err = jobsDB.GetDistinctParameterValues(ctx, Connection, "OTHER")
+	require.NoError(t, err)
+	require.Empty(t, connections)
+
+	unprocessed := func(sourceID, destinationID string) int {
+		res, err := jobsDB.GetUnprocessed(ctx, GetQueryParams{
+			ParameterFilters:         []ParameterFilterT{{Name: "source_id", Value: sourceID}, {Name: "destination_id", Value: destinationID}},
+			MatchAllParameterFilters: true,
+			JobsLimit:                10,
+		})
+		require.NoError(t, err)
+		return len(res.Jobs)
+	}
+	require.Equal(t, 1, unprocessed("s1", ""))
+	require.Equal(t, 1, unprocessed("s1", "d1"))
+	require.Equal(t, 0, unprocessed("s2", ""))
+	require.Equal(t, 0, unprocessed("s3", "d1"), "no results should now be cached")
+
+	require.NoError(t, jobsDB.Store(ctx, []*JobT{job(`{"source_id":"s3","destination_id":"d1"}`)}))
+	require.Equal(t, 1, unprocessed("s3", "d1"), "storing a job should invalidate the cached no results of its connection")
+}
+
 func TestGetActiveWorkspaces(t *testing.T) {
 	_ = startPostgres(t)
 	c := config.New()
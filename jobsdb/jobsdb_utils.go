@@ -132,6 +132,19 @@ func constructParameterJSONQuery(alias string, parameterFilters []ParameterFilte
 	return "(" + strings.Join(conditions, " OR ") + ")"
 }
 
+// constructParameterJSONQueryAND constructs a query where all parameter filters need to match.
+// A parameter filter with an empty value matches jobs that don't have the parameter at all.
+func constructParameterJSONQueryAND(alias string, parameterFilters []ParameterFilterT) string {
+	conditions := lo.Map(parameterFilters, func(parameter ParameterFilterT, _ int) string {
+		if parameter.Value == "" {
+			return fmt.Sprintf(`%s.parameters->>'%s' IS NULL`, alias, parameter.Name)
+		}
+		return fmt.Sprintf(`%s.parameters->>'%s'='%s'`, alias, parameter.Name, parameter.Value)
+	})
+
+	return "(" + strings.Join(conditions, " AND ") + ")"
+}
+
 // statTags is a struct to hold tags for stats
 type statTags struct {
 	CustomValFilters []string
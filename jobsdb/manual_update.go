@@ -0,0 +1,345 @@
+package jobsdb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+// manualUpdateBatchSize is the number of jobs updated per transaction by manual job state updates
+const manualUpdateBatchSize = 1000
+
+// UpdateJobStatesArg are the arguments of [Admin.UpdateJobStates]
+type UpdateJobStatesArg struct {
+	Prefix        string   // table prefix of the jobsdb
+	Action        string   // abort, retry or skip
+	States        []string // last states of the jobs to update, all the states the action applies to if empty
+	WorkspaceID   string
+	DestinationID string
+	CustomVal     string
+	ErrorCode     string // error code of the last status of the jobs to update
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	Reason        string // recorded in the error response of the new statuses
+	DryRun        bool   // only counts the jobs which would be updated
+}
+
+// manualAction is a manual job state update, moving jobs in any of fromStates to state
+type manualAction struct {
+	state      string
+	fromStates []string
+	resetRetry bool // whether the attempts of jobs are reset
+}
+
+var manualActions = map[string]manualAction{
+	"abort": {state: Aborted.State, fromStates: []string{Unprocessed.State, Failed.State, Waiting.State}},
+	"skip":  {state: Filtered.State, fromStates: []string{Unprocessed.State, Failed.State, Waiting.State}},
+	"retry": {state: Failed.State, fromStates: []string{Aborted.State, Failed.State}, resetRetry: true},
+}
+
+type manualUpdateResult struct {
+	Action string           `json:"action"`
+	DryRun bool             `json:"dryRun"`
+	OpID   int64            `json:"opId,omitempty"`
+	Total  int64            `json:"total"`
+	Counts map[string]int64 `json:"counts"` // by last state before the update
+}
+
+type jobToUpdate struct {
+	jobID       int64
+	workspaceID string
+	parameters  []byte
+	state       string
+	attempt     int
+}
+
+// UpdateJobStates aborts, retries or skips the jobs of a jobsdb matching the given filters, by adding new statuses to them.
+// Only jobs which aren't being processed can be updated: jobs can be aborted or skipped if they are unprocessed, failed or waiting,
+// and retried if they are aborted or failed. At least a workspace, destination or custom value is required, so that jobs aren't
+// updated across the board by mistake. The operation is journaled, and aborted or skipped jobs are reported if reporting is set up.
+func (a *Admin) UpdateJobStates(arg UpdateJobStatesArg, reply *string) (err error) {
+	defer recoverInspection(&err)
+	action, ok := manualActions[arg.Action]
+	if !ok {
+		return fmt.Errorf("invalid action %q, valid actions are abort, retry and skip", arg.Action)
+	}
+	if arg.WorkspaceID == "" && arg.DestinationID == "" && arg.CustomVal == "" {
+		return fmt.Errorf("at least a workspace, destination or custom value is required")
+	}
+	states := arg.States
+	if len(states) == 0 {
+		states = action.fromStates
+	}
+	for _, state := range states {
+		if !slices.Contains(action.fromStates, state) {
+			return fmt.Errorf("jobs in state %q can't be updated by %s, valid states are %s", state, arg.Action, strings.Join(action.fromStates, ", "))
+		}
+	}
+	filter := jobFilter{
+		States:        states,
+		WorkspaceID:   arg.WorkspaceID,
+		DestinationID: arg.DestinationID,
+		CustomVal:     arg.CustomVal,
+		ErrorCode:     arg.ErrorCode,
+		CreatedAfter:  arg.CreatedAfter,
+		CreatedBefore: arg.CreatedBefore,
+	}
+
+	result := manualUpdateResult{Action: arg.Action, DryRun: arg.DryRun, Counts: make(map[string]int64)}
+	if arg.DryRun {
+		err = inspect(arg.Prefix, func(ctx context.Context, jd *Handle, tx *Tx, dsList []dataSetT) error {
+			condition, args := filter.condition(1)
+			for _, ds := range dsList {
+				if err := jd.countJobsByStateInTx(ctx, tx, ds, condition, args, result.Counts); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	} else {
+		h, ok := inspectableHandles.Load(arg.Prefix)
+		if !ok {
+			return fmt.Errorf("no jobsdb with prefix %q", arg.Prefix)
+		}
+		jd := h.(*Handle)
+		if jd.ownerType == Write {
+			return fmt.Errorf("jobs of %q can't be updated by this server, which only writes them", arg.Prefix)
+		}
+		result.OpID, err = jd.updateJobStatesManually(arg, action, filter, result.Counts)
+	}
+	for _, count := range result.Counts {
+		result.Total += count
+	}
+	if err != nil {
+		return fmt.Errorf("%w, after updating %d jobs", err, result.Total)
+	}
+	return marshalReply(result, reply)
+}
+
+// countJobsByStateInTx adds the number of jobs of a dataset matching the condition to counts, by their last state
+func (jd *Handle) countJobsByStateInTx(ctx context.Context, tx *Tx, ds dataSetT, condition string, args []any, counts map[string]int64) error {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+		`select coalesce(js.job_state, '%[3]s'), count(*) from %[1]q j left join "v_last_%[2]s" js on js.job_id = j.job_id
+		where `+condition+` group by 1`,
+		ds.JobTable, ds.JobStatusTable, Unprocessed.State,
+	), args...)
+	if err != nil {
+		return fmt.Errorf("counting jobs of %s: %w", ds.JobTable, err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var state string
+		var count int64
+		if err := rows.Scan(&state, &count); err != nil {
+			return fmt.Errorf("scanning counts of %s: %w", ds.JobTable, err)
+		}
+		counts[state] += count
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("rows.Err() on counts of %s: %w", ds.JobTable, err)
+	}
+	return nil
+}
+
+// updateJobStatesManually updates the states of all jobs matching the filter in batches, each one in its own transaction,
+// adding the number of jobs updated to counts by their previous state. It returns the id of the journal entry of the operation,
+// which is marked as done along with the counts, and the error if the operation failed part way.
+func (jd *Handle) updateJobStatesManually(arg UpdateJobStatesArg, action manualAction, filter jobFilter, counts map[string]int64) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jd.config.GetDurationVar(10, time.Minute, jd.configKeys("manualUpdateTimeout")...))
+	defer cancel()
+	opPayload, err := jsonrs.Marshal(arg)
+	if err != nil {
+		return 0, err
+	}
+	reason := arg.Reason
+	if reason == "" {
+		reason = "manual " + arg.Action
+	}
+	errorResponse, err := jsonrs.Marshal(map[string]string{"reason": reason})
+	if err != nil {
+		return 0, err
+	}
+	opID, err := jd.JournalMarkStart(manualUpdateOperation, opPayload)
+	if err != nil {
+		return 0, fmt.Errorf("journaling manual update: %w", err)
+	}
+	err = jd.updateJobStatesInBatches(ctx, action, filter, errorResponse, counts)
+	if doneErr := jd.journalMarkManualUpdateDone(opID, counts, err); doneErr != nil {
+		if err == nil {
+			err = doneErr
+		}
+		jd.logger.Errorn("Journaling manual update as done", logger.NewIntField("opID", opID), obskit.Error(doneErr))
+	}
+	if err != nil {
+		return opID, err
+	}
+	jd.logger.Infon("Updated job states manually",
+		logger.NewIntField("opID", opID),
+		logger.NewStringField("action", arg.Action),
+		logger.NewStringField("reason", reason),
+		logger.NewStringField("workspaceID", arg.WorkspaceID),
+		logger.NewStringField("destinationID", arg.DestinationID),
+		logger.NewStringField("customVal", arg.CustomVal),
+	)
+	return opID, nil
+}
+
+// journalMarkManualUpdateDone marks the journal entry of a manual update as done, whether it succeeded or not, so that it isn't
+// left pending forever. The counts of jobs updated and the error, if any, are added to the payload of the entry.
+func (jd *Handle) journalMarkManualUpdateDone(opID int64, counts map[string]int64, opErr error) error {
+	outcome := map[string]any{"updated": counts}
+	if opErr != nil {
+		outcome["error"] = opErr.Error()
+	}
+	payload, err := jsonrs.Marshal(outcome)
+	if err != nil {
+		return err
+	}
+	return jd.WithTx(func(tx *Tx) error {
+		_, err := tx.Exec(fmt.Sprintf(`UPDATE %s_journal SET done=true, end_time=$2, operation_payload=operation_payload || $3::jsonb WHERE id=$1 AND owner=$4`, jd.tablePrefix),
+			opID, time.Now(), payload, jd.ownerType)
+		return err
+	})
+}
+
+// updateJobStatesInBatches moves the jobs matching the filter to the state of the action, in batches of [manualUpdateBatchSize]
+// jobs each updated in its own transaction, adding the number of jobs updated to counts by their previous state
+func (jd *Handle) updateJobStatesInBatches(ctx context.Context, action manualAction, filter jobFilter, errorResponse []byte, counts map[string]int64) error {
+	condition, args := filter.condition(2)
+	var lastJobID int64
+	for {
+		var jobs []jobToUpdate
+		err := jd.WithUpdateSafeTx(ctx, func(tx UpdateSafeTx) error {
+			jobs = nil
+			for _, ds := range tx.getDSList() {
+				batch, err := jd.readJobsToUpdateInTx(ctx, tx.Tx(), ds, condition, append([]any{lastJobID}, args...), manualUpdateBatchSize-len(jobs))
+				if err != nil {
+					return err
+				}
+				if jobs = append(jobs, batch...); len(jobs) == manualUpdateBatchSize {
+					break
+				}
+			}
+			if len(jobs) == 0 {
+				return nil
+			}
+			now := time.Now()
+			statusList := make([]*JobStatusT, len(jobs))
+			for i, job := range jobs {
+				attempt := job.attempt
+				if action.resetRetry {
+					attempt = 0
+				}
+				statusList[i] = &JobStatusT{
+					JobID:         job.jobID,
+					JobState:      action.state,
+					AttemptNum:    attempt,
+					ExecTime:      now,
+					RetryTime:     now,
+					ErrorResponse: errorResponse,
+					Parameters:    []byte(`{}`),
+					JobParameters: job.parameters,
+					WorkspaceId:   job.workspaceID,
+				}
+			}
+			if err := jd.UpdateJobStatusInTx(ctx, tx, statusList, nil, nil); err != nil {
+				return err
+			}
+			// retried jobs are reported by whoever processes them again
+			if jd.reporting != nil && !action.resetRetry {
+				return jd.reporting.Report(ctx, jd.manualUpdateMetrics(jobs, action.state, errorResponse), tx.Tx())
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+		for _, job := range jobs {
+			counts[job.state]++
+		}
+		lastJobID = jobs[len(jobs)-1].jobID
+	}
+}
+
+// readJobsToUpdateInTx reads up to limit jobs of a dataset matching the condition, after the job id given as the first argument
+func (jd *Handle) readJobsToUpdateInTx(ctx context.Context, tx *Tx, ds dataSetT, condition string, args []any, limit int) ([]jobToUpdate, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+		`select j.job_id, j.workspace_id, j.parameters, coalesce(js.job_state, '%[3]s'), coalesce(js.attempt, 0)
+		from %[1]q j left join "v_last_%[2]s" js on js.job_id = j.job_id
+		where j.job_id > $1 and `+condition+fmt.Sprintf(` order by j.job_id limit %d`, limit),
+		ds.JobTable, ds.JobStatusTable, Unprocessed.State,
+	), args...)
+	if err != nil {
+		return nil, fmt.Errorf("reading jobs to update from %s: %w", ds.JobTable, err)
+	}
+	defer func() { _ = rows.Close() }()
+	var jobs []jobToUpdate
+	for rows.Next() {
+		var job jobToUpdate
+		if err := rows.Scan(&job.jobID, &job.workspaceID, &job.parameters, &job.state, &job.attempt); err != nil {
+			return nil, fmt.Errorf("scanning jobs to update from %s: %w", ds.JobTable, err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() on jobs to update from %s: %w", ds.JobTable, err)
+	}
+	return jobs, nil
+}
+
+// manualUpdateMetrics returns the reporting metrics of jobs moved to state, as if they were by the jobsdb's processing unit
+func (jd *Handle) manualUpdateMetrics(jobs []jobToUpdate, state string, errorResponse []byte) []*types.PUReportedMetric {
+	var metrics []*types.PUReportedMetric
+	metricsByKey := make(map[string]*types.PUReportedMetric)
+	for _, job := range jobs {
+		params := gjson.ParseBytes(job.parameters)
+		cd := types.ConnectionDetails{
+			SourceID:                params.Get("source_id").String(),
+			DestinationID:           params.Get("destination_id").String(),
+			SourceTaskRunID:         params.Get("source_task_run_id").String(),
+			SourceJobID:             params.Get("source_job_id").String(),
+			SourceJobRunID:          params.Get("source_job_run_id").String(),
+			SourceDefinitionID:      params.Get("source_definition_id").String(),
+			DestinationDefinitionID: params.Get("destination_definition_id").String(),
+			SourceCategory:          params.Get("source_category").String(),
+		}
+		eventName, eventType := params.Get("event_name").String(), params.Get("event_type").String()
+		key := strings.Join([]string{cd.SourceID, cd.DestinationID, cd.SourceJobRunID, eventName, eventType}, ":")
+		m, ok := metricsByKey[key]
+		if !ok {
+			inPU := types.EVENT_FILTER
+			if params.Get("transform_at").String() == "processor" {
+				inPU = types.DEST_TRANSFORMER
+			}
+			m = &types.PUReportedMetric{
+				ConnectionDetails: cd,
+				PUDetails:         *types.CreatePUDetails(inPU, jd.reportingPU, true, false),
+				StatusDetail: &types.StatusDetail{
+					Status:         state,
+					SampleResponse: string(errorResponse),
+					SampleEvent:    []byte(`{}`),
+					EventName:      eventName,
+					EventType:      eventType,
+				},
+			}
+			metricsByKey[key] = m
+			metrics = append(metrics, m)
+		}
+		m.StatusDetail.Count++
+	}
+	return metrics
+}
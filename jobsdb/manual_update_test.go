@@ -0,0 +1,136 @@
+package jobsdb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+
+	mocksTypes "github.com/rudderlabs/rudder-server/mocks/utils/types"
+	"github.com/rudderlabs/rudder-server/utils/tx"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+func TestAdminUpdateJobStates(t *testing.T) {
+	config.Reset()
+	_ = startPostgres(t)
+	ctx := context.Background()
+
+	var reported []*types.PUReportedMetric
+	reporting := mocksTypes.NewMockReporting(gomock.NewController(t))
+	reporting.EXPECT().Report(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, metrics []*types.PUReportedMetric, _ *tx.Tx) error {
+		reported = append(reported, metrics...)
+		return nil
+	}).AnyTimes()
+
+	c := config.New()
+	jd := Handle{config: c}
+	WithReporting(reporting, types.ROUTER)(&jd)
+	require.NoError(t, jd.Setup(ReadWrite, true, "manual"))
+	defer jd.TearDown()
+
+	jobs := genJobs("ws-1", "WEBHOOK", 4, 1)
+	for _, job := range jobs {
+		job.Parameters = []byte(`{"source_id":"source-1","destination_id":"dest-1","event_name":"Order Completed","event_type":"track"}`)
+	}
+	jobs[3].Parameters = []byte(`{"source_id":"source-1","destination_id":"dest-2"}`)
+	require.NoError(t, jd.Store(ctx, jobs))
+	failed := genJobStatuses(jobs[:1], Failed.State)
+	failed[0].ErrorCode = "400"
+	require.NoError(t, jd.UpdateJobStatus(ctx, failed, []string{"WEBHOOK"}, nil))
+	require.NoError(t, jd.UpdateJobStatus(ctx, genJobStatuses(jobs[1:2], Executing.State), []string{"WEBHOOK"}, nil))
+
+	a := NewAdmin()
+	var reply string
+	update := func(arg UpdateJobStatesArg) manualUpdateResult {
+		arg.Prefix = "manual"
+		require.NoError(t, a.UpdateJobStates(arg, &reply))
+		var result manualUpdateResult
+		require.NoError(t, jsonrs.Unmarshal([]byte(reply), &result))
+		return result
+	}
+	lastStates := func() map[int64]string {
+		res, err := jd.GetJobs(ctx, []string{Unprocessed.State, Failed.State, Executing.State, Aborted.State, Filtered.State}, GetQueryParams{JobsLimit: 100, IgnoreCustomValFiltersInQuery: true})
+		require.NoError(t, err)
+		states := make(map[int64]string)
+		for _, job := range res.Jobs {
+			states[job.JobID] = job.LastJobStatus.JobState
+			if states[job.JobID] == "" {
+				states[job.JobID] = Unprocessed.State
+			}
+		}
+		return states
+	}
+
+	t.Run("invalid updates", func(t *testing.T) {
+		require.Error(t, a.UpdateJobStates(UpdateJobStatesArg{Prefix: "manual", Action: "delete", DestinationID: "dest-1"}, &reply))
+		require.Error(t, a.UpdateJobStates(UpdateJobStatesArg{Prefix: "manual", Action: "abort"}, &reply), "updates need a filter")
+		require.Error(t, a.UpdateJobStates(UpdateJobStatesArg{Prefix: "manual", Action: "abort", DestinationID: "dest-1", States: []string{Executing.State}}, &reply))
+		require.Error(t, a.UpdateJobStates(UpdateJobStatesArg{Prefix: "unknown", Action: "abort", DestinationID: "dest-1"}, &reply))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		result := update(UpdateJobStatesArg{Action: "abort", DestinationID: "dest-1", DryRun: true})
+		require.EqualValues(t, 2, result.Total, "executing jobs shouldn't be updated")
+		require.Equal(t, map[string]int64{Unprocessed.State: 1, Failed.State: 1}, result.Counts)
+		require.Equal(t, map[int64]string{1: Failed.State, 2: Executing.State, 3: Unprocessed.State, 4: Unprocessed.State}, lastStates())
+
+		result = update(UpdateJobStatesArg{Action: "abort", DestinationID: "dest-1", ErrorCode: "400", DryRun: true})
+		require.Equal(t, map[string]int64{Failed.State: 1}, result.Counts)
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		result := update(UpdateJobStatesArg{Action: "abort", DestinationID: "dest-1", Reason: "misconfigured destination"})
+		require.EqualValues(t, 2, result.Total)
+		require.NotZero(t, result.OpID)
+		require.Equal(t, map[int64]string{1: Aborted.State, 2: Executing.State, 3: Aborted.State, 4: Unprocessed.State}, lastStates())
+
+		require.Len(t, reported, 1)
+		require.Equal(t, "source-1", reported[0].SourceID)
+		require.Equal(t, "dest-1", reported[0].DestinationID)
+		require.Equal(t, types.ROUTER, reported[0].PU)
+		require.True(t, reported[0].TerminalPU)
+		require.Equal(t, Aborted.State, reported[0].StatusDetail.Status)
+		require.EqualValues(t, 2, reported[0].StatusDetail.Count)
+		require.JSONEq(t, `{"reason":"misconfigured destination"}`, reported[0].StatusDetail.SampleResponse)
+
+		var done bool
+		var payload string
+		require.NoError(t, jd.dbHandle.QueryRow(`select done, operation_payload->>'updated' from manual_journal where id = $1`, result.OpID).Scan(&done, &payload))
+		require.True(t, done, "the operation should be journaled")
+		require.JSONEq(t, `{"failed":1,"not_picked_yet":1}`, payload)
+	})
+
+	t.Run("failed update", func(t *testing.T) {
+		c.Set("JobsDB.manualUpdateTimeout", "1ns")
+		defer c.Set("JobsDB.manualUpdateTimeout", "10m")
+		require.Error(t, a.UpdateJobStates(UpdateJobStatesArg{Prefix: "manual", Action: "abort", DestinationID: "dest-2"}, &reply))
+
+		var done bool
+		var opErr string
+		require.NoError(t, jd.dbHandle.QueryRow(`select done, operation_payload->>'error' from manual_journal order by id desc limit 1`).Scan(&done, &opErr))
+		require.True(t, done, "failed operations shouldn't be left pending")
+		require.NotEmpty(t, opErr)
+		require.Equal(t, Unprocessed.State, lastStates()[4])
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		reported = nil
+		result := update(UpdateJobStatesArg{Action: "retry", WorkspaceID: "ws-1"})
+		require.Equal(t, map[string]int64{Aborted.State: 2}, result.Counts)
+		require.Equal(t, map[int64]string{1: Failed.State, 2: Executing.State, 3: Failed.State, 4: Unprocessed.State}, lastStates())
+		require.Empty(t, reported, "retried jobs are reported once processed again")
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		result := update(UpdateJobStatesArg{Action: "skip", DestinationID: "dest-2"})
+		require.Equal(t, map[string]int64{Unprocessed.State: 1}, result.Counts)
+		require.Equal(t, Filtered.State, lastStates()[4])
+		require.Len(t, reported, 1)
+		require.Equal(t, Filtered.State, reported[0].StatusDetail.Status)
+	})
+}
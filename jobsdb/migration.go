@@ -542,6 +542,20 @@ func (jd *Handle) migrateJobsInTx(ctx context.Context, tx *Tx, srcDS, destDS dat
 	if err = rows.Err(); err != nil {
 		return 0, fmt.Errorf("rows.Err() on column types: %w", err)
 	}
+	recode, err := jd.needsPayloadRecoding(ctx, tx, srcDS, columnTypeMap[srcDS.JobTable], columnTypeMap[destDS.JobTable])
+	if err != nil {
+		return 0, err
+	}
+	if recode {
+		numJobsMigrated, err := jd.migrateJobsRecodingPayloadsInTx(ctx, tx, srcDS, destDS, columnTypeMap[destDS.JobTable])
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(fmt.Sprintf(`ANALYZE %q, %q`, destDS.JobTable, destDS.JobStatusTable)); err != nil {
+			return 0, err
+		}
+		return numJobsMigrated, nil
+	}
 	payloadLiteral, err := getColumnConversion(columnTypeMap[srcDS.JobTable], columnTypeMap[destDS.JobTable])
 	if err != nil {
 		return 0, err
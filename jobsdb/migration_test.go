@@ -2,6 +2,7 @@ package jobsdb
 
 import (
 	"context"
+	cryptorand "crypto/rand"
 	"fmt"
 	"strings"
 	"testing"
@@ -10,7 +11,11 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
 	"github.com/rudderlabs/rudder-go-kit/testhelper/rand"
+	"github.com/rudderlabs/rudder-server/jobsdb/internal/payloadcodec"
+	"github.com/rudderlabs/rudder-server/jobsdb/internal/payloadcrypto"
 	"github.com/rudderlabs/rudder-server/utils/tx"
 )
 
@@ -676,3 +681,200 @@ func Test_GetColumnConversion(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestPayloadCompression(t *testing.T) {
+	config.Reset()
+	c := config.New()
+	c.Set("JobsDB.payloadCompression.codec", "zstd")
+	c.Set("JobsDB.payloadCompression.minSize", 1)
+
+	pg := startPostgres(t)
+	db := pg.DB
+	ctx := context.Background()
+
+	statsStore, err := memstats.New()
+	require.NoError(t, err)
+	compressedJD := Handle{config: c, stats: statsStore}
+	compressedJD.conf.payloadColumnType = BYTEA
+	require.NoError(t, compressedJD.Setup(ReadWrite, true, "compressed"))
+	defer compressedJD.TearDown()
+
+	// a jobsdb not compressing payloads, sharing the same tables
+	uncompressedConf := config.New()
+	uncompressedJD := Handle{config: uncompressedConf}
+	uncompressedJD.conf.payloadColumnType = BYTEA
+	require.NoError(t, uncompressedJD.Setup(ReadWrite, false, "compressed"))
+	defer uncompressedJD.TearDown()
+
+	textJD := Handle{config: c}
+	textJD.conf.payloadColumnType = TEXT
+	require.NoError(t, textJD.Setup(ReadWrite, true, "uncompressed_text"))
+	defer textJD.TearDown()
+
+	jobs := genJobs("wsid", "cv", 2, 1)
+	require.NoError(t, compressedJD.Store(ctx, jobs[:1]))
+	require.NoError(t, uncompressedJD.Store(ctx, jobs[1:]))
+	// text columns are never compressed
+	require.NoError(t, textJD.Store(ctx, genJobs("wsid", "cv", 1, 1)))
+
+	var codecs []int
+	rows, err := db.QueryContext(ctx, `select get_byte(event_payload, 1) from compressed_jobs_1 order by job_id`)
+	require.NoError(t, err)
+	for rows.Next() {
+		var codec int
+		require.NoError(t, rows.Scan(&codec))
+		codecs = append(codecs, codec)
+	}
+	require.NoError(t, rows.Err())
+	require.NoError(t, rows.Close())
+	require.Equal(t, []int{int(payloadcodec.Zstd), int('"')}, codecs, "only the first payload should be compressed")
+
+	tags := stats.Tags{"tablePrefix": "compressed", "customVal": "compressed", "codec": "zstd"}
+	uncompressedBytes := statsStore.Get("jobsdb_payload_uncompressed_bytes", tags).LastValue()
+	require.EqualValues(t, len(jobs[0].EventPayload), uncompressedBytes)
+	require.Less(t, statsStore.Get("jobsdb_payload_compressed_bytes", tags).LastValue(), uncompressedBytes)
+
+	// mixed datasets are readable
+	res, err := compressedJD.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 100, IgnoreCustomValFiltersInQuery: true})
+	require.NoError(t, err)
+	require.Len(t, res.Jobs, 2)
+	for i := range res.Jobs {
+		require.JSONEq(t, string(jobs[i].EventPayload), string(res.Jobs[i].EventPayload))
+	}
+
+	// compressed payloads are decompressed when migrated to a text dataset and compressed when migrated back to a bytea one
+	_, err = db.ExecContext(ctx, `ALTER TABLE uncompressed_text_jobs_1 DROP CONSTRAINT uncompressed_text_jobs_1_pkey`)
+	require.NoError(t, err)
+	_, err = db.ExecContext(ctx, `ALTER TABLE compressed_jobs_1 DROP CONSTRAINT compressed_jobs_1_pkey`)
+	require.NoError(t, err)
+	txn, err := db.Begin()
+	require.NoError(t, err)
+	compressed := dataSetT{JobTable: "compressed_jobs_1", JobStatusTable: "compressed_job_status_1", Index: "1"}
+	text := dataSetT{JobTable: "uncompressed_text_jobs_1", JobStatusTable: "uncompressed_text_job_status_1", Index: "1"}
+	migrated, err := compressedJD.migrateJobsInTx(ctx, &tx.Tx{Tx: txn}, compressed, text)
+	require.NoError(t, err)
+	require.Equal(t, 2, migrated)
+	migrated, err = compressedJD.migrateJobsInTx(ctx, &tx.Tx{Tx: txn}, text, compressed)
+	require.NoError(t, err)
+	require.Equal(t, 3, migrated)
+	require.NoError(t, txn.Commit())
+
+	textJobs, err := textJD.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 100, IgnoreCustomValFiltersInQuery: true})
+	require.NoError(t, err)
+	require.Len(t, textJobs.Jobs, 3)
+	compressedJobs, err := compressedJD.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 100, IgnoreCustomValFiltersInQuery: true})
+	require.NoError(t, err)
+	require.Len(t, compressedJobs.Jobs, 5)
+	for _, job := range append(textJobs.Jobs, compressedJobs.Jobs...) {
+		require.JSONEq(t, string(jobs[0].EventPayload), string(job.EventPayload))
+	}
+	var uncompressedCount int
+	require.NoError(t, db.QueryRowContext(ctx, `select count(*) from compressed_jobs_1 where get_byte(event_payload, 0) <> 0`).Scan(&uncompressedCount))
+	require.Equal(t, 1, uncompressedCount, "only the payload stored uncompressed in the first place should remain so")
+}
+
+func TestPayloadEncryption(t *testing.T) {
+	config.Reset()
+	c := config.New()
+	c.Set("JobsDB.payloadCompression.codec", "zstd")
+	c.Set("JobsDB.payloadEncryption.enabled", true)
+
+	pg := startPostgres(t)
+	db := pg.DB
+	ctx := context.Background()
+
+	k1, k2 := make([]byte, 32), make([]byte, 32)
+	_, err := cryptorand.Read(k1)
+	require.NoError(t, err)
+	_, err = cryptorand.Read(k2)
+	require.NoError(t, err)
+	oldKeys, err := payloadcrypto.NewLocalKeyProvider("k1", map[string][]byte{"k1": k1})
+	require.NoError(t, err)
+	rotatedKeys, err := payloadcrypto.NewLocalKeyProvider("k2", map[string][]byte{"k1": k1, "k2": k2})
+	require.NoError(t, err)
+
+	encryptedJD := Handle{config: c}
+	encryptedJD.conf.payloadColumnType = BYTEA
+	WithPayloadKeyProvider(oldKeys)(&encryptedJD)
+	require.NoError(t, encryptedJD.Setup(ReadWrite, true, "encrypted"))
+	defer encryptedJD.TearDown()
+
+	rotatedJD := Handle{config: c}
+	rotatedJD.conf.payloadColumnType = BYTEA
+	WithPayloadKeyProvider(rotatedKeys)(&rotatedJD)
+	require.NoError(t, rotatedJD.Setup(ReadWrite, true, "rotated"))
+	defer rotatedJD.TearDown()
+
+	jobs := genJobs("wsid", "cv", 2, 1)
+	require.NoError(t, encryptedJD.Store(ctx, jobs))
+
+	keyIDs := func(table string) []string {
+		rows, err := db.QueryContext(ctx, fmt.Sprintf(`select event_payload from %q order by job_id`, table))
+		require.NoError(t, err)
+		defer func() { _ = rows.Close() }()
+		var ids []string
+		for rows.Next() {
+			var payload []byte
+			require.NoError(t, rows.Scan(&payload))
+			require.NotContains(t, string(payload), "Demo Track", "payloads shouldn't be stored in plaintext")
+			id, err := payloadcrypto.KeyID(payload)
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		require.NoError(t, rows.Err())
+		return ids
+	}
+	require.Equal(t, []string{"k1", "k1"}, keyIDs("encrypted_jobs_1"))
+
+	res, err := encryptedJD.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 100, IgnoreCustomValFiltersInQuery: true})
+	require.NoError(t, err)
+	require.Len(t, res.Jobs, 2)
+	for i := range res.Jobs {
+		require.JSONEq(t, string(jobs[i].EventPayload), string(res.Jobs[i].EventPayload))
+	}
+
+	// encrypted payloads can't be read without keys
+	noKeysJD := Handle{config: config.New()}
+	noKeysJD.conf.payloadColumnType = BYTEA
+	require.NoError(t, noKeysJD.Setup(ReadWrite, false, "encrypted"))
+	defer noKeysJD.TearDown()
+	_, err = noKeysJD.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 100, IgnoreCustomValFiltersInQuery: true})
+	require.Error(t, err)
+
+	// payloads encrypted with a previous key are re-encrypted with the current one when migrated
+	txn, err := db.Begin()
+	require.NoError(t, err)
+	encrypted := dataSetT{JobTable: "encrypted_jobs_1", JobStatusTable: "encrypted_job_status_1", Index: "1"}
+	rotated := dataSetT{JobTable: "rotated_jobs_1", JobStatusTable: "rotated_job_status_1", Index: "1"}
+	migrated, err := rotatedJD.migrateJobsInTx(ctx, &tx.Tx{Tx: txn}, encrypted, rotated)
+	require.NoError(t, err)
+	require.Equal(t, 2, migrated)
+	require.NoError(t, txn.Commit())
+	require.Equal(t, []string{"k2", "k2"}, keyIDs("rotated_jobs_1"))
+
+	res, err = rotatedJD.GetUnprocessed(ctx, GetQueryParams{JobsLimit: 100, IgnoreCustomValFiltersInQuery: true})
+	require.NoError(t, err)
+	require.Len(t, res.Jobs, 2)
+	for i := range res.Jobs {
+		require.JSONEq(t, string(jobs[i].EventPayload), string(res.Jobs[i].EventPayload))
+	}
+
+	// payloads already encrypted with the current key don't need to be recoded
+	txn, err = db.Begin()
+	require.NoError(t, err)
+	recode, err := rotatedJD.needsPayloadRecoding(ctx, &tx.Tx{Tx: txn}, rotated, string(BYTEA), string(BYTEA))
+	require.NoError(t, err)
+	require.False(t, recode)
+	recode, err = rotatedJD.needsPayloadRecoding(ctx, &tx.Tx{Tx: txn}, encrypted, string(BYTEA), string(BYTEA))
+	require.NoError(t, err)
+	require.True(t, recode)
+
+	// batches are bounded by the size of their payloads, while always reading at least one job
+	batch, err := rotatedJD.readJobsToMigrateInTx(ctx, &tx.Tx{Tx: txn}, rotated, 0, 100, 1)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	batch, err = rotatedJD.readJobsToMigrateInTx(ctx, &tx.Tx{Tx: txn}, rotated, 0, 100, int64(len(batch[0].payload)+1))
+	require.NoError(t, err)
+	require.Len(t, batch, 2)
+	require.NoError(t, txn.Rollback())
+}
@@ -0,0 +1,272 @@
+package jobsdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	"github.com/rudderlabs/rudder-server/jobsdb/internal/payloadcodec"
+	"github.com/rudderlabs/rudder-server/jobsdb/internal/payloadcrypto"
+	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
+)
+
+// payloadColumnTypeOf returns the type of the payload column of a jobs table, which may differ from the configured one
+// for tables created before the configuration changed
+func (jd *Handle) payloadColumnTypeOf(ctx context.Context, tx *Tx, jobTable string) (payloadColumnType, error) {
+	if columnType, ok := jd.payloadColumnTypes.Load(jobTable); ok {
+		return columnType.(payloadColumnType), nil
+	}
+	var columnType string
+	if err := tx.QueryRowContext(
+		ctx,
+		`select data_type from information_schema.columns where table_name = $1 and column_name = 'event_payload'`,
+		jobTable,
+	).Scan(&columnType); err != nil {
+		return "", fmt.Errorf("get payload column type of %s: %w", jobTable, err)
+	}
+	jd.payloadColumnTypes.Store(jobTable, payloadColumnType(columnType))
+	return payloadColumnType(columnType), nil
+}
+
+// storablePayloads returns the values to be stored in the payload column for each job.
+// Payloads are compressed using the configured codec and then encrypted if encryption is enabled, only if the column is of bytea type.
+func (jd *Handle) storablePayloads(ctx context.Context, tx *Tx, ds dataSetT, jobList []*JobT) ([]interface{}, error) {
+	payloads := make([]interface{}, len(jobList))
+	codec := jd.conf.payloadCompression.codec
+	encrypt := jd.conf.payloadEncryption.enabled
+	if codec != payloadcodec.None || encrypt {
+		columnType, err := jd.payloadColumnTypeOf(ctx, tx, ds.JobTable)
+		if err != nil {
+			return nil, err
+		}
+		if columnType != BYTEA {
+			codec, encrypt = payloadcodec.None, false
+		}
+	}
+	if codec == payloadcodec.None && !encrypt {
+		for i, job := range jobList {
+			payloads[i] = string(job.EventPayload)
+		}
+		return payloads, nil
+	}
+
+	var uncompressedSize, compressedSize int
+	for i, job := range jobList {
+		payload, err := jd.encodePayload(codec, job.EventPayload)
+		if err != nil {
+			return nil, fmt.Errorf("encoding payload of job %s: %w", job.UUID, err)
+		}
+		uncompressedSize += len(job.EventPayload)
+		compressedSize += len(payload)
+		if encrypt {
+			if payload, err = jd.conf.payloadEncryption.encryptor.Encrypt(ctx, payload); err != nil {
+				return nil, fmt.Errorf("encrypting payload of job %s: %w", job.UUID, err)
+			}
+		}
+		payloads[i] = payload
+	}
+	if codec != payloadcodec.None {
+		tx.AddSuccessListener(func() {
+			tags := stats.Tags{"tablePrefix": jd.tablePrefix, "customVal": jd.tablePrefix, "codec": codec.String()}
+			jd.stats.NewTaggedStat("jobsdb_payload_uncompressed_bytes", stats.CountType, tags).Count(uncompressedSize)
+			jd.stats.NewTaggedStat("jobsdb_payload_compressed_bytes", stats.CountType, tags).Count(compressedSize)
+		})
+	}
+	return payloads, nil
+}
+
+// encodePayload compresses the payload using codec, unless it is smaller than the configured minimum size
+func (jd *Handle) encodePayload(codec payloadcodec.Codec, payload []byte) ([]byte, error) {
+	if len(payload) < jd.conf.payloadCompression.minSize.Load() {
+		return payload, nil
+	}
+	return codec.Encode(payload)
+}
+
+// decodePayload decrypts and decompresses a stored payload, returning plain payloads as is
+func (jd *Handle) decodePayload(ctx context.Context, payload []byte) ([]byte, error) {
+	if payloadcrypto.IsEncrypted(payload) {
+		if jd.conf.payloadEncryption.encryptor == nil {
+			return nil, errors.New("payload is encrypted but no key provider is configured")
+		}
+		var err error
+		if payload, err = jd.conf.payloadEncryption.encryptor.Decrypt(ctx, payload); err != nil {
+			return nil, err
+		}
+	}
+	return payloadcodec.Decode(payload)
+}
+
+// needsPayloadRecoding returns whether payloads need to be decoded or encoded while migrating jobs from srcDS to destDS,
+// which is the case if compressed or encrypted payloads are moved to a dataset that isn't of bytea type, or if payloads
+// are moved to a dataset of bytea type while compression or encryption is enabled. In the latter case, payloads are
+// re-encrypted if they were encrypted with a key other than the current one, which is how keys get rotated, so
+// migrations between bytea datasets only recode payloads if some of the jobs to migrate aren't encrypted with the current key.
+func (jd *Handle) needsPayloadRecoding(ctx context.Context, tx *Tx, srcDS dataSetT, srcType, destType string) (bool, error) {
+	if destType == string(BYTEA) && jd.conf.payloadEncryption.enabled {
+		if srcType != string(BYTEA) {
+			return true, nil
+		}
+		prefix := payloadcrypto.KeyPrefix(jd.conf.payloadEncryption.encryptor.CurrentKeyID())
+		var stale bool
+		if err := tx.QueryRowContext(ctx, fmt.Sprintf(
+			`select exists (select 1 from %[1]q j left join "v_last_%[2]s" js on js.job_id = j.job_id
+			where (js.job_id is null or js.job_state = ANY($1)) and substring(j.event_payload from 1 for $2) <> $3)`,
+			srcDS.JobTable, srcDS.JobStatusTable,
+		), pq.Array(validNonTerminalStates), len(prefix), prefix).Scan(&stale); err != nil {
+			return false, fmt.Errorf("check for payloads not encrypted with the current key in %s: %w", srcDS.JobTable, err)
+		}
+		return stale, nil
+	}
+	if srcType == destType {
+		return false, nil
+	}
+	if destType == string(BYTEA) {
+		return jd.conf.payloadCompression.codec != payloadcodec.None, nil
+	}
+	if srcType != string(BYTEA) {
+		return false, nil
+	}
+	var encoded bool
+	if err := tx.QueryRowContext(
+		ctx,
+		fmt.Sprintf(`select exists (select 1 from %q where substring(event_payload from 1 for 1) = '\x00'::bytea)`, srcDS.JobTable),
+	).Scan(&encoded); err != nil {
+		return false, fmt.Errorf("check for encoded payloads in %s: %w", srcDS.JobTable, err)
+	}
+	return encoded, nil
+}
+
+// migratedPayload returns the value to be stored in the payload column of a dataset of destType for a migrated payload.
+// Payloads already encrypted with the current key are kept as is.
+func (jd *Handle) migratedPayload(ctx context.Context, payload []byte, destType string) (interface{}, error) {
+	encrypt := destType == string(BYTEA) && jd.conf.payloadEncryption.enabled
+	if encrypt && payloadcrypto.IsEncrypted(payload) {
+		keyID, err := payloadcrypto.KeyID(payload)
+		if err != nil {
+			return nil, err
+		}
+		if keyID == jd.conf.payloadEncryption.encryptor.CurrentKeyID() {
+			return payload, nil
+		}
+	}
+	payload, err := jd.decodePayload(ctx, payload)
+	if err != nil {
+		return nil, fmt.Errorf("decoding: %w", err)
+	}
+	if destType != string(BYTEA) {
+		return string(payload), nil
+	}
+	if payload, err = jd.encodePayload(jd.conf.payloadCompression.codec, payload); err != nil {
+		return nil, fmt.Errorf("encoding: %w", err)
+	}
+	if encrypt {
+		if payload, err = jd.conf.payloadEncryption.encryptor.Encrypt(ctx, payload); err != nil {
+			return nil, fmt.Errorf("encrypting: %w", err)
+		}
+	}
+	return payload, nil
+}
+
+// migrateJobsRecodingPayloadsInTx migrates the non-terminal jobs from srcDS to destDS like [Handle.migrateJobsInTx],
+// decoding their payloads and encoding them again according to the type of the destination's payload column
+func (jd *Handle) migrateJobsRecodingPayloadsInTx(ctx context.Context, tx *Tx, srcDS, destDS dataSetT, destType string) (int, error) {
+	batchSize, batchBytes := jd.conf.migration.recodeBatchSize.Load(), jd.conf.migration.recodeBatchBytes.Load()
+	var (
+		numJobsMigrated int
+		lastJobID       int64
+	)
+	for {
+		batch, err := jd.readJobsToMigrateInTx(ctx, tx, srcDS, lastJobID, batchSize, batchBytes)
+		if err != nil {
+			return 0, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		stmt, err := tx.PrepareContext(ctx, pq.CopyIn(destDS.JobTable, "job_id", "workspace_id", "uuid", "user_id", "custom_val", "parameters", "event_payload", "event_count", "created_at", "expire_at"))
+		if err != nil {
+			return 0, err
+		}
+		for _, job := range batch {
+			value, err := jd.migratedPayload(ctx, job.payload, destType)
+			if err != nil {
+				_ = stmt.Close()
+				return 0, fmt.Errorf("migrating payload of job %d: %w", job.jobID, err)
+			}
+			if _, err := stmt.ExecContext(ctx, job.jobID, job.workspaceID, job.uuid, job.userID, job.customVal, string(job.parameters), value, job.eventCount, job.createdAt, job.expireAt); err != nil {
+				_ = stmt.Close()
+				return 0, err
+			}
+		}
+		if _, err := stmt.ExecContext(ctx); err != nil {
+			_ = stmt.Close()
+			return 0, err
+		}
+		if err := stmt.Close(); err != nil {
+			return 0, err
+		}
+		numJobsMigrated += len(batch)
+		lastJobID = batch[len(batch)-1].jobID
+	}
+
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(
+		`insert into %[1]q (job_id, job_state, attempt, exec_time, retry_time, error_code, error_response, parameters)
+		(select job_id, job_state, attempt, exec_time, retry_time, error_code, error_response, parameters from "v_last_%[2]s" where job_state = ANY($1))`,
+		destDS.JobStatusTable, srcDS.JobStatusTable,
+	), pq.Array(validNonTerminalStates)); err != nil {
+		return 0, fmt.Errorf("migrate job statuses: %w", err)
+	}
+	return numJobsMigrated, nil
+}
+
+type jobToMigrate struct {
+	jobID       int64
+	workspaceID string
+	uuid        string
+	userID      string
+	customVal   string
+	parameters  []byte
+	payload     []byte
+	eventCount  int
+	createdAt   time.Time
+	expireAt    time.Time
+}
+
+// readJobsToMigrateInTx reads up to limit jobs of srcDS after afterJobID, which are either unprocessed or in a non-terminal state.
+// Jobs are read until their payloads exceed bytesLimit, though at least one job is read.
+func (jd *Handle) readJobsToMigrateInTx(ctx context.Context, tx *Tx, srcDS dataSetT, afterJobID int64, limit int, bytesLimit int64) ([]jobToMigrate, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+		`select job_id, workspace_id, uuid, user_id, custom_val, parameters, event_payload, event_count, created_at, expire_at from (
+			select t.*, sum(octet_length(t.event_payload)) over (order by t.job_id) - octet_length(t.event_payload) as preceding_bytes from (
+				select j.job_id, j.workspace_id, j.uuid, j.user_id, j.custom_val, j.parameters, j.event_payload, j.event_count, j.created_at, j.expire_at
+				from %[1]q j left join "v_last_%[2]s" js on js.job_id = j.job_id
+				where (js.job_id is null or js.job_state = ANY($1)) and j.job_id > $2
+				order by j.job_id limit $3
+			) t
+		) b where preceding_bytes < $4
+		order by job_id`,
+		srcDS.JobTable, srcDS.JobStatusTable,
+	), pq.Array(validNonTerminalStates), afterJobID, limit, bytesLimit)
+	if err != nil {
+		return nil, fmt.Errorf("read jobs to migrate from %s: %w", srcDS.JobTable, err)
+	}
+	defer func() { _ = rows.Close() }()
+	var jobs []jobToMigrate
+	for rows.Next() {
+		var job jobToMigrate
+		if err := rows.Scan(&job.jobID, &job.workspaceID, &job.uuid, &job.userID, &job.customVal, &job.parameters, &job.payload, &job.eventCount, &job.createdAt, &job.expireAt); err != nil {
+			return nil, fmt.Errorf("scan job to migrate: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err() on jobs to migrate: %w", err)
+	}
+	return jobs, nil
+}
@@ -13,6 +13,7 @@ import (
 	http "net/http"
 	reflect "reflect"
 
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -77,3 +78,15 @@ func (mr *MockWebhookRequestHandlerMockRecorder) Shutdown() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockWebhookRequestHandler)(nil).Shutdown))
 }
+
+// UpdateMappings mocks base method.
+func (m *MockWebhookRequestHandler) UpdateMappings(sources map[string]backendconfig.SourceT) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "UpdateMappings", sources)
+}
+
+// UpdateMappings indicates an expected call of UpdateMappings.
+func (mr *MockWebhookRequestHandlerMockRecorder) UpdateMappings(sources any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMappings", reflect.TypeOf((*MockWebhookRequestHandler)(nil).UpdateMappings), sources)
+}
@@ -2,6 +2,7 @@ package processor
 
 import (
 	"fmt"
+	"strconv"
 
 	"github.com/samber/lo"
 
@@ -9,6 +10,7 @@ import (
 	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/internal/tcf"
 	"github.com/rudderlabs/rudder-server/processor/types"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 )
@@ -18,6 +20,7 @@ type ConsentManagementInfo struct {
 	AllowedConsentIDs  interface{} `json:"allowedConsentIds"` // Not used currently but added for future use
 	Provider           string      `json:"provider"`
 	ResolutionStrategy string      `json:"resolutionStrategy"`
+	TCString           string      `json:"tcString"` // IAB TCF v2.2 consent string
 }
 
 type GenericConsentManagementProviderData struct {
@@ -35,11 +38,70 @@ type GenericConsentManagementProviderConfig struct {
 	Consents           []GenericConsentsConfig `json:"consents"`
 }
 
+// IABConsentConfig holds the IAB TCF consents a destination requires, configured as iabTcfConsent in its config
+type IABConsentConfig struct {
+	// VendorID is the destination's vendor ID in the Global Vendor List, if any.
+	// If set, the vendor must have the user's consent for Purposes, and an established legitimate interest for LegitimateInterestPurposes.
+	VendorID int
+	// Purposes the user must have consented to
+	Purposes []int
+	// LegitimateInterestPurposes which may be processed on the basis of a legitimate interest, unless the user objected to it
+	LegitimateInterestPurposes []int
+	// SpecialFeatures the user must have opted in
+	SpecialFeatures []int
+}
+
+// allows returns whether the consents of the TC string allow sending events to the destination
+func (c *IABConsentConfig) allows(tcString *tcf.ConsentString) bool {
+	if tcString == nil {
+		return false
+	}
+	restriction := func(purposeID int) (tcf.RestrictionType, bool) {
+		if c.VendorID == 0 {
+			return 0, false
+		}
+		return tcString.PublisherRestriction(purposeID, c.VendorID)
+	}
+	hasConsent := func(purposeID int) bool {
+		return tcString.PurposeConsent(purposeID) && (c.VendorID == 0 || tcString.VendorConsent(c.VendorID))
+	}
+	for _, purposeID := range c.Purposes {
+		if r, ok := restriction(purposeID); ok && r == tcf.RestrictionNotAllowed {
+			return false
+		}
+		if !hasConsent(purposeID) {
+			return false
+		}
+	}
+	for _, purposeID := range c.LegitimateInterestPurposes {
+		r, ok := restriction(purposeID)
+		switch {
+		case ok && r == tcf.RestrictionNotAllowed:
+			return false
+		case ok && r == tcf.RestrictionRequireConsent:
+			if !hasConsent(purposeID) {
+				return false
+			}
+		default:
+			if !tcString.PurposeLegitimateInterest(purposeID) || (c.VendorID != 0 && !tcString.VendorLegitimateInterest(c.VendorID)) {
+				return false
+			}
+		}
+	}
+	for _, featureID := range c.SpecialFeatures {
+		if !tcString.SpecialFeatureOptIn(featureID) {
+			return false
+		}
+	}
+	return true
+}
+
 /*
 Filters and returns destinations based on the consents configured for the destination and the user consents present in the event.
 
-Supports legacy and generic consent management.
+Supports legacy and generic consent management, along with IAB TCF v2.2 consent strings.
 For GCM based filtering, uses source and destination IDs to fetch the appropriate GCM data from the config.
+Destinations requiring IAB consents are filtered out if the event's TC string cannot be decoded.
 */
 func (proc *Handle) getConsentFilteredDestinations(event types.SingularEventT, sourceID string, destinations []backendconfig.DestinationT) []backendconfig.DestinationT {
 	// If the event does not have denied consent IDs, do not filter any destinations
@@ -49,11 +111,29 @@ func (proc *Handle) getConsentFilteredDestinations(event types.SingularEventT, s
 		proc.logger.Errorn("failed to get consent management info", obskit.Error(err))
 	}
 
-	if len(consentManagementInfo.DeniedConsentIDs) == 0 {
+	if len(consentManagementInfo.DeniedConsentIDs) == 0 && consentManagementInfo.TCString == "" {
 		return destinations
 	}
 
+	var tcString *tcf.ConsentString
+	if consentManagementInfo.TCString != "" {
+		if tcString, err = tcf.Decode(consentManagementInfo.TCString); err != nil {
+			proc.logger.Warnn("failed to decode IAB TC string", obskit.Error(err))
+		}
+	}
+
 	return lo.Filter(destinations, func(dest backendconfig.DestinationT, _ int) bool {
+		// IAB TCF consent management
+		if consentManagementInfo.TCString != "" {
+			if iabConsentConfig := proc.getIABConsentData(dest.ID); iabConsentConfig != nil {
+				return iabConsentConfig.allows(tcString)
+			}
+		}
+
+		if len(consentManagementInfo.DeniedConsentIDs) == 0 {
+			return true
+		}
+
 		// Generic consent management
 		if cmpData := proc.getGCMData(sourceID, dest.ID, consentManagementInfo.Provider); len(cmpData.Consents) > 0 {
 
@@ -106,6 +186,12 @@ func (proc *Handle) getKetchConsentData(destinationID string) []string {
 	return proc.config.ketchConsentCategoriesMap[destinationID]
 }
 
+func (proc *Handle) getIABConsentData(destinationID string) *IABConsentConfig {
+	proc.config.configSubscriberLock.RLock()
+	defer proc.config.configSubscriberLock.RUnlock()
+	return proc.config.iabConsentMap[destinationID]
+}
+
 func (proc *Handle) getGCMData(sourceID, destinationID, provider string) GenericConsentManagementProviderData {
 	proc.config.configSubscriberLock.RLock()
 	defer proc.config.configSubscriberLock.RUnlock()
@@ -164,6 +250,46 @@ func getKetchConsentCategories(dest *backendconfig.DestinationT) []string {
 	})
 }
 
+// getIABConsentConfig returns the IAB TCF consents required by the destination, or nil if it doesn't require any, e.g.
+//
+//	"iabTcfConsent": {"vendorId": 755, "purposes": [1, 3], "legitimateInterestPurposes": [7], "specialFeatures": [1]}
+func getIABConsentConfig(dest *backendconfig.DestinationT) *IABConsentConfig {
+	iabConsent, ok := dest.Config["iabTcfConsent"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	ids := func(key string) []int {
+		values, _ := iabConsent[key].([]interface{})
+		return lo.FilterMap(values, func(value interface{}, _ int) (int, bool) {
+			return toConsentID(value)
+		})
+	}
+	consentConfig := &IABConsentConfig{
+		Purposes:                   ids("purposes"),
+		LegitimateInterestPurposes: ids("legitimateInterestPurposes"),
+		SpecialFeatures:            ids("specialFeatures"),
+	}
+	consentConfig.VendorID, _ = toConsentID(iabConsent["vendorId"])
+	if consentConfig.VendorID == 0 && len(consentConfig.Purposes) == 0 && len(consentConfig.LegitimateInterestPurposes) == 0 && len(consentConfig.SpecialFeatures) == 0 {
+		return nil
+	}
+	return consentConfig
+}
+
+// toConsentID converts a numeric or string IAB id (vendor, purpose or special feature) to a positive integer
+func toConsentID(value interface{}) (int, bool) {
+	var id int
+	switch v := value.(type) {
+	case float64:
+		id = int(v)
+	case int:
+		id = v
+	case string:
+		id, _ = strconv.Atoi(v)
+	}
+	return id, id > 0
+}
+
 func getGenericConsentManagementData(dest *backendconfig.DestinationT) (ConsentProviderMap, error) {
 	genericConsentManagementData := make(ConsentProviderMap)
 
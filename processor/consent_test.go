@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"encoding/base64"
 	"testing"
 
 	"github.com/samber/lo"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/internal/tcf"
 	"github.com/rudderlabs/rudder-server/processor/types"
 )
 
@@ -1794,3 +1796,192 @@ func TestGetGenericConsentManagementData(t *testing.T) {
 		})
 	}
 }
+
+func TestGetIABConsentConfig(t *testing.T) {
+	require.Nil(t, getIABConsentConfig(&backendconfig.DestinationT{Config: map[string]interface{}{}}))
+	require.Nil(t, getIABConsentConfig(&backendconfig.DestinationT{Config: map[string]interface{}{"iabTcfConsent": "invalid"}}))
+	require.Nil(t, getIABConsentConfig(&backendconfig.DestinationT{Config: map[string]interface{}{"iabTcfConsent": map[string]interface{}{}}}))
+	require.Equal(t, &IABConsentConfig{
+		VendorID:                   755,
+		Purposes:                   []int{1, 3},
+		LegitimateInterestPurposes: []int{7},
+		SpecialFeatures:            []int{1},
+	}, getIABConsentConfig(&backendconfig.DestinationT{Config: map[string]interface{}{
+		"iabTcfConsent": map[string]interface{}{
+			"vendorId":                   "755",
+			"purposes":                   []interface{}{float64(1), "3", "invalid", float64(0)},
+			"legitimateInterestPurposes": []interface{}{"7"},
+			"specialFeatures":            []interface{}{float64(1)},
+		},
+	}}))
+}
+
+// tcString encodes a TC string with the given consents, along with a publisher restriction of type restrictionType on purpose 7 for vendor 755 if restrictionType >= 0
+func tcString(purposes, liPurposes, specialFeatures, vendorConsents, vendorLIs []int, restrictionType int) string {
+	var bits []bool
+	write := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, (v>>i)&1 == 1)
+		}
+	}
+	bitField := func(n int, ids []int) {
+		for i := 1; i <= n; i++ {
+			bits = append(bits, lo.Contains(ids, i))
+		}
+	}
+	write(2, 6)                                                  // Version
+	write(0, 36+36+12+12+6)                                      // Created, LastUpdated, CmpId, CmpVersion, ConsentScreen
+	write(4, 6)                                                  // ConsentLanguage: E
+	write(13, 6)                                                 // ConsentLanguage: N
+	write(0, 12+6+2)                                             // VendorListVersion, TcfPolicyVersion, IsServiceSpecific, UseNonStandardTexts
+	bitField(12, specialFeatures)                                // SpecialFeatureOptIns
+	bitField(24, purposes)                                       // PurposesConsent
+	bitField(24, liPurposes)                                     // PurposesLITransparency
+	write(0, 1)                                                  // PurposeOneTreatment
+	write(3, 6)                                                  // PublisherCC: D
+	write(4, 6)                                                  // PublisherCC: E
+	for _, vendors := range [][]int{vendorConsents, vendorLIs} { // vendor sections
+		write(1000, 16)
+		write(0, 1)
+		bitField(1000, vendors)
+	}
+	if restrictionType < 0 {
+		write(0, 12)
+	} else {
+		write(1, 12)
+		write(7, 6)
+		write(restrictionType, 2)
+		write(1, 12)
+		write(0, 1)
+		write(755, 16)
+	}
+	data := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		if bit {
+			data[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestFilterDestinationsIABConsent(t *testing.T) {
+	destinations := []backendconfig.DestinationT{
+		{
+			ID: "no-iab-config",
+			Config: map[string]interface{}{
+				"oneTrustCookieCategories": []interface{}{
+					map[string]interface{}{"oneTrustCookieCategory": "foo"},
+				},
+			},
+		},
+		{
+			ID: "purposes-only",
+			Config: map[string]interface{}{
+				"iabTcfConsent": map[string]interface{}{"purposes": []interface{}{"1", "3"}},
+			},
+		},
+		{
+			ID: "vendor",
+			Config: map[string]interface{}{
+				"iabTcfConsent": map[string]interface{}{
+					"vendorId":                   float64(755),
+					"purposes":                   []interface{}{float64(1)},
+					"legitimateInterestPurposes": []interface{}{float64(7)},
+				},
+			},
+		},
+		{
+			ID: "special-feature",
+			Config: map[string]interface{}{
+				"iabTcfConsent": map[string]interface{}{"specialFeatures": []interface{}{float64(1)}},
+			},
+		},
+	}
+	proc := &Handle{}
+	proc.logger = logger.NOP
+	proc.config.iabConsentMap = make(map[string]*IABConsentConfig)
+	proc.config.oneTrustConsentCategoriesMap = make(map[string][]string)
+	for i := range destinations {
+		proc.config.oneTrustConsentCategoriesMap[destinations[i].ID] = getOneTrustConsentCategories(&destinations[i])
+		if c := getIABConsentConfig(&destinations[i]); c != nil {
+			proc.config.iabConsentMap[destinations[i].ID] = c
+		}
+	}
+	event := func(tcString string, deniedConsentIDs ...interface{}) types.SingularEventT {
+		return types.SingularEventT{
+			"context": map[string]interface{}{
+				"consentManagement": map[string]interface{}{
+					"tcString":         tcString,
+					"deniedConsentIds": deniedConsentIDs,
+				},
+			},
+		}
+	}
+	filter := func(e types.SingularEventT) []string {
+		return lo.Map(proc.getConsentFilteredDestinations(e, "sourceID-1", destinations), func(dest backendconfig.DestinationT, _ int) string {
+			return dest.ID
+		})
+	}
+
+	testCases := []struct {
+		description     string
+		event           types.SingularEventT
+		expectedDestIDs []string
+	}{
+		{
+			description:     "no TC string",
+			event:           event(""),
+			expectedDestIDs: []string{"no-iab-config", "purposes-only", "vendor", "special-feature"},
+		},
+		{
+			description:     "all consents given",
+			event:           event(tcString([]int{1, 3}, []int{7}, []int{1}, []int{755}, []int{755}, -1)),
+			expectedDestIDs: []string{"no-iab-config", "purposes-only", "vendor", "special-feature"},
+		},
+		{
+			description:     "purpose consent missing",
+			event:           event(tcString([]int{1}, []int{7}, []int{1}, []int{755}, []int{755}, -1)),
+			expectedDestIDs: []string{"no-iab-config", "vendor", "special-feature"},
+		},
+		{
+			description:     "vendor consent missing",
+			event:           event(tcString([]int{1, 3}, []int{7}, nil, nil, []int{755}, -1)),
+			expectedDestIDs: []string{"no-iab-config", "purposes-only"},
+		},
+		{
+			description:     "objection to legitimate interest",
+			event:           event(tcString([]int{1, 3}, nil, []int{1}, []int{755}, []int{755}, -1)),
+			expectedDestIDs: []string{"no-iab-config", "purposes-only", "special-feature"},
+		},
+		{
+			description:     "publisher disallows the legitimate interest purpose for the vendor",
+			event:           event(tcString([]int{1, 3}, []int{7}, []int{1}, []int{755}, []int{755}, int(tcf.RestrictionNotAllowed))),
+			expectedDestIDs: []string{"no-iab-config", "purposes-only", "special-feature"},
+		},
+		{
+			description:     "publisher requires consent for the legitimate interest purpose, which is missing",
+			event:           event(tcString([]int{1, 3}, []int{7}, []int{1}, []int{755}, []int{755}, int(tcf.RestrictionRequireConsent))),
+			expectedDestIDs: []string{"no-iab-config", "purposes-only", "special-feature"},
+		},
+		{
+			description:     "publisher requires consent for the legitimate interest purpose, which is given",
+			event:           event(tcString([]int{1, 3, 7}, nil, []int{1}, []int{755}, nil, int(tcf.RestrictionRequireConsent))),
+			expectedDestIDs: []string{"no-iab-config", "purposes-only", "vendor", "special-feature"},
+		},
+		{
+			description:     "invalid TC string filters out destinations requiring IAB consents",
+			event:           event("invalid!"),
+			expectedDestIDs: []string{"no-iab-config"},
+		},
+		{
+			description:     "denied consent IDs still apply to destinations without IAB consents config",
+			event:           event(tcString([]int{1, 3}, []int{7}, []int{1}, []int{755}, []int{755}, -1), "foo"),
+			expectedDestIDs: []string{"purposes-only", "vendor", "special-feature"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			require.Equal(t, tc.expectedDestIDs, filter(tc.event))
+		})
+	}
+}
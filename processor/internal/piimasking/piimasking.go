@@ -0,0 +1,340 @@
+// Package piimasking masks personally identifiable information found in events, according to policies defined in source and destination configs.
+package piimasking
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+// ConfigKey is the key of a source's or destination's config containing its PII masking policy, e.g.
+//
+//	"piiMasking": {
+//	  "salt": "...",
+//	  "encryptionKey": "<base64 encoded 16, 24 or 32 bytes AES key>",
+//	  "rules": [
+//	    {"action": "hash", "paths": ["context.traits.email", "traits.email"]},
+//	    {"action": "redact", "paths": ["context.ip", "request_ip"]},
+//	    {"action": "truncate", "length": 4, "paths": ["context.traits.phone"]},
+//	    {"action": "encrypt", "paths": ["properties.addresses.*.street"]}
+//	  ]
+//	}
+//
+// Paths are dot-separated keys into the event, where array elements can be selected either by their index or all of them using "*".
+const ConfigKey = "piiMasking"
+
+// Action is a masking action applied on the fields matching a rule's paths
+type Action string
+
+const (
+	// ActionRedact removes the field from the event
+	ActionRedact Action = "redact"
+	// ActionHash replaces the field's value with the hex encoded SHA-256 hash of the salt followed by the value
+	ActionHash Action = "hash"
+	// ActionTruncate keeps only the first length characters of the field's value
+	ActionTruncate Action = "truncate"
+	// ActionEncrypt replaces the field's value with its AES-GCM ciphertext, see [Decrypt]
+	ActionEncrypt Action = "encrypt"
+)
+
+// Actions lists all supported actions
+var Actions = []Action{ActionRedact, ActionHash, ActionTruncate, ActionEncrypt}
+
+type rule struct {
+	action Action
+	length int
+	paths  [][]string
+}
+
+// Policy masks the fields of events according to a list of rules
+type Policy struct {
+	rules []rule
+	salt  []byte
+	aead  cipher.AEAD
+	// redactAll redacts all fields of events, for policies too malformed to tell which fields should be masked
+	redactAll bool
+}
+
+// Counts holds the number of fields masked per action
+type Counts map[Action]int
+
+// Parse parses a PII masking policy, as found in a destination's config or, in its raw JSON form, in a source's config.
+//
+// Parsing fails closed: rules which are invalid (e.g. having an unknown action, or requiring an encryption key which is missing)
+// are turned into redact rules for the same paths, so that their fields never leave unmasked. In that case a non-nil policy is
+// returned along with an error describing the problems. Policies which are malformed altogether (e.g. not being an object, or
+// none of their rules being usable) redact all fields of events instead. A nil policy is only returned for policies without any rules.
+func Parse(v interface{}) (*Policy, error) {
+	if raw, ok := v.([]byte); ok {
+		var parsed interface{}
+		if err := jsonrs.Unmarshal(raw, &parsed); err != nil {
+			return &Policy{redactAll: true}, fmt.Errorf("invalid policy, redacting all fields: %w", err)
+		}
+		v = parsed
+	}
+	conf, ok := v.(map[string]interface{})
+	if !ok {
+		return &Policy{redactAll: true}, errors.New("invalid policy, redacting all fields: expected an object")
+	}
+	rawRules, ok := conf["rules"].([]interface{})
+	if !ok {
+		return &Policy{redactAll: true}, errors.New("invalid policy, redacting all fields: rules must be an array")
+	}
+
+	var problems []string
+	p := &Policy{}
+	if salt, _ := conf["salt"].(string); salt != "" {
+		p.salt = []byte(salt)
+	}
+	if encryptionKey, _ := conf["encryptionKey"].(string); encryptionKey != "" {
+		aead, err := newAEAD(encryptionKey)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else {
+			p.aead = aead
+		}
+	}
+
+	for i, rawRule := range rawRules {
+		ruleConf, ok := rawRule.(map[string]interface{})
+		if !ok {
+			problems = append(problems, fmt.Sprintf("rules[%d]: expected an object", i))
+			continue
+		}
+		r := rule{action: Action(strings.ToLower(fmt.Sprint(ruleConf["action"])))}
+		rawPaths, _ := ruleConf["paths"].([]interface{})
+		for _, rawPath := range rawPaths {
+			if path, ok := rawPath.(string); ok && strings.TrimSpace(path) != "" {
+				r.paths = append(r.paths, strings.Split(strings.TrimSpace(path), "."))
+			}
+		}
+		if len(r.paths) == 0 {
+			problems = append(problems, fmt.Sprintf("rules[%d]: paths are required", i))
+			continue
+		}
+		switch r.action {
+		case ActionRedact, ActionHash:
+		case ActionTruncate:
+			length, _ := ruleConf["length"].(float64)
+			if length < 0 || length != float64(int(length)) {
+				problems = append(problems, fmt.Sprintf("rules[%d]: length must be a non-negative integer, redacting instead", i))
+				r.action = ActionRedact
+			}
+			r.length = int(length)
+		case ActionEncrypt:
+			if p.aead == nil {
+				problems = append(problems, fmt.Sprintf("rules[%d]: a valid encryptionKey is required for encrypting, redacting instead", i))
+				r.action = ActionRedact
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("rules[%d]: unknown action %q, redacting instead", i, r.action))
+			r.action = ActionRedact
+		}
+		p.rules = append(p.rules, r)
+	}
+
+	if len(problems) == 0 {
+		if len(p.rules) == 0 {
+			return nil, nil
+		}
+		return p, nil
+	}
+	if len(p.rules) == 0 {
+		return &Policy{redactAll: true}, fmt.Errorf("invalid policy, redacting all fields: %s", strings.Join(problems, "; "))
+	}
+	return p, fmt.Errorf("invalid policy: %s", strings.Join(problems, "; "))
+}
+
+func newAEAD(encryptionKey string) (cipher.AEAD, error) {
+	key, err := base64.StdEncoding.DecodeString(encryptionKey)
+	if err != nil {
+		return nil, errors.New("encryptionKey must be base64 encoded")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.New("encryptionKey must be 16, 24 or 32 bytes long")
+	}
+	return cipher.NewGCM(block)
+}
+
+// Apply returns the message with all fields matching the policy's rules masked, along with the number of fields masked per action.
+// The provided message is never modified: maps and arrays leading to masked fields are copied, while the rest of the message is shared.
+func (p *Policy) Apply(message map[string]interface{}) (map[string]interface{}, Counts) {
+	counts := make(Counts)
+	if p == nil {
+		return message, counts
+	}
+	if p.redactAll {
+		counts[ActionRedact] = len(message)
+		return make(map[string]interface{}), counts
+	}
+	result := message
+	for _, r := range p.rules {
+		for _, path := range r.paths {
+			var n int
+			result, n = p.mask(result, path, r)
+			counts[r.action] += n
+		}
+	}
+	return result, counts
+}
+
+// mask masks the fields matching path in m, returning a copy of m if any field was masked
+func (p *Policy) mask(m map[string]interface{}, path []string, r rule) (map[string]interface{}, int) {
+	value, ok := m[path[0]]
+	if !ok || value == nil {
+		return m, 0
+	}
+	if len(path) == 1 {
+		masked, keep := p.maskValue(value, r)
+		result := copyMap(m)
+		if keep {
+			result[path[0]] = masked
+		} else {
+			delete(result, path[0])
+		}
+		return result, 1
+	}
+	replaced, n := p.maskNested(value, path[1:], r)
+	if n == 0 {
+		return m, 0
+	}
+	result := copyMap(m)
+	result[path[0]] = replaced
+	return result, n
+}
+
+func (p *Policy) maskNested(value interface{}, path []string, r rule) (interface{}, int) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return p.mask(v, path, r)
+	case []interface{}:
+		var indexes []int
+		if path[0] == "*" {
+			for i := range v {
+				indexes = append(indexes, i)
+			}
+		} else if i, err := strconv.Atoi(path[0]); err == nil && i >= 0 && i < len(v) {
+			indexes = []int{i}
+		}
+		var result []interface{}
+		var total int
+		var redacted []int
+		for _, i := range indexes {
+			var replaced interface{}
+			var n int
+			keep := true
+			if len(path) == 1 {
+				if v[i] == nil {
+					continue
+				}
+				replaced, keep = p.maskValue(v[i], r)
+				n = 1
+			} else {
+				replaced, n = p.maskNested(v[i], path[1:], r)
+			}
+			if n == 0 {
+				continue
+			}
+			if result == nil {
+				result = append([]interface{}(nil), v...)
+			}
+			if keep {
+				result[i] = replaced
+			} else {
+				redacted = append(redacted, i)
+			}
+			total += n
+		}
+		if total == 0 {
+			return value, 0
+		}
+		for j := len(redacted) - 1; j >= 0; j-- {
+			result = append(result[:redacted[j]], result[redacted[j]+1:]...)
+		}
+		return result, total
+	default:
+		return value, 0
+	}
+}
+
+// maskValue returns the masked value and whether it should be kept in the event
+func (p *Policy) maskValue(value interface{}, r rule) (interface{}, bool) {
+	switch r.action {
+	case ActionHash:
+		h := sha256.New()
+		h.Write(p.salt)
+		h.Write([]byte(stringify(value)))
+		return hex.EncodeToString(h.Sum(nil)), true
+	case ActionTruncate:
+		s := []rune(stringify(value))
+		if len(s) > r.length {
+			s = s[:r.length]
+		}
+		return string(s), true
+	case ActionEncrypt:
+		nonce := make([]byte, p.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, false // never leave the value unmasked
+		}
+		return base64.StdEncoding.EncodeToString(p.aead.Seal(nonce, nonce, []byte(stringify(value)), nil)), true
+	default:
+		return nil, false
+	}
+}
+
+// Decrypt decrypts a value encrypted by a policy using the given base64 encoded key.
+// Encrypted values are the base64 encoding of the AES-GCM nonce followed by the ciphertext.
+func Decrypt(encryptionKey, value string) (string, error) {
+	aead, err := newAEAD(encryptionKey)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// stringify returns the string representation of a value, JSON encoding non-scalar values
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool, int, int64:
+		return fmt.Sprint(v)
+	default:
+		b, err := jsonrs.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,145 @@
+package piimasking_test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+
+	"github.com/rudderlabs/rudder-server/processor/internal/piimasking"
+)
+
+const encryptionKey = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=" // 32 bytes
+
+func TestParse(t *testing.T) {
+	t.Run("valid policy", func(t *testing.T) {
+		p, err := piimasking.Parse([]byte(`{"salt":"s","encryptionKey":"` + encryptionKey + `","rules":[
+			{"action":"redact","paths":["context.ip"]},
+			{"action":"hash","paths":["userId"]},
+			{"action":"truncate","length":3,"paths":["context.traits.phone"]},
+			{"action":"encrypt","paths":["context.traits.email"]}
+		]}`))
+		require.NoError(t, err)
+		require.NotNil(t, p)
+	})
+
+	t.Run("invalid rules fail closed", func(t *testing.T) {
+		p, err := piimasking.Parse(map[string]interface{}{
+			"rules": []interface{}{
+				map[string]interface{}{"action": "encrypt", "paths": []interface{}{"email"}},
+				map[string]interface{}{"action": "unknown", "paths": []interface{}{"phone"}},
+			},
+		})
+		require.Error(t, err)
+		require.NotNil(t, p)
+
+		masked, counts := p.Apply(map[string]interface{}{"email": "a@b.c", "phone": "123", "name": "n"})
+		require.Equal(t, map[string]interface{}{"name": "n"}, masked)
+		require.Equal(t, 2, counts[piimasking.ActionRedact])
+	})
+
+	t.Run("malformed policy redacts all fields", func(t *testing.T) {
+		for _, v := range []interface{}{
+			[]byte(`not json`),
+			[]byte(`["context.ip"]`),
+			"a string",
+			map[string]interface{}{"rules": "not an array"},
+			map[string]interface{}{"rules": []interface{}{map[string]interface{}{"action": "hash"}}},
+		} {
+			p, err := piimasking.Parse(v)
+			require.Error(t, err)
+			require.NotNil(t, p)
+
+			masked, counts := p.Apply(map[string]interface{}{"userId": "u", "context": map[string]interface{}{"ip": "1.2.3.4"}})
+			require.Empty(t, masked)
+			require.Equal(t, 2, counts[piimasking.ActionRedact])
+		}
+	})
+
+	t.Run("policy without rules", func(t *testing.T) {
+		p, err := piimasking.Parse(map[string]interface{}{"rules": []interface{}{}})
+		require.NoError(t, err)
+		require.Nil(t, p)
+	})
+}
+
+func TestApply(t *testing.T) {
+	p, err := piimasking.Parse([]byte(`{"salt":"salt","encryptionKey":"` + encryptionKey + `","rules":[
+		{"action":"redact","paths":["context.ip","properties.cards.*.cvv","missing.path"]},
+		{"action":"hash","paths":["userId"]},
+		{"action":"truncate","length":3,"paths":["context.traits.phone"]},
+		{"action":"encrypt","paths":["context.traits.email","properties.cards.0.number"]}
+	]}`))
+	require.NoError(t, err)
+
+	var message map[string]interface{}
+	require.NoError(t, jsonrs.Unmarshal([]byte(`{
+		"userId": "user-1",
+		"context": {"ip": "1.2.3.4", "traits": {"email": "a@b.c", "phone": "+301234567"}},
+		"properties": {"cards": [{"number": "4111", "cvv": "123"}, {"number": "5500", "cvv": "456"}]},
+		"event": "Order Completed"
+	}`), &message))
+	original, err := jsonrs.Marshal(message)
+	require.NoError(t, err)
+
+	masked, counts := p.Apply(message)
+
+	after, err := jsonrs.Marshal(message)
+	require.NoError(t, err)
+	require.JSONEq(t, string(original), string(after), "the original message should not be modified")
+
+	require.Equal(t, piimasking.Counts{
+		piimasking.ActionRedact:   3,
+		piimasking.ActionHash:     1,
+		piimasking.ActionTruncate: 1,
+		piimasking.ActionEncrypt:  2,
+	}, counts)
+
+	hash := sha256.Sum256([]byte("saltuser-1"))
+	require.Equal(t, hex.EncodeToString(hash[:]), masked["userId"])
+	require.Equal(t, "Order Completed", masked["event"])
+
+	context := masked["context"].(map[string]interface{})
+	require.NotContains(t, context, "ip")
+	traits := context["traits"].(map[string]interface{})
+	require.Equal(t, "+30", traits["phone"])
+	email, err := piimasking.Decrypt(encryptionKey, traits["email"].(string))
+	require.NoError(t, err)
+	require.Equal(t, "a@b.c", email)
+
+	cards := masked["properties"].(map[string]interface{})["cards"].([]interface{})
+	require.Len(t, cards, 2)
+	number, err := piimasking.Decrypt(encryptionKey, cards[0].(map[string]interface{})["number"].(string))
+	require.NoError(t, err)
+	require.Equal(t, "4111", number)
+	require.Equal(t, map[string]interface{}{"number": "5500"}, cards[1])
+
+	t.Run("redacting array elements", func(t *testing.T) {
+		p, err := piimasking.Parse(map[string]interface{}{
+			"rules": []interface{}{map[string]interface{}{"action": "redact", "paths": []interface{}{"emails.*"}}},
+		})
+		require.NoError(t, err)
+		masked, counts := p.Apply(map[string]interface{}{"emails": []interface{}{"a@b.c", "d@e.f"}})
+		require.Equal(t, map[string]interface{}{"emails": []interface{}{}}, masked)
+		require.Equal(t, 2, counts[piimasking.ActionRedact])
+	})
+
+	t.Run("nil policy", func(t *testing.T) {
+		var p *piimasking.Policy
+		m := map[string]interface{}{"userId": "u"}
+		masked, counts := p.Apply(m)
+		require.Equal(t, m, masked)
+		require.Empty(t, counts)
+	})
+}
+
+func TestDecrypt(t *testing.T) {
+	_, err := piimasking.Decrypt(encryptionKey, base64.StdEncoding.EncodeToString([]byte("short")))
+	require.Error(t, err)
+	_, err = piimasking.Decrypt("invalid key", "")
+	require.Error(t, err)
+}
@@ -0,0 +1,284 @@
+// Package tcf decodes IAB Transparency & Consent Framework v2.2 consent strings (TC strings).
+//
+// Only the core segment is decoded, which holds the user's purpose, special feature and vendor consents along with
+// the publisher's restrictions. The optional segments which may follow it (disclosed vendors, publisher purposes) are ignored.
+package tcf
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Version is the only TC string version supported
+const Version = 2
+
+// RestrictionType is the type of a publisher restriction on a purpose for a vendor
+type RestrictionType int
+
+const (
+	// RestrictionNotAllowed disallows the vendor to process data for the purpose
+	RestrictionNotAllowed RestrictionType = 0
+	// RestrictionRequireConsent requires consent for the purpose, even if the vendor declared a legitimate interest
+	RestrictionRequireConsent RestrictionType = 1
+	// RestrictionRequireLegitimateInterest requires a legitimate interest for the purpose, even if the vendor declared consent
+	RestrictionRequireLegitimateInterest RestrictionType = 2
+)
+
+// ErrInvalid is wrapped by errors returned when decoding malformed TC strings
+var ErrInvalid = errors.New("invalid TC string")
+
+// ConsentString is a decoded TC string's core segment
+type ConsentString struct {
+	Version             int
+	Created             time.Time
+	LastUpdated         time.Time
+	CmpID               int
+	CmpVersion          int
+	ConsentScreen       int
+	ConsentLanguage     string
+	VendorListVersion   int
+	TcfPolicyVersion    int
+	IsServiceSpecific   bool
+	UseNonStandardTexts bool
+	PurposeOneTreatment bool
+	PublisherCC         string
+
+	specialFeatureOptIns     idSet
+	purposesConsent          idSet
+	purposesLITransparency   idSet
+	vendorConsents           idSet
+	vendorLegitimateInterest idSet
+	publisherRestrictions    []publisherRestriction
+}
+
+// publisherRestriction is a restriction of a purpose for the vendors of its ranges, kept as ranges rather than
+// expanded as restrictions may cover many vendors for each purpose
+type publisherRestriction struct {
+	purposeID       int
+	restrictionType RestrictionType
+	vendors         []idRange
+}
+
+// idSet is a bit set of ids
+type idSet []uint64
+
+func newIDSet(maxID int) idSet { return make(idSet, maxID/64+1) }
+
+func (s idSet) add(id int) { s[id/64] |= 1 << (id % 64) }
+
+func (s idSet) has(id int) bool {
+	return id >= 0 && id/64 < len(s) && s[id/64]&(1<<(id%64)) != 0
+}
+
+// idRange is an inclusive range of ids
+type idRange struct {
+	start, end int
+}
+
+// SpecialFeatureOptIn returns whether the user opted in the special feature
+func (c *ConsentString) SpecialFeatureOptIn(id int) bool { return c.specialFeatureOptIns.has(id) }
+
+// PurposeConsent returns whether the user consented to the purpose
+func (c *ConsentString) PurposeConsent(id int) bool { return c.purposesConsent.has(id) }
+
+// PurposeLegitimateInterest returns whether the legitimate interest for the purpose was established, i.e. the user didn't object to it
+func (c *ConsentString) PurposeLegitimateInterest(id int) bool {
+	return c.purposesLITransparency.has(id)
+}
+
+// VendorConsent returns whether the user consented to the vendor
+func (c *ConsentString) VendorConsent(id int) bool { return c.vendorConsents.has(id) }
+
+// VendorLegitimateInterest returns whether the vendor's legitimate interest was established, i.e. the user didn't object to it
+func (c *ConsentString) VendorLegitimateInterest(id int) bool {
+	return c.vendorLegitimateInterest.has(id)
+}
+
+// PublisherRestriction returns the publisher's restriction on the purpose for the vendor, if any
+func (c *ConsentString) PublisherRestriction(purposeID, vendorID int) (RestrictionType, bool) {
+	// later restrictions take precedence over earlier ones for the same purpose and vendor
+	for i := len(c.publisherRestrictions) - 1; i >= 0; i-- {
+		restriction := c.publisherRestrictions[i]
+		if restriction.purposeID != purposeID {
+			continue
+		}
+		for _, vendors := range restriction.vendors {
+			if vendorID >= vendors.start && vendorID <= vendors.end {
+				return restriction.restrictionType, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Decode decodes a TC string, returning an error wrapping [ErrInvalid] if it is malformed or not a v2 TC string
+func Decode(s string) (*ConsentString, error) {
+	core, _, _ := strings.Cut(strings.TrimSpace(s), ".")
+	if core == "" {
+		return nil, fmt.Errorf("%w: empty", ErrInvalid)
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(core, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	r := &bitReader{data: data}
+	c := &ConsentString{}
+	if c.Version = r.int(6); c.Version != Version {
+		if r.err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, r.err)
+		}
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalid, c.Version)
+	}
+	c.Created = r.time()
+	c.LastUpdated = r.time()
+	c.CmpID = r.int(12)
+	c.CmpVersion = r.int(12)
+	c.ConsentScreen = r.int(6)
+	c.ConsentLanguage = r.letters()
+	c.VendorListVersion = r.int(12)
+	c.TcfPolicyVersion = r.int(6)
+	c.IsServiceSpecific = r.bool()
+	c.UseNonStandardTexts = r.bool()
+	c.specialFeatureOptIns = r.bitField(12)
+	c.purposesConsent = r.bitField(24)
+	c.purposesLITransparency = r.bitField(24)
+	c.PurposeOneTreatment = r.bool()
+	c.PublisherCC = r.letters()
+	c.vendorConsents = r.vendors()
+	c.vendorLegitimateInterest = r.vendors()
+	c.publisherRestrictions = r.publisherRestrictions()
+	if r.err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, r.err)
+	}
+	return c, nil
+}
+
+var errTruncated = errors.New("truncated core segment")
+
+// bitReader reads big-endian bit fields, recording the first error encountered
+type bitReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *bitReader) int(bits int) int {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos+bits > len(r.data)*8 {
+		r.err = errTruncated
+		return 0
+	}
+	var v int
+	for i := 0; i < bits; i++ {
+		bit := (r.data[r.pos/8] >> (7 - r.pos%8)) & 1
+		v = v<<1 | int(bit)
+		r.pos++
+	}
+	return v
+}
+
+func (r *bitReader) bool() bool { return r.int(1) == 1 }
+
+// time reads a timestamp encoded as deciseconds since the epoch
+func (r *bitReader) time() time.Time {
+	return time.UnixMilli(int64(r.int(36)) * 100).UTC()
+}
+
+// letters reads a two letters code, each letter being encoded as its offset from 'A' in 6 bits
+func (r *bitReader) letters() string {
+	a, b := r.int(6), r.int(6)
+	if r.err != nil {
+		return ""
+	}
+	if a > 25 || b > 25 {
+		r.err = fmt.Errorf("invalid letters %d, %d", a, b)
+		return ""
+	}
+	return string([]byte{byte('A' + a), byte('A' + b)})
+}
+
+// bitField reads a field of the given number of bits, where bit i (starting at 1) being set means id i is set
+func (r *bitReader) bitField(bits int) idSet {
+	ids := newIDSet(bits)
+	for i := 1; i <= bits && r.err == nil; i++ {
+		if r.bool() {
+			ids.add(i)
+		}
+	}
+	return ids
+}
+
+// vendors reads a vendor section, encoded either as a bit field or as ranges
+func (r *bitReader) vendors() idSet {
+	maxVendorID := r.int(16)
+	if isRangeEncoding := r.bool(); !isRangeEncoding {
+		return r.bitField(maxVendorID)
+	}
+	ranges := r.ranges(maxVendorID)
+	if r.err != nil {
+		return nil
+	}
+	ids := newIDSet(maxVendorID)
+	for _, rng := range ranges {
+		for id := rng.start; id <= rng.end; id++ {
+			ids.add(id)
+		}
+	}
+	return ids
+}
+
+// maxRestrictedVendorID is the largest vendor id publisher restrictions may refer to, as they don't declare a max vendor id
+const maxRestrictedVendorID = 1<<16 - 1
+
+// ranges reads a number of entries, each being either a single id or an inclusive range of ids within [1, maxID].
+// Since ids are distinct, the ranges may cover no more than maxID ids in total, which guards against malicious
+// strings made of many large ranges.
+func (r *bitReader) ranges(maxID int) []idRange {
+	var (
+		ranges []idRange
+		total  int
+	)
+	numEntries := r.int(12)
+	for i := 0; i < numEntries && r.err == nil; i++ {
+		isRange := r.bool()
+		start := r.int(16)
+		end := start
+		if isRange {
+			end = r.int(16)
+		}
+		if r.err != nil {
+			return nil
+		}
+		if start < 1 || end < start {
+			r.err = fmt.Errorf("invalid range %d-%d", start, end)
+			return nil
+		}
+		if end > maxID {
+			r.err = fmt.Errorf("vendor id %d exceeds max vendor id %d", end, maxID)
+			return nil
+		}
+		if total += end - start + 1; total > maxID {
+			r.err = fmt.Errorf("ranges cover more than %d ids", maxID)
+			return nil
+		}
+		ranges = append(ranges, idRange{start: start, end: end})
+	}
+	return ranges
+}
+
+func (r *bitReader) publisherRestrictions() []publisherRestriction {
+	var restrictions []publisherRestriction
+	numRestrictions := r.int(12)
+	for i := 0; i < numRestrictions && r.err == nil; i++ {
+		purposeID := r.int(6)
+		restrictionType := RestrictionType(r.int(2))
+		vendors := r.ranges(maxRestrictedVendorID)
+		restrictions = append(restrictions, publisherRestriction{purposeID: purposeID, restrictionType: restrictionType, vendors: vendors})
+	}
+	return restrictions
+}
@@ -0,0 +1,231 @@
+package tcf_test
+
+import (
+	"encoding/base64"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/processor/internal/tcf"
+)
+
+// bitWriter encodes TC strings for testing
+type bitWriter struct {
+	bits []bool
+}
+
+func (w *bitWriter) int(v, bits int) *bitWriter {
+	for i := bits - 1; i >= 0; i-- {
+		w.bits = append(w.bits, (v>>i)&1 == 1)
+	}
+	return w
+}
+
+func (w *bitWriter) bool(v bool) *bitWriter {
+	if v {
+		return w.int(1, 1)
+	}
+	return w.int(0, 1)
+}
+
+func (w *bitWriter) letters(s string) *bitWriter {
+	return w.int(int(s[0]-'A'), 6).int(int(s[1]-'A'), 6)
+}
+
+func (w *bitWriter) bitField(bits int, ids ...int) *bitWriter {
+	set := make(map[int]bool)
+	for _, id := range ids {
+		set[id] = true
+	}
+	for i := 1; i <= bits; i++ {
+		w.bool(set[i])
+	}
+	return w
+}
+
+// ranges writes range entries, each being a single id ([id]) or an inclusive range ([start, end])
+func (w *bitWriter) ranges(entries ...[]int) *bitWriter {
+	w.int(len(entries), 12)
+	for _, e := range entries {
+		w.bool(len(e) == 2).int(e[0], 16)
+		if len(e) == 2 {
+			w.int(e[1], 16)
+		}
+	}
+	return w
+}
+
+func (w *bitWriter) String() string {
+	data := make([]byte, (len(w.bits)+7)/8)
+	for i, bit := range w.bits {
+		if bit {
+			data[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+var (
+	created     = time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	lastUpdated = time.Date(2025, 3, 2, 12, 30, 0, 500_000_000, time.UTC)
+)
+
+// header writes the core segment's fields up to the vendor sections
+func header(version int) *bitWriter {
+	return (&bitWriter{}).
+		int(version, 6).
+		int(int(created.UnixMilli()/100), 36).
+		int(int(lastUpdated.UnixMilli()/100), 36).
+		int(300, 12).       // CmpId
+		int(2, 12).         // CmpVersion
+		int(1, 6).          // ConsentScreen
+		letters("EN").      // ConsentLanguage
+		int(150, 12).       // VendorListVersion
+		int(5, 6).          // TcfPolicyVersion
+		bool(true).         // IsServiceSpecific
+		bool(false).        // UseNonStandardTexts
+		bitField(12, 1).    // SpecialFeatureOptIns
+		bitField(24, 1, 3). // PurposesConsent
+		bitField(24, 2, 7). // PurposesLITransparency
+		bool(false).        // PurposeOneTreatment
+		letters("DE")       // PublisherCC
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("bit field vendors", func(t *testing.T) {
+		s := header(2).
+			int(10, 16).bool(false).bitField(10, 2, 10). // vendor consents
+			int(5, 16).bool(false).bitField(5, 4).       // vendor legitimate interests
+			int(0, 12).                                  // publisher restrictions
+			String()
+
+		c, err := tcf.Decode(s)
+		require.NoError(t, err)
+		require.Equal(t, 2, c.Version)
+		require.Equal(t, created, c.Created)
+		require.Equal(t, lastUpdated, c.LastUpdated)
+		require.Equal(t, 300, c.CmpID)
+		require.Equal(t, 2, c.CmpVersion)
+		require.Equal(t, 1, c.ConsentScreen)
+		require.Equal(t, "EN", c.ConsentLanguage)
+		require.Equal(t, 150, c.VendorListVersion)
+		require.Equal(t, 5, c.TcfPolicyVersion)
+		require.True(t, c.IsServiceSpecific)
+		require.False(t, c.UseNonStandardTexts)
+		require.False(t, c.PurposeOneTreatment)
+		require.Equal(t, "DE", c.PublisherCC)
+
+		require.True(t, c.SpecialFeatureOptIn(1))
+		require.False(t, c.SpecialFeatureOptIn(2))
+		for purpose := 1; purpose <= 11; purpose++ {
+			require.Equal(t, purpose == 1 || purpose == 3, c.PurposeConsent(purpose), "purpose %d", purpose)
+			require.Equal(t, purpose == 2 || purpose == 7, c.PurposeLegitimateInterest(purpose), "purpose %d", purpose)
+		}
+		for vendor := 1; vendor <= 12; vendor++ {
+			require.Equal(t, vendor == 2 || vendor == 10, c.VendorConsent(vendor), "vendor %d", vendor)
+			require.Equal(t, vendor == 4, c.VendorLegitimateInterest(vendor), "vendor %d", vendor)
+		}
+		_, ok := c.PublisherRestriction(1, 2)
+		require.False(t, ok)
+	})
+
+	t.Run("range vendors and publisher restrictions", func(t *testing.T) {
+		s := header(2).
+			int(800, 16).bool(true).ranges([]int{5}, []int{100, 102}, []int{755}). // vendor consents
+			int(800, 16).bool(true).ranges([]int{755, 800}).                       // vendor legitimate interests
+			int(2, 12).                                                            // publisher restrictions
+			int(3, 6).int(int(tcf.RestrictionNotAllowed), 2).ranges([]int{755}).
+			int(7, 6).int(int(tcf.RestrictionRequireConsent), 2).ranges([]int{100, 101}).
+			String()
+
+		// optional segments following the core segment are ignored
+		c, err := tcf.Decode(s + ".IFoEUQQgAIQwgIwQABAEAAAAOIAACAIAAAAQAIAgEAACEAAAAAgAQBAAAAAAAGBAAgAAAAAAAFAAECAAAgAAQARAEQAAAAAJAAIAAgAAAYQEAAAQmAgBC3ZAYzUw")
+		require.NoError(t, err)
+
+		for _, vendor := range []int{5, 100, 101, 102, 755} {
+			require.True(t, c.VendorConsent(vendor), "vendor %d", vendor)
+		}
+		for _, vendor := range []int{1, 4, 6, 99, 103, 754, 756} {
+			require.False(t, c.VendorConsent(vendor), "vendor %d", vendor)
+		}
+		require.True(t, c.VendorLegitimateInterest(755))
+		require.True(t, c.VendorLegitimateInterest(800))
+		require.False(t, c.VendorLegitimateInterest(754))
+
+		restriction, ok := c.PublisherRestriction(3, 755)
+		require.True(t, ok)
+		require.Equal(t, tcf.RestrictionNotAllowed, restriction)
+		restriction, ok = c.PublisherRestriction(7, 101)
+		require.True(t, ok)
+		require.Equal(t, tcf.RestrictionRequireConsent, restriction)
+		_, ok = c.PublisherRestriction(7, 755)
+		require.False(t, ok)
+	})
+
+	t.Run("malicious ranges", func(t *testing.T) {
+		allocated := func(f func()) uint64 {
+			var before, after runtime.MemStats
+			runtime.ReadMemStats(&before)
+			f()
+			runtime.ReadMemStats(&after)
+			return after.TotalAlloc - before.TotalAlloc
+		}
+		entries := make([][]int, 4095)
+		for i := range entries {
+			entries[i] = []int{1, 65535}
+		}
+
+		// vendor ranges covering more ids than the max vendor id get rejected before being expanded
+		s := header(2).int(65535, 16).bool(true).ranges(entries...).int(0, 16).bool(false).int(0, 12).String()
+		require.Less(t, allocated(func() {
+			_, err := tcf.Decode(s)
+			require.ErrorIs(t, err, tcf.ErrInvalid)
+		}), uint64(1<<20))
+
+		// restrictions are kept as ranges, whatever the number of vendors they cover
+		w := header(2).int(0, 16).bool(false).int(0, 16).bool(false).int(4095, 12)
+		for i := range 4095 {
+			w.int(i%24+1, 6).int(int(tcf.RestrictionRequireConsent), 2).ranges([]int{1, 65535})
+		}
+		s = w.String()
+		require.Less(t, allocated(func() {
+			c, err := tcf.Decode(s)
+			require.NoError(t, err)
+			restriction, ok := c.PublisherRestriction(24, 65535)
+			require.True(t, ok)
+			require.Equal(t, tcf.RestrictionRequireConsent, restriction)
+		}), uint64(1<<20))
+
+		// a restriction may not cover more vendors than there can be
+		s = header(2).int(0, 16).bool(false).int(0, 16).bool(false).int(1, 12).
+			int(1, 6).int(int(tcf.RestrictionNotAllowed), 2).ranges([]int{1, 65535}, []int{1}).String()
+		_, err := tcf.Decode(s)
+		require.ErrorIs(t, err, tcf.ErrInvalid)
+	})
+
+	t.Run("invalid strings", func(t *testing.T) {
+		valid := header(2).int(0, 16).bool(false).int(0, 16).bool(false).int(0, 12)
+		_, err := tcf.Decode(valid.String())
+		require.NoError(t, err)
+
+		for name, s := range map[string]string{
+			"empty":              "",
+			"not base64":         "not*base64!",
+			"unsupported v1":     header(1).int(0, 16).bool(false).int(0, 16).bool(false).int(0, 12).String(),
+			"truncated":          header(2).int(10, 16).bool(false).String(),
+			"vendor out of max":  header(2).int(5, 16).bool(true).ranges([]int{6}).int(0, 16).bool(false).int(0, 12).String(),
+			"reversed range":     header(2).int(50, 16).bool(true).ranges([]int{10, 5}).int(0, 16).bool(false).int(0, 12).String(),
+			"vendor id zero":     header(2).int(50, 16).bool(true).ranges([]int{0, 5}).int(0, 16).bool(false).int(0, 12).String(),
+			"overlapping ranges": header(2).int(50, 16).bool(true).ranges([]int{1, 50}, []int{50}).int(0, 16).bool(false).int(0, 12).String(),
+			"too short for head": "CQ",
+		} {
+			t.Run(name, func(t *testing.T) {
+				c, err := tcf.Decode(s)
+				require.ErrorIs(t, err, tcf.ErrInvalid)
+				require.Nil(t, c)
+			})
+		}
+	})
+}
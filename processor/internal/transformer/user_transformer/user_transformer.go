@@ -23,6 +23,7 @@ import (
 	transformerclient "github.com/rudderlabs/rudder-server/internal/transformer-client"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
 	transformerutils "github.com/rudderlabs/rudder-server/processor/internal/transformer"
+	"github.com/rudderlabs/rudder-server/processor/internal/transformer/user_transformer/wasm"
 	"github.com/rudderlabs/rudder-server/processor/types"
 	"github.com/rudderlabs/rudder-server/utils/httputil"
 	reportingtypes "github.com/rudderlabs/rudder-server/utils/types"
@@ -38,6 +39,11 @@ func ForMirroring() Opt {
 	return func(s *Client) { s.config.forMirroring = true }
 }
 
+// WithWasmRuntime runs transformation versions having a wasm module in-process using the given runtime
+func WithWasmRuntime(runtime *wasm.Runtime) Opt {
+	return func(s *Client) { s.wasm = runtime }
+}
+
 func New(conf *config.Config, log logger.Logger, stat stats.Stats, opts ...Opt) *Client {
 	handle := &Client{}
 	handle.conf = conf
@@ -59,6 +65,7 @@ func New(conf *config.Config, log logger.Logger, stat stats.Stats, opts ...Opt)
 
 	if handle.config.forMirroring {
 		handle.config.userTransformationURL = handle.conf.GetString("USER_TRANSFORM_MIRROR_URL", "")
+		handle.wasm = nil // mirroring always targets the remote transformer
 	}
 
 	return handle
@@ -80,6 +87,7 @@ type Client struct {
 	log    logger.Logger
 	stat   stats.Stats
 	client transformerclient.Client
+	wasm   *wasm.Runtime
 }
 
 func (u *Client) Transform(ctx context.Context, clientEvents []types.TransformerEvent) types.Response {
@@ -87,9 +95,16 @@ func (u *Client) Transform(ctx context.Context, clientEvents []types.Transformer
 		return types.Response{}
 	}
 	batchSize := u.config.batchSize.Load()
-	var transformationID string
+	var transformationID, transformationVersionID string
 	if len(clientEvents[0].Destination.Transformations) > 0 {
 		transformationID = clientEvents[0].Destination.Transformations[0].ID
+		transformationVersionID = clientEvents[0].Destination.Transformations[0].VersionID
+	}
+
+	// transformation versions without a wasm module fall back to the remote transformer
+	var wasmModule *wasm.Module
+	if u.wasm != nil {
+		wasmModule = u.wasm.Module(ctx, transformationVersionID)
 	}
 
 	userURL := u.userTransformURL()
@@ -104,6 +119,9 @@ func (u *Client) Transform(ctx context.Context, clientEvents []types.Transformer
 		TransformationID: transformationID,
 		Mirroring:        u.config.forMirroring,
 	}
+	if wasmModule != nil {
+		labels.Endpoint = "wasm"
+	}
 
 	var trackWg sync.WaitGroup
 	defer trackWg.Wait()
@@ -134,7 +152,11 @@ func (u *Client) Transform(ctx context.Context, clientEvents []types.Transformer
 		batches,
 		func(batch []types.TransformerEvent, i int) {
 			go func() {
-				transformResponse[i] = u.sendBatch(ctx, u.userTransformURL(), labels, batch)
+				if wasmModule != nil {
+					transformResponse[i] = u.transformWasmBatch(ctx, wasmModule, labels, batch)
+				} else {
+					transformResponse[i] = u.sendBatch(ctx, u.userTransformURL(), labels, batch)
+				}
 				wg.Done()
 			}()
 		},
@@ -180,15 +202,7 @@ func (u *Client) sendBatch(ctx context.Context, url string, labels types.Transfo
 		err     error
 	)
 
-	data := lo.Map(clientEvents, func(clientEvent types.TransformerEvent, index int) types.UserTransformerEvent {
-		res := *clientEvent.ToUserTransformerEvent()
-		// flip sourceID and originalSourceID if it's a replay source for the purpose of any user transformation
-		// flip back afterward
-		if res.Metadata.OriginalSourceID != "" {
-			res.Metadata.OriginalSourceID, res.Metadata.SourceID = res.Metadata.SourceID, res.Metadata.OriginalSourceID
-		}
-		return res
-	})
+	data := toUserTransformerEvents(clientEvents)
 
 	rawJSON, err = jsonrs.Marshal(data)
 	if err != nil {
@@ -273,6 +287,41 @@ func (u *Client) sendBatch(ctx context.Context, url string, labels types.Transfo
 	return transformerResponses
 }
 
+// transformWasmBatch runs the transformation of a batch in-process using its wasm module.
+// Only the events the module reports as failed are failed, whereas batches the module fails to transform altogether,
+// e.g. because it couldn't be instantiated, trapped, ran out of memory or timed out, are sent to the remote transformer instead.
+func (u *Client) transformWasmBatch(ctx context.Context, module *wasm.Module, labels types.TransformerMetricLabels, clientEvents []types.TransformerEvent) []types.TransformerResponse {
+	if len(clientEvents) == 0 {
+		return nil
+	}
+	start := time.Now()
+	transformerResponses, err := module.Transform(ctx, toUserTransformerEvents(clientEvents))
+	if err != nil {
+		u.log.Warnn("Wasm user transformation failed, falling back to the remote transformer", append(labels.ToLoggerFields(), obskit.Error(err))...)
+		u.stat.NewTaggedStat("transformer_client_wasm_fallbacks", stats.CountType, labels.ToStatsTag()).Increment()
+		remoteLabels := labels
+		remoteLabels.Endpoint = transformerutils.GetEndpointFromURL(u.userTransformURL())
+		return u.sendBatch(ctx, u.userTransformURL(), remoteLabels, clientEvents)
+	}
+	u.stat.NewTaggedStat("transformer_client_request_total_events", stats.CountType, labels.ToStatsTag()).Count(len(clientEvents))
+	u.stat.NewTaggedStat("transformer_client_response_total_events", stats.CountType, labels.ToStatsTag()).Count(len(transformerResponses))
+	u.stat.NewTaggedStat("transformer_client_total_time", stats.TimerType, labels.ToStatsTag()).SendTiming(time.Since(start))
+	return transformerResponses
+}
+
+// toUserTransformerEvents converts events to the payload expected by user transformations
+func toUserTransformerEvents(clientEvents []types.TransformerEvent) []types.UserTransformerEvent {
+	return lo.Map(clientEvents, func(clientEvent types.TransformerEvent, index int) types.UserTransformerEvent {
+		res := *clientEvent.ToUserTransformerEvent()
+		// flip sourceID and originalSourceID if it's a replay source for the purpose of any user transformation
+		// flip back afterward
+		if res.Metadata.OriginalSourceID != "" {
+			res.Metadata.OriginalSourceID, res.Metadata.SourceID = res.Metadata.SourceID, res.Metadata.OriginalSourceID
+		}
+		return res
+	})
+}
+
 func (u *Client) doPost(ctx context.Context, rawJSON []byte, url string, labels types.TransformerMetricLabels) ([]byte, int, error) {
 	var (
 		retryCount int
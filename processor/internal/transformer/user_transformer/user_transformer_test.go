@@ -7,6 +7,8 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"slices"
 	"strconv"
 	"sync/atomic"
@@ -15,6 +17,7 @@ import (
 
 	"github.com/rudderlabs/rudder-go-kit/jsonrs"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -30,6 +33,7 @@ import (
 	"github.com/rudderlabs/rudder-server/gateway/response"
 	transformerutils "github.com/rudderlabs/rudder-server/processor/internal/transformer"
 	"github.com/rudderlabs/rudder-server/processor/internal/transformer/user_transformer"
+	"github.com/rudderlabs/rudder-server/processor/internal/transformer/user_transformer/wasm"
 	"github.com/rudderlabs/rudder-server/processor/types"
 	"github.com/rudderlabs/rudder-server/testhelper/backendconfigtest"
 	reportingtypes "github.com/rudderlabs/rudder-server/utils/types"
@@ -857,3 +861,85 @@ func TestTransformerEvent_ToUserTransformerEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestWasmUserTransformer(t *testing.T) {
+	modulesDir := t.TempDir()
+	cmd := exec.Command("go", "build", "-buildmode=c-shared", "-o", filepath.Join(modulesDir, "wasm-version.wasm"), "./wasm/testdata/guest")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	ft := &fakeTransformer{t: t}
+	srv := httptest.NewServer(ft)
+	defer srv.Close()
+
+	conf := config.New()
+	conf.Set("USER_TRANSFORM_URL", srv.URL)
+	conf.Set("Processor.UserTransformer.wasm.modulesDir", modulesDir)
+	runtime := wasm.New(context.Background(), conf, logger.NOP)
+	defer func() { _ = runtime.Close(context.Background()) }()
+	statsStore, err := memstats.New()
+	require.NoError(t, err)
+	tr := user_transformer.New(conf, logger.NOP, statsStore, user_transformer.WithClient(srv.Client()), user_transformer.WithWasmRuntime(runtime))
+
+	newEvents := func(versionID string, names ...string) []types.TransformerEvent {
+		destination := backendconfigtest.NewDestinationBuilder("WEBHOOK").WithUserTransformation("transformation-1", versionID).Build()
+		return lo.Map(names, func(name string, i int) types.TransformerEvent {
+			return types.TransformerEvent{
+				Metadata: types.Metadata{MessageID: fmt.Sprintf("messageID-%d", i), DestinationID: destination.ID},
+				Message: map[string]interface{}{
+					"event":           name,
+					"src-key-1":       name,
+					"forceStatusCode": http.StatusOK,
+				},
+				Destination: destination,
+			}
+		})
+	}
+
+	t.Run("version with a wasm module", func(t *testing.T) {
+		rsp := tr.Transform(context.Background(), newEvents("wasm-version", "Product Viewed", "drop", "fail"))
+		require.Empty(t, ft.requests, "remote transformer shouldn't be called")
+		require.Len(t, rsp.Events, 1)
+		require.Equal(t, "wasm", rsp.Events[0].Output["transformedBy"])
+		require.Equal(t, "messageID-0", rsp.Events[0].Metadata.MessageID)
+		require.Len(t, rsp.FailedEvents, 1)
+		require.Equal(t, http.StatusBadRequest, rsp.FailedEvents[0].StatusCode)
+		require.Equal(t, "messageID-2", rsp.FailedEvents[0].Metadata.MessageID)
+		metrics := statsStore.GetByName("transformer_client_request_total_events")
+		require.Len(t, metrics, 1)
+		require.Equal(t, "wasm", metrics[0].Tags["endpoint"])
+		require.EqualValues(t, 3, metrics[0].Value)
+	})
+
+	t.Run("batches the wasm module fails to transform fall back to the remote transformer", func(t *testing.T) {
+		rsp := tr.Transform(context.Background(), newEvents("wasm-version", "Product Viewed", "oom"))
+		require.Len(t, ft.requests, 1)
+		require.Len(t, rsp.Events, 2)
+		require.Empty(t, rsp.FailedEvents)
+		for _, event := range rsp.Events {
+			require.NotContains(t, event.Output, "transformedBy")
+		}
+		fallbacks := statsStore.GetByName("transformer_client_wasm_fallbacks")
+		require.Len(t, fallbacks, 1)
+		require.EqualValues(t, 1, fallbacks[0].Value)
+		ft.requests = nil
+	})
+
+	t.Run("version without a wasm module falls back to the remote transformer", func(t *testing.T) {
+		rsp := tr.Transform(context.Background(), newEvents("remote-version", "Product Viewed"))
+		require.Len(t, ft.requests, 1)
+		require.Len(t, rsp.Events, 1)
+		require.Equal(t, "Product Viewed", rsp.Events[0].Output["echo-key-1"])
+		require.NotContains(t, rsp.Events[0].Output, "transformedBy")
+	})
+
+	t.Run("mirroring always uses the remote transformer", func(t *testing.T) {
+		conf.Set("USER_TRANSFORM_MIRROR_URL", srv.URL)
+		mirror := user_transformer.New(conf, logger.NOP, stats.NOP, user_transformer.WithClient(srv.Client()), user_transformer.WithWasmRuntime(runtime), user_transformer.ForMirroring())
+		rsp := mirror.Transform(context.Background(), newEvents("wasm-version", "Product Viewed"))
+		require.Len(t, ft.requests, 2)
+		require.Len(t, rsp.Events, 1)
+		require.NotContains(t, rsp.Events[0].Output, "transformedBy")
+	})
+}
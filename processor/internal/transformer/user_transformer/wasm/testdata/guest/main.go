@@ -0,0 +1,72 @@
+// Command guest is a user transformation used for testing the wasm runtime, built with:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o guest.wasm ./testdata/guest
+//
+// It adds a "transformedBy" property to events, except for events named:
+//   - "drop": dropped
+//   - "fail": failing with a 400 status code
+//   - "loop": never completing
+//   - "oom": allocating memory until exhaustion
+package main
+
+import (
+	"encoding/json"
+	"unsafe"
+)
+
+func main() {}
+
+// buffers keeps allocated memory reachable until the instance is closed
+var buffers [][]byte
+
+//go:wasmexport alloc
+func alloc(size int32) int32 {
+	buf := make([]byte, size)
+	buffers = append(buffers, buf)
+	return int32(uintptr(unsafe.Pointer(unsafe.SliceData(buf))))
+}
+
+type event struct {
+	Message  map[string]interface{} `json:"message"`
+	Metadata json.RawMessage        `json:"metadata"`
+}
+
+type response struct {
+	Output     map[string]interface{} `json:"output,omitempty"`
+	Metadata   json.RawMessage        `json:"metadata"`
+	StatusCode int                    `json:"statusCode"`
+	Error      string                 `json:"error,omitempty"`
+}
+
+//go:wasmexport transform
+func transform(ptr, size int32) int64 {
+	input := unsafe.Slice((*byte)(unsafe.Pointer(uintptr(ptr))), size)
+	var events []event
+	if err := json.Unmarshal(input, &events); err != nil {
+		panic(err)
+	}
+	responses := []response{}
+	for _, e := range events {
+		switch e.Message["event"] {
+		case "drop":
+		case "fail":
+			responses = append(responses, response{Metadata: e.Metadata, StatusCode: 400, Error: "failed by transformation"})
+		case "loop":
+			for {
+			}
+		case "oom":
+			for {
+				buffers = append(buffers, make([]byte, 1024*1024))
+			}
+		default:
+			e.Message["transformedBy"] = "wasm"
+			responses = append(responses, response{Output: e.Message, Metadata: e.Metadata, StatusCode: 200})
+		}
+	}
+	output, err := json.Marshal(responses)
+	if err != nil {
+		panic(err)
+	}
+	buffers = append(buffers, output)
+	return int64(uintptr(unsafe.Pointer(unsafe.SliceData(output))))<<32 | int64(len(output))
+}
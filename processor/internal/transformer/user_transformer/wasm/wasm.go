@@ -0,0 +1,210 @@
+// Package wasm runs user transformations compiled to WebAssembly in-process, sandboxed by a pure-Go runtime.
+//
+// A transformation version is run in-process if a module named <versionID>.wasm is found in the modules directory,
+// otherwise the remote transformer is used for it, as it is for batches the module fails to transform. Modules are compiled once and instantiated anew for every batch,
+// so that no state is shared between batches, with their memory and execution time being limited.
+//
+// Modules follow the same batch contract as the remote transformer's /customTransform endpoint and must export:
+//
+//   - memory: the module's linear memory
+//   - alloc(size i32) i32: allocates size bytes, returning a pointer to them
+//   - transform(ptr i32, size i32) i64: transforms the JSON array of events found at ptr, returning the location of
+//     the JSON array of responses it produced, packed as (pointer << 32 | size)
+//
+// Modules are instantiated with WASI preview 1 imports available, but without any filesystem, environment variables or arguments.
+// Reactor modules (e.g. built using GOOS=wasip1 -buildmode=c-shared) have their _initialize function called after instantiation.
+package wasm
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	"github.com/rudderlabs/rudder-server/processor/types"
+)
+
+const wasmPageSize = 64 * 1024
+
+// ErrTimeout is returned when a module doesn't complete transforming a batch within the configured timeout
+var ErrTimeout = errors.New("wasm transformation timed out")
+
+// Runtime compiles and runs transformation modules
+type Runtime struct {
+	log        logger.Logger
+	modulesDir string
+	timeout    config.ValueLoader[time.Duration]
+	lookupTTL  time.Duration
+	now        func() time.Time
+
+	runtime wazero.Runtime
+
+	modulesMu sync.Mutex
+	modules   map[string]*moduleEntry
+}
+
+// moduleEntry is the result of looking up the module of a transformation version.
+// Its module and lookedUp fields are guarded by the runtime's modulesMu, lookedUp being zero while the lookup is in progress.
+type moduleEntry struct {
+	once     sync.Once
+	module   *Module
+	lookedUp time.Time
+}
+
+// New returns a new runtime loading modules from Processor.UserTransformer.wasm.modulesDir.
+// Instances are limited to Processor.UserTransformer.wasm.memoryLimit megabytes of memory,
+// and are interrupted if a batch takes longer than Processor.UserTransformer.wasm.timeout.
+func New(ctx context.Context, conf *config.Config, log logger.Logger) *Runtime {
+	memoryLimit := conf.GetInt64Var(64, 1, "Processor.UserTransformer.wasm.memoryLimit")
+	r := &Runtime{
+		log:        log.Child("wasm"),
+		modulesDir: conf.GetStringVar("/etc/rudderstack/transformations", "Processor.UserTransformer.wasm.modulesDir"),
+		timeout:    conf.GetReloadableDurationVar(5, time.Second, "Processor.UserTransformer.wasm.timeout"),
+		lookupTTL:  conf.GetDurationVar(60, time.Second, "Processor.UserTransformer.wasm.lookupTTL"),
+		now:        time.Now,
+		runtime: wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+			WithMemoryLimitPages(uint32(memoryLimit*1024*1024/wasmPageSize)).
+			WithCloseOnContextDone(true),
+		),
+		modules: make(map[string]*moduleEntry),
+	}
+	wasi_snapshot_preview1.MustInstantiate(ctx, r.runtime)
+	return r
+}
+
+// Module returns the compiled module of the transformation version, or nil if there isn't any usable module for it,
+// in which case the transformation should be run by the remote transformer.
+// Versions without a module are looked up again after Processor.UserTransformer.wasm.lookupTTL.
+func (r *Runtime) Module(ctx context.Context, versionID string) *Module {
+	if versionID == "" || !filepath.IsLocal(versionID) {
+		return nil
+	}
+	r.modulesMu.Lock()
+	entry, ok := r.modules[versionID]
+	if !ok || (entry.module == nil && !entry.lookedUp.IsZero() && r.now().Sub(entry.lookedUp) > r.lookupTTL) {
+		entry = &moduleEntry{}
+		r.modules[versionID] = entry
+	}
+	r.modulesMu.Unlock()
+
+	entry.once.Do(func() {
+		module := r.load(ctx, versionID)
+		r.modulesMu.Lock()
+		entry.module, entry.lookedUp = module, r.now()
+		r.modulesMu.Unlock()
+	})
+	r.modulesMu.Lock()
+	defer r.modulesMu.Unlock()
+	return entry.module
+}
+
+func (r *Runtime) load(ctx context.Context, versionID string) *Module {
+	log := r.log.Withn(logger.NewStringField("transformationVersionID", versionID))
+	code, err := os.ReadFile(filepath.Join(r.modulesDir, versionID+".wasm"))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Warnn("Reading wasm transformation module, falling back to the remote transformer", obskit.Error(err))
+		}
+		return nil
+	}
+	compiled, err := r.runtime.CompileModule(ctx, code)
+	if err != nil {
+		log.Errorn("Compiling wasm transformation module, falling back to the remote transformer", obskit.Error(err))
+		return nil
+	}
+	exports := compiled.ExportedFunctions()
+	for _, name := range []string{"alloc", "transform"} {
+		if _, ok := exports[name]; !ok {
+			_ = compiled.Close(ctx)
+			log.Errorn("Invalid wasm transformation module, falling back to the remote transformer",
+				obskit.Error(fmt.Errorf("missing %q export", name)))
+			return nil
+		}
+	}
+	log.Infon("Loaded wasm transformation module")
+	return &Module{runtime: r, compiled: compiled}
+}
+
+// Close closes the runtime along with all compiled modules
+func (r *Runtime) Close(ctx context.Context) error {
+	return r.runtime.Close(ctx)
+}
+
+// Module is a compiled transformation module
+type Module struct {
+	runtime  *Runtime
+	compiled wazero.CompiledModule
+}
+
+// Transform runs the transformation on a batch of events, in a new instance of the module.
+// An error is returned if the module couldn't transform the batch, e.g. when it traps, exceeds its memory limit or times out ([ErrTimeout]).
+func (m *Module) Transform(ctx context.Context, events []types.UserTransformerEvent) ([]types.TransformerResponse, error) {
+	input, err := jsonrs.Marshal(events)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling events: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.runtime.timeout.Load())
+	defer cancel()
+
+	output, err := m.call(ctx, input)
+	if err != nil {
+		var exitErr *sys.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == sys.ExitCodeDeadlineExceeded {
+			return nil, ErrTimeout
+		}
+		return nil, err
+	}
+	var responses []types.TransformerResponse
+	if err := jsonrs.Unmarshal(output, &responses); err != nil {
+		return nil, fmt.Errorf("unmarshalling responses: %w", err)
+	}
+	return responses, nil
+}
+
+func (m *Module) call(ctx context.Context, input []byte) ([]byte, error) {
+	instance, err := m.runtime.runtime.InstantiateModule(ctx, m.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithSysWalltime().
+		WithSysNanotime().
+		WithRandSource(rand.Reader),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("instantiating module: %w", err)
+	}
+	defer func() { _ = instance.Close(context.WithoutCancel(ctx)) }()
+
+	results, err := instance.ExportedFunction("alloc").Call(ctx, api.EncodeI32(int32(len(input))))
+	if err != nil {
+		return nil, fmt.Errorf("allocating input: %w", err)
+	}
+	ptr := api.DecodeU32(results[0])
+	if !instance.Memory().Write(ptr, input) {
+		return nil, fmt.Errorf("writing input: %d bytes at %d out of memory range", len(input), ptr)
+	}
+	results, err = instance.ExportedFunction("transform").Call(ctx, uint64(ptr), uint64(len(input)))
+	if err != nil {
+		return nil, fmt.Errorf("transforming: %w", err)
+	}
+	outPtr, outSize := uint32(results[0]>>32), uint32(results[0])
+	output, ok := instance.Memory().Read(outPtr, outSize)
+	if !ok {
+		return nil, fmt.Errorf("reading output: %d bytes at %d out of memory range", outSize, outPtr)
+	}
+	// the instance's memory is released once closed
+	return append([]byte(nil), output...), nil
+}
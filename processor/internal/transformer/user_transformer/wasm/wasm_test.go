@@ -0,0 +1,138 @@
+package wasm_test
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+
+	"github.com/rudderlabs/rudder-server/processor/internal/transformer/user_transformer/wasm"
+	"github.com/rudderlabs/rudder-server/processor/types"
+)
+
+// buildGuest builds the test guest module into dir as <versionID>.wasm
+func buildGuest(t *testing.T, dir, versionID string) {
+	t.Helper()
+	cmd := exec.Command("go", "build", "-buildmode=c-shared", "-o", filepath.Join(dir, versionID+".wasm"), "./testdata/guest")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(out))
+}
+
+func TestRuntime(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	buildGuest(t, dir, "version-1")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.wasm"), []byte("not wasm"), 0o644))
+
+	c := config.New()
+	c.Set("Processor.UserTransformer.wasm.modulesDir", dir)
+	c.Set("Processor.UserTransformer.wasm.timeout", "2s")
+	c.Set("Processor.UserTransformer.wasm.memoryLimit", 64)
+	r := wasm.New(ctx, c, logger.NOP)
+	t.Cleanup(func() { _ = r.Close(ctx) })
+
+	event := func(name, messageID string) types.UserTransformerEvent {
+		return types.UserTransformerEvent{
+			Message:  types.SingularEventT{"event": name, "messageId": messageID},
+			Metadata: types.Metadata{MessageID: messageID, JobID: 1},
+		}
+	}
+
+	t.Run("missing or invalid modules", func(t *testing.T) {
+		require.Nil(t, r.Module(ctx, "unknown"))
+		require.Nil(t, r.Module(ctx, "invalid"))
+		require.Nil(t, r.Module(ctx, "../version-1"))
+		require.Nil(t, r.Module(ctx, ""))
+	})
+
+	module := r.Module(ctx, "version-1")
+	require.NotNil(t, module)
+	require.Same(t, module, r.Module(ctx, "version-1"), "compiled modules should be cached")
+
+	t.Run("transform", func(t *testing.T) {
+		responses, err := module.Transform(ctx, []types.UserTransformerEvent{
+			event("Product Viewed", "m1"),
+			event("drop", "m2"),
+			event("fail", "m3"),
+		})
+		require.NoError(t, err)
+		require.Len(t, responses, 2)
+
+		require.Equal(t, 200, responses[0].StatusCode)
+		require.Equal(t, "m1", responses[0].Metadata.MessageID)
+		require.Equal(t, "wasm", responses[0].Output["transformedBy"])
+		require.Equal(t, "Product Viewed", responses[0].Output["event"])
+
+		require.Equal(t, 400, responses[1].StatusCode)
+		require.Equal(t, "m3", responses[1].Metadata.MessageID)
+		require.Equal(t, "failed by transformation", responses[1].Error)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		start := time.Now()
+		_, err := module.Transform(ctx, []types.UserTransformerEvent{event("loop", "m1")})
+		require.ErrorIs(t, err, wasm.ErrTimeout)
+		require.Less(t, time.Since(start), 10*time.Second)
+	})
+
+	t.Run("memory limit", func(t *testing.T) {
+		_, err := module.Transform(ctx, []types.UserTransformerEvent{event("oom", "m1")})
+		require.Error(t, err)
+	})
+
+	t.Run("instances don't share state", func(t *testing.T) {
+		responses, err := module.Transform(ctx, []types.UserTransformerEvent{event("Order Completed", "m4")})
+		require.NoError(t, err)
+		require.Len(t, responses, 1)
+		require.Equal(t, "m4", responses[0].Metadata.MessageID)
+	})
+
+	t.Run("modules added later", func(t *testing.T) {
+		c := config.New()
+		c.Set("Processor.UserTransformer.wasm.modulesDir", dir)
+		c.Set("Processor.UserTransformer.wasm.lookupTTL", "0s")
+		r := wasm.New(ctx, c, logger.NOP)
+		t.Cleanup(func() { _ = r.Close(ctx) })
+
+		require.Nil(t, r.Module(ctx, "version-2"))
+		data, err := os.ReadFile(filepath.Join(dir, "version-1.wasm"))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "version-2.wasm"), data, 0o644))
+		require.NotNil(t, r.Module(ctx, "version-2"))
+	})
+
+	t.Run("concurrent lookups", func(t *testing.T) {
+		c := config.New()
+		c.Set("Processor.UserTransformer.wasm.modulesDir", dir)
+		c.Set("Processor.UserTransformer.wasm.lookupTTL", "0s")
+		r := wasm.New(ctx, c, logger.NOP)
+		t.Cleanup(func() { _ = r.Close(ctx) })
+
+		var wg sync.WaitGroup
+		modules := make([]*wasm.Module, 10)
+		for i := range modules {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 10 {
+					modules[i] = r.Module(ctx, "version-1")
+					require.Nil(t, r.Module(ctx, "unknown"))
+				}
+			}()
+		}
+		wg.Wait()
+		for _, m := range modules {
+			require.NotNil(t, m)
+			require.Same(t, modules[0], m, "a version's module should only be compiled once")
+		}
+	})
+}
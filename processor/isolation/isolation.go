@@ -3,6 +3,7 @@ package isolation
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/rudderlabs/rudder-server/jobsdb"
 )
@@ -10,12 +11,29 @@ import (
 type Mode string
 
 const (
-	ModeNone      Mode = "none"
-	ModeWorkspace Mode = "workspace"
-	ModeSource    Mode = "source"
+	ModeNone        Mode = "none"
+	ModeWorkspace   Mode = "workspace"
+	ModeSource      Mode = "source"
+	ModeDestination Mode = "destination"
+	ModeConnection  Mode = "connection"
 )
 
-// GetStrategy returns the strategy for the given isolation mode. An error is returned if the mode is invalid
+// CoversEventStream returns whether the mode isolates event stream jobs, i.e. jobs stored by the gateway, at the mode's level.
+// It doesn't for [ModeDestination] and [ModeConnection], which isolate event stream jobs by source, see [GetStrategy].
+func (m Mode) CoversEventStream() bool {
+	return m != ModeDestination && m != ModeConnection
+}
+
+// connectionPartitionSeparator separates the source and destination IDs in a connection partition
+const connectionPartitionSeparator = "/"
+
+// GetStrategy returns the strategy for the given isolation mode. An error is returned if the mode is invalid.
+//
+// Destination and connection modes isolate only jobs bound to a destination, i.e. jobs having a destination_id parameter
+// such as rETL jobs. Jobs stored by the gateway fan out to all the destinations of their source and don't have one,
+// thus they are isolated at source level in both modes: a slow transformation of one of their connections still
+// delays the source's other connections. Splitting them per connection would require processing the same job in more
+// than one partition, which jobsdb statuses can't track.
 func GetStrategy(mode Mode) (Strategy, error) {
 	switch mode {
 	case ModeNone:
@@ -24,6 +42,10 @@ func GetStrategy(mode Mode) (Strategy, error) {
 		return workspaceStrategy{}, nil
 	case ModeSource:
 		return sourceStrategy{}, nil
+	case ModeDestination:
+		return destinationStrategy{}, nil
+	case ModeConnection:
+		return connectionStrategy{}, nil
 	default:
 		return noneStrategy{}, errors.New("unsupported isolation mode")
 	}
@@ -72,3 +94,88 @@ func (sourceStrategy) ActivePartitions(ctx context.Context, db jobsdb.JobsDB) ([
 func (sourceStrategy) AugmentQueryParams(partition string, params *jobsdb.GetQueryParams) {
 	params.ParameterFilters = append(params.ParameterFilters, jobsdb.ParameterFilterT{Name: "source_id", Value: partition})
 }
+
+// destinationStrategy implements isolation at destination level.
+// Jobs that are bound to a specific destination (e.g. rETL jobs) are partitioned by their destinationID,
+// whereas jobs fanning out to all the destinations of their source are partitioned by their sourceID.
+type destinationStrategy struct{}
+
+// ActivePartitions returns the list of active destinationIDs along with the list of active sourceIDs in jobsdb
+func (destinationStrategy) ActivePartitions(ctx context.Context, db jobsdb.JobsDB) ([]string, error) {
+	destinationIDs, err := db.GetDistinctParameterValues(ctx, jobsdb.DestinationID, "")
+	if err != nil {
+		return nil, err
+	}
+	sourceIDs, err := db.GetDistinctParameterValues(ctx, jobsdb.SourceID, "")
+	if err != nil {
+		return nil, err
+	}
+	partitions := make([]string, 0, len(destinationIDs)+len(sourceIDs))
+	for _, destinationID := range destinationIDs {
+		partitions = append(partitions, connectionPartition("", destinationID))
+	}
+	for _, sourceID := range sourceIDs {
+		partitions = append(partitions, connectionPartition(sourceID, ""))
+	}
+	return partitions, nil
+}
+
+// AugmentQueryParams augments the given GetQueryParamsT by adding either the destinationID or the sourceID parameter filter, depending on the partition
+func (destinationStrategy) AugmentQueryParams(partition string, params *jobsdb.GetQueryParams) {
+	sourceID, destinationID := parseConnectionPartition(partition)
+	if destinationID != "" {
+		params.ParameterFilters = append(params.ParameterFilters, jobsdb.ParameterFilterT{Name: "destination_id", Value: destinationID})
+		return
+	}
+	params.MatchAllParameterFilters = true
+	params.ParameterFilters = append(params.ParameterFilters,
+		jobsdb.ParameterFilterT{Name: "source_id", Value: sourceID},
+		jobsdb.ParameterFilterT{Name: "destination_id", Value: ""},
+	)
+}
+
+// connectionStrategy implements isolation at connection (source & destination) level.
+// Jobs that are bound to a specific destination are partitioned by their source and destination pair,
+// whereas jobs fanning out to all the destinations of their source are partitioned by their sourceID.
+type connectionStrategy struct{}
+
+// ActivePartitions returns the list of active sourceIDs along with the list of active connections in jobsdb.
+// Connections are the source and destination pairs found in jobs, regardless of whether they are still connected, so that
+// jobs of disconnected pairs are picked up too.
+func (connectionStrategy) ActivePartitions(ctx context.Context, db jobsdb.JobsDB) ([]string, error) {
+	sourceIDs, err := db.GetDistinctParameterValues(ctx, jobsdb.SourceID, "")
+	if err != nil {
+		return nil, err
+	}
+	connections, err := db.GetDistinctParameterValues(ctx, jobsdb.Connection, "")
+	if err != nil {
+		return nil, err
+	}
+	partitions := make([]string, 0, len(sourceIDs)+len(connections))
+	partitions = append(partitions, sourceIDs...)
+	return append(partitions, connections...), nil
+}
+
+// AugmentQueryParams augments the given GetQueryParamsT by adding the partition's sourceID and destinationID as parameter filters which need to match together
+func (connectionStrategy) AugmentQueryParams(partition string, params *jobsdb.GetQueryParams) {
+	sourceID, destinationID := parseConnectionPartition(partition)
+	params.MatchAllParameterFilters = true
+	params.ParameterFilters = append(params.ParameterFilters,
+		jobsdb.ParameterFilterT{Name: "source_id", Value: sourceID},
+		jobsdb.ParameterFilterT{Name: "destination_id", Value: destinationID},
+	)
+}
+
+// connectionPartition returns the partition key for the given source and destination pair
+func connectionPartition(sourceID, destinationID string) string {
+	if destinationID == "" {
+		return sourceID
+	}
+	return sourceID + connectionPartitionSeparator + destinationID
+}
+
+// parseConnectionPartition returns the source and destination IDs of the given partition key
+func parseConnectionPartition(partition string) (sourceID, destinationID string) {
+	sourceID, destinationID, _ = strings.Cut(partition, connectionPartitionSeparator)
+	return sourceID, destinationID
+}
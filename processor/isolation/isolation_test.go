@@ -0,0 +1,98 @@
+package isolation_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/processor/isolation"
+)
+
+func TestIsolationStrategy(t *testing.T) {
+	db := &distinctValuesJobsDB{
+		values: map[jobsdb.ParameterName][]string{
+			jobsdb.SourceID:      {"src1", "src2", "src3"},
+			jobsdb.DestinationID: {"dst1", "dst2"},
+			jobsdb.Connection:    {"src1/dst1", "src2/dst1", "src3/dst1", "src2/dst2"},
+		},
+	}
+
+	t.Run("invalid mode", func(t *testing.T) {
+		_, err := isolation.GetStrategy("invalid")
+		require.Error(t, err)
+	})
+
+	t.Run("event stream coverage", func(t *testing.T) {
+		for _, mode := range []isolation.Mode{isolation.ModeNone, isolation.ModeWorkspace, isolation.ModeSource} {
+			require.True(t, mode.CoversEventStream(), mode)
+		}
+		for _, mode := range []isolation.Mode{isolation.ModeDestination, isolation.ModeConnection} {
+			require.False(t, mode.CoversEventStream(), "event stream jobs are isolated by source in %s mode", mode)
+		}
+	})
+
+	t.Run("destination", func(t *testing.T) {
+		strategy, err := isolation.GetStrategy(isolation.ModeDestination)
+		require.NoError(t, err)
+
+		t.Run("active partitions", func(t *testing.T) {
+			partitions, err := strategy.ActivePartitions(context.Background(), db)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []string{"/dst1", "/dst2", "src1", "src2", "src3"}, partitions)
+		})
+		t.Run("augment query params for destination partition", func(t *testing.T) {
+			var params jobsdb.GetQueryParams
+			strategy.AugmentQueryParams("/dst1", &params)
+			var expected jobsdb.GetQueryParams
+			expected.ParameterFilters = []jobsdb.ParameterFilterT{{Name: "destination_id", Value: "dst1"}}
+			require.Equal(t, expected, params)
+		})
+		t.Run("augment query params for source partition", func(t *testing.T) {
+			var params jobsdb.GetQueryParams
+			strategy.AugmentQueryParams("src1", &params)
+			var expected jobsdb.GetQueryParams
+			expected.MatchAllParameterFilters = true
+			expected.ParameterFilters = []jobsdb.ParameterFilterT{{Name: "source_id", Value: "src1"}, {Name: "destination_id", Value: ""}}
+			require.Equal(t, expected, params)
+		})
+	})
+
+	t.Run("connection", func(t *testing.T) {
+		strategy, err := isolation.GetStrategy(isolation.ModeConnection)
+		require.NoError(t, err)
+
+		t.Run("active partitions", func(t *testing.T) {
+			partitions, err := strategy.ActivePartitions(context.Background(), db)
+			require.NoError(t, err)
+			// src3 is no longer connected to dst1, yet its jobs still need to be picked up
+			require.ElementsMatch(t, []string{"src1", "src2", "src3", "src1/dst1", "src2/dst1", "src3/dst1", "src2/dst2"}, partitions)
+		})
+		t.Run("augment query params for connection partition", func(t *testing.T) {
+			var params jobsdb.GetQueryParams
+			strategy.AugmentQueryParams("src1/dst1", &params)
+			var expected jobsdb.GetQueryParams
+			expected.MatchAllParameterFilters = true
+			expected.ParameterFilters = []jobsdb.ParameterFilterT{{Name: "source_id", Value: "src1"}, {Name: "destination_id", Value: "dst1"}}
+			require.Equal(t, expected, params)
+		})
+		t.Run("augment query params for source partition", func(t *testing.T) {
+			var params jobsdb.GetQueryParams
+			strategy.AugmentQueryParams("src1", &params)
+			var expected jobsdb.GetQueryParams
+			expected.MatchAllParameterFilters = true
+			expected.ParameterFilters = []jobsdb.ParameterFilterT{{Name: "source_id", Value: "src1"}, {Name: "destination_id", Value: ""}}
+			require.Equal(t, expected, params)
+		})
+	})
+}
+
+type distinctValuesJobsDB struct {
+	jobsdb.JobsDB
+	values map[jobsdb.ParameterName][]string
+}
+
+func (db *distinctValuesJobsDB) GetDistinctParameterValues(_ context.Context, parameter jobsdb.ParameterName, _ string) ([]string, error) {
+	return db.values[parameter], nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/rudderlabs/rudder-server/enterprise/trackedusers"
 	"github.com/rudderlabs/rudder-server/internal/enricher"
 	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/processor/internal/transformer/user_transformer/wasm"
 	"github.com/rudderlabs/rudder-server/processor/transformer"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
 	transformationdebugger "github.com/rudderlabs/rudder-server/services/debugger/transformation"
@@ -49,6 +50,7 @@ type LifecycleManager struct {
 	enrichers                  []enricher.PipelineEnricher
 	trackedUsersReporter       trackedusers.UsersReporter
 	pendingEventsRegistry      rmetrics.PendingEventsRegistry
+	wasmRuntime                *wasm.Runtime
 }
 
 // Start starts a processor, this is not a blocking call.
@@ -107,10 +109,16 @@ func (proc *LifecycleManager) Start() error {
 }
 
 // Stop stops the processor, this is a blocking call.
+// The wasm runtime of user transformations is closed once the processor is stopped for good, i.e. when its main context is done.
 func (proc *LifecycleManager) Stop() {
 	proc.currentCancel()
 	proc.waitGroup.Wait()
 	proc.Handle.Shutdown()
+	if proc.wasmRuntime != nil && proc.mainCtx.Err() != nil {
+		if err := proc.wasmRuntime.Close(context.WithoutCancel(proc.mainCtx)); err != nil {
+			proc.Handle.logger.Warnn("Closing wasm runtime", obskit.Error(err))
+		}
+	}
 }
 
 // New creates a new Processor instance
@@ -130,16 +138,23 @@ func New(
 	pendingEventsRegistry rmetrics.PendingEventsRegistry,
 	opts ...Opts,
 ) *LifecycleManager {
+	log := logger.NewLogger().Child("processor")
+	var wasmRuntime *wasm.Runtime
+	if config.GetBool("Processor.UserTransformer.wasm.enabled", false) {
+		wasmRuntime = wasm.New(ctx, config.Default, log)
+	}
 	proc := &LifecycleManager{
 		Handle: NewHandle(
 			config.Default,
 			transformer.NewClients(
 				config.Default,
-				logger.NewLogger().Child("processor"),
+				log,
 				stats.Default,
 				transformer.WithFeatureService(transformerFeaturesService),
+				transformer.WithWasmRuntime(wasmRuntime),
 			),
 		),
+		wasmRuntime:                wasmRuntime,
 		mainCtx:                    ctx,
 		gatewayDB:                  gwDb,
 		routerDB:                   rtDb,
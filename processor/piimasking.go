@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/internal/piimasking"
+	"github.com/rudderlabs/rudder-server/processor/types"
+)
+
+// getSourcePIIMaskingPolicy returns the PII masking policy configured for the source, if any
+func (proc *Handle) getSourcePIIMaskingPolicy(source *backendconfig.SourceT) *piimasking.Policy {
+	if len(source.Config) == 0 {
+		return nil
+	}
+	raw := gjson.GetBytes(source.Config, piimasking.ConfigKey)
+	if !raw.Exists() || raw.Type == gjson.Null {
+		return nil
+	}
+	policy, err := piimasking.Parse([]byte(raw.Raw))
+	if err != nil {
+		proc.logger.Errorn("Invalid PII masking policy for source", obskit.SourceID(source.ID), obskit.Error(err))
+	}
+	return policy
+}
+
+// getDestinationPIIMaskingPolicy returns the PII masking policy configured for the destination, if any
+func (proc *Handle) getDestinationPIIMaskingPolicy(destination *backendconfig.DestinationT) *piimasking.Policy {
+	raw, ok := destination.Config[piimasking.ConfigKey]
+	if !ok || raw == nil {
+		return nil
+	}
+	policy, err := piimasking.Parse(raw)
+	if err != nil {
+		proc.logger.Errorn("Invalid PII masking policy for destination", obskit.DestinationID(destination.ID), obskit.Error(err))
+	}
+	return policy
+}
+
+// maskPII applies the PII masking policies of the source and the destination, in that order, on the events' messages.
+// Messages are shared between the events of different destinations, thus masked messages are always copies.
+func (proc *Handle) maskPII(events []types.TransformerEvent, sourceID string, destination *backendconfig.DestinationT) []types.TransformerEvent {
+	proc.config.configSubscriberLock.RLock()
+	sourcePolicy := proc.config.sourcePIIMaskingPolicies[sourceID]
+	destinationPolicy := proc.config.destinationPIIMaskingPolicies[destination.ID]
+	proc.config.configSubscriberLock.RUnlock()
+	if sourcePolicy == nil && destinationPolicy == nil {
+		return events
+	}
+
+	counts := make(piimasking.Counts)
+	for i := range events {
+		for _, policy := range []*piimasking.Policy{sourcePolicy, destinationPolicy} {
+			if policy == nil {
+				continue
+			}
+			var masked piimasking.Counts
+			events[i].Message, masked = policy.Apply(events[i].Message)
+			for action, n := range masked {
+				counts[action] += n
+			}
+		}
+	}
+
+	var workspaceID string
+	if len(events) > 0 {
+		workspaceID = events[0].Metadata.WorkspaceID
+	}
+	for action, n := range counts {
+		if n == 0 {
+			continue
+		}
+		proc.statsFactory.NewTaggedStat("processor_pii_fields_masked", stats.CountType, stats.Tags{
+			"workspaceId":   workspaceID,
+			"sourceId":      sourceID,
+			"destinationId": destination.ID,
+			"destType":      destination.DestinationDefinition.Name,
+			"action":        string(action),
+		}).Count(n)
+	}
+	return events
+}
@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/internal/piimasking"
+	"github.com/rudderlabs/rudder-server/processor/types"
+)
+
+func TestMaskPII(t *testing.T) {
+	statsStore, err := memstats.New()
+	require.NoError(t, err)
+
+	proc := &Handle{}
+	proc.logger = logger.NOP
+	proc.statsFactory = statsStore
+
+	source := backendconfig.SourceT{
+		ID:     "source-1",
+		Config: []byte(`{"piiMasking":{"rules":[{"action":"redact","paths":["context.ip"]}]}}`),
+	}
+	destination := backendconfig.DestinationT{
+		ID: "dest-1",
+		Config: map[string]interface{}{
+			"piiMasking": map[string]interface{}{
+				"salt":  "salt",
+				"rules": []interface{}{map[string]interface{}{"action": "hash", "paths": []interface{}{"userId"}}},
+			},
+		},
+		DestinationDefinition: backendconfig.DestinationDefinitionT{Name: "WEBHOOK"},
+	}
+	otherDestination := backendconfig.DestinationT{ID: "dest-2"}
+	proc.config.sourcePIIMaskingPolicies = map[string]*piimasking.Policy{source.ID: proc.getSourcePIIMaskingPolicy(&source)}
+	proc.config.destinationPIIMaskingPolicies = map[string]*piimasking.Policy{destination.ID: proc.getDestinationPIIMaskingPolicy(&destination)}
+	require.Nil(t, proc.getDestinationPIIMaskingPolicy(&otherDestination))
+
+	message := types.SingularEventT{"userId": "user-1", "context": map[string]interface{}{"ip": "1.2.3.4", "locale": "en"}}
+	events := proc.maskPII([]types.TransformerEvent{{
+		Message:     message,
+		Metadata:    types.Metadata{WorkspaceID: "workspace-1"},
+		Destination: destination,
+	}}, source.ID, &destination)
+
+	require.Len(t, events, 1)
+	require.Equal(t, "user-1", message["userId"], "the shared message should not be modified")
+	require.Equal(t, "1.2.3.4", message["context"].(map[string]interface{})["ip"])
+	require.Len(t, events[0].Message["userId"], 64)
+	require.Equal(t, map[string]interface{}{"locale": "en"}, events[0].Message["context"])
+
+	tags := stats.Tags{"workspaceId": "workspace-1", "sourceId": "source-1", "destinationId": "dest-1", "destType": "WEBHOOK"}
+	tags["action"] = "redact"
+	require.EqualValues(t, 1, statsStore.Get("processor_pii_fields_masked", tags).LastValue())
+	tags["action"] = "hash"
+	require.EqualValues(t, 1, statsStore.Get("processor_pii_fields_masked", tags).LastValue())
+
+	t.Run("malformed policy", func(t *testing.T) {
+		destination := backendconfig.DestinationT{
+			ID:                    "dest-3",
+			Config:                map[string]interface{}{"piiMasking": map[string]interface{}{"rules": "context.ip"}},
+			DestinationDefinition: backendconfig.DestinationDefinitionT{Name: "WEBHOOK"},
+		}
+		policy := proc.getDestinationPIIMaskingPolicy(&destination)
+		require.NotNil(t, policy, "malformed policies should fail closed")
+		proc.config.destinationPIIMaskingPolicies[destination.ID] = policy
+
+		events := proc.maskPII([]types.TransformerEvent{{Message: message}}, "source-2", &destination)
+		require.Empty(t, events[0].Message)
+		require.Equal(t, "1.2.3.4", message["context"].(map[string]interface{})["ip"], "the shared message should not be modified")
+	})
+
+	t.Run("no policies", func(t *testing.T) {
+		events := proc.maskPII([]types.TransformerEvent{{Message: message}}, "source-2", &otherDestination)
+		require.Equal(t, message, events[0].Message)
+	})
+}
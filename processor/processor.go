@@ -40,6 +40,7 @@ import (
 	"github.com/rudderlabs/rudder-server/processor/delayed"
 	"github.com/rudderlabs/rudder-server/processor/eventfilter"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
+	"github.com/rudderlabs/rudder-server/processor/internal/piimasking"
 	"github.com/rudderlabs/rudder-server/processor/isolation"
 	"github.com/rudderlabs/rudder-server/processor/stash"
 	"github.com/rudderlabs/rudder-server/processor/transformer"
@@ -47,9 +48,11 @@ import (
 	"github.com/rudderlabs/rudder-server/router/batchrouter"
 	"github.com/rudderlabs/rudder-server/rruntime"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
+	"github.com/rudderlabs/rudder-server/services/debugger/livestream"
 	transformationdebugger "github.com/rudderlabs/rudder-server/services/debugger/transformation"
 	deduptypes "github.com/rudderlabs/rudder-server/services/dedup/types"
 	"github.com/rudderlabs/rudder-server/services/fileuploader"
+	"github.com/rudderlabs/rudder-server/services/lineage"
 	"github.com/rudderlabs/rudder-server/services/rmetrics"
 	"github.com/rudderlabs/rudder-server/services/rsources"
 	transformerFeaturesService "github.com/rudderlabs/rudder-server/services/transformer"
@@ -116,6 +119,7 @@ type Handle struct {
 	logger                     logger.Logger
 	enrichers                  []enricher.PipelineEnricher
 	dedup                      deduptypes.Dedup
+	lineage                    *lineage.Recorder
 	reporting                  reportingtypes.Reporting
 	reportingEnabled           bool
 	backgroundWait             func() error
@@ -161,7 +165,10 @@ type Handle struct {
 		oneTrustConsentCategoriesMap              map[string][]string
 		connectionConfigMap                       map[connection]backendconfig.Connection
 		ketchConsentCategoriesMap                 map[string][]string
+		iabConsentMap                             map[string]*IABConsentConfig
 		genericConsentManagementMap               SourceConsentMap
+		sourcePIIMaskingPolicies                  map[string]*piimasking.Policy
+		destinationPIIMaskingPolicies             map[string]*piimasking.Policy
 		batchDestinations                         []string
 		configSubscriberLock                      sync.RWMutex
 		enableDedup                               bool
@@ -452,6 +459,7 @@ func (proc *Handle) Setup(
 
 	proc.trackedUsersReporter = trackedUsersReporter
 	proc.tracer = tracing.New(proc.statsFactory.NewTracer("processor"), tracing.WithNamePrefix("proc"))
+	proc.lineage = lineage.Default()
 	proc.stats.statGatewayDBR = func(partition string) stats.Measurement {
 		return proc.statsFactory.NewTaggedStat("processor_gateway_db_read", stats.CountType, stats.Tags{
 			"partition": partition,
@@ -621,6 +629,10 @@ func (proc *Handle) Start(ctx context.Context) error {
 	if proc.isolationStrategy, err = isolation.GetStrategy(proc.config.isolationMode); err != nil {
 		return fmt.Errorf("resolving isolation strategy for mode %q: %w", proc.config.isolationMode, err)
 	}
+	if !proc.config.isolationMode.CoversEventStream() {
+		proc.logger.Warnn("Isolation mode applies only to jobs bound to a destination, event stream jobs are isolated by source",
+			logger.NewStringField("isolationMode", string(proc.config.isolationMode)))
+	}
 
 	// limiters
 	s := proc.statsFactory
@@ -797,6 +809,7 @@ func (proc *Handle) backendConfigSubscriber(ctx context.Context) {
 		var (
 			oneTrustConsentCategoriesMap = make(map[string][]string)
 			ketchConsentCategoriesMap    = make(map[string][]string)
+			iabConsentMap                = make(map[string]*IABConsentConfig)
 			genericConsentManagementMap  = make(SourceConsentMap)
 			workspaceLibrariesMap        = make(map[string]backendconfig.LibrariesT, len(config))
 			sourceIdDestinationMap       = make(map[string][]backendconfig.DestinationT)
@@ -805,6 +818,8 @@ func (proc *Handle) backendConfigSubscriber(ctx context.Context) {
 			credentialsMap               = make(map[string][]types.Credential)
 			nonEventStreamSources        = make(map[string]bool)
 			connectionConfigMap          = make(map[connection]backendconfig.Connection)
+			sourcePIIMaskingPolicies     = make(map[string]*piimasking.Policy)
+			destPIIMaskingPolicies       = make(map[string]*piimasking.Policy)
 		)
 		for workspaceID, wConfig := range config {
 			for _, conn := range wConfig.Connections {
@@ -813,6 +828,9 @@ func (proc *Handle) backendConfigSubscriber(ctx context.Context) {
 			for i := range wConfig.Sources {
 				source := &wConfig.Sources[i]
 				sourceIdSourceMap[source.ID] = *source
+				if policy := proc.getSourcePIIMaskingPolicy(source); policy != nil {
+					sourcePIIMaskingPolicies[source.ID] = policy
+				}
 				if source.Enabled {
 					sourceIdDestinationMap[source.ID] = source.Destinations
 					genericConsentManagementMap[SourceID(source.ID)] = make(DestConsentMap)
@@ -820,6 +838,12 @@ func (proc *Handle) backendConfigSubscriber(ctx context.Context) {
 						destination := &source.Destinations[j]
 						oneTrustConsentCategoriesMap[destination.ID] = getOneTrustConsentCategories(destination)
 						ketchConsentCategoriesMap[destination.ID] = getKetchConsentCategories(destination)
+						if iabConsentConfig := getIABConsentConfig(destination); iabConsentConfig != nil {
+							iabConsentMap[destination.ID] = iabConsentConfig
+						}
+						if policy := proc.getDestinationPIIMaskingPolicy(destination); policy != nil {
+							destPIIMaskingPolicies[destination.ID] = policy
+						}
 
 						var err error
 						genericConsentManagementMap[SourceID(source.ID)][DestinationID(destination.ID)], err = getGenericConsentManagementData(destination)
@@ -847,7 +871,10 @@ func (proc *Handle) backendConfigSubscriber(ctx context.Context) {
 		proc.config.connectionConfigMap = connectionConfigMap
 		proc.config.oneTrustConsentCategoriesMap = oneTrustConsentCategoriesMap
 		proc.config.ketchConsentCategoriesMap = ketchConsentCategoriesMap
+		proc.config.iabConsentMap = iabConsentMap
 		proc.config.genericConsentManagementMap = genericConsentManagementMap
+		proc.config.sourcePIIMaskingPolicies = sourcePIIMaskingPolicies
+		proc.config.destinationPIIMaskingPolicies = destPIIMaskingPolicies
 		proc.config.workspaceLibrariesMap = workspaceLibrariesMap
 		proc.config.sourceIdDestinationMap = sourceIdDestinationMap
 		proc.config.sourceIdSourceMap = sourceIdSourceMap
@@ -1020,7 +1047,7 @@ func getSourceAndDestIDsFromKey(key string) (sourceID, destID string) {
 
 func (proc *Handle) recordEventDeliveryStatus(jobsByDestID map[string][]*jobsdb.JobT) {
 	for destID, jobs := range jobsByDestID {
-		if !proc.destDebugger.HasUploadEnabled(destID) {
+		if !proc.destDebugger.HasUploadEnabled(destID) && !livestream.Default.Active() {
 			continue
 		}
 		for _, job := range jobs {
@@ -1969,9 +1996,11 @@ func (proc *Handle) preprocessStage(partition string, subJobs subJob) (*preTrans
 			if !allowedBatchKeys[event.dedupKey] {
 				proc.logger.Debugn("Dropping event with duplicate key %s", logger.NewStringField("key", event.dedupKey.Key))
 				sourceDupStats[dupStatKey{sourceID: event.eventParams.SourceId}] += 1
+				proc.recordDedupLineage(event.messageID, event.jobID, event.eventParams, "dropped")
 				continue
 			}
 			dedupKeys[event.dedupKey.Key] = struct{}{}
+			proc.recordDedupLineage(event.messageID, event.jobID, event.eventParams, "passed")
 		}
 
 		proc.updateSourceEventStatsDetailed(event.singularEvent, sourceId)
@@ -3215,6 +3244,7 @@ func (proc *Handle) userTransformAndFilter(
 			var successMetrics []*reportingtypes.PUReportedMetric
 			var successCountMap map[string]int64
 			var successCountMetadataMap map[string]MetricMetadata
+			proc.recordTransformLineage(lineage.StageUserTransform, response)
 			eventsToTransform, successMetrics, successCountMap, successCountMetadataMap = proc.getTransformerEvents(response, commonMetaData, eventsByMessageID, destination, connection, inPU, reportingtypes.USER_TRANSFORMER)
 			nonSuccessMetrics := proc.getNonSuccessfulMetrics(response, commonMetaData, eventsByMessageID, inPU, reportingtypes.USER_TRANSFORMER)
 			droppedJobs = append(droppedJobs, append(proc.getDroppedJobs(response, eventList), append(nonSuccessMetrics.failedJobs, nonSuccessMetrics.filteredJobs...)...)...)
@@ -3261,6 +3291,9 @@ func (proc *Handle) userTransformAndFilter(
 		eventsToTransform = eventList
 	}
 
+	// Masking PII before the events reach the destination transformer, or the router when transforming there
+	eventsToTransform = proc.maskPII(eventsToTransform, sourceID, destination)
+
 	if len(eventsToTransform) == 0 {
 		return userTransformAndFilterOutput{
 			eventsToTransform:     eventsToTransform,
@@ -3409,6 +3442,7 @@ func (proc *Handle) destTransform(
 
 			destTransformationStat := proc.newDestinationTransformationStat(sourceID, workspaceID, transformAt, destination)
 			destTransformationStat.transformTime.Since(s)
+			proc.recordTransformLineage(lineage.StageDestTransform, response)
 			transformAt = "processor"
 
 			proc.logger.Debugn("Dest Transform output size", logger.NewIntField("outputSize", int64(len(response.Events))))
@@ -4037,3 +4071,49 @@ func getUTSamplingUploader(conf *config.Config, log logger.Logger) (filemanager.
 		return conf.GetDuration("UTSampling.Timeout", 120, time.Second)
 	})
 }
+
+// recordDedupLineage records the dedup stage of an event, if it is traced
+func (proc *Handle) recordDedupLineage(messageID string, jobID int64, eventParams types.EventParams, status string) {
+	if !proc.lineage.Active() {
+		return
+	}
+	proc.lineage.Record(lineage.Record{
+		Stage:       lineage.StageDedup,
+		Status:      status,
+		MessageID:   messageID,
+		JobID:       jobID,
+		SourceID:    eventParams.SourceId,
+		TraceParent: eventParams.TraceParent,
+	})
+}
+
+// recordTransformLineage records the outcome of a transformation stage for the traced events of its response
+func (proc *Handle) recordTransformLineage(stage lineage.Stage, response types.Response) {
+	if !proc.lineage.Active() {
+		return
+	}
+	record := func(event *types.TransformerResponse, status string) {
+		for _, messageID := range event.Metadata.GetMessagesIDs() {
+			proc.lineage.Record(lineage.Record{
+				Stage:         stage,
+				Status:        status,
+				Error:         event.Error,
+				MessageID:     messageID,
+				JobID:         event.Metadata.JobID,
+				SourceID:      event.Metadata.SourceID,
+				DestinationID: event.Metadata.DestinationID,
+				TraceParent:   event.Metadata.TraceParent,
+			})
+		}
+	}
+	for i := range response.Events {
+		record(&response.Events[i], "succeeded")
+	}
+	for i := range response.FailedEvents {
+		if response.FailedEvents[i].StatusCode == reportingtypes.FilterEventCode {
+			record(&response.FailedEvents[i], "filtered")
+		} else {
+			record(&response.FailedEvents[i], "failed")
+		}
+	}
+}
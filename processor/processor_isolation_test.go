@@ -52,6 +52,8 @@ func TestProcessorIsolation(t *testing.T) {
 		isolation.ModeNone,
 		isolation.ModeWorkspace,
 		isolation.ModeSource,
+		isolation.ModeDestination,
+		isolation.ModeConnection,
 	} {
 		t.Run(fmt.Sprintf("%s isolation", isolationMode), func(t *testing.T) {
 			t.Run("1 worker", func(t *testing.T) {
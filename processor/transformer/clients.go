@@ -11,6 +11,7 @@ import (
 	"github.com/rudderlabs/rudder-server/processor/internal/transformer/destination_transformer"
 	"github.com/rudderlabs/rudder-server/processor/internal/transformer/trackingplan_validation"
 	"github.com/rudderlabs/rudder-server/processor/internal/transformer/user_transformer"
+	"github.com/rudderlabs/rudder-server/processor/internal/transformer/user_transformer/wasm"
 	"github.com/rudderlabs/rudder-server/processor/types"
 	transformerfs "github.com/rudderlabs/rudder-server/services/transformer"
 )
@@ -48,6 +49,16 @@ func WithFeatureService(featuresService transformerfs.FeaturesService) func(*opt
 	}
 }
 
+// WithWasmRuntime is used to set the runtime the user transformer runs transformation versions having a wasm module with.
+// The runtime is owned by the caller, who is responsible for closing it.
+func WithWasmRuntime(runtime *wasm.Runtime) func(*opts) {
+	return func(o *opts) {
+		if runtime != nil {
+			o.userOpts = append(o.userOpts, user_transformer.WithWasmRuntime(runtime))
+		}
+	}
+}
+
 // NewClients creates a new instance of TransformerClients.
 func NewClients(conf *config.Config, log logger.Logger, statsFactory stats.Stats, options ...func(*opts)) TransformerClients {
 	var opts opts
@@ -55,7 +66,7 @@ func NewClients(conf *config.Config, log logger.Logger, statsFactory stats.Stats
 		option(&opts)
 	}
 	return &Clients{
-		user:         user_transformer.New(conf, log, statsFactory),
+		user:         user_transformer.New(conf, log, statsFactory, opts.userOpts...),
 		userMirror:   user_transformer.New(conf, log, statsFactory, user_transformer.ForMirroring()),
 		destination:  destination_transformer.New(conf, log, statsFactory, opts.destinationOpts...),
 		trackingplan: trackingplan_validation.New(conf, log, statsFactory),
@@ -72,4 +83,5 @@ func (c *Clients) TrackingPlan() TrackingPlanClient { return c.trackingplan }
 
 type opts struct {
 	destinationOpts []destination_transformer.Opt
+	userOpts        []user_transformer.Opt
 }
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-server/router/internal/circuitbreaker"
+)
+
+// NewAdmin returns a new router admin, exposing admin functions of all routers created by a [Factory] configured with it
+func NewAdmin() *Admin {
+	return &Admin{routers: make(map[string]*Handle)}
+}
+
+// Admin exposes router admin functions over the admin rpc interface
+type Admin struct {
+	mu      sync.RWMutex
+	routers map[string]*Handle // destType -> router
+}
+
+// register adds the router to the list of routers managed by the admin
+func (a *Admin) register(rt *Handle) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.routers[rt.destType] = rt
+}
+
+// CircuitBreakers returns the status of the destinations' circuit breakers grouped by destination type.
+// If a destination type is provided, only circuit breakers of this destination type are returned.
+func (a *Admin) CircuitBreakers(destType string, reply *string) error {
+	a.mu.RLock()
+	destTypes := make([]string, 0, len(a.routers))
+	for dt := range a.routers {
+		if destType == "" || dt == destType {
+			destTypes = append(destTypes, dt)
+		}
+	}
+	sort.Strings(destTypes)
+	statuses := make(map[string]map[string]circuitbreaker.Status, len(destTypes))
+	for _, dt := range destTypes {
+		if rt := a.routers[dt]; rt.circuitBreakers != nil {
+			statuses[dt] = rt.circuitBreakers.Statuses()
+		}
+	}
+	a.mu.RUnlock()
+
+	formattedOutput, err := jsonrs.MarshalIndent(statuses, "", "  ")
+	if err != nil {
+		return err
+	}
+	*reply = string(formattedOutput)
+	return nil
+}
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-server/router/internal/circuitbreaker"
+)
+
+func TestAdminCircuitBreakers(t *testing.T) {
+	newRouter := func(destType string) *Handle {
+		return &Handle{
+			destType: destType,
+			circuitBreakers: circuitbreaker.NewRegistry(
+				circuitbreaker.WithEnabled(config.SingleValueLoader(true)),
+				circuitbreaker.WithMinRequests(config.SingleValueLoader(1)),
+			),
+		}
+	}
+	webhook := newRouter("WEBHOOK")
+	webhook.circuitBreakers.Failure("dest-1")
+	webhook.circuitBreakers.Success("dest-2")
+	ga := newRouter("GA")
+	ga.circuitBreakers.Success("dest-3")
+
+	a := NewAdmin()
+	a.register(webhook)
+	a.register(ga)
+
+	t.Run("all destination types", func(t *testing.T) {
+		var reply string
+		require.NoError(t, a.CircuitBreakers("", &reply))
+		var statuses map[string]map[string]circuitbreaker.Status
+		require.NoError(t, jsonrs.Unmarshal([]byte(reply), &statuses))
+		require.Len(t, statuses, 2)
+		require.Equal(t, "open", statuses["WEBHOOK"]["dest-1"].State)
+		require.Equal(t, "closed", statuses["WEBHOOK"]["dest-2"].State)
+		require.Equal(t, 1, statuses["WEBHOOK"]["dest-2"].Requests)
+		require.Equal(t, "closed", statuses["GA"]["dest-3"].State)
+	})
+
+	t.Run("single destination type", func(t *testing.T) {
+		var reply string
+		require.NoError(t, a.CircuitBreakers("GA", &reply))
+		var statuses map[string]map[string]circuitbreaker.Status
+		require.NoError(t, jsonrs.Unmarshal([]byte(reply), &statuses))
+		require.Len(t, statuses, 1)
+		require.Contains(t, statuses, "GA")
+	})
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	asynccommon "github.com/rudderlabs/rudder-server/router/batchrouter/asyncdestinationmanager/common"
 	"github.com/rudderlabs/rudder-server/router/batchrouter/isolation"
+	"github.com/rudderlabs/rudder-server/router/internal/priority"
 	"github.com/rudderlabs/rudder-server/router/rterror"
 	routerutils "github.com/rudderlabs/rudder-server/router/utils"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
@@ -86,6 +87,7 @@ type Handle struct {
 	schemaGenerationWorkers      config.ValueLoader[int]
 	reportingEnabled             bool
 	jobQueryBatchSize            config.ValueLoader[int]
+	maxPriorityWeight            config.ValueLoader[int]
 	pollStatusLoopSleep          config.ValueLoader[time.Duration]
 	payloadLimit                 config.ValueLoader[int64]
 	jobsDBCommandTimeout         config.ValueLoader[time.Duration]
@@ -117,6 +119,7 @@ type Handle struct {
 	destinationsMap          map[string]*routerutils.DestinationWithSources // destinationID -> destination
 	connectionWHNamespaceMap map[string]string                              // connectionIdentifier -> warehouseConnectionIdentifier(+namepsace)
 	uploadIntervalMap        map[string]time.Duration
+	priorities               *priority.Registry // priority tiers & weights of workspaces and destinations
 
 	encounteredMergeRuleMapMu sync.Mutex
 	encounteredMergeRuleMap   map[string]map[string]bool
@@ -185,7 +188,9 @@ func (brt *Handle) getWorkerJobs(partition string) (workerJobs []*DestinationJob
 		return
 	}
 
-	defer brt.limiter.read.Begin("")()
+	partitionPriority := brt.priorities.Partition(partition)
+	readPriority, _ := partitionPriority.LimiterPriority()
+	defer brt.limiter.read.BeginWithPriority("", readPriority)()
 
 	brt.configSubscriberMu.RLock()
 	destinationsMap := brt.destinationsMap
@@ -199,7 +204,7 @@ func (brt *Handle) getWorkerJobs(partition string) (workerJobs []*DestinationJob
 	queryStart := time.Now()
 	queryParams := jobsdb.GetQueryParams{
 		CustomValFilters: []string{brt.destType},
-		JobsLimit:        brt.jobQueryBatchSize.Load(),
+		JobsLimit:        brt.priorities.Limit(partition, brt.jobQueryBatchSize.Load(), brt.maxPriorityWeight.Load()),
 		PayloadSizeLimit: brt.adaptiveLimit(brt.payloadLimit.Load()),
 	}
 	brt.isolationStrategy.AugmentQueryParams(partition, &queryParams)
@@ -30,6 +30,7 @@ type Factory struct {
 	Debugger                   destinationdebugger.DestinationDebugger
 	AdaptiveLimit              func(int64) int64
 	PendingEventsRegistry      rmetrics.PendingEventsRegistry
+	Admin                      *Admin // optional, exposes admin functions of the routers created by the factory
 }
 
 func (f *Factory) New(destination *backendconfig.DestinationT) *Handle {
@@ -51,6 +52,9 @@ func (f *Factory) New(destination *backendconfig.DestinationT) *Handle {
 		f.ThrottlerFactory,
 		f.PendingEventsRegistry,
 	)
+	if f.Admin != nil {
+		f.Admin.register(r)
+	}
 	return r
 }
 
@@ -25,9 +25,13 @@ import (
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	customDestinationManager "github.com/rudderlabs/rudder-server/router/customdestinationmanager"
+	"github.com/rudderlabs/rudder-server/router/internal/circuitbreaker"
+	"github.com/rudderlabs/rudder-server/router/internal/deliveryauth"
 	"github.com/rudderlabs/rudder-server/router/internal/eventorder"
 	"github.com/rudderlabs/rudder-server/router/internal/jobiterator"
 	"github.com/rudderlabs/rudder-server/router/internal/partition"
+	"github.com/rudderlabs/rudder-server/router/internal/priority"
+	"github.com/rudderlabs/rudder-server/router/internal/retrypolicy"
 	"github.com/rudderlabs/rudder-server/router/isolation"
 	rtThrottler "github.com/rudderlabs/rudder-server/router/throttler"
 	"github.com/rudderlabs/rudder-server/router/transformer"
@@ -35,7 +39,7 @@ import (
 	routerutils "github.com/rudderlabs/rudder-server/router/utils"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
 	"github.com/rudderlabs/rudder-server/services/diagnostics"
-
+	"github.com/rudderlabs/rudder-server/services/lineage"
 	"github.com/rudderlabs/rudder-server/services/rmetrics"
 	"github.com/rudderlabs/rudder-server/services/rsources"
 	transformerFeaturesService "github.com/rudderlabs/rudder-server/services/transformer"
@@ -60,6 +64,7 @@ type Handle struct {
 	debugger                   destinationdebugger.DestinationDebugger
 	pendingEventsRegistry      rmetrics.PendingEventsRegistry
 	adaptiveLimit              func(int64) int64
+	statsFactory               stats.Stats // stats of the circuit breakers, defaults to stats.Default
 
 	// configuration
 	reloadableConfig                   *reloadableConfig
@@ -86,6 +91,7 @@ type Handle struct {
 
 	logger                         logger.Logger
 	tracer                         stats.Tracer
+	lineage                        *lineage.Recorder
 	destinationResponseHandler     ResponseHandler
 	telemetry                      *Diagnostic
 	netHandle                      NetHandle
@@ -95,6 +101,10 @@ type Handle struct {
 	destinationsMapMu              sync.RWMutex
 	destinationsMap                map[string]*routerutils.DestinationWithSources // destinationID -> destination
 	connectionsMap                 map[types.SourceDest]types.ConnectionWithID
+	priorities                     *priority.Registry       // priority tiers & weights of workspaces and destinations
+	circuitBreakers                *circuitbreaker.Registry // circuit breakers of destinations with sustained delivery failures
+	retryPolicies                  *retrypolicy.Registry    // retry policies of destinations and destination definitions
+	deliveryAuth                   *deliveryauth.Registry   // mTLS & request signing settings of destinations
 	isBackendConfigInitialized     bool
 	backendConfigInitialized       chan bool
 	responseQ                      chan workerJobStatus
@@ -148,6 +158,7 @@ func (rt *Handle) activePartitions(ctx context.Context) []string {
 		panic(err)
 	}
 	stats.Default.NewTaggedStat("rt_active_partitions", stats.GaugeType, statTags).Gauge(len(keys))
+	rt.priorities.SetActivePartitions(keys)
 	return keys
 }
 
@@ -159,7 +170,7 @@ func (rt *Handle) pickup(ctx context.Context, partition string, workers []*worke
 	var discardedCount int
 	limiter := rt.limiter.pickup
 	limiterStats := rt.limiter.stats.pickup
-	limiterEnd := limiter.BeginWithPriority("", LimiterPriorityValueFrom(limiterStats.Score(partition), 100))
+	limiterEnd := limiter.BeginWithPriority("", rt.pickupPriority(partition))
 	defer limiterEnd()
 
 	defer func() {
@@ -184,7 +195,7 @@ func (rt *Handle) pickup(ctx context.Context, partition string, workers []*worke
 		"Router.jobIterator.discardedPercentageTolerance")
 
 	iterator := jobiterator.New(
-		rt.getQueryParams(partition, rt.reloadableConfig.jobQueryBatchSize.Load()),
+		rt.getQueryParams(partition, rt.priorities.Limit(partition, rt.reloadableConfig.jobQueryBatchSize.Load(), rt.reloadableConfig.maxPriorityWeight.Load())),
 		rt.getJobsFn(ctx),
 		jobiterator.WithDiscardedPercentageTolerance(jobIteratorDiscardedPercentageTolerance),
 		jobiterator.WithMaxQueries(jobIteratorMaxQueries),
@@ -199,10 +210,11 @@ func (rt *Handle) pickup(ctx context.Context, partition string, workers []*worke
 	}
 
 	type reservedJob struct {
-		slot        *workerSlot
-		job         *jobsdb.JobT
-		drainReason string
-		parameters  routerutils.JobParameters
+		slot                     *workerSlot
+		job                      *jobsdb.JobT
+		drainReason              string
+		circuitBreakerGeneration int
+		parameters               routerutils.JobParameters
 	}
 
 	var statusList []*jobsdb.JobStatusT
@@ -227,7 +239,7 @@ func (rt *Handle) pickup(ctx context.Context, partition string, workers []*worke
 		rt.logger.Debugn("[DRAIN DEBUG] counts final jobs length being processed", obskit.DestinationType(rt.destType), logger.NewIntField("jobsLength", int64(len(reservedJobs))))
 		assignedTime := time.Now()
 		for _, reservedJob := range reservedJobs {
-			reservedJob.slot.Use(workerJob{job: reservedJob.job, assignedAt: assignedTime, drainReason: reservedJob.drainReason, parameters: &reservedJob.parameters})
+			reservedJob.slot.Use(workerJob{job: reservedJob.job, assignedAt: assignedTime, drainReason: reservedJob.drainReason, circuitBreakerGeneration: reservedJob.circuitBreakerGeneration, parameters: &reservedJob.parameters})
 		}
 		pickupCount += len(reservedJobs)
 		reservedJobs = nil
@@ -243,6 +255,9 @@ func (rt *Handle) pickup(ctx context.Context, partition string, workers []*worke
 
 	// Identify jobs which can be processed
 	var iterationInterrupted bool
+	// partitions spanning multiple destinations can't stop iterating when a destination's circuit is open,
+	// so the rest of the jobs of such destinations are discarded without being considered any further
+	circuitOpenDestinations := make(map[string]struct{})
 	for iterator.HasNext() {
 		if ctx.Err() != nil {
 			return 0, false
@@ -258,7 +273,13 @@ func (rt *Handle) pickup(ctx context.Context, partition string, workers []*worke
 			rt.logger.Errorn("Error occurred while unmarshalling job parameters. Panicking", obskit.Error(err))
 			panic(err)
 		}
-		workerJobSlot, err := rt.findWorkerSlot(ctx, workers, job, parameters, blockedOrderKeys)
+		var workerJobSlot *workerJobSlot
+		var err error
+		if _, ok := circuitOpenDestinations[parameters.DestinationID]; ok {
+			err = types.ErrDestinationCircuitOpen
+		} else {
+			workerJobSlot, err = rt.findWorkerSlot(ctx, workers, job, parameters, blockedOrderKeys)
+		}
 		if err == nil {
 			traceParent := jsonparser.GetStringOrEmpty(job.Parameters, "traceparent")
 			if traceParent != "" {
@@ -289,7 +310,7 @@ func (rt *Handle) pickup(ctx context.Context, partition string, workers []*worke
 				WorkspaceId:   job.WorkspaceId,
 			}
 			statusList = append(statusList, &status)
-			reservedJobs = append(reservedJobs, reservedJob{slot: workerJobSlot.slot, job: job, drainReason: workerJobSlot.drainReason, parameters: parameters})
+			reservedJobs = append(reservedJobs, reservedJob{slot: workerJobSlot.slot, job: job, drainReason: workerJobSlot.drainReason, circuitBreakerGeneration: workerJobSlot.circuitBreakerGeneration, parameters: parameters})
 			if shouldFlush() {
 				flush()
 			}
@@ -298,6 +319,9 @@ func (rt *Handle) pickup(ctx context.Context, partition string, workers []*worke
 			discardedJobCountStat.Increment()
 			iterator.Discard(job)
 			discardedCount++
+			if errors.Is(err, types.ErrDestinationCircuitOpen) {
+				circuitOpenDestinations[parameters.DestinationID] = struct{}{}
+			}
 			if rt.stopIteration(err, parameters.DestinationID) {
 				discarded := iterator.Stop() // stop the iterator and count all additional jobs discarded by operator by using the same reason as the last job that was discarded
 				discardedJobCountStat.Count(discarded)
@@ -335,6 +359,15 @@ func (rt *Handle) pickup(ctx context.Context, partition string, workers []*worke
 	return
 }
 
+// pickupPriority returns the pickup limiter priority for the given partition. Partitions with a priority tier defined in backend config
+// are using the tier's priority, otherwise the priority is calculated dynamically based on the partition's pickup stats
+func (rt *Handle) pickupPriority(partition string) kitsync.LimiterPriorityValue {
+	if p, ok := rt.priorities.Partition(partition).LimiterPriority(); ok {
+		return p
+	}
+	return LimiterPriorityValueFrom(rt.limiter.stats.pickup.Score(partition), 100)
+}
+
 func (rt *Handle) stopIteration(err error, destinationID string) bool {
 	// if the context is cancelled, we can stop iteration
 	if errors.Is(err, types.ErrContextCancelled) {
@@ -428,6 +461,22 @@ func (rt *Handle) commitStatusList(workerJobStatuses *[]workerJobStatus) {
 		// REPORTING - ROUTER - END
 
 		statusList = append(statusList, workerJobStatus.status)
+		if rt.lineage.Active() {
+			var errorResponse string
+			if workerJobStatus.status.JobState != jobsdb.Succeeded.State {
+				errorResponse = string(workerJobStatus.status.ErrorResponse)
+			}
+			rt.lineage.Record(lineage.Record{
+				Stage:         lineage.StageRouterDelivery,
+				Status:        workerJobStatus.status.JobState,
+				Error:         errorResponse,
+				MessageID:     parameters.MessageID,
+				JobID:         workerJobStatus.job.JobID,
+				SourceID:      parameters.SourceID,
+				DestinationID: parameters.DestinationID,
+				TraceParent:   parameters.TraceParent,
+			})
+		}
 
 		// tracking router errors
 		if diagnostics.EnableDestinationFailuresMetric {
@@ -561,8 +610,9 @@ func (rt *Handle) getQueryParams(partition string, pickUpCount int) jobsdb.GetQu
 }
 
 type workerJobSlot struct {
-	slot        *workerSlot
-	drainReason string
+	slot                     *workerSlot
+	drainReason              string
+	circuitBreakerGeneration int // generation of the destination's circuit breaker when the job was allowed
 }
 
 func (rt *Handle) findWorkerSlot(ctx context.Context, workers []*worker, job *jobsdb.JobT, parameters routerutils.JobParameters, blockedOrderKeys map[eventorder.BarrierKey]struct{}) (*workerJobSlot, error) {
@@ -603,12 +653,19 @@ func (rt *Handle) findWorkerSlot(ctx context.Context, workers []*worker, job *jo
 			slot.Release()
 			return nil, types.ErrJobBackoff
 		}
+		// the circuit breaker is checked first, so that jobs of destinations with an open circuit don't consume any throttling limits
+		allowed, circuitBreakerGeneration := rt.circuitBreakerAllows(parameters.DestinationID)
+		if !allowed {
+			slot.Release()
+			return nil, types.ErrDestinationCircuitOpen
+		}
 		if rt.shouldThrottle(ctx, job, parameters.DestinationID, parameters.EventType) {
 			slot.Release()
+			rt.circuitBreakerRelease(parameters.DestinationID, circuitBreakerGeneration)
 			return nil, types.ErrDestinationThrottled
 		}
 
-		return &workerJobSlot{slot: slot}, nil
+		return &workerJobSlot{slot: slot, circuitBreakerGeneration: circuitBreakerGeneration}, nil
 	}
 
 	// checking if the orderKey is in blockedOrderKeys. If yes, returning nil.
@@ -641,13 +698,24 @@ func (rt *Handle) findWorkerSlot(ctx context.Context, workers []*worker, job *jo
 		return nil, types.ErrBarrierExists
 	}
 	rt.logger.Debugn("EventOrder: job is allowed to be processed", logger.NewIntField("jobID", job.JobID), logger.NewStringField("orderKey", orderKey.String()))
-	if !abortedJob && rt.shouldThrottle(ctx, job, parameters.DestinationID, parameters.EventType) {
-		blockedOrderKeys[orderKey] = struct{}{}
-		worker.barrier.Leave(orderKey, job.JobID)
-		slot.Release()
-		return nil, types.ErrDestinationThrottled
+	var circuitBreakerGeneration int
+	if !abortedJob {
+		var allowed bool
+		if allowed, circuitBreakerGeneration = rt.circuitBreakerAllows(parameters.DestinationID); !allowed {
+			blockedOrderKeys[orderKey] = struct{}{}
+			worker.barrier.Leave(orderKey, job.JobID)
+			slot.Release()
+			return nil, types.ErrDestinationCircuitOpen
+		}
+		if rt.shouldThrottle(ctx, job, parameters.DestinationID, parameters.EventType) {
+			blockedOrderKeys[orderKey] = struct{}{}
+			worker.barrier.Leave(orderKey, job.JobID)
+			slot.Release()
+			rt.circuitBreakerRelease(parameters.DestinationID, circuitBreakerGeneration)
+			return nil, types.ErrDestinationThrottled
+		}
 	}
-	return &workerJobSlot{slot: slot, drainReason: abortReason}, nil
+	return &workerJobSlot{slot: slot, drainReason: abortReason, circuitBreakerGeneration: circuitBreakerGeneration}, nil
 	//#EndJobOrder
 }
 
@@ -688,11 +756,91 @@ func (rt *Handle) retryLimitReached(status *jobsdb.JobStatusT) bool {
 		maxFailedCountForJob = rt.reloadableConfig.maxFailedCountForSourcesJob.Load()
 		retryTimeWindow = rt.reloadableConfig.sourcesRetryTimeWindow.Load()
 	}
+	// a destination's retry policy limits apply on their own: jobs get aborted as soon as either of the limits it defines is reached
+	if policy, ok := rt.retryPolicy(jsonparser.GetStringOrEmpty(status.JobParameters, "destination_id")); ok && (policy.MaxAttempts > 0 || policy.MaxAge > 0) {
+		return (policy.MaxAttempts > 0 && status.AttemptNum >= policy.MaxAttempts) ||
+			(policy.MaxAge > 0 && time.Since(firstAttemptedAtTime) > policy.MaxAge)
+	}
 
 	return time.Since(firstAttemptedAtTime) > retryTimeWindow &&
 		status.AttemptNum >= maxFailedCountForJob // retry time window exceeded
 }
 
+// circuitBreakerAllows returns true if the destination's circuit breaker allows a job to be delivered, along with the circuit breaker's generation.
+// While the circuit breaker is half-open, every allowed job is a canary job.
+func (rt *Handle) circuitBreakerAllows(destinationID string) (bool, int) {
+	if rt.circuitBreakers == nil {
+		return true, 0
+	}
+	return rt.circuitBreakers.Allow(destinationID)
+}
+
+// circuitBreakerRelease gives back a canary job allowed by the destination's circuit breaker, which is not going to be delivered after all
+func (rt *Handle) circuitBreakerRelease(destinationID string, generation int) {
+	if rt.circuitBreakers != nil {
+		rt.circuitBreakers.Release(destinationID, generation)
+	}
+}
+
+// circuitBreakerStateChanged reports the new state of a destination's circuit breaker
+func (rt *Handle) circuitBreakerStateChanged(destinationID string, from, to circuitbreaker.State) {
+	rt.logger.Warnn("circuit breaker state changed",
+		obskit.DestinationType(rt.destType),
+		obskit.DestinationID(destinationID),
+		logger.NewStringField("from", from.String()),
+		logger.NewStringField("to", to.String()),
+	)
+	rt.statsFactory.NewTaggedStat("router_circuit_breaker_state", stats.GaugeType, stats.Tags{
+		"destType":      rt.destType,
+		"destinationId": destinationID,
+	}).Gauge(int(to))
+	rt.statsFactory.NewTaggedStat("router_circuit_breaker_transitions", stats.CountType, stats.Tags{
+		"destType":      rt.destType,
+		"destinationId": destinationID,
+		"from":          from.String(),
+		"to":            to.String(),
+	}).Increment()
+}
+
+// pruneCircuitBreakers drops the circuit breakers of destinations which are no longer part of the config, resetting their state gauge
+func (rt *Handle) pruneCircuitBreakers(destinations map[string]*routerutils.DestinationWithSources) {
+	for _, destinationID := range rt.circuitBreakers.Prune(func(key string) bool {
+		_, ok := destinations[key]
+		return ok
+	}) {
+		rt.statsFactory.NewTaggedStat("router_circuit_breaker_state", stats.GaugeType, stats.Tags{
+			"destType":      rt.destType,
+			"destinationId": destinationID,
+		}).Gauge(int(circuitbreaker.StateClosed))
+	}
+}
+
+// retryPolicy returns the retry policy of the given destination and a boolean indicating whether a policy is defined in backend config or not
+func (rt *Handle) retryPolicy(destinationID string) (retrypolicy.Policy, bool) {
+	if rt.retryPolicies == nil {
+		return retrypolicy.Policy{}, false
+	}
+	return rt.retryPolicies.Destination(destinationID)
+}
+
+// retryBackoff returns the delay before the next attempt of a failed job. A retry delay requested by the destination (e.g. through a Retry-After header)
+// takes precedence over the destination's retry policy, whereas destinations without a retry policy are using the router's exponential backoff settings.
+func (rt *Handle) retryBackoff(destinationID string, attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, rt.reloadableConfig.maxRetryAfter.Load())
+	}
+	minRetryBackoff, maxRetryBackoff := rt.reloadableConfig.minRetryBackoff.Load(), rt.reloadableConfig.maxRetryBackoff.Load()
+	policy, ok := rt.retryPolicy(destinationID)
+	if !ok {
+		return nextAttemptAfter(attempt, minRetryBackoff, maxRetryBackoff)
+	}
+	return policy.WithDefaults(retrypolicy.Policy{
+		Strategy:   retrypolicy.StrategyExponential,
+		MinBackoff: minRetryBackoff,
+		MaxBackoff: maxRetryBackoff,
+	}).NextAttemptAfter(attempt)
+}
+
 func (*Handle) shouldBackoff(job *jobsdb.JobT) bool {
 	return job.LastJobStatus.JobState == jobsdb.Failed.State && job.LastJobStatus.AttemptNum > 0 && time.Until(job.LastJobStatus.RetryTime) > 0
 }
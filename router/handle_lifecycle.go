@@ -21,8 +21,12 @@ import (
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	customDestinationManager "github.com/rudderlabs/rudder-server/router/customdestinationmanager"
+	"github.com/rudderlabs/rudder-server/router/internal/circuitbreaker"
+	"github.com/rudderlabs/rudder-server/router/internal/deliveryauth"
 	"github.com/rudderlabs/rudder-server/router/internal/eventorder"
 	"github.com/rudderlabs/rudder-server/router/internal/partition"
+	"github.com/rudderlabs/rudder-server/router/internal/priority"
+	"github.com/rudderlabs/rudder-server/router/internal/retrypolicy"
 	"github.com/rudderlabs/rudder-server/router/isolation"
 	"github.com/rudderlabs/rudder-server/router/throttler"
 	"github.com/rudderlabs/rudder-server/router/transformer"
@@ -30,6 +34,7 @@ import (
 	routerutils "github.com/rudderlabs/rudder-server/router/utils"
 	"github.com/rudderlabs/rudder-server/rruntime"
 	destinationdebugger "github.com/rudderlabs/rudder-server/services/debugger/destination"
+	"github.com/rudderlabs/rudder-server/services/lineage"
 	oauthv2 "github.com/rudderlabs/rudder-server/services/oauth/v2"
 	"github.com/rudderlabs/rudder-server/services/oauth/v2/common"
 	"github.com/rudderlabs/rudder-server/services/rmetrics"
@@ -83,10 +88,11 @@ func (rt *Handle) Setup(
 		})
 	rt.reloadableConfig = &reloadableConfig{}
 	rt.setupReloadableVars()
+	rt.deliveryAuth = deliveryauth.NewRegistry()
 	rt.crashRecover()
 	rt.responseQ = make(chan workerJobStatus, rt.reloadableConfig.jobQueryBatchSize.Load())
 	if rt.netHandle == nil {
-		netHandle := &netHandle{disableEgress: config.GetBool("disableEgress", false), destType: destType}
+		netHandle := &netHandle{disableEgress: config.GetBool("disableEgress", false), destType: destType, auth: rt.deliveryAuth}
 		netHandle.logger = rt.logger.Child("network")
 		err := netHandle.Setup(config, rt.netClientTimeout)
 		if err != nil {
@@ -126,6 +132,7 @@ func (rt *Handle) Setup(
 
 	statTags := stats.Tags{"destType": rt.destType}
 	rt.tracer = stats.Default.NewTracer("router")
+	rt.lineage = lineage.Default()
 	rt.batchSizeHistogramStat = stats.Default.NewTaggedStat("router_batch_size", stats.HistogramType, statTags)
 	rt.batchInputCountStat = stats.Default.NewTaggedStat("router_batch_num_input_jobs", stats.CountType, statTags)
 	rt.batchOutputCountStat = stats.Default.NewTaggedStat("router_batch_num_output_jobs", stats.CountType, statTags)
@@ -159,6 +166,8 @@ func (rt *Handle) Setup(
 
 	rt.isBackendConfigInitialized = false
 	rt.backendConfigInitialized = make(chan bool)
+	rt.priorities = priority.NewRegistry()
+	rt.retryPolicies = retrypolicy.NewRegistry()
 
 	isolationMode := isolationMode(destType, config)
 	if rt.isolationStrategy, err = isolation.GetStrategy(isolationMode, rt.destType, func(destinationID string) bool {
@@ -193,6 +202,19 @@ func (rt *Handle) Setup(
 		}),
 	)
 
+	if rt.statsFactory == nil {
+		rt.statsFactory = stats.Default
+	}
+	rt.circuitBreakers = circuitbreaker.NewRegistry(
+		circuitbreaker.WithEnabled(config.GetReloadableBoolVar(false, getRouterConfigKeys("circuitBreaker.enabled", destType)...)),
+		circuitbreaker.WithFailureRatio(config.GetReloadableFloat64Var(0.9, getRouterConfigKeys("circuitBreaker.failureRatio", destType)...)),
+		circuitbreaker.WithMinRequests(config.GetReloadableIntVar(100, 1, getRouterConfigKeys("circuitBreaker.minRequests", destType)...)),
+		circuitbreaker.WithInterval(config.GetReloadableDurationVar(1, time.Minute, getRouterConfigKeys("circuitBreaker.interval", destType)...)),
+		circuitbreaker.WithOpenStateDuration(config.GetReloadableDurationVar(5, time.Minute, getRouterConfigKeys("circuitBreaker.openStateDuration", destType)...)),
+		circuitbreaker.WithCanaryJobs(config.GetReloadableIntVar(10, 1, getRouterConfigKeys("circuitBreaker.canaryJobs", destType)...)),
+		circuitbreaker.WithStateChangeListener(rt.circuitBreakerStateChanged),
+	)
+
 	ctx, cancel := context.WithCancel(context.Background())
 	g, ctx := errgroup.WithContext(ctx)
 
@@ -336,10 +358,12 @@ func (rt *Handle) setupReloadableVars() {
 	rt.reloadableConfig.maxStatusUpdateWait = config.GetReloadableDurationVar(5, time.Second, getRouterConfigKeys("maxStatusUpdateWait", rt.destType)...)
 	rt.reloadableConfig.minRetryBackoff = config.GetReloadableDurationVar(10, time.Second, getRouterConfigKeys("minRetryBackoff", rt.destType)...)
 	rt.reloadableConfig.maxRetryBackoff = config.GetReloadableDurationVar(300, time.Second, getRouterConfigKeys("maxRetryBackoff", rt.destType)...)
+	rt.reloadableConfig.maxRetryAfter = config.GetReloadableDurationVar(60, time.Minute, getRouterConfigKeys("maxRetryAfter", rt.destType)...)
 	rt.reloadableConfig.pickupFlushInterval = config.GetReloadableDurationVar(2, time.Second, getRouterConfigKeys("pickupFlushInterval", rt.destType)...)
 	rt.reloadableConfig.failingJobsPenaltySleep = config.GetReloadableDurationVar(2000, time.Millisecond, getRouterConfigKeys("failingJobsPenaltySleep", rt.destType)...)
 	rt.reloadableConfig.failingJobsPenaltyThreshold = config.GetReloadableFloat64Var(0.6, getRouterConfigKeys("failingJobsPenaltyThreshold", rt.destType)...)
 	rt.reloadableConfig.oauthV2ExpirationTimeDiff = config.GetReloadableDurationVar(5, time.Minute, getRouterConfigKeys("oauth.expirationTimeDiff", rt.destType)...)
+	rt.reloadableConfig.maxPriorityWeight = config.GetReloadableIntVar(10, 1, getRouterConfigKeys("priority.maxWeight", rt.destType)...)
 	rt.diagnosisTickerTime = config.GetDurationVar(60, time.Second, "Diagnostics.routerTimePeriod", "Diagnostics.routerTimePeriodInS")
 	rt.netClientTimeout = config.GetDurationVar(10, time.Second,
 		"Router."+rt.destType+".httpTimeout",
@@ -492,6 +516,13 @@ func (rt *Handle) backendConfigSubscriber() {
 		rt.connectionsMap = connectionsMap
 		rt.destinationsMap = destinationsMap
 		rt.destinationsMapMu.Unlock()
+		rt.priorities.Update(configData)
+		rt.retryPolicies.Update(configData)
+		rt.deliveryAuth.Update(configData)
+		rt.pruneCircuitBreakers(destinationsMap)
+		if netHandle, ok := rt.netHandle.(*netHandle); ok {
+			netHandle.pruneMTLSClients()
+		}
 		if !rt.isBackendConfigInitialized {
 			rt.isBackendConfigInitialized = true
 			rt.backendConfigInitialized <- true
@@ -0,0 +1,339 @@
+package circuitbreaker
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+)
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// StateClosed is the state of a healthy circuit breaker, all jobs are allowed to be delivered
+	StateClosed State = iota
+	// StateOpen is the state of a tripped circuit breaker, no jobs are allowed to be delivered
+	StateOpen
+	// StateHalfOpen is the state of a circuit breaker probing whether the destination has recovered, only a limited number of canary jobs are allowed to be delivered
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type OptFn func(r *Registry)
+
+// WithEnabled sets whether circuit breakers are enabled or not. Disabled circuit breakers are always closed.
+func WithEnabled(enabled config.ValueLoader[bool]) OptFn {
+	return func(r *Registry) {
+		r.enabled = enabled
+	}
+}
+
+// WithFailureRatio sets the ratio of failed jobs over all jobs within an interval after which the circuit breaker trips
+func WithFailureRatio(failureRatio config.ValueLoader[float64]) OptFn {
+	return func(r *Registry) {
+		r.failureRatio = failureRatio
+	}
+}
+
+// WithMinRequests sets the minimum number of jobs that need to be attempted within an interval before the circuit breaker can trip
+func WithMinRequests(minRequests config.ValueLoader[int]) OptFn {
+	return func(r *Registry) {
+		r.minRequests = minRequests
+	}
+}
+
+// WithInterval sets the duration of the interval over which failures are counted while the circuit breaker is closed
+func WithInterval(interval config.ValueLoader[time.Duration]) OptFn {
+	return func(r *Registry) {
+		r.interval = interval
+	}
+}
+
+// WithOpenStateDuration sets the duration for which the circuit breaker will remain open before moving to the half-open state
+func WithOpenStateDuration(openStateDuration config.ValueLoader[time.Duration]) OptFn {
+	return func(r *Registry) {
+		r.openStateDuration = openStateDuration
+	}
+}
+
+// WithCanaryJobs sets the number of canary jobs allowed while the circuit breaker is half-open.
+// The circuit breaker closes as soon as all of them succeed, whereas a single failure opens it again.
+func WithCanaryJobs(canaryJobs config.ValueLoader[int]) OptFn {
+	return func(r *Registry) {
+		r.canaryJobs = canaryJobs
+	}
+}
+
+// WithStateChangeListener sets a function to be called whenever the state of a circuit breaker changes.
+// The function is called while holding the registry's lock, thus it must not call back into the registry.
+func WithStateChangeListener(onStateChange func(key string, from, to State)) OptFn {
+	return func(r *Registry) {
+		r.onStateChange = onStateChange
+	}
+}
+
+// WithNow sets the function used for getting the current time
+func WithNow(now func() time.Time) OptFn {
+	return func(r *Registry) {
+		r.now = now
+	}
+}
+
+// NewRegistry creates a new properly initialized circuit breaker registry
+func NewRegistry(fns ...OptFn) *Registry {
+	r := &Registry{
+		breakers:          make(map[string]*breaker),
+		enabled:           config.SingleValueLoader(false),
+		failureRatio:      config.SingleValueLoader(0.9),
+		minRequests:       config.SingleValueLoader(100),
+		interval:          config.SingleValueLoader(time.Minute),
+		openStateDuration: config.SingleValueLoader(5 * time.Minute),
+		canaryJobs:        config.SingleValueLoader(10),
+		onStateChange:     func(string, State, State) {},
+		now:               time.Now,
+	}
+	for _, fn := range fns {
+		fn(r)
+	}
+	return r
+}
+
+// Registry keeps track of a circuit breaker per key (e.g. destinationID).
+//
+// A circuit breaker starts closed and trips (opens) when the ratio of failed jobs within an interval exceeds the configured threshold.
+// While open, no jobs are allowed to be delivered. After the open state duration elapses the circuit breaker becomes half-open,
+// allowing a small number of canary jobs to go through: if all of them succeed the circuit breaker closes, whereas if any of them fails, it opens again.
+type Registry struct {
+	mu       sync.Mutex
+	breakers map[string]*breaker
+
+	enabled           config.ValueLoader[bool]
+	failureRatio      config.ValueLoader[float64]
+	minRequests       config.ValueLoader[int]
+	interval          config.ValueLoader[time.Duration]
+	openStateDuration config.ValueLoader[time.Duration]
+	canaryJobs        config.ValueLoader[int]
+
+	onStateChange func(key string, from, to State)
+	now           func() time.Time
+}
+
+// Status is a snapshot of a circuit breaker's status
+type Status struct {
+	State    string    `json:"state"`
+	Since    time.Time `json:"since"`
+	Requests int       `json:"requests"`
+	Failures int       `json:"failures"`
+}
+
+type breaker struct {
+	state State
+	since time.Time // time of the last state change
+
+	windowStart time.Time // start of the current counting interval (closed state)
+	requests    int       // number of jobs with an outcome in the current interval, or canary outcomes in the half-open state
+	failures    int       // number of failed jobs in the current interval
+
+	canaries int // number of canary jobs allowed in the half-open state
+
+	generation int // incremented every time the circuit breaker trips
+}
+
+// Allow returns true if a job for the given key is allowed to be delivered, along with the circuit breaker's current generation.
+// While half-open, every allowed job is considered to be a canary job.
+func (r *Registry) Allow(key string) (allowed bool, generation int) {
+	if !r.enabled.Load() {
+		return true, 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[key]
+	if !ok {
+		return true, 0
+	}
+	switch r.refresh(key, b) {
+	case StateOpen:
+		return false, b.generation
+	case StateHalfOpen:
+		if b.canaries >= r.canaryJobs.Load() {
+			return false, b.generation
+		}
+		b.canaries++
+		return true, b.generation
+	default:
+		return true, b.generation
+	}
+}
+
+// Release gives back a canary job allowed during the given generation which is not going to be delivered after all (e.g. because it got throttled),
+// so that another job can be allowed in its place while the circuit breaker is half-open
+func (r *Registry) Release(key string, generation int) {
+	if !r.enabled.Load() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[key]
+	if !ok || b.state != StateHalfOpen || b.generation != generation || b.canaries == 0 {
+		return
+	}
+	b.canaries--
+}
+
+// Tripped returns true if the circuit breaker for the given key has tripped after the provided generation, i.e.
+// a job which was allowed during that generation shouldn't be delivered anymore.
+func (r *Registry) Tripped(key string, generation int) bool {
+	if !r.enabled.Load() {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[key]
+	if !ok {
+		return false
+	}
+	return b.generation != generation
+}
+
+// State returns the current state of the circuit breaker for the given key
+func (r *Registry) State(key string) State {
+	if !r.enabled.Load() {
+		return StateClosed
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[key]
+	if !ok {
+		return StateClosed
+	}
+	return r.refresh(key, b)
+}
+
+// Success records a successful job for the given key
+func (r *Registry) Success(key string) {
+	r.record(key, false)
+}
+
+// Failure records a failed job for the given key
+func (r *Registry) Failure(key string) {
+	r.record(key, true)
+}
+
+// Statuses returns a snapshot of the status of all circuit breakers that have recorded any outcomes
+func (r *Registry) Statuses() map[string]Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make(map[string]Status, len(r.breakers))
+	for key, b := range r.breakers {
+		state := r.refresh(key, b)
+		statuses[key] = Status{
+			State:    state.String(),
+			Since:    b.since,
+			Requests: b.requests,
+			Failures: b.failures,
+		}
+	}
+	return statuses
+}
+
+// Prune drops the circuit breakers whose keys are not to be kept (e.g. of destinations which have been removed), returning their keys
+func (r *Registry) Prune(keep func(key string) bool) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var pruned []string
+	for key := range r.breakers {
+		if !keep(key) {
+			delete(r.breakers, key)
+			pruned = append(pruned, key)
+		}
+	}
+	return pruned
+}
+
+func (r *Registry) record(key string, failed bool) {
+	if !r.enabled.Load() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.breakers[key]
+	if !ok {
+		now := r.now()
+		b = &breaker{state: StateClosed, since: now, windowStart: now}
+		r.breakers[key] = b
+	}
+	switch r.refresh(key, b) {
+	case StateOpen: // outcomes of jobs which were allowed before the circuit breaker tripped are ignored
+		return
+	case StateHalfOpen:
+		if failed {
+			r.setState(key, b, StateOpen)
+			return
+		}
+		b.requests++
+		if b.requests >= r.canaryJobs.Load() {
+			r.setState(key, b, StateClosed)
+		}
+	default:
+		b.requests++
+		if failed {
+			b.failures++
+		}
+		if b.requests >= r.minRequests.Load() && float64(b.failures)/float64(b.requests) >= r.failureRatio.Load() {
+			r.setState(key, b, StateOpen)
+		}
+	}
+}
+
+// refresh applies any time-based state transitions and returns the breaker's current state
+func (r *Registry) refresh(key string, b *breaker) State {
+	now := r.now()
+	switch b.state {
+	case StateOpen:
+		if now.Sub(b.since) >= r.openStateDuration.Load() {
+			r.setState(key, b, StateHalfOpen)
+		}
+	case StateHalfOpen:
+		// canary jobs might never report back (e.g. failing at transformation), don't let a stale half-open state block the key forever
+		if now.Sub(b.since) >= r.openStateDuration.Load() {
+			b.since = now
+			b.requests = 0
+			b.canaries = 0
+		}
+	default:
+		if now.Sub(b.windowStart) >= r.interval.Load() {
+			b.windowStart = now
+			b.requests = 0
+			b.failures = 0
+		}
+	}
+	return b.state
+}
+
+func (r *Registry) setState(key string, b *breaker, state State) {
+	previous := b.state
+	now := r.now()
+	b.state = state
+	b.since = now
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+	b.canaries = 0
+	if state == StateOpen {
+		b.generation++
+	}
+	r.onStateChange(key, previous, state)
+}
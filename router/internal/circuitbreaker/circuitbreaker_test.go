@@ -0,0 +1,211 @@
+package circuitbreaker_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-server/router/internal/circuitbreaker"
+)
+
+func allow(r *circuitbreaker.Registry, key string) bool {
+	allowed, _ := r.Allow(key)
+	return allowed
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	const key = "dest-1"
+
+	newRegistry := func(now *time.Time, transitions *[]circuitbreaker.State) *circuitbreaker.Registry {
+		return circuitbreaker.NewRegistry(
+			circuitbreaker.WithEnabled(config.SingleValueLoader(true)),
+			circuitbreaker.WithFailureRatio(config.SingleValueLoader(0.5)),
+			circuitbreaker.WithMinRequests(config.SingleValueLoader(4)),
+			circuitbreaker.WithInterval(config.SingleValueLoader(time.Minute)),
+			circuitbreaker.WithOpenStateDuration(config.SingleValueLoader(5*time.Minute)),
+			circuitbreaker.WithCanaryJobs(config.SingleValueLoader(2)),
+			circuitbreaker.WithNow(func() time.Time { return *now }),
+			circuitbreaker.WithStateChangeListener(func(_ string, _, to circuitbreaker.State) {
+				*transitions = append(*transitions, to)
+			}),
+		)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		r := circuitbreaker.NewRegistry(circuitbreaker.WithMinRequests(config.SingleValueLoader(1)))
+		r.Failure(key)
+		require.True(t, allow(r, key))
+		require.Equal(t, circuitbreaker.StateClosed, r.State(key))
+		require.Empty(t, r.Statuses())
+	})
+
+	t.Run("does not trip before reaching the minimum number of requests", func(t *testing.T) {
+		now := time.Now()
+		var transitions []circuitbreaker.State
+		r := newRegistry(&now, &transitions)
+		r.Failure(key)
+		r.Failure(key)
+		r.Failure(key)
+		require.Equal(t, circuitbreaker.StateClosed, r.State(key))
+		require.True(t, allow(r, key))
+		require.Empty(t, transitions)
+	})
+
+	t.Run("does not trip below the failure ratio", func(t *testing.T) {
+		now := time.Now()
+		var transitions []circuitbreaker.State
+		r := newRegistry(&now, &transitions)
+		r.Success(key)
+		r.Success(key)
+		r.Success(key)
+		r.Failure(key)
+		require.Equal(t, circuitbreaker.StateClosed, r.State(key))
+	})
+
+	t.Run("counts are reset after the interval", func(t *testing.T) {
+		now := time.Now()
+		var transitions []circuitbreaker.State
+		r := newRegistry(&now, &transitions)
+		r.Failure(key)
+		r.Failure(key)
+		r.Failure(key)
+		now = now.Add(time.Minute)
+		r.Failure(key)
+		require.Equal(t, circuitbreaker.StateClosed, r.State(key))
+		require.Equal(t, 1, r.Statuses()[key].Requests)
+	})
+
+	t.Run("trips, probes and closes", func(t *testing.T) {
+		now := time.Now()
+		var transitions []circuitbreaker.State
+		r := newRegistry(&now, &transitions)
+		r.Success(key)
+		r.Failure(key)
+		r.Failure(key)
+		r.Failure(key)
+		require.Equal(t, circuitbreaker.StateOpen, r.State(key))
+		require.False(t, allow(r, key))
+		require.Equal(t, "open", r.Statuses()[key].State)
+
+		r.Success(key) // outcomes of jobs allowed before tripping are ignored
+		require.Equal(t, circuitbreaker.StateOpen, r.State(key))
+
+		now = now.Add(5 * time.Minute)
+		require.Equal(t, circuitbreaker.StateHalfOpen, r.State(key))
+		require.True(t, allow(r, key), "first canary")
+		require.True(t, allow(r, key), "second canary")
+		require.False(t, allow(r, key), "canary jobs exhausted")
+
+		r.Success(key)
+		require.Equal(t, circuitbreaker.StateHalfOpen, r.State(key))
+		r.Success(key)
+		require.Equal(t, circuitbreaker.StateClosed, r.State(key))
+		require.True(t, allow(r, key))
+
+		require.Equal(t, []circuitbreaker.State{circuitbreaker.StateOpen, circuitbreaker.StateHalfOpen, circuitbreaker.StateClosed}, transitions)
+	})
+
+	t.Run("canary failure opens again", func(t *testing.T) {
+		now := time.Now()
+		var transitions []circuitbreaker.State
+		r := newRegistry(&now, &transitions)
+		for range 4 {
+			r.Failure(key)
+		}
+		now = now.Add(5 * time.Minute)
+		require.True(t, allow(r, key))
+		r.Failure(key)
+		require.Equal(t, circuitbreaker.StateOpen, r.State(key))
+		require.False(t, allow(r, key))
+		require.Equal(t, []circuitbreaker.State{circuitbreaker.StateOpen, circuitbreaker.StateHalfOpen, circuitbreaker.StateOpen}, transitions)
+	})
+
+	t.Run("stale canaries are released", func(t *testing.T) {
+		now := time.Now()
+		var transitions []circuitbreaker.State
+		r := newRegistry(&now, &transitions)
+		for range 4 {
+			r.Failure(key)
+		}
+		now = now.Add(5 * time.Minute)
+		require.True(t, allow(r, key))
+		require.True(t, allow(r, key))
+		require.False(t, allow(r, key))
+
+		now = now.Add(5 * time.Minute)
+		require.Equal(t, circuitbreaker.StateHalfOpen, r.State(key))
+		require.True(t, allow(r, key), "canary reservations should be reset")
+	})
+
+	t.Run("released canaries", func(t *testing.T) {
+		now := time.Now()
+		var transitions []circuitbreaker.State
+		r := newRegistry(&now, &transitions)
+		for range 4 {
+			r.Failure(key)
+		}
+		r.Release(key, 1) // no canaries to release while open
+		require.False(t, allow(r, key))
+
+		now = now.Add(5 * time.Minute)
+		_, generation := r.Allow(key)
+		require.True(t, allow(r, key))
+		require.False(t, allow(r, key))
+		r.Release(key, generation)
+		require.True(t, allow(r, key), "a released canary should allow another job")
+		r.Release(key, generation-1)
+		require.False(t, allow(r, key), "canaries of previous generations should not be released")
+	})
+
+	t.Run("tripped since generation", func(t *testing.T) {
+		now := time.Now()
+		var transitions []circuitbreaker.State
+		r := newRegistry(&now, &transitions)
+		require.False(t, r.Tripped(key, 0))
+
+		_, generation := r.Allow(key)
+		for range 4 {
+			r.Failure(key)
+		}
+		require.True(t, r.Tripped(key, generation), "jobs allowed before tripping")
+
+		now = now.Add(5 * time.Minute)
+		allowed, canaryGeneration := r.Allow(key)
+		require.True(t, allowed)
+		require.False(t, r.Tripped(key, canaryGeneration), "canary jobs")
+		require.True(t, r.Tripped(key, generation))
+
+		r.Failure(key)
+		require.True(t, r.Tripped(key, canaryGeneration), "canary jobs after a canary failure")
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		now := time.Now()
+		var transitions []circuitbreaker.State
+		r := newRegistry(&now, &transitions)
+		for range 4 {
+			r.Failure(key)
+		}
+		require.Equal(t, circuitbreaker.StateOpen, r.State(key))
+		require.Equal(t, circuitbreaker.StateClosed, r.State("dest-2"))
+		require.True(t, allow(r, "dest-2"))
+	})
+
+	t.Run("prune", func(t *testing.T) {
+		now := time.Now()
+		var transitions []circuitbreaker.State
+		r := newRegistry(&now, &transitions)
+		for range 4 {
+			r.Failure(key)
+		}
+		r.Success("dest-2")
+		pruned := r.Prune(func(k string) bool { return k == "dest-2" })
+		require.Equal(t, []string{key}, pruned)
+		require.Equal(t, circuitbreaker.StateClosed, r.State(key), "pruned circuit breakers start afresh")
+		require.True(t, allow(r, key))
+		require.Contains(t, r.Statuses(), "dest-2")
+		require.NotContains(t, r.Statuses(), key)
+	})
+}
@@ -0,0 +1,127 @@
+package deliveryauth_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/router/internal/deliveryauth"
+	"github.com/rudderlabs/rudder-server/testhelper/certs"
+)
+
+func configWith(destinations ...backendconfig.DestinationT) map[string]backendconfig.ConfigT {
+	return map[string]backendconfig.ConfigT{
+		"workspace-1": {
+			Sources: []backendconfig.SourceT{{ID: "source-1", Destinations: destinations}},
+		},
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	clientCert := certs.SelfSigned(t, "client")
+	caCert := certs.SelfSigned(t, "server", "localhost")
+
+	mtls := map[string]interface{}{
+		"clientCertificate": string(clientCert.Certificate),
+		"clientKey":         string(clientCert.Key),
+		"caCertificate":     string(caCert.Certificate),
+		"serverName":        "localhost",
+	}
+	signing := map[string]interface{}{
+		"secret":          "s3cr3t",
+		"signatureHeader": "X-Signature",
+	}
+
+	t.Run("destinations without settings", func(t *testing.T) {
+		r := deliveryauth.NewRegistry()
+		r.Update(configWith(backendconfig.DestinationT{ID: "dest-1", Config: map[string]interface{}{"url": "https://example.com"}}))
+		_, ok := r.Destination("dest-1")
+		require.False(t, ok)
+	})
+
+	t.Run("mtls and signing", func(t *testing.T) {
+		r := deliveryauth.NewRegistry()
+		r.Update(configWith(backendconfig.DestinationT{ID: "dest-1", Config: map[string]interface{}{"mtls": mtls, "requestSigning": signing}}))
+		auth, ok := r.Destination("dest-1")
+		require.True(t, ok)
+		require.NoError(t, auth.Err)
+		require.NotNil(t, auth.TLSConfig)
+		require.Len(t, auth.TLSConfig.Certificates, 1)
+		require.NotNil(t, auth.TLSConfig.RootCAs)
+		require.Equal(t, "localhost", auth.TLSConfig.ServerName)
+		require.NotNil(t, auth.Signer)
+		require.Nil(t, auth.OAuth)
+	})
+
+	t.Run("oauth2", func(t *testing.T) {
+		r := deliveryauth.NewRegistry()
+		r.Update(configWith(backendconfig.DestinationT{ID: "dest-1", Config: map[string]interface{}{"oauth2": map[string]interface{}{
+			"tokenUrl":     "https://idp.example.com/oauth/token",
+			"clientId":     "client",
+			"clientSecret": "s3cr3t",
+			"scopes":       "events:write events:read",
+		}}}))
+		auth, ok := r.Destination("dest-1")
+		require.True(t, ok)
+		require.NoError(t, auth.Err)
+		require.NotNil(t, auth.OAuth)
+		require.Equal(t, []string{"events:write", "events:read"}, auth.OAuth.Scopes)
+	})
+
+	t.Run("settings are retained if unchanged", func(t *testing.T) {
+		r := deliveryauth.NewRegistry()
+		r.Update(configWith(backendconfig.DestinationT{ID: "dest-1", Config: map[string]interface{}{"mtls": mtls}}))
+		before, _ := r.Destination("dest-1")
+		r.Update(configWith(backendconfig.DestinationT{ID: "dest-1", Config: map[string]interface{}{"mtls": mtls}}))
+		after, _ := r.Destination("dest-1")
+		require.Same(t, before, after)
+
+		r.Update(configWith(backendconfig.DestinationT{ID: "dest-1", Config: map[string]interface{}{"mtls": mtls, "requestSigning": signing}}))
+		changed, _ := r.Destination("dest-1")
+		require.NotSame(t, before, changed)
+
+		r.Update(configWith())
+		_, ok := r.Destination("dest-1")
+		require.False(t, ok)
+	})
+
+	t.Run("invalid settings", func(t *testing.T) {
+		r := deliveryauth.NewRegistry()
+		r.Update(configWith(
+			backendconfig.DestinationT{ID: "missing-key", Config: map[string]interface{}{"mtls": map[string]interface{}{"clientCertificate": string(clientCert.Certificate)}}},
+			backendconfig.DestinationT{ID: "bad-ca", Config: map[string]interface{}{"mtls": map[string]interface{}{
+				"clientCertificate": string(clientCert.Certificate),
+				"clientKey":         string(clientCert.Key),
+				"caCertificate":     "not a certificate",
+			}}},
+			backendconfig.DestinationT{ID: "missing-secret", Config: map[string]interface{}{"requestSigning": map[string]interface{}{}}},
+			backendconfig.DestinationT{ID: "bad-algorithm", Config: map[string]interface{}{"requestSigning": map[string]interface{}{"secret": "s", "algorithm": "md5"}}},
+			backendconfig.DestinationT{ID: "bad-oauth2", Config: map[string]interface{}{"oauth2": map[string]interface{}{"tokenUrl": "https://idp.example.com/oauth/token"}}},
+		))
+		for _, id := range []string{"missing-key", "bad-ca", "missing-secret", "bad-algorithm", "bad-oauth2"} {
+			auth, ok := r.Destination(id)
+			require.True(t, ok, id)
+			require.Error(t, auth.Err, id)
+		}
+	})
+}
+
+func TestSigner(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	r := deliveryauth.NewRegistry(deliveryauth.WithNow(func() time.Time { return now }))
+	r.Update(configWith(backendconfig.DestinationT{ID: "dest-1", Config: map[string]interface{}{
+		"requestSigning": map[string]interface{}{"algorithm": "HMAC-SHA256", "secret": "s3cr3t"},
+	}}))
+	auth, ok := r.Destination("dest-1")
+	require.True(t, ok)
+	require.NoError(t, auth.Err)
+
+	header := http.Header{}
+	body := []byte(`{"event":"Signed Up"}`)
+	auth.Signer.Sign(header, body)
+	require.Equal(t, "1700000000", header.Get(deliveryauth.DefaultTimestampHeader))
+	require.Equal(t, "sha256=f63ec04ab2dec6fbb342e03471e1d886baf0d811cf2efdc0e4ad081d19863068", header.Get(deliveryauth.DefaultSignatureHeader))
+}
@@ -0,0 +1,216 @@
+package priority
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	kitsync "github.com/rudderlabs/rudder-go-kit/sync"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+)
+
+// Tier is the priority tier of a workspace or destination
+type Tier string
+
+const (
+	TierNone   Tier = ""
+	TierLow    Tier = "low"
+	TierMedium Tier = "medium"
+	TierHigh   Tier = "high"
+)
+
+// destinationConfigKey is the key of a destination's config containing its priority settings, e.g.
+//
+//	"deliveryPriority": {"tier": "high", "weight": 2}
+const destinationConfigKey = "deliveryPriority"
+
+// Settings are the priority settings of a workspace or destination
+type Settings struct {
+	Tier   Tier
+	Weight int
+}
+
+// IsZero returns true if no priority settings are defined
+func (s Settings) IsZero() bool {
+	return s.Tier == TierNone && s.Weight <= 0
+}
+
+// LimiterPriority returns the limiter priority value corresponding to the settings' tier and a boolean indicating whether a tier is defined or not
+func (s Settings) LimiterPriority() (kitsync.LimiterPriorityValue, bool) {
+	switch s.Tier {
+	case TierHigh:
+		return kitsync.LimiterPriorityValueHigh, true
+	case TierMedium:
+		return kitsync.LimiterPriorityValueMedium, true
+	case TierLow:
+		return kitsync.LimiterPriorityValueLow, true
+	default:
+		return kitsync.LimiterPriorityValueLow, false
+	}
+}
+
+// NewRegistry returns a new, empty priority registry
+func NewRegistry() *Registry {
+	return &Registry{
+		workspaces:   make(map[string]Settings),
+		destinations: make(map[string]Settings),
+	}
+}
+
+// Registry keeps track of the priority settings of workspaces and destinations, as defined in backend config.
+// Destination settings take precedence over the settings of the workspace the destination belongs to.
+type Registry struct {
+	mu                  sync.RWMutex
+	workspaces          map[string]Settings // workspaceID -> settings
+	destinations        map[string]Settings // destinationID -> settings (already merged with workspace settings)
+	activePartitions    []string            // partitions having pending jobs, as last reported through [Registry.SetActivePartitions]
+	activeHighestWeight int                 // highest weight of the active partitions, 0 unless more than one partition is active
+}
+
+// Update replaces the registry's settings with the ones found in the provided backend config
+func (r *Registry) Update(config map[string]backendconfig.ConfigT) {
+	workspaces := make(map[string]Settings)
+	destinations := make(map[string]Settings)
+	for workspaceID, wConfig := range config {
+		workspaceSettings := Settings{
+			Tier:   parseTier(wConfig.Settings.Priority.Tier),
+			Weight: wConfig.Settings.Priority.Weight,
+		}
+		if !workspaceSettings.IsZero() {
+			workspaces[workspaceID] = workspaceSettings
+		}
+		for i := range wConfig.Sources {
+			source := &wConfig.Sources[i]
+			for j := range source.Destinations {
+				destination := &source.Destinations[j]
+				if _, ok := destinations[destination.ID]; ok {
+					continue
+				}
+				destinationSettings := merge(destinationSettingsFromConfig(destination.Config), workspaceSettings)
+				if !destinationSettings.IsZero() {
+					destinations[destination.ID] = destinationSettings
+				}
+			}
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workspaces = workspaces
+	r.destinations = destinations
+	r.activeHighestWeight = r.highestWeight(r.activePartitions)
+}
+
+// SetActivePartitions sets the partitions currently having pending jobs, i.e. the partitions competing for the pickup limit
+func (r *Registry) SetActivePartitions(partitions []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.activePartitions = partitions
+	r.activeHighestWeight = r.highestWeight(partitions)
+}
+
+// highestWeight returns the highest weight of the given partitions, or 0 if there are less than two of them, since a single partition doesn't compete with any other.
+// It needs to be called while holding the lock.
+func (r *Registry) highestWeight(partitions []string) int {
+	if len(partitions) < 2 {
+		return 0
+	}
+	var highestWeight int
+	for _, partition := range partitions {
+		highestWeight = max(highestWeight, r.partition(partition).Weight)
+	}
+	return highestWeight
+}
+
+// Workspace returns the priority settings of the given workspace
+func (r *Registry) Workspace(workspaceID string) Settings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.workspaces[workspaceID]
+}
+
+// Destination returns the priority settings of the given destination, falling back to the settings of its workspace
+func (r *Registry) Destination(destinationID string) Settings {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.destinations[destinationID]
+}
+
+// Partition returns the priority settings of the given partition, which can either be a destinationID or a workspaceID
+// depending on the isolation mode being used. Empty settings are returned for partitions not corresponding to any of the two.
+func (r *Registry) Partition(partition string) Settings {
+	if partition == "" {
+		return Settings{}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.partition(partition)
+}
+
+func (r *Registry) partition(partition string) Settings {
+	if s, ok := r.destinations[partition]; ok {
+		return s
+	}
+	return r.workspaces[partition]
+}
+
+// Limit returns the share of the given pickup limit of the given partition, according to its weight relative to the highest weight of the active partitions.
+// Weights apply only while partitions compete, i.e. while more than one partition is active: the active partitions having the highest weight get the
+// whole limit, while the rest get a proportional share of it, partitions without a weight counting as having a weight of 1. Weights are capped to maxWeight,
+// so that no partition gets less than 1/maxWeight of the limit. The limit is never exceeded, and it is returned as is if no weights are defined,
+// if partitions don't compete or if jobs are not partitioned (empty partition).
+func (r *Registry) Limit(partition string, limit, maxWeight int) int {
+	if partition == "" {
+		return limit
+	}
+	r.mu.RLock()
+	weight := max(r.partition(partition).Weight, 1)
+	highestWeight := r.activeHighestWeight
+	r.mu.RUnlock()
+	if maxWeight > 0 {
+		weight, highestWeight = min(weight, maxWeight), min(highestWeight, maxWeight)
+	}
+	if highestWeight <= 1 {
+		return limit
+	}
+	return max(min(limit*weight/highestWeight, limit), 1)
+}
+
+// merge fills any missing values of s from the provided fallback settings
+func merge(s, fallback Settings) Settings {
+	if s.Tier == TierNone {
+		s.Tier = fallback.Tier
+	}
+	if s.Weight <= 0 {
+		s.Weight = fallback.Weight
+	}
+	return s
+}
+
+func destinationSettingsFromConfig(config map[string]interface{}) Settings {
+	var s Settings
+	priority, ok := config[destinationConfigKey].(map[string]interface{})
+	if !ok {
+		return s
+	}
+	if tier, ok := priority["tier"].(string); ok {
+		s.Tier = parseTier(tier)
+	}
+	switch weight := priority["weight"].(type) {
+	case float64:
+		s.Weight = int(weight)
+	case int:
+		s.Weight = weight
+	case string:
+		s.Weight, _ = strconv.Atoi(weight)
+	}
+	return s
+}
+
+func parseTier(tier string) Tier {
+	switch t := Tier(strings.ToLower(strings.TrimSpace(tier))); t {
+	case TierLow, TierMedium, TierHigh:
+		return t
+	default:
+		return TierNone
+	}
+}
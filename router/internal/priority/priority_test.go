@@ -0,0 +1,113 @@
+package priority_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	kitsync "github.com/rudderlabs/rudder-go-kit/sync"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/router/internal/priority"
+)
+
+func TestRegistry(t *testing.T) {
+	r := priority.NewRegistry()
+	r.Update(map[string]backendconfig.ConfigT{
+		"ws-1": {
+			Settings: backendconfig.Settings{Priority: backendconfig.Priority{Tier: "High", Weight: 3}},
+			Sources: []backendconfig.SourceT{{
+				ID: "src-1",
+				Destinations: []backendconfig.DestinationT{
+					{ID: "dest-1"},
+					{ID: "dest-2", Config: map[string]interface{}{"deliveryPriority": map[string]interface{}{"tier": "low"}}},
+					{ID: "dest-3", Config: map[string]interface{}{"deliveryPriority": map[string]interface{}{"weight": float64(5)}}},
+				},
+			}},
+		},
+		"ws-2": {
+			Sources: []backendconfig.SourceT{{
+				ID: "src-2",
+				Destinations: []backendconfig.DestinationT{
+					{ID: "dest-4"},
+					{ID: "dest-5", Config: map[string]interface{}{"deliveryPriority": map[string]interface{}{"tier": "medium", "weight": "2"}}},
+					{ID: "dest-6", Config: map[string]interface{}{"deliveryPriority": map[string]interface{}{"tier": "invalid"}}},
+				},
+			}},
+		},
+	})
+
+	t.Run("workspace", func(t *testing.T) {
+		require.Equal(t, priority.Settings{Tier: priority.TierHigh, Weight: 3}, r.Workspace("ws-1"))
+		require.True(t, r.Workspace("ws-2").IsZero())
+	})
+
+	t.Run("destination", func(t *testing.T) {
+		require.Equal(t, priority.Settings{Tier: priority.TierHigh, Weight: 3}, r.Destination("dest-1"), "inherits workspace settings")
+		require.Equal(t, priority.Settings{Tier: priority.TierLow, Weight: 3}, r.Destination("dest-2"), "overrides workspace tier")
+		require.Equal(t, priority.Settings{Tier: priority.TierHigh, Weight: 5}, r.Destination("dest-3"), "overrides workspace weight")
+		require.True(t, r.Destination("dest-4").IsZero())
+		require.Equal(t, priority.Settings{Tier: priority.TierMedium, Weight: 2}, r.Destination("dest-5"))
+		require.True(t, r.Destination("dest-6").IsZero())
+	})
+
+	t.Run("partition", func(t *testing.T) {
+		require.Equal(t, r.Destination("dest-2"), r.Partition("dest-2"))
+		require.Equal(t, r.Workspace("ws-1"), r.Partition("ws-1"))
+		require.True(t, r.Partition("").IsZero())
+		require.True(t, r.Partition("unknown").IsZero())
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		require.Equal(t, 100, r.Limit("dest-4", 100, 10), "weights don't apply while partitions don't compete")
+		r.SetActivePartitions([]string{"dest-4"})
+		require.Equal(t, 100, r.Limit("dest-4", 100, 10), "a single active partition doesn't compete with any other")
+
+		r.SetActivePartitions([]string{"dest-3", "ws-1", "dest-5", "dest-4"})
+		require.Equal(t, 100, r.Limit("dest-3", 100, 10), "the highest weight gets the whole limit")
+		require.Equal(t, 60, r.Limit("ws-1", 100, 10))
+		require.Equal(t, 40, r.Limit("dest-5", 100, 10))
+		require.Equal(t, 20, r.Limit("dest-4", 100, 10), "partitions without a weight count as having a weight of 1")
+		require.Equal(t, 100, r.Limit("", 100, 10), "jobs which are not partitioned get the whole limit")
+		require.Equal(t, 50, r.Limit("dest-4", 100, 2), "weights are capped")
+		require.Equal(t, 1, r.Limit("dest-4", 1, 10), "the limit is at least 1")
+
+		r.SetActivePartitions([]string{"ws-1", "dest-4"})
+		require.Equal(t, 100, r.Limit("ws-1", 100, 10), "weights are relative to the highest weight of the active partitions")
+		require.Equal(t, 33, r.Limit("dest-4", 100, 10))
+		require.Equal(t, 100, r.Limit("dest-3", 100, 10), "the limit is never exceeded")
+		r.SetActivePartitions(nil)
+
+		r := priority.NewRegistry()
+		r.Update(map[string]backendconfig.ConfigT{
+			"ws-1": {Settings: backendconfig.Settings{Priority: backendconfig.Priority{Tier: "high"}}},
+		})
+		require.Equal(t, 100, r.Limit("ws-1", 100, 10), "the limit is kept without any weights")
+		require.Equal(t, 100, r.Limit("ws-2", 100, 10))
+	})
+
+	t.Run("update replaces previous settings", func(t *testing.T) {
+		r := priority.NewRegistry()
+		r.Update(map[string]backendconfig.ConfigT{
+			"ws-1": {Settings: backendconfig.Settings{Priority: backendconfig.Priority{Tier: "high"}}},
+		})
+		require.False(t, r.Workspace("ws-1").IsZero())
+		r.Update(map[string]backendconfig.ConfigT{})
+		require.True(t, r.Workspace("ws-1").IsZero())
+	})
+}
+
+func TestSettings(t *testing.T) {
+	t.Run("limiter priority", func(t *testing.T) {
+		for tier, expected := range map[priority.Tier]kitsync.LimiterPriorityValue{
+			priority.TierHigh:   kitsync.LimiterPriorityValueHigh,
+			priority.TierMedium: kitsync.LimiterPriorityValueMedium,
+			priority.TierLow:    kitsync.LimiterPriorityValueLow,
+		} {
+			p, ok := priority.Settings{Tier: tier}.LimiterPriority()
+			require.True(t, ok)
+			require.Equal(t, expected, p)
+		}
+		_, ok := priority.Settings{}.LimiterPriority()
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,183 @@
+package retrypolicy
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+)
+
+// Strategy is the backoff strategy of a retry policy
+type Strategy string
+
+const (
+	StrategyNone        Strategy = ""
+	StrategyExponential Strategy = "exponential" // minBackoff * 2^(attempt-1)
+	StrategyLinear      Strategy = "linear"      // minBackoff * attempt
+	StrategyFixed       Strategy = "fixed"       // minBackoff
+)
+
+// configKey is the key of a destination definition's or destination's config containing its retry policy, e.g.
+//
+//	"retryPolicy": {"strategy": "linear", "minBackoff": "10s", "maxBackoff": "5m", "jitter": 0.2, "maxAttempts": 5, "maxAge": "3h"}
+const configKey = "retryPolicy"
+
+// Policy is the retry policy of a destination. Zero values denote settings which are not defined.
+type Policy struct {
+	Strategy    Strategy
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	Jitter      float64       // fraction of the backoff to be randomly added or subtracted, between 0 and 1
+	MaxAttempts int           // number of attempts after which a job gets aborted, regardless of its age
+	MaxAge      time.Duration // time since the first attempt after which a job gets aborted, regardless of its attempts
+}
+
+// IsZero returns true if no retry policy settings are defined
+func (p Policy) IsZero() bool {
+	return p == Policy{}
+}
+
+// NextAttemptAfter returns the delay before the next attempt of a job which has already been attempted the given number of times
+func (p Policy) NextAttemptAfter(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	var backoff float64
+	switch p.Strategy {
+	case StrategyFixed:
+		backoff = float64(p.MinBackoff)
+	case StrategyLinear:
+		backoff = float64(p.MinBackoff) * float64(attempt)
+	default:
+		backoff = float64(p.MinBackoff) * math.Exp2(float64(attempt-1))
+	}
+	if p.MaxBackoff > 0 {
+		backoff = math.Min(backoff, float64(p.MaxBackoff))
+	}
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(backoff)
+}
+
+// WithDefaults fills any missing values of the policy from the provided fallback policy
+func (p Policy) WithDefaults(fallback Policy) Policy {
+	if p.Strategy == StrategyNone {
+		p.Strategy = fallback.Strategy
+	}
+	if p.MinBackoff <= 0 {
+		p.MinBackoff = fallback.MinBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = fallback.MaxBackoff
+	}
+	if p.Jitter <= 0 {
+		p.Jitter = fallback.Jitter
+	}
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = fallback.MaxAttempts
+	}
+	if p.MaxAge <= 0 {
+		p.MaxAge = fallback.MaxAge
+	}
+	return p
+}
+
+// NewRegistry returns a new, empty retry policy registry
+func NewRegistry() *Registry {
+	return &Registry{destinations: make(map[string]Policy)}
+}
+
+// Registry keeps track of the retry policies of destinations, as defined in backend config.
+// Policy settings of a destination take precedence over the settings of its destination definition.
+type Registry struct {
+	mu           sync.RWMutex
+	destinations map[string]Policy // destinationID -> policy (already merged with the destination definition's policy)
+}
+
+// Update replaces the registry's policies with the ones found in the provided backend config
+func (r *Registry) Update(config map[string]backendconfig.ConfigT) {
+	destinations := make(map[string]Policy)
+	for _, wConfig := range config {
+		for i := range wConfig.Sources {
+			source := &wConfig.Sources[i]
+			for j := range source.Destinations {
+				destination := &source.Destinations[j]
+				if _, ok := destinations[destination.ID]; ok {
+					continue
+				}
+				policy := fromConfig(destination.Config).WithDefaults(fromConfig(destination.DestinationDefinition.Config))
+				if !policy.IsZero() {
+					destinations[destination.ID] = policy
+				}
+			}
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.destinations = destinations
+}
+
+// Destination returns the retry policy of the given destination and a boolean indicating whether a policy is defined or not
+func (r *Registry) Destination(destinationID string) (Policy, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.destinations[destinationID]
+	return p, ok
+}
+
+func fromConfig(config map[string]interface{}) Policy {
+	var p Policy
+	policy, ok := config[configKey].(map[string]interface{})
+	if !ok {
+		return p
+	}
+	if strategy, ok := policy["strategy"].(string); ok {
+		p.Strategy = parseStrategy(strategy)
+	}
+	p.MinBackoff = max(parseDuration(policy["minBackoff"]), 0)
+	p.MaxBackoff = max(parseDuration(policy["maxBackoff"]), 0)
+	if jitter := parseFloat(policy["jitter"]); jitter > 0 {
+		p.Jitter = math.Min(jitter, 1)
+	}
+	p.MaxAttempts = max(int(parseFloat(policy["maxAttempts"])), 0)
+	p.MaxAge = max(parseDuration(policy["maxAge"]), 0)
+	return p
+}
+
+func parseStrategy(strategy string) Strategy {
+	switch s := Strategy(strings.ToLower(strings.TrimSpace(strategy))); s {
+	case StrategyExponential, StrategyLinear, StrategyFixed:
+		return s
+	default:
+		return StrategyNone
+	}
+}
+
+// parseDuration parses either a duration string (e.g. "10s") or a number of seconds
+func parseDuration(v interface{}) time.Duration {
+	if s, ok := v.(string); ok {
+		if d, err := time.ParseDuration(s); err == nil {
+			return d
+		}
+	}
+	return time.Duration(parseFloat(v) * float64(time.Second))
+}
+
+func parseFloat(v interface{}) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case int:
+		return float64(v)
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,96 @@
+package retrypolicy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/router/internal/retrypolicy"
+)
+
+func TestPolicy(t *testing.T) {
+	t.Run("exponential", func(t *testing.T) {
+		p := retrypolicy.Policy{Strategy: retrypolicy.StrategyExponential, MinBackoff: 10 * time.Second, MaxBackoff: 300 * time.Second}
+		require.Equal(t, 10*time.Second, p.NextAttemptAfter(0))
+		require.Equal(t, 10*time.Second, p.NextAttemptAfter(1))
+		require.Equal(t, 20*time.Second, p.NextAttemptAfter(2))
+		require.Equal(t, 160*time.Second, p.NextAttemptAfter(5))
+		require.Equal(t, 300*time.Second, p.NextAttemptAfter(6))
+	})
+
+	t.Run("linear", func(t *testing.T) {
+		p := retrypolicy.Policy{Strategy: retrypolicy.StrategyLinear, MinBackoff: 10 * time.Second, MaxBackoff: 35 * time.Second}
+		require.Equal(t, 10*time.Second, p.NextAttemptAfter(1))
+		require.Equal(t, 20*time.Second, p.NextAttemptAfter(2))
+		require.Equal(t, 30*time.Second, p.NextAttemptAfter(3))
+		require.Equal(t, 35*time.Second, p.NextAttemptAfter(4))
+	})
+
+	t.Run("fixed", func(t *testing.T) {
+		p := retrypolicy.Policy{Strategy: retrypolicy.StrategyFixed, MinBackoff: 10 * time.Second}
+		require.Equal(t, 10*time.Second, p.NextAttemptAfter(1))
+		require.Equal(t, 10*time.Second, p.NextAttemptAfter(10))
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		p := retrypolicy.Policy{Strategy: retrypolicy.StrategyFixed, MinBackoff: 10 * time.Second, Jitter: 0.5}
+		for range 100 {
+			d := p.NextAttemptAfter(1)
+			require.GreaterOrEqual(t, d, 5*time.Second)
+			require.LessOrEqual(t, d, 15*time.Second)
+		}
+	})
+}
+
+func TestRegistry(t *testing.T) {
+	r := retrypolicy.NewRegistry()
+	definitionConfig := map[string]interface{}{
+		"retryPolicy": map[string]interface{}{"strategy": "linear", "minBackoff": "5s", "maxAttempts": float64(3)},
+	}
+	r.Update(map[string]backendconfig.ConfigT{
+		"ws-1": {
+			Sources: []backendconfig.SourceT{{
+				ID: "src-1",
+				Destinations: []backendconfig.DestinationT{
+					{ID: "dest-1"},
+					{ID: "dest-2", DestinationDefinition: backendconfig.DestinationDefinitionT{Config: definitionConfig}},
+					{
+						ID:                    "dest-3",
+						DestinationDefinition: backendconfig.DestinationDefinitionT{Config: definitionConfig},
+						Config: map[string]interface{}{
+							"retryPolicy": map[string]interface{}{"strategy": "Fixed", "maxBackoff": float64(60), "jitter": "0.1", "maxAge": "1h"},
+						},
+					},
+					{ID: "dest-4", Config: map[string]interface{}{"retryPolicy": map[string]interface{}{"strategy": "invalid", "minBackoff": "invalid"}}},
+				},
+			}},
+		},
+	})
+
+	_, ok := r.Destination("dest-1")
+	require.False(t, ok)
+
+	p, ok := r.Destination("dest-2")
+	require.True(t, ok)
+	require.Equal(t, retrypolicy.Policy{Strategy: retrypolicy.StrategyLinear, MinBackoff: 5 * time.Second, MaxAttempts: 3}, p)
+
+	p, ok = r.Destination("dest-3")
+	require.True(t, ok)
+	require.Equal(t, retrypolicy.Policy{
+		Strategy:    retrypolicy.StrategyFixed,
+		MinBackoff:  5 * time.Second,
+		MaxBackoff:  time.Minute,
+		Jitter:      0.1,
+		MaxAttempts: 3,
+		MaxAge:      time.Hour,
+	}, p, "destination settings override destination definition settings")
+
+	_, ok = r.Destination("dest-4")
+	require.False(t, ok)
+
+	r.Update(map[string]backendconfig.ConfigT{})
+	_, ok = r.Destination("dest-2")
+	require.False(t, ok, "update replaces previous policies")
+}
@@ -63,6 +63,7 @@ func (noneStrategy) AugmentQueryParams(_ string, _ *jobsdb.GetQueryParams) {
 	// no-op
 }
 
+// StopIteration always returns false, since the partition contains the jobs of all destinations
 func (noneStrategy) StopIteration(_ error, _ string) bool {
 	return false
 }
@@ -85,6 +86,7 @@ func (workspaceStrategy) AugmentQueryParams(partition string, params *jobsdb.Get
 	params.WorkspaceID = partition
 }
 
+// StopIteration always returns false, since the partition contains the jobs of all the workspace's destinations
 func (workspaceStrategy) StopIteration(_ error, _ string) bool {
 	return false
 }
@@ -118,8 +120,11 @@ func (*destinationStrategy) AugmentQueryParams(partition string, params *jobsdb.
 	params.ParameterFilters = append(params.ParameterFilters, jobsdb.ParameterFilterT{Name: "destination_id", Value: partition})
 }
 
-// StopIteration returns true if the error is ErrDestinationThrottled
+// StopIteration returns true if the error is ErrDestinationCircuitOpen or ErrDestinationThrottled
 func (ds *destinationStrategy) StopIteration(err error, destinationID string) bool {
+	if errors.Is(err, types.ErrDestinationCircuitOpen) {
+		return true
+	}
 	return errors.Is(err, types.ErrDestinationThrottled) && !ds.hasDestinationThrottlerPerEventType(destinationID)
 }
 
@@ -33,6 +33,7 @@ func TestIsolationStrategy(t *testing.T) {
 		t.Run("stop iteration", func(t *testing.T) {
 			require.False(t, strategy.StopIteration(types.ErrBarrierExists, destinationID))
 			require.False(t, strategy.StopIteration(types.ErrDestinationThrottled, destinationID))
+			require.False(t, strategy.StopIteration(types.ErrDestinationCircuitOpen, destinationID))
 		})
 		t.Run("stop queries", func(t *testing.T) {
 			require.False(t, strategy.StopQueries(types.ErrBarrierExists, destinationID))
@@ -54,6 +55,7 @@ func TestIsolationStrategy(t *testing.T) {
 		t.Run("stop iteration", func(t *testing.T) {
 			require.False(t, strategy.StopIteration(types.ErrBarrierExists, destinationID))
 			require.False(t, strategy.StopIteration(types.ErrDestinationThrottled, destinationID))
+			require.False(t, strategy.StopIteration(types.ErrDestinationCircuitOpen, destinationID))
 		})
 
 		t.Run("stop queries", func(t *testing.T) {
@@ -67,6 +69,7 @@ func TestIsolationStrategy(t *testing.T) {
 		t.Run("stop iteration", func(t *testing.T) {
 			require.False(t, strategy.StopIteration(types.ErrBarrierExists, destinationID))
 			require.True(t, strategy.StopIteration(types.ErrDestinationThrottled, destinationID))
+			require.True(t, strategy.StopIteration(types.ErrDestinationCircuitOpen, destinationID))
 		})
 
 		t.Run("stop queries", func(t *testing.T) {
@@ -16,6 +16,7 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
@@ -25,7 +26,9 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
+	"github.com/rudderlabs/rudder-server/router/internal/deliveryauth"
 	"github.com/rudderlabs/rudder-server/router/utils"
+	oauthv2 "github.com/rudderlabs/rudder-server/services/oauth/v2"
 	"github.com/rudderlabs/rudder-server/utils/httputil"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/utils/sysUtils"
@@ -44,6 +47,31 @@ type netHandle struct {
 	blockPrivateIPs      bool
 	blockPrivateIPsCIDRs netutil.CIDRs
 	destType             string
+
+	auth             *deliveryauth.Registry // mTLS, request signing & oauth2 settings of destinations
+	oauthTokens      *oauthv2.LocalTokenProvider
+	transport        *http.Transport // base transport, cloned for destinations using mTLS
+	netClientTimeout time.Duration
+	mtlsClientsMu    sync.Mutex
+	mtlsClients      map[string]*mtlsClient // destinationID -> client
+}
+
+// mtlsClient is an http client presenting a destination's client certificates
+type mtlsClient struct {
+	tlsConfig *tls.Config
+	client    *http.Client
+}
+
+type destinationIDContextKey struct{}
+
+// withDestinationID returns a context carrying the ID of the destination a request is sent to
+func withDestinationID(ctx context.Context, destinationID string) context.Context {
+	return context.WithValue(ctx, destinationIDContextKey{}, destinationID)
+}
+
+func destinationIDFromContext(ctx context.Context) string {
+	destinationID, _ := ctx.Value(destinationIDContextKey{}).(string)
+	return destinationID
 }
 
 // NetHandle interface
@@ -91,6 +119,18 @@ func (network *netHandle) SendPost(ctx context.Context, structData integrations.
 	// in future it's expected that we will build on top of this response type
 	// so, code addition should be done here instead of version bumping of response.
 	if isRest && !isMultipart {
+		auth := network.destinationAuth(ctx)
+		if auth != nil && auth.Err != nil {
+			// retryable, so that events aren't aborted until the destination's config gets fixed
+			return &utils.SendPostResponse{
+				StatusCode:   http.StatusInternalServerError,
+				ResponseBody: []byte(fmt.Sprintf("500 Invalid destination delivery auth config: %v", auth.Err)),
+			}
+		}
+		if auth != nil && auth.TLSConfig != nil {
+			client = network.mtlsClient(destinationIDFromContext(ctx), auth.TLSConfig)
+		}
+
 		requestMethod := postInfo.RequestMethod
 		requestBody := postInfo.Body
 		requestQueryParams := postInfo.QueryParams
@@ -191,6 +231,19 @@ func (network *netHandle) SendPost(ctx context.Context, structData integrations.
 			}
 		}
 
+		var body []byte
+		if auth != nil && auth.Signer != nil && payload != nil {
+			// the exact bytes sent over the wire need to be signed
+			var err error
+			if body, err = io.ReadAll(payload); err != nil {
+				return &utils.SendPostResponse{
+					StatusCode:   400,
+					ResponseBody: []byte("400 Unable to read payload for signing. Unexpected response"),
+				}
+			}
+			payload = bytes.NewReader(body)
+		}
+
 		req, err := http.NewRequestWithContext(ctx, requestMethod, postInfo.URL, payload)
 		if err != nil {
 			network.logger.Errorn("400 Unable to construct request",
@@ -223,6 +276,21 @@ func (network *netHandle) SendPost(ctx context.Context, structData integrations.
 			req.Header.Add(key, val)
 		}
 
+		if auth != nil && auth.Signer != nil {
+			auth.Signer.Sign(req.Header, body)
+		}
+
+		oauthToken, err := network.oauthToken(ctx, destinationIDFromContext(ctx))
+		if err != nil {
+			return &utils.SendPostResponse{
+				StatusCode:   http.StatusInternalServerError,
+				ResponseBody: []byte(fmt.Sprintf("500 Unable to obtain OAuth2 access token: %v", err)),
+			}
+		}
+		if oauthToken != nil {
+			req.Header.Set("Authorization", oauthToken.AuthorizationHeader())
+		}
+
 		resp, err := client.Do(req)
 		if errors.Is(err, ErrDenyPrivateIP) {
 			return &utils.SendPostResponse{
@@ -240,6 +308,10 @@ func (network *netHandle) SendPost(ctx context.Context, structData integrations.
 
 		defer func() { httputil.CloseResponse(resp) }()
 
+		if oauthToken != nil && resp.StatusCode == http.StatusUnauthorized {
+			network.invalidateOAuthToken(destinationIDFromContext(ctx), oauthToken)
+		}
+
 		respBody, err := io.ReadAll(resp.Body)
 		if err != nil {
 			return &utils.SendPostResponse{
@@ -276,6 +348,7 @@ func (network *netHandle) SendPost(ctx context.Context, structData integrations.
 			StatusCode:          resp.StatusCode,
 			ResponseBody:        respBody,
 			ResponseContentType: contentTypeHeader,
+			RetryAfter:          utils.RetryAfter(resp.Header, time.Now()),
 		}
 	}
 
@@ -288,6 +361,80 @@ func (network *netHandle) SendPost(ctx context.Context, structData integrations.
 	}
 }
 
+// destinationAuth returns the mTLS & request signing settings of the destination found in the context, if any
+func (network *netHandle) destinationAuth(ctx context.Context) *deliveryauth.Auth {
+	if network.auth == nil {
+		return nil
+	}
+	destinationID := destinationIDFromContext(ctx)
+	if destinationID == "" {
+		return nil
+	}
+	auth, _ := network.auth.Destination(destinationID)
+	return auth
+}
+
+// oauthToken returns an OAuth2 access token of the given destination, or nil if the destination has no OAuth2 settings
+func (network *netHandle) oauthToken(ctx context.Context, destinationID string) (*oauthv2.LocalToken, error) {
+	if network.auth == nil || network.oauthTokens == nil || destinationID == "" {
+		return nil, nil
+	}
+	auth, _ := network.auth.Destination(destinationID)
+	if auth == nil || auth.OAuth == nil {
+		return nil, nil
+	}
+	return network.oauthTokens.Token(ctx, destinationID, auth.OAuth)
+}
+
+// invalidateOAuthToken invalidates an access token of the given destination which got rejected with a 401, since it might have been revoked.
+// A new one gets requested when the job is retried.
+func (network *netHandle) invalidateOAuthToken(destinationID string, token *oauthv2.LocalToken) {
+	if auth, _ := network.auth.Destination(destinationID); auth != nil && auth.OAuth != nil {
+		network.oauthTokens.Invalidate(destinationID, auth.OAuth, token)
+	}
+}
+
+// mtlsClient returns an http client presenting the client certificates of the provided tls config for the given destination.
+// Clients are cached per destination and recreated whenever the destination's tls config changes.
+func (network *netHandle) mtlsClient(destinationID string, tlsConfig *tls.Config) *http.Client {
+	network.mtlsClientsMu.Lock()
+	defer network.mtlsClientsMu.Unlock()
+	if c, ok := network.mtlsClients[destinationID]; ok {
+		if c.tlsConfig == tlsConfig {
+			return c.client
+		}
+		c.client.CloseIdleConnections()
+	}
+	base := network.transport
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	transport := base.Clone()
+	conf := tlsConfig.Clone()
+	if base.TLSClientConfig != nil && len(base.TLSClientConfig.NextProtos) > 0 {
+		conf.NextProtos = base.TLSClientConfig.NextProtos // e.g. forceHTTP1
+	}
+	transport.TLSClientConfig = conf
+	client := &http.Client{Transport: transport, Timeout: network.netClientTimeout}
+	if network.mtlsClients == nil {
+		network.mtlsClients = make(map[string]*mtlsClient)
+	}
+	network.mtlsClients[destinationID] = &mtlsClient{tlsConfig: tlsConfig, client: client}
+	return client
+}
+
+// pruneMTLSClients closes and removes the cached clients of destinations whose tls config changed or got removed
+func (network *netHandle) pruneMTLSClients() {
+	network.mtlsClientsMu.Lock()
+	defer network.mtlsClientsMu.Unlock()
+	for destinationID, c := range network.mtlsClients {
+		if auth, _ := network.auth.Destination(destinationID); auth == nil || auth.TLSConfig != c.tlsConfig {
+			c.client.CloseIdleConnections()
+			delete(network.mtlsClients, destinationID)
+		}
+	}
+}
+
 // Setup initializes the module
 func (network *netHandle) Setup(config *config.Config, netClientTimeout time.Duration) error {
 	network.logger.Infon("Network Handler Startup")
@@ -361,6 +508,17 @@ func (network *netHandle) Setup(config *config.Config, netClientTimeout time.Dur
 		logger.NewIntField("maxIdleConnsPerHost", int64(defaultTransportCopy.MaxIdleConnsPerHost)),
 		logger.NewDurationField("timeout", netClientTimeout),
 	)
+	network.transport = &defaultTransportCopy
+	network.netClientTimeout = netClientTimeout
 	network.httpClient = &http.Client{Transport: &defaultTransportCopy, Timeout: netClientTimeout}
+	// authorization servers are usually internal, thus tokens are requested with a client of their own which isn't subject to blockPrivateIPs
+	network.oauthTokens = oauthv2.NewLocalTokenProvider(
+		oauthv2.NewCache(),
+		&http.Client{
+			Transport: defaultTransportPointer.Clone(),
+			Timeout:   config.GetDurationVar(30, time.Second, "Router."+network.destType+".oauth2.timeout", "Router.oauth2.timeout"),
+		},
+		config.GetDurationVar(60, time.Second, "Router."+network.destType+".oauth2.refreshBefore", "Router.oauth2.refreshBefore"),
+	)
 	return nil
 }
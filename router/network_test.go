@@ -4,9 +4,14 @@ import (
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -16,8 +21,17 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/netutil"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	mocksTransformer "github.com/rudderlabs/rudder-server/mocks/router/transformer"
 	mocksSysUtils "github.com/rudderlabs/rudder-server/mocks/utils/sysUtils"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
+	"github.com/rudderlabs/rudder-server/router/internal/deliveryauth"
+	"github.com/rudderlabs/rudder-server/router/transformer"
+	"github.com/rudderlabs/rudder-server/router/types"
+	oauthv2 "github.com/rudderlabs/rudder-server/services/oauth/v2"
+	transformerFeaturesService "github.com/rudderlabs/rudder-server/services/transformer"
+	"github.com/rudderlabs/rudder-server/testhelper/certs"
 )
 
 func TestSendPostWithGzipData(t *testing.T) {
@@ -537,3 +551,250 @@ func TestResponseContentType(t *testing.T) {
 		})
 	}
 }
+
+func TestSendPostWithDeliveryAuth(t *testing.T) {
+	const destinationID = "dest-1"
+
+	newNetwork := func(t *testing.T, destination backendconfig.DestinationT) *netHandle {
+		t.Helper()
+		destination.ID = destinationID
+		auth := deliveryauth.NewRegistry()
+		auth.Update(map[string]backendconfig.ConfigT{
+			"workspace-1": {Sources: []backendconfig.SourceT{{ID: "source-1", Destinations: []backendconfig.DestinationT{destination}}}},
+		})
+		return &netHandle{
+			logger:               logger.NOP,
+			httpClient:           http.DefaultClient,
+			blockPrivateIPsCIDRs: netutil.DefaultPrivateCidrRanges,
+			auth:                 auth,
+			oauthTokens:          oauthv2.NewLocalTokenProvider(nil, nil, time.Minute),
+		}
+	}
+	postParameters := func(url string) integrations.PostParametersT {
+		return integrations.PostParametersT{
+			Type:          "REST",
+			RequestMethod: "POST",
+			URL:           url,
+			Body: map[string]interface{}{
+				"JSON": map[string]interface{}{"event": "Signed Up"},
+			},
+		}
+	}
+
+	t.Run("mtls", func(t *testing.T) {
+		serverCert := certs.SelfSigned(t, "server", "127.0.0.1")
+		clientCert := certs.SelfSigned(t, "client")
+
+		serverKeyPair, err := tls.X509KeyPair(serverCert.Certificate, serverCert.Key)
+		require.NoError(t, err)
+		clientCAs := x509.NewCertPool()
+		require.True(t, clientCAs.AppendCertsFromPEM(clientCert.Certificate))
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if len(r.TLS.PeerCertificates) == 0 {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+		}))
+		server.TLS = &tls.Config{
+			Certificates: []tls.Certificate{serverKeyPair},
+			ClientCAs:    clientCAs,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		}
+		server.StartTLS()
+		defer server.Close()
+
+		network := newNetwork(t, backendconfig.DestinationT{Config: map[string]interface{}{
+			"mtls": map[string]interface{}{
+				"clientCertificate": string(clientCert.Certificate),
+				"clientKey":         string(clientCert.Key),
+				"caCertificate":     string(serverCert.Certificate),
+			},
+		}})
+
+		resp := network.SendPost(withDestinationID(context.Background(), destinationID), postParameters(server.URL))
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(resp.ResponseBody))
+		require.Equal(t, "client", string(resp.ResponseBody))
+
+		resp = network.SendPost(withDestinationID(context.Background(), "other-destination"), postParameters(server.URL))
+		require.Equal(t, http.StatusGatewayTimeout, resp.StatusCode, "requests of other destinations should use the default client")
+
+		network.pruneMTLSClients()
+		require.Len(t, network.mtlsClients, 1, "clients of unchanged configs should be kept")
+		network.auth.Update(map[string]backendconfig.ConfigT{})
+		network.pruneMTLSClients()
+		require.Empty(t, network.mtlsClients, "clients of removed configs should be pruned")
+	})
+
+	t.Run("request signing", func(t *testing.T) {
+		now := time.Now()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			timestamp := r.Header.Get("X-Timestamp")
+			if r.Header.Get("X-Signature") != deliveryauth.Signature([]byte("s3cr3t"), timestamp, body) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(timestamp))
+		}))
+		defer server.Close()
+
+		network := newNetwork(t, backendconfig.DestinationT{Config: map[string]interface{}{
+			"requestSigning": map[string]interface{}{
+				"algorithm":       "hmac-sha256",
+				"secret":          "s3cr3t",
+				"signatureHeader": "X-Signature",
+				"timestampHeader": "X-Timestamp",
+			},
+		}})
+
+		resp := network.SendPost(withDestinationID(context.Background(), destinationID), postParameters(server.URL))
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(resp.ResponseBody))
+		timestamp, err := strconv.ParseInt(string(resp.ResponseBody), 10, 64)
+		require.NoError(t, err)
+		require.InDelta(t, now.Unix(), timestamp, 5)
+
+		resp = network.SendPost(context.Background(), postParameters(server.URL))
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode, "requests without a destination shouldn't be signed")
+	})
+
+	t.Run("oauth2", func(t *testing.T) {
+		var tokensIssued atomic.Int64
+		tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if clientID, clientSecret, _ := r.BasicAuth(); clientID != "client" || clientSecret != "s3cr3t" || r.FormValue("grant_type") != "client_credentials" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600}`, tokensIssued.Add(1))
+		}))
+		defer tokenServer.Close()
+		var revoked atomic.Bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "Bearer token-1" && revoked.Load() {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+		}))
+		defer server.Close()
+
+		oauthConfig := map[string]interface{}{
+			"tokenUrl":     tokenServer.URL,
+			"clientId":     "client",
+			"clientSecret": "s3cr3t",
+		}
+		network := newNetwork(t, backendconfig.DestinationT{Config: map[string]interface{}{"oauth2": oauthConfig}})
+		ctx := withDestinationID(context.Background(), destinationID)
+
+		resp := network.SendPost(ctx, postParameters(server.URL))
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(resp.ResponseBody))
+		require.Equal(t, "Bearer token-1", string(resp.ResponseBody))
+		resp = network.SendPost(ctx, postParameters(server.URL))
+		require.Equal(t, "Bearer token-1", string(resp.ResponseBody), "tokens should be cached")
+		require.EqualValues(t, 1, tokensIssued.Load())
+
+		revoked.Store(true)
+		resp = network.SendPost(ctx, postParameters(server.URL))
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		resp = network.SendPost(ctx, postParameters(server.URL))
+		require.Equal(t, http.StatusOK, resp.StatusCode, "a new token should be requested after a 401")
+		require.Equal(t, "Bearer token-2", string(resp.ResponseBody))
+
+		oauthConfig["clientSecret"] = "wrong"
+		network = newNetwork(t, backendconfig.DestinationT{Config: map[string]interface{}{"oauth2": oauthConfig}})
+		resp = network.SendPost(ctx, postParameters(server.URL))
+		require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		require.Contains(t, string(resp.ResponseBody), "invalid_client")
+	})
+
+	t.Run("oauth2 through the transformer proxy", func(t *testing.T) {
+		var tokensIssued atomic.Int64
+		tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"bearer","expires_in":3600}`, tokensIssued.Add(1))
+		}))
+		defer tokenServer.Close()
+		network := newNetwork(t, backendconfig.DestinationT{Config: map[string]interface{}{"oauth2": map[string]interface{}{
+			"tokenUrl":     tokenServer.URL,
+			"clientId":     "client",
+			"clientSecret": "s3cr3t",
+		}}})
+
+		var proxied []string
+		statusCode := http.StatusOK
+		mockTransformer := mocksTransformer.NewMockTransformer(gomock.NewController(t))
+		mockTransformer.EXPECT().ProxyRequest(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, params *transformer.ProxyRequestParams) transformer.ProxyRequestResponse {
+			authorization, _ := params.ResponseData.PostParametersT.Headers["Authorization"].(string)
+			proxied = append(proxied, authorization)
+			return transformer.ProxyRequestResponse{ProxyRequestStatusCode: http.StatusOK, RespStatusCodes: map[int64]int{1: statusCode}}
+		}).AnyTimes()
+		w := &worker{
+			logger:          logger.NOP,
+			routerProxyStat: stats.NOP.NewStat("router_proxy_latency", stats.TimerType),
+			rt: &Handle{
+				netHandle:                  network,
+				transformer:                mockTransformer,
+				transformerFeaturesService: transformerFeaturesService.NewNoOpService(),
+				logger:                     logger.NOP,
+			},
+		}
+		destinationJob := types.DestinationJobT{
+			Destination:      backendconfig.DestinationT{ID: destinationID},
+			JobMetadataArray: []types.JobMetadataT{{JobID: 1, DestinationID: destinationID}},
+		}
+
+		w.proxyRequestWithOAuth(context.Background(), destinationJob, postParameters("https://example.com"))
+		statusCode = http.StatusUnauthorized
+		w.proxyRequestWithOAuth(context.Background(), destinationJob, postParameters("https://example.com"))
+		statusCode = http.StatusOK
+		w.proxyRequestWithOAuth(context.Background(), destinationJob, postParameters("https://example.com"))
+		require.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}, proxied, "a new token should be requested after a 401")
+
+		destinationJob.Destination.ID = "other"
+		w.proxyRequestWithOAuth(context.Background(), destinationJob, postParameters("https://example.com"))
+		require.Empty(t, proxied[3], "destinations without oauth2 settings shouldn't get a token")
+	})
+
+	t.Run("oauth2 against a private authorization server", func(t *testing.T) {
+		tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"access_token":"token","token_type":"bearer","expires_in":3600}`))
+		}))
+		defer tokenServer.Close()
+		network := newNetwork(t, backendconfig.DestinationT{Config: map[string]interface{}{"oauth2": map[string]interface{}{
+			"tokenUrl":     tokenServer.URL,
+			"clientId":     "client",
+			"clientSecret": "s3cr3t",
+		}}})
+		network.destType = "WEBHOOK"
+		require.NoError(t, network.Setup(config.New(), time.Second))
+		network.blockPrivateIPs = true
+		token, err := network.oauthToken(context.Background(), destinationID)
+		require.NoError(t, err, "tokens shouldn't be subject to blockPrivateIPs")
+		require.Equal(t, "Bearer token", token.AuthorizationHeader())
+		resp := network.SendPost(withDestinationID(context.Background(), destinationID), postParameters(tokenServer.URL))
+		require.Equal(t, http.StatusForbidden, resp.StatusCode, "deliveries should be subject to blockPrivateIPs")
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			config map[string]interface{}
+			err    string
+		}{
+			"mtls":            {config: map[string]interface{}{"mtls": map[string]interface{}{"clientCertificate": "invalid"}}, err: "invalid mtls config"},
+			"request signing": {config: map[string]interface{}{"requestSigning": map[string]interface{}{"algorithm": "md5", "secret": "s3cr3t"}}, err: "unsupported algorithm"},
+			"oauth2":          {config: map[string]interface{}{"oauth2": map[string]interface{}{"tokenUrl": "not a url"}}, err: "tokenUrl must be a valid http(s) url"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				network := newNetwork(t, backendconfig.DestinationT{Config: tc.config})
+				resp := network.SendPost(withDestinationID(context.Background(), destinationID), postParameters("http://localhost"))
+				require.Equal(t, http.StatusInternalServerError, resp.StatusCode, "invalid configs should be retried instead of aborting events")
+				require.Contains(t, string(resp.ResponseBody), tc.err)
+			})
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"slices"
 	"sync/atomic"
 	"testing"
@@ -20,6 +21,8 @@ import (
 
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
 	"github.com/rudderlabs/rudder-server/admin"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/enterprise/reporting"
@@ -29,7 +32,9 @@ import (
 	mocksRouter "github.com/rudderlabs/rudder-server/mocks/router"
 	mocksTransformer "github.com/rudderlabs/rudder-server/mocks/router/transformer"
 	mockutils "github.com/rudderlabs/rudder-server/mocks/utils/types"
+	"github.com/rudderlabs/rudder-server/router/internal/circuitbreaker"
 	"github.com/rudderlabs/rudder-server/router/internal/eventorder"
+	"github.com/rudderlabs/rudder-server/router/internal/retrypolicy"
 	"github.com/rudderlabs/rudder-server/router/throttler"
 	"github.com/rudderlabs/rudder-server/router/transformer"
 	"github.com/rudderlabs/rudder-server/router/types"
@@ -206,6 +211,63 @@ func TestBackoff(t *testing.T) {
 		require.Equal(t, 300*time.Second, nextAttemptAfter(6, minBackoff, maxBackoff))
 	})
 
+	t.Run("retryBackoff", func(t *testing.T) {
+		r := &Handle{
+			reloadableConfig: &reloadableConfig{
+				minRetryBackoff: config.SingleValueLoader(10 * time.Second),
+				maxRetryBackoff: config.SingleValueLoader(300 * time.Second),
+				maxRetryAfter:   config.SingleValueLoader(time.Hour),
+			},
+			retryPolicies: retrypolicy.NewRegistry(),
+		}
+		r.retryPolicies.Update(map[string]backendconfig.ConfigT{
+			"ws-1": {Sources: []backendconfig.SourceT{{Destinations: []backendconfig.DestinationT{
+				{ID: "linear", Config: map[string]interface{}{"retryPolicy": map[string]interface{}{"strategy": "linear"}}},
+			}}}},
+		})
+		require.Equal(t, 40*time.Second, r.retryBackoff("no-policy", 3, 0), "router's exponential backoff")
+		require.Equal(t, 30*time.Second, r.retryBackoff("linear", 3, 0), "destination's retry policy using router defaults")
+		require.Equal(t, 5*time.Second, r.retryBackoff("linear", 3, 5*time.Second), "retry-after takes precedence")
+		require.Equal(t, time.Hour, r.retryBackoff("linear", 3, 2*time.Hour), "retry-after is capped")
+	})
+
+	t.Run("retryLimitReached with retry policy", func(t *testing.T) {
+		r := &Handle{
+			reloadableConfig: &reloadableConfig{
+				maxFailedCountForJob: config.SingleValueLoader(3),
+				retryTimeWindow:      config.SingleValueLoader(180 * time.Minute),
+			},
+			retryPolicies: retrypolicy.NewRegistry(),
+		}
+		r.retryPolicies.Update(map[string]backendconfig.ConfigT{
+			"ws-1": {Sources: []backendconfig.SourceT{{Destinations: []backendconfig.DestinationT{
+				{ID: "dest-1", Config: map[string]interface{}{"retryPolicy": map[string]interface{}{"maxAttempts": float64(5), "maxAge": "2h"}}},
+				{ID: "dest-2", Config: map[string]interface{}{"retryPolicy": map[string]interface{}{"maxAttempts": float64(5)}}},
+				{ID: "dest-3", Config: map[string]interface{}{"retryPolicy": map[string]interface{}{"maxAge": "10m"}}},
+				{ID: "dest-4", Config: map[string]interface{}{"retryPolicy": map[string]interface{}{"strategy": "linear"}}},
+			}}}},
+		})
+		status := func(destinationID string, attempts int, age time.Duration) *jobsdb.JobStatusT {
+			return &jobsdb.JobStatusT{
+				AttemptNum:    attempts,
+				ErrorCode:     "500",
+				ErrorResponse: []byte(`{"firstAttemptedAt": "` + time.Now().Add(-age).Format(misc.RFC3339Milli) + `"}`),
+				JobParameters: []byte(`{"destination_id": "` + destinationID + `"}`),
+			}
+		}
+		require.False(t, r.retryLimitReached(status("no-policy", 3, time.Hour)), "router's retry time window hasn't elapsed")
+		require.True(t, r.retryLimitReached(status("no-policy", 3, 4*time.Hour)), "router's retry time window has elapsed and max attempts reached")
+		require.False(t, r.retryLimitReached(status("dest-4", 3, time.Hour)), "router's limits apply to policies without limits")
+
+		require.False(t, r.retryLimitReached(status("dest-1", 4, time.Hour)), "none of the policy's limits reached")
+		require.True(t, r.retryLimitReached(status("dest-1", 5, time.Minute)), "policy's max attempts reached, its max age hasn't elapsed")
+		require.True(t, r.retryLimitReached(status("dest-1", 1, 3*time.Hour)), "policy's max age has elapsed, its max attempts not reached")
+
+		require.True(t, r.retryLimitReached(status("dest-2", 5, time.Minute)), "policy's max attempts reached, regardless of the router's retry time window")
+		require.False(t, r.retryLimitReached(status("dest-2", 4, 4*time.Hour)), "policy's max attempts not reached, regardless of the router's retry time window")
+		require.True(t, r.retryLimitReached(status("dest-3", 1, time.Hour)), "policy's max age has elapsed, regardless of the router's max attempts")
+	})
+
 	t.Run("findWorker", func(t *testing.T) {
 		backoffJob := &jobsdb.JobT{
 			JobID:      1,
@@ -418,6 +480,38 @@ func TestBackoff(t *testing.T) {
 			require.ErrorIs(t, err, types.ErrJobOrderBlocked)
 		})
 
+		t.Run("circuit breaker open", func(t *testing.T) {
+			defer func() { r.circuitBreakers = nil }()
+			r.drainer = &drainer{}
+			r.circuitBreakers = circuitbreaker.NewRegistry(
+				circuitbreaker.WithEnabled(config.SingleValueLoader(true)),
+				circuitbreaker.WithMinRequests(config.SingleValueLoader(1)),
+			)
+			workers := []*worker{{
+				logger:  logger.NOP,
+				inputCh: make(chan workerJob, 3),
+				barrier: eventorder.NewBarrier(),
+			}}
+			for _, guaranteeUserEventOrder := range []bool{false, true} {
+				r.guaranteeUserEventOrder = guaranteeUserEventOrder
+				slot, err := r.findWorkerSlot(context.Background(), workers, noBackoffJob1, parameters, map[eventorder.BarrierKey]struct{}{})
+				require.NoError(t, err)
+				require.NotNil(t, slot)
+				slot.slot.Release()
+			}
+
+			r.circuitBreakers.Failure(destinationID)
+			throttlerCount := r.throttlerFactory.(*mockThrottlerFactory).count.Load()
+			for _, guaranteeUserEventOrder := range []bool{false, true} {
+				r.guaranteeUserEventOrder = guaranteeUserEventOrder
+				slot, err := r.findWorkerSlot(context.Background(), workers, noBackoffJob2, parameters, map[eventorder.BarrierKey]struct{}{})
+				require.Nil(t, slot)
+				require.ErrorIs(t, err, types.ErrDestinationCircuitOpen)
+				require.Equal(t, 3, workers[0].AvailableSlots(), "slot should be released")
+			}
+			require.Equal(t, throttlerCount, r.throttlerFactory.(*mockThrottlerFactory).count.Load(), "throttler shouldn't be checked while the circuit is open")
+		})
+
 		t.Run("job not blocked after event ordering is disabled(destinationID level)", func(t *testing.T) {
 			r.guaranteeUserEventOrder = true
 			workers[0].inputReservations = 0
@@ -2594,3 +2688,45 @@ func TestAllowRouterAbortAlert(t *testing.T) {
 		})
 	}
 }
+
+func TestCircuitBreakerOutcomes(t *testing.T) {
+	statsStore, err := memstats.New()
+	require.NoError(t, err)
+	rt := &Handle{
+		destType:     "WEBHOOK",
+		logger:       logger.NOP,
+		statsFactory: statsStore,
+	}
+	rt.circuitBreakers = circuitbreaker.NewRegistry(
+		circuitbreaker.WithEnabled(config.SingleValueLoader(true)),
+		circuitbreaker.WithFailureRatio(config.SingleValueLoader(0.5)),
+		circuitbreaker.WithMinRequests(config.SingleValueLoader(2)),
+		circuitbreaker.WithStateChangeListener(rt.circuitBreakerStateChanged),
+	)
+	w := &worker{rt: rt}
+	state := func(destinationID string) float64 {
+		return statsStore.Get("router_circuit_breaker_state", stats.Tags{"destType": "WEBHOOK", "destinationId": destinationID}).LastValue()
+	}
+
+	t.Run("throttled jobs are ignored", func(t *testing.T) {
+		w.recordCircuitBreakerOutcome("dest-1", http.StatusTooManyRequests, routerutils.ERROR_AT_DEL)
+		w.recordCircuitBreakerOutcome("dest-1", http.StatusTooManyRequests, routerutils.ERROR_AT_DEL)
+		require.Equal(t, circuitbreaker.StateClosed, rt.circuitBreakers.State("dest-1"))
+		require.NotContains(t, rt.circuitBreakers.Statuses(), "dest-1")
+	})
+
+	t.Run("5xx responses are failures", func(t *testing.T) {
+		w.recordCircuitBreakerOutcome("dest-1", http.StatusBadRequest, routerutils.ERROR_AT_DEL)
+		w.recordCircuitBreakerOutcome("dest-1", http.StatusGatewayTimeout, routerutils.ERROR_AT_DEL)
+		require.Equal(t, circuitbreaker.StateOpen, rt.circuitBreakers.State("dest-1"))
+		require.EqualValues(t, circuitbreaker.StateOpen, state("dest-1"))
+	})
+
+	t.Run("circuit breakers of removed destinations are pruned", func(t *testing.T) {
+		w.recordCircuitBreakerOutcome("dest-2", http.StatusOK, routerutils.ERROR_AT_DEL)
+		rt.pruneCircuitBreakers(map[string]*routerutils.DestinationWithSources{"dest-2": {}})
+		require.Equal(t, circuitbreaker.StateClosed, rt.circuitBreakers.State("dest-1"))
+		require.EqualValues(t, circuitbreaker.StateClosed, state("dest-1"), "the state of pruned circuit breakers should be reset")
+		require.Contains(t, rt.circuitBreakers.Statuses(), "dest-2")
+	})
+}
@@ -30,6 +30,7 @@ import (
 	transformerclient "github.com/rudderlabs/rudder-server/internal/transformer-client"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
 	"github.com/rudderlabs/rudder-server/router/types"
+	routerutils "github.com/rudderlabs/rudder-server/router/utils"
 	oauthv2 "github.com/rudderlabs/rudder-server/services/oauth/v2"
 	"github.com/rudderlabs/rudder-server/services/oauth/v2/common"
 	cntx "github.com/rudderlabs/rudder-server/services/oauth/v2/context"
@@ -108,6 +109,7 @@ type ProxyRequestResponse struct {
 	RespBodys                map[int64]string
 	DontBatchDirectives      map[int64]bool
 	OAuthErrorCategory       string
+	RetryAfter               time.Duration // delay requested by the destination before retrying, if any
 }
 
 // Transformer provides methods to transform events
@@ -512,6 +514,10 @@ func (trans *handle) ProxyRequest(ctx context.Context, proxyReqParams *ProxyRequ
 	**/
 	respData = []byte(gjson.GetBytes(respData, "output").Raw)
 	integrations.CollectDestErrorStats(respData)
+	retryAfter := httpPrxResp.retryAfter
+	if retryAfter == 0 {
+		retryAfter = destinationResponseRetryAfter(respData)
+	}
 
 	transResp, err := proxyReqParams.Adapter.getResponse(respData, respCode, proxyReqParams.ResponseData.Metadata)
 	if err != nil {
@@ -555,9 +561,26 @@ func (trans *handle) ProxyRequest(ctx context.Context, proxyReqParams *ProxyRequ
 		RespBodys:                transResp.routerJobResponseBodys,
 		DontBatchDirectives:      transResp.routerJobDontBatchDirectives,
 		OAuthErrorCategory:       transResp.authErrorCategory,
+		RetryAfter:               retryAfter,
 	}
 }
 
+// destinationResponseRetryAfter returns the retry delay indicated by the destination response headers forwarded by the transformer proxy, e.g.
+//
+//	{"destinationResponse": {"headers": {"retry-after": "30"}}}
+func destinationResponseRetryAfter(respData []byte) time.Duration {
+	headers := gjson.GetBytes(respData, "destinationResponse.headers")
+	if !headers.IsObject() {
+		return 0
+	}
+	header := make(http.Header)
+	headers.ForEach(func(key, value gjson.Result) bool {
+		header.Set(key.String(), value.String())
+		return true
+	})
+	return routerutils.RetryAfter(header, time.Now())
+}
+
 func (trans *handle) setup(destinationTimeout, transformTimeout time.Duration, cache *oauthv2.Cache, locker *sync.PartitionRWLocker, backendConfig backendconfig.BackendConfig, featuresService transformerfs.FeaturesService) {
 	if loggerOverride == nil {
 		trans.logger = logger.NewLogger().Child("router").Child("transformer")
@@ -634,6 +657,7 @@ func (trans *handle) transformerClientConfig() *transformerclient.ClientConfig {
 type httpProxyResponse struct {
 	respData   []byte
 	statusCode int
+	retryAfter time.Duration
 	err        error
 }
 
@@ -734,6 +758,7 @@ func (trans *handle) doProxyRequest(ctx context.Context, proxyUrl string, proxyR
 	return httpProxyResponse{
 		respData:   respData,
 		statusCode: resp.StatusCode,
+		retryAfter: routerutils.RetryAfter(resp.Header, time.Now()),
 	}
 }
 
@@ -2091,3 +2091,10 @@ func TestTransformerCompactionFlags(t *testing.T) {
 		require.True(t, h.compactRequestPayloads())
 	})
 }
+
+func TestDestinationResponseRetryAfter(t *testing.T) {
+	require.Zero(t, destinationResponseRetryAfter([]byte(`{"destinationResponse": {"status": 429}}`)))
+	require.Zero(t, destinationResponseRetryAfter([]byte(`{"destinationResponse": "some error"}`)))
+	require.Equal(t, 30*time.Second, destinationResponseRetryAfter([]byte(`{"destinationResponse": {"status": 429, "headers": {"retry-after": "30"}}}`)))
+	require.Equal(t, 10*time.Second, destinationResponseRetryAfter([]byte(`{"destinationResponse": {"status": 429, "headers": {"x-ratelimit-reset": 10}}}`)))
+}
@@ -43,6 +43,7 @@ type JobResponse struct {
 	respStatusCode         int
 	respBody               string
 	errorAt                string
+	retryAfter             time.Duration // retry delay requested by the destination, if any
 	status                 *jobsdb.JobStatusT
 }
 
@@ -53,6 +54,7 @@ type reloadableConfig struct {
 	maxStatusUpdateWait               config.ValueLoader[time.Duration]
 	minRetryBackoff                   config.ValueLoader[time.Duration]
 	maxRetryBackoff                   config.ValueLoader[time.Duration]
+	maxRetryAfter                     config.ValueLoader[time.Duration] // upper limit for retry delays requested by destinations
 	jobsBatchTimeout                  config.ValueLoader[time.Duration]
 	failingJobsPenaltyThreshold       config.ValueLoader[float64]
 	failingJobsPenaltySleep           config.ValueLoader[time.Duration]
@@ -70,4 +72,5 @@ type reloadableConfig struct {
 	skipRtAbortAlertForTransformation config.ValueLoader[bool] // represents if event delivery(via transformerProxy) should be alerted via router-aborted-count alert def
 	skipRtAbortAlertForDelivery       config.ValueLoader[bool] // represents if transformation(router or batch) should be alerted via router-aborted-count alert def
 	oauthV2ExpirationTimeDiff         config.ValueLoader[time.Duration]
+	maxPriorityWeight                 config.ValueLoader[int]
 }
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"net/http"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -48,6 +50,7 @@ type SendPostResponse struct {
 	StatusCode          int
 	ResponseContentType string
 	ResponseBody        []byte
+	RetryAfter          time.Duration // delay requested by the destination before retrying, if any
 }
 
 type JobParameters struct {
@@ -218,3 +221,32 @@ func UpdateProcessedEventsMetrics(statsHandle stats.Stats, module, destType stri
 		}
 	}
 }
+
+// unixTimestampThreshold is used for telling apart rate limit reset headers containing a unix timestamp from the ones containing a number of seconds
+const unixTimestampThreshold = 1_000_000_000
+
+// RetryAfter returns the delay requested by a destination before retrying a request, as indicated by
+// the Retry-After (seconds or http date) or X-RateLimit-Reset (seconds or unix timestamp) response headers.
+// Zero is returned if no valid delay is found.
+func RetryAfter(header http.Header, now time.Time) time.Duration {
+	if header == nil {
+		return 0
+	}
+	if v := strings.TrimSpace(header.Get("Retry-After")); v != "" {
+		if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return max(time.Duration(seconds)*time.Second, 0)
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(t.Sub(now), 0)
+		}
+	}
+	if v := strings.TrimSpace(header.Get("X-RateLimit-Reset")); v != "" {
+		if seconds, err := strconv.ParseFloat(v, 64); err == nil {
+			if seconds >= unixTimestampThreshold {
+				return max(time.Unix(0, int64(seconds*float64(time.Second))).Sub(now), 0)
+			}
+			return max(time.Duration(seconds*float64(time.Second)), 0)
+		}
+	}
+	return 0
+}
@@ -1,7 +1,10 @@
 package utils_test
 
 import (
+	"net/http"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -152,3 +155,24 @@ func TestUpdateProcessedEventsMetrics(t *testing.T) {
 		"destinationId": destinationID3,
 	}).LastValue())
 }
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	header := func(kv ...string) http.Header {
+		h := http.Header{}
+		for i := 0; i < len(kv); i += 2 {
+			h.Set(kv[i], kv[i+1])
+		}
+		return h
+	}
+
+	require.Zero(t, routerutils.RetryAfter(nil, now))
+	require.Zero(t, routerutils.RetryAfter(header(), now))
+	require.Zero(t, routerutils.RetryAfter(header("Retry-After", "invalid"), now))
+	require.Equal(t, 30*time.Second, routerutils.RetryAfter(header("Retry-After", "30"), now), "seconds")
+	require.Equal(t, 2*time.Minute, routerutils.RetryAfter(header("Retry-After", now.Add(2*time.Minute).Format(http.TimeFormat)), now), "http date")
+	require.Zero(t, routerutils.RetryAfter(header("Retry-After", now.Add(-time.Minute).Format(http.TimeFormat)), now), "http date in the past")
+	require.Equal(t, 10*time.Second, routerutils.RetryAfter(header("X-RateLimit-Reset", "10"), now), "rate limit reset seconds")
+	require.Equal(t, 5*time.Minute, routerutils.RetryAfter(header("X-RateLimit-Reset", strconv.FormatInt(now.Add(5*time.Minute).Unix(), 10)), now), "rate limit reset timestamp")
+	require.Equal(t, 30*time.Second, routerutils.RetryAfter(header("Retry-After", "30", "X-RateLimit-Reset", "10"), now), "retry-after takes precedence")
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"slices"
 	"sort"
@@ -54,16 +55,18 @@ type worker struct {
 }
 
 type workerJob struct {
-	job         *jobsdb.JobT
-	parameters  *routerutils.JobParameters
-	assignedAt  time.Time
-	drainReason string
+	job                      *jobsdb.JobT
+	parameters               *routerutils.JobParameters
+	assignedAt               time.Time
+	drainReason              string
+	circuitBreakerGeneration int // generation of the destination's circuit breaker when the job was allowed
 }
 
 // acceptWorkerJob accepts a worker job and returns a router job if batching/router transformation is enabled.
 //
 //   - If the job is aborted, it sends an aborted job status to responseQ.
 //   - If the job needs to wait due to event ordering, it sends a waiting job status to responseQ.
+//   - If the destination's circuit breaker has tripped after the job was picked up, it parks the job by sending a waiting job status to responseQ.
 //   - If no batching or router transformation is enabled, it processes the job immediately, otherwise it returns a router job for batching or transformation.
 func (w *worker) acceptWorkerJob(workerJob workerJob) *types.RouterJobT {
 	job := workerJob.job
@@ -148,6 +151,26 @@ func (w *worker) acceptWorkerJob(workerJob workerJob) *types.RouterJobT {
 		}
 	}
 
+	if w.rt.circuitBreakers != nil && w.rt.circuitBreakers.Tripped(parameters.DestinationID, workerJob.circuitBreakerGeneration) {
+		// park the job without attempting delivery, it will be picked up again once the circuit breaker allows it
+		w.logger.Debugn("circuit breaker tripped, parking job",
+			obskit.DestinationID(parameters.DestinationID),
+			logger.NewIntField("jobId", job.JobID))
+		status := jobsdb.JobStatusT{
+			JobID:         job.JobID,
+			AttemptNum:    job.LastJobStatus.AttemptNum,
+			ExecTime:      time.Now(),
+			RetryTime:     time.Now(),
+			JobState:      jobsdb.Waiting.State,
+			ErrorResponse: misc.UpdateJSONWithNewKeyVal(routerutils.EmptyPayload, "reason", "circuit breaker open"),
+			Parameters:    routerutils.EmptyPayload,
+			JobParameters: job.Parameters,
+			WorkspaceId:   job.WorkspaceId,
+		}
+		w.rt.responseQ <- workerJobStatus{userID: userID, worker: w, job: job, status: &status, parameters: *parameters}
+		return nil
+	}
+
 	firstAttemptedAt := gjson.GetBytes(job.LastJobStatus.ErrorResponse, "firstAttemptedAt").Str
 	dontBatch := gjson.GetBytes(job.LastJobStatus.ErrorResponse, "dontBatch").Bool()
 	jobMetadata := types.JobMetadataT{
@@ -427,6 +450,7 @@ func (w *worker) process(destinationJobs []types.DestinationJobT) {
 	for _, destinationJob := range destinationJobs {
 		var respStatusCodes map[int64]int
 		var respBodys map[int64]string
+		var retryAfter time.Duration // retry delay requested by the destination
 
 		var errorAt string
 		if destinationJob.StatusCode == 200 || destinationJob.StatusCode == 0 {
@@ -501,11 +525,12 @@ func (w *worker) process(destinationJobs []types.DestinationJobT) {
 							if transformerProxy {
 								attemptedRequests++
 								attemptedJobs += len(destinationJob.JobMetadataArray)
-								resp := w.proxyRequest(ctx, destinationJob, val)
+								resp := w.proxyRequestWithOAuth(ctx, destinationJob, val)
 								for k, v := range resp.DontBatchDirectives {
 									dontBatchDirectives[k] = v
 								}
 								respStatusCodes, respBodyTemps, respContentType = resp.RespStatusCodes, resp.RespBodys, resp.RespContentType
+								retryAfter = resp.RetryAfter
 								// If this is the last iteration, use respStatusCodes & respBodyTemps as is
 								// If this is not the last iteration, mark all the jobs as failed.
 								if i < len(result)-1 && anyNonTerminalCode(respStatusCodes) {
@@ -519,12 +544,14 @@ func (w *worker) process(destinationJobs []types.DestinationJobT) {
 								}
 							} else {
 								sendCtx, cancel := context.WithTimeout(ctx, w.rt.netClientTimeout)
+								sendCtx = withDestinationID(sendCtx, destinationID)
 								rdlTime := time.Now()
 								attemptedRequests++
 								attemptedJobs += len(destinationJob.JobMetadataArray)
 								resp := w.rt.netHandle.SendPost(sendCtx, val)
 								cancel()
 								respStatusCode, respBodyTemp, respContentType = resp.StatusCode, string(resp.ResponseBody), resp.ResponseContentType
+								retryAfter = resp.RetryAfter
 								w.routerDeliveryLatencyStat.SendTiming(time.Since(rdlTime))
 
 								if isSuccessStatus(respStatusCode) {
@@ -612,7 +639,11 @@ func (w *worker) process(destinationJobs []types.DestinationJobT) {
 		}
 
 		w.updateFailedJobOrderKeys(failedJobOrderKeys, &destinationJob, respStatusCodes)
-		routerJobResponses = append(routerJobResponses, w.prepareRouterJobResponses(destinationJob, respStatusCodes, respBodys, errorAt, transformerProxy)...)
+		jobResponses := w.prepareRouterJobResponses(destinationJob, respStatusCodes, respBodys, errorAt, transformerProxy)
+		for _, jobResponse := range jobResponses {
+			jobResponse.retryAfter = retryAfter
+		}
+		routerJobResponses = append(routerJobResponses, jobResponses...)
 	}
 
 	sort.Slice(routerJobResponses, func(i, j int) bool {
@@ -681,7 +712,7 @@ func (w *worker) process(destinationJobs []types.DestinationJobT) {
 				status.ErrorResponse = misc.UpdateJSONWithNewKeyVal(status.ErrorResponse, "dontBatch", true)
 			}
 		}
-		w.postStatusOnResponseQ(respStatusCode, destinationJob, respContentType, destinationJobMetadata, &status, routerJobResponse.errorAt)
+		w.postStatusOnResponseQ(respStatusCode, routerJobResponse.retryAfter, destinationJob, respContentType, destinationJobMetadata, &status, routerJobResponse.errorAt)
 
 		w.sendEventDeliveryStat(destinationJobMetadata, &status, &destinationJob.Destination)
 
@@ -743,6 +774,42 @@ func anyNonTerminalCode(respStatusCodes map[int64]int) bool {
 	return false
 }
 
+// proxyRequestWithOAuth sends the request through the transformer proxy, presenting an OAuth2 access token of the destination if it has OAuth2 settings.
+// Just like for requests sent directly, the token is invalidated if the destination rejects it with a 401.
+func (w *worker) proxyRequestWithOAuth(ctx context.Context, destinationJob types.DestinationJobT, val integrations.PostParametersT) transformer.ProxyRequestResponse {
+	network, ok := w.rt.netHandle.(*netHandle)
+	if !ok {
+		return w.proxyRequest(ctx, destinationJob, val)
+	}
+	destinationID := destinationJob.Destination.ID
+	token, err := network.oauthToken(ctx, destinationID)
+	if err != nil {
+		resp := transformer.ProxyRequestResponse{
+			ProxyRequestStatusCode: http.StatusInternalServerError,
+			RespStatusCodes:        make(map[int64]int),
+			RespBodys:              make(map[int64]string),
+		}
+		for _, metadata := range destinationJob.JobMetadataArray {
+			resp.RespStatusCodes[metadata.JobID] = http.StatusInternalServerError
+			resp.RespBodys[metadata.JobID] = fmt.Sprintf("500 Unable to obtain OAuth2 access token: %v", err)
+		}
+		return resp
+	}
+	if token == nil {
+		return w.proxyRequest(ctx, destinationJob, val)
+	}
+	val.Headers = maps.Clone(val.Headers)
+	if val.Headers == nil {
+		val.Headers = make(map[string]interface{})
+	}
+	val.Headers["Authorization"] = token.AuthorizationHeader()
+	resp := w.proxyRequest(ctx, destinationJob, val)
+	if resp.ProxyRequestStatusCode == http.StatusUnauthorized || lo.Contains(lo.Values(resp.RespStatusCodes), http.StatusUnauthorized) {
+		network.invalidateOAuthToken(destinationID, token)
+	}
+	return resp
+}
+
 func (w *worker) proxyRequest(ctx context.Context, destinationJob types.DestinationJobT, val integrations.PostParametersT) transformer.ProxyRequestResponse {
 	jobID := destinationJob.JobMetadataArray[0].JobID
 	w.logger.Debugn("TransformerProxy: Request started",
@@ -944,10 +1011,29 @@ func (w *worker) updateAbortedMetrics(destinationID, workspaceId, statusCode, er
 	eventsAbortedStat.Increment()
 }
 
-func (w *worker) postStatusOnResponseQ(respStatusCode int, destinationJob *types.DestinationJobT,
+// recordCircuitBreakerOutcome records the delivery outcome of a job to the destination's circuit breaker.
+// Only delivery attempts are taken into account, i.e. transformation errors, filtered and suppressed events are ignored.
+// Only 5xx responses, which network errors are reported as, count as failures: throttled jobs (429) are ignored, since
+// the destination is up, merely asking for fewer requests.
+func (w *worker) recordCircuitBreakerOutcome(destinationID string, respStatusCode int, errorAt string) {
+	if w.rt.circuitBreakers == nil || errorAt == routerutils.ERROR_AT_TF {
+		return
+	}
+	switch {
+	case respStatusCode == utilTypes.FilterEventCode || respStatusCode == utilTypes.SuppressEventCode || respStatusCode == http.StatusTooManyRequests:
+	case respStatusCode >= http.StatusInternalServerError:
+		w.rt.circuitBreakers.Failure(destinationID)
+	default:
+		w.rt.circuitBreakers.Success(destinationID)
+	}
+}
+
+func (w *worker) postStatusOnResponseQ(respStatusCode int, retryAfter time.Duration, destinationJob *types.DestinationJobT,
 	respContentType string, destinationJobMetadata *types.JobMetadataT, status *jobsdb.JobStatusT,
 	errorAt string,
 ) {
+	w.recordCircuitBreakerOutcome(destinationJobMetadata.DestinationID, respStatusCode, errorAt)
+
 	// Enhancing status.ErrorResponse with firstAttemptedAt
 	firstAttemptedAtTime := time.Now()
 	if destinationJobMetadata.FirstAttemptedAt != "" {
@@ -1015,7 +1101,7 @@ func (w *worker) postStatusOnResponseQ(respStatusCode int, destinationJob *types
 	} else {
 		status.JobState = jobsdb.Failed.State
 		if !w.rt.retryLimitReached(status) { // don't delay retry time if retry limit is reached, so that the job can be aborted immediately on the next loop
-			status.RetryTime = status.ExecTime.Add(nextAttemptAfter(status.AttemptNum, w.rt.reloadableConfig.minRetryBackoff.Load(), w.rt.reloadableConfig.maxRetryBackoff.Load()))
+			status.RetryTime = status.ExecTime.Add(w.rt.retryBackoff(destinationJobMetadata.DestinationID, status.AttemptNum, retryAfter))
 		}
 	}
 
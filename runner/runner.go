@@ -27,11 +27,14 @@ import (
 	"github.com/rudderlabs/rudder-server/app/apphandlers"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/info"
+	"github.com/rudderlabs/rudder-server/jobsdb"
 	"github.com/rudderlabs/rudder-server/router/customdestinationmanager"
 	"github.com/rudderlabs/rudder-server/rruntime"
 	"github.com/rudderlabs/rudder-server/services/alert"
 	"github.com/rudderlabs/rudder-server/services/controlplane"
 	"github.com/rudderlabs/rudder-server/services/diagnostics"
+	"github.com/rudderlabs/rudder-server/services/lineage"
+	"github.com/rudderlabs/rudder-server/services/rmetrics"
 	"github.com/rudderlabs/rudder-server/services/streammanager/kafka"
 	"github.com/rudderlabs/rudder-server/utils/crash"
 	"github.com/rudderlabs/rudder-server/utils/misc"
@@ -123,7 +126,7 @@ func (r *Runner) Run(ctx context.Context, args []string) int {
 	for histogramName, buckets := range customBuckets {
 		statsOptions = append(statsOptions, stats.WithHistogramBuckets(histogramName, buckets))
 	}
-	stats.Default = stats.NewStats(config.Default, logger.Default, svcMetric.Instance, statsOptions...)
+	stats.Default = rmetrics.NewCardinalityLimiter(stats.NewStats(config.Default, logger.Default, svcMetric.Instance, statsOptions...), config.Default)
 	if err := stats.Default.Start(ctx, rruntime.GoRoutineFactory); err != nil {
 		r.logger.Errorn("Failed to start stats", obskit.Error(err))
 		return 1
@@ -172,6 +175,9 @@ func (r *Runner) Run(ctx context.Context, args []string) int {
 		r.logger.Errorn("Unable to setup backend config", obskit.Error(err))
 		return 1
 	}
+	admin.RegisterAdminHandler("BackendConfig", backendconfig.NewAdmin(backendconfig.DefaultBackendConfig))
+	admin.RegisterAdminHandler("JobsDB", jobsdb.NewAdmin())
+	admin.RegisterAdminHandler("Lineage", lineage.NewAdmin(lineage.Default()))
 	backendconfig.DefaultBackendConfig.StartWithIDs(ctx, "")
 
 	// Prepare databases in sequential order, so that failure in one doesn't affect others (leaving dirty schema migration state)
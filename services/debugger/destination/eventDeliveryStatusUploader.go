@@ -16,6 +16,7 @@ import (
 	"github.com/rudderlabs/rudder-server/rruntime"
 	"github.com/rudderlabs/rudder-server/services/debugger"
 	"github.com/rudderlabs/rudder-server/services/debugger/cache"
+	"github.com/rudderlabs/rudder-server/services/debugger/livestream"
 )
 
 // DeliveryStatusT is a structure to hold everything related to event delivery
@@ -114,6 +115,14 @@ type EventDeliveryStatusUploader struct {
 // RecordEventDeliveryStatus is used to put the delivery status in the deliveryStatusesBatchChannel,
 // which will be processed by handleJobs.
 func (h *Handle) RecordEventDeliveryStatus(destinationID string, deliveryStatus *DeliveryStatusT) bool {
+	livestream.Default.Publish(&livestream.Event{
+		Kind:          livestream.KindDelivery,
+		SourceID:      deliveryStatus.SourceID,
+		DestinationID: destinationID,
+		EventName:     deliveryStatus.EventName,
+		EventType:     deliveryStatus.EventType,
+		Payload:       deliveryStatus,
+	})
 	// if disableEventDeliveryStatusUploads is true, return;
 	if !h.started || h.disableEventDeliveryStatusUploads.Load() {
 		return false
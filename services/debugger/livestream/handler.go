@@ -0,0 +1,146 @@
+package livestream
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+)
+
+// Path is where the live debugger stream is served
+const Path = "/internal/v1/debugger/stream"
+
+// Enabled returns whether the live debugger stream should be served
+func Enabled(conf *config.Config) bool {
+	return conf.GetBoolVar(false, "Debugger.LiveStream.enabled")
+}
+
+// Authenticated returns whether Debugger.LiveStream.token is configured, so that the stream requires it.
+// Since streamed events contain full payloads, the stream should only be served on public ports when authenticated.
+func Authenticated(conf *config.Config) bool {
+	return conf.GetStringVar("", "Debugger.LiveStream.token") != ""
+}
+
+// NewHandler returns a handler streaming the broker's events as server-sent events, until either the request or ctx is done.
+//
+// Events can be filtered using the kind, sourceId, destinationId and eventName query parameters, each accepting
+// comma separated values. Every event is sent with its kind as the event type and its json representation as data.
+// Heartbeat comments are sent periodically, along with the number of events dropped so far because the client couldn't keep up.
+// If Debugger.LiveStream.token is configured, requests need to provide it as a bearer token.
+func NewHandler(ctx context.Context, broker *Broker, conf *config.Config, log logger.Logger) http.Handler {
+	h := &handler{
+		ctx:               ctx,
+		broker:            broker,
+		log:               log.Child("stream"),
+		token:             conf.GetStringVar("", "Debugger.LiveStream.token"),
+		bufferSize:        conf.GetIntVar(1000, 1, "Debugger.LiveStream.bufferSize"),
+		maxSubscribers:    conf.GetReloadableIntVar(10, 1, "Debugger.LiveStream.maxSubscribers"),
+		heartbeatInterval: conf.GetDurationVar(15, time.Second, "Debugger.LiveStream.heartbeatInterval"),
+	}
+	return h
+}
+
+type handler struct {
+	ctx               context.Context
+	broker            *Broker
+	log               logger.Logger
+	token             string
+	bufferSize        int
+	maxSubscribers    config.ValueLoader[int]
+	heartbeatInterval time.Duration
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if h.token != "" {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+	}
+	if h.broker.Subscribers() >= h.maxSubscribers.Load() {
+		http.Error(w, "too many live debugger subscribers", http.StatusTooManyRequests)
+		return
+	}
+	query := r.URL.Query()
+	filter := Filter{
+		SourceIDs:      queryValues(query["sourceId"]),
+		DestinationIDs: queryValues(query["destinationId"]),
+		EventNames:     queryValues(query["eventName"]),
+	}
+	for _, kind := range queryValues(query["kind"]) {
+		switch k := Kind(kind); k {
+		case KindSource, KindTransformation, KindDelivery:
+			filter.Kinds = append(filter.Kinds, k)
+		default:
+			http.Error(w, fmt.Sprintf("unknown kind %q", kind), http.StatusBadRequest)
+			return
+		}
+	}
+
+	rc := http.NewResponseController(w)
+	// streams outlive the server's write timeout
+	_ = rc.SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		h.log.Errorn("live debugger stream not supported by response writer", obskit.Error(err))
+		return
+	}
+
+	sub := h.broker.Subscribe(filter, h.bufferSize)
+	defer sub.Close()
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		var err error
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprintf(w, ": heartbeat dropped=%d\n\n", sub.Dropped())
+		case e := <-sub.Events():
+			var data []byte
+			if data, err = jsonrs.Marshal(e); err != nil {
+				h.log.Warnn("marshalling live debugger event", obskit.Error(err))
+				continue
+			}
+			_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Kind, data)
+		}
+		if err == nil {
+			err = rc.Flush()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// queryValues splits comma separated query parameter values, ignoring empty ones
+func queryValues(values []string) []string {
+	var res []string
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				res = append(res, s)
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,163 @@
+package livestream_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+
+	"github.com/rudderlabs/rudder-server/services/debugger/livestream"
+)
+
+func TestFilter(t *testing.T) {
+	e := &livestream.Event{Kind: livestream.KindDelivery, SourceID: "s1", DestinationID: "d1", EventName: "Order Completed"}
+	require.True(t, livestream.Filter{}.Match(e))
+	require.True(t, livestream.Filter{Kinds: []livestream.Kind{livestream.KindSource, livestream.KindDelivery}}.Match(e))
+	require.True(t, livestream.Filter{SourceIDs: []string{"s1"}, DestinationIDs: []string{"d1"}, EventNames: []string{"Order Completed"}}.Match(e))
+	require.False(t, livestream.Filter{Kinds: []livestream.Kind{livestream.KindSource}}.Match(e))
+	require.False(t, livestream.Filter{SourceIDs: []string{"s2"}}.Match(e))
+	require.False(t, livestream.Filter{DestinationIDs: []string{"d2"}}.Match(e))
+	require.False(t, livestream.Filter{EventNames: []string{"Product Viewed"}}.Match(e))
+}
+
+func TestBroker(t *testing.T) {
+	b := livestream.NewBroker()
+	require.False(t, b.Active())
+	b.Publish(&livestream.Event{Kind: livestream.KindSource}) // no subscribers, nothing happens
+
+	all := b.Subscribe(livestream.Filter{}, 2)
+	deliveries := b.Subscribe(livestream.Filter{Kinds: []livestream.Kind{livestream.KindDelivery}}, 10)
+	require.True(t, b.Active())
+	require.Equal(t, 2, b.Subscribers())
+
+	// publishing never blocks, events are dropped for subscribers which are full
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 5; i++ {
+			b.Publish(&livestream.Event{Kind: livestream.KindSource})
+		}
+		b.Publish(&livestream.Event{Kind: livestream.KindDelivery})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishing blocked")
+	}
+	require.Len(t, all.Events(), 2)
+	require.EqualValues(t, 4, all.Dropped())
+	require.Len(t, deliveries.Events(), 1)
+	require.Zero(t, deliveries.Dropped())
+	e := <-deliveries.Events()
+	require.Equal(t, livestream.KindDelivery, e.Kind)
+	require.False(t, e.Timestamp.IsZero())
+
+	all.Close()
+	all.Close() // closing twice is harmless
+	require.True(t, b.Active())
+	deliveries.Close()
+	require.False(t, b.Active())
+	_, ok := <-deliveries.Events()
+	require.False(t, ok, "events channel should be closed")
+}
+
+func TestHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := livestream.NewBroker()
+	c := config.New()
+	c.Set("Debugger.LiveStream.maxSubscribers", 1)
+	c.Set("Debugger.LiveStream.heartbeatInterval", "10ms")
+	srv := httptest.NewServer(livestream.NewHandler(ctx, b, c, logger.NOP))
+	defer srv.Close()
+
+	t.Run("invalid kind", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "?kind=unknown")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "?kind=source,transformation&sourceId=s1&eventName=Order%20Completed")
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+		require.Eventually(t, b.Active, 5*time.Second, time.Millisecond)
+
+		// subscribers are limited
+		limited, err := http.Get(srv.URL)
+		require.NoError(t, err)
+		_ = limited.Body.Close()
+		require.Equal(t, http.StatusTooManyRequests, limited.StatusCode)
+
+		b.Publish(&livestream.Event{Kind: livestream.KindSource, SourceID: "s2", EventName: "Order Completed"})
+		b.Publish(&livestream.Event{Kind: livestream.KindDelivery, SourceID: "s1", EventName: "Order Completed"})
+		b.Publish(&livestream.Event{Kind: livestream.KindSource, SourceID: "s1", EventName: "Product Viewed"})
+		b.Publish(&livestream.Event{Kind: livestream.KindTransformation, SourceID: "s1", EventName: "Order Completed", Payload: map[string]interface{}{"key": "value"}})
+
+		scanner := bufio.NewScanner(resp.Body)
+		var eventType, data string
+		for scanner.Scan() && data == "" {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				eventType = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+		require.Equal(t, "transformation", eventType)
+		var e livestream.Event
+		require.NoError(t, jsonrs.Unmarshal([]byte(data), &e))
+		require.Equal(t, "s1", e.SourceID)
+		require.Equal(t, "Order Completed", e.EventName)
+		require.Equal(t, map[string]interface{}{"key": "value"}, e.Payload)
+	})
+
+	t.Run("token", func(t *testing.T) {
+		c := config.New()
+		c.Set("Debugger.LiveStream.token", "secret")
+		require.True(t, livestream.Authenticated(c))
+		srv := httptest.NewServer(livestream.NewHandler(ctx, livestream.NewBroker(), c, logger.NOP))
+		defer srv.Close()
+
+		for _, authorization := range []string{"", "Bearer wrong", "secret"} {
+			req, err := http.NewRequest(http.MethodGet, srv.URL, http.NoBody)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", authorization)
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, srv.URL, http.NoBody)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("streams end with the server", func(t *testing.T) {
+		require.Eventually(t, func() bool { return !b.Active() }, 5*time.Second, time.Millisecond)
+		resp, err := http.Get(srv.URL)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+		require.Eventually(t, b.Active, 5*time.Second, time.Millisecond)
+		cancel()
+		require.Eventually(t, func() bool { return !b.Active() }, 5*time.Second, time.Millisecond)
+	})
+}
@@ -0,0 +1,158 @@
+// Package livestream broadcasts live debugger events (source events, transformation results and destination delivery statuses)
+// to local subscribers, independently of the control plane.
+//
+// Publishing never blocks: every subscriber has a bounded buffer and events are dropped for subscribers that can't keep up,
+// so that a slow consumer can never back-pressure the pipeline. Publishers are expected to check [Broker.Active] before
+// building events, so that streaming costs nothing while nobody is listening.
+package livestream
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/stats"
+)
+
+// Kind is the kind of a live event
+type Kind string
+
+const (
+	// KindSource is an event received by a source
+	KindSource Kind = "source"
+	// KindTransformation is the result of a user transformation for an event
+	KindTransformation Kind = "transformation"
+	// KindDelivery is the delivery status of an event to a destination
+	KindDelivery Kind = "delivery"
+)
+
+// Event is a live event
+type Event struct {
+	Kind             Kind        `json:"kind"`
+	Timestamp        time.Time   `json:"timestamp"`
+	SourceID         string      `json:"sourceId,omitempty"`
+	DestinationID    string      `json:"destinationId,omitempty"`
+	TransformationID string      `json:"transformationId,omitempty"`
+	EventName        string      `json:"eventName,omitempty"`
+	EventType        string      `json:"eventType,omitempty"`
+	Payload          interface{} `json:"payload"`
+}
+
+// Filter selects the events delivered to a subscriber. Empty fields match everything.
+type Filter struct {
+	Kinds          []Kind
+	SourceIDs      []string
+	DestinationIDs []string
+	EventNames     []string
+}
+
+// Match returns whether the event is selected by the filter
+func (f Filter) Match(e *Event) bool {
+	return matches(f.Kinds, e.Kind) &&
+		matches(f.SourceIDs, e.SourceID) &&
+		matches(f.DestinationIDs, e.DestinationID) &&
+		matches(f.EventNames, e.EventName)
+}
+
+func matches[T comparable](values []T, v T) bool {
+	return len(values) == 0 || slices.Contains(values, v)
+}
+
+// Default is the broker used by the debuggers and served by the live debugger endpoints
+var Default = NewBroker()
+
+// Broker fans out published events to its subscribers
+type Broker struct {
+	mu          sync.RWMutex
+	subscribers map[*Subscription]struct{}
+	active      atomic.Bool
+}
+
+// NewBroker creates a broker without subscribers
+func NewBroker() *Broker {
+	return &Broker{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Active returns whether there is any subscriber, i.e. whether published events would be delivered anywhere
+func (b *Broker) Active() bool {
+	return b.active.Load()
+}
+
+// Subscribers returns the number of subscribers
+func (b *Broker) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers)
+}
+
+// Publish delivers the event to all matching subscribers having room for it, dropping it for the rest
+func (b *Broker) Publish(e *Event) {
+	if !b.Active() {
+		return
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for s := range b.subscribers {
+		if !s.filter.Match(e) {
+			continue
+		}
+		select {
+		case s.ch <- e:
+		default:
+			s.dropped.Add(1)
+			stats.Default.NewTaggedStat("debugger_stream_events_dropped", stats.CountType, stats.Tags{"kind": string(e.Kind)}).Increment()
+		}
+	}
+}
+
+// Subscribe registers a subscriber receiving the events matching filter, buffering up to bufferSize of them
+func (b *Broker) Subscribe(filter Filter, bufferSize int) *Subscription {
+	s := &Subscription{
+		broker: b,
+		filter: filter,
+		ch:     make(chan *Event, max(bufferSize, 1)),
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[s] = struct{}{}
+	b.active.Store(true)
+	return s
+}
+
+func (b *Broker) unsubscribe(s *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[s]; !ok {
+		return
+	}
+	delete(b.subscribers, s)
+	close(s.ch)
+	b.active.Store(len(b.subscribers) > 0)
+}
+
+// Subscription receives the events published to a broker matching its filter
+type Subscription struct {
+	broker  *Broker
+	filter  Filter
+	ch      chan *Event
+	dropped atomic.Int64
+}
+
+// Events returns the channel events are delivered to, which is closed once the subscription is closed
+func (s *Subscription) Events() <-chan *Event {
+	return s.ch
+}
+
+// Dropped returns the number of events dropped so far because the subscription's buffer was full
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close unregisters the subscription
+func (s *Subscription) Close() {
+	s.broker.unsubscribe(s)
+}
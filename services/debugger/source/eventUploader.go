@@ -20,6 +20,7 @@ import (
 	"github.com/rudderlabs/rudder-server/rruntime"
 	"github.com/rudderlabs/rudder-server/services/debugger"
 	"github.com/rudderlabs/rudder-server/services/debugger/cache"
+	"github.com/rudderlabs/rudder-server/services/debugger/livestream"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 )
 
@@ -54,6 +55,7 @@ type Handle struct {
 
 	uploadEnabledWriteKeysMu sync.RWMutex
 	uploadEnabledWriteKeys   []string
+	sourceIDsByWriteKey      map[string]string
 
 	ctx         context.Context
 	cancel      func()
@@ -112,6 +114,9 @@ func (h *Handle) Stop() {
 // RecordEvent is used to put the event batch in the eventBatchChannel,
 // which will be processed by handleEvents.
 func (h *Handle) RecordEvent(writeKey string, eventBatch []byte) bool {
+	if livestream.Default.Active() {
+		h.publish(writeKey, eventBatch)
+	}
 	if !h.started || h.disableEventUploads.Load() {
 		return false
 	}
@@ -130,10 +135,38 @@ func (h *Handle) RecordEvent(writeKey string, eventBatch []byte) bool {
 	return true
 }
 
+// publish publishes the batch's events to the live debugger stream
+func (h *Handle) publish(writeKey string, eventBatch []byte) {
+	var batch EventUploadBatchT
+	if err := jsonrs.Unmarshal(eventBatch, &batch); err != nil {
+		h.log.Warnn("[Source live events] Failed to unmarshal for streaming", obskit.Error(err))
+		return
+	}
+	h.uploadEnabledWriteKeysMu.RLock()
+	sourceID := h.sourceIDsByWriteKey[writeKey]
+	h.uploadEnabledWriteKeysMu.RUnlock()
+	for _, ev := range batch.Batch {
+		eventType := stringify.Any(ev["type"])
+		eventName, _ := ev["event"].(string)
+		if eventName == "" {
+			eventName = eventType
+		}
+		livestream.Default.Publish(&livestream.Event{
+			Kind:      livestream.KindSource,
+			SourceID:  sourceID,
+			EventName: eventName,
+			EventType: eventType,
+			Payload:   ev,
+		})
+	}
+}
+
 func (h *Handle) updateConfig(config map[string]backendconfig.ConfigT) {
 	var uploadEnabledWriteKeys []string
+	sourceIDsByWriteKey := make(map[string]string)
 	for _, wConfig := range config {
 		for _, source := range wConfig.Sources {
+			sourceIDsByWriteKey[source.WriteKey] = source.ID
 			if source.Config != nil {
 				eventUploadEnabled, err := jsonparser.GetBoolean(source.Config, "eventUpload")
 				if err != nil && !errors.Is(err, jsonparser.KeyPathNotFoundError) && !errors.Is(err, jsonparser.NullValueError) {
@@ -147,6 +180,7 @@ func (h *Handle) updateConfig(config map[string]backendconfig.ConfigT) {
 	}
 	h.uploadEnabledWriteKeysMu.Lock()
 	h.uploadEnabledWriteKeys = uploadEnabledWriteKeys
+	h.sourceIDsByWriteKey = sourceIDsByWriteKey
 	h.uploadEnabledWriteKeysMu.Unlock()
 	h.recordHistoricEvents(uploadEnabledWriteKeys)
 }
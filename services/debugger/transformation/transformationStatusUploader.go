@@ -18,6 +18,7 @@ import (
 	"github.com/rudderlabs/rudder-server/rruntime"
 	"github.com/rudderlabs/rudder-server/services/debugger"
 	"github.com/rudderlabs/rudder-server/services/debugger/cache"
+	"github.com/rudderlabs/rudder-server/services/debugger/livestream"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	reportingtypes "github.com/rudderlabs/rudder-server/utils/types"
 )
@@ -263,6 +264,12 @@ func (h *Handle) UploadTransformationStatus(tStatus *TransformationStatusT) bool
 		}
 	}()
 
+	if livestream.Default.Active() {
+		for _, transformation := range tStatus.Destination.Transformations {
+			h.processTransformationStatus(tStatus, transformation.ID, publishTransformationStatus)
+		}
+	}
+
 	// if disableTransformationUploads is true, return;
 	if h.disableTransformationUploads.Load() {
 		return false
@@ -271,7 +278,7 @@ func (h *Handle) UploadTransformationStatus(tStatus *TransformationStatusT) bool
 
 	for _, transformation := range tStatus.Destination.Transformations {
 		if h.IsUploadEnabled(transformation.ID) {
-			h.processRecordTransformationStatus(tStatus, transformation.ID)
+			h.processTransformationStatus(tStatus, transformation.ID, h.RecordTransformationStatus)
 		} else {
 			err := h.transformationCacheMap.Update(
 				transformation.ID,
@@ -330,12 +337,29 @@ func (h *Handle) recordHistoricTransformations(tIDs []string) {
 			continue
 		}
 		for _, tStatus := range tStatuses {
-			h.processRecordTransformationStatus(&tStatus, tID)
+			h.processTransformationStatus(&tStatus, tID, h.RecordTransformationStatus)
 		}
 	}
 }
 
-func (h *Handle) processRecordTransformationStatus(tStatus *TransformationStatusT, tID string) {
+// publishTransformationStatus publishes the transformation status to the live debugger stream
+func publishTransformationStatus(transformStatus *TransformStatusT) {
+	e := &livestream.Event{
+		Kind:             livestream.KindTransformation,
+		SourceID:         transformStatus.SourceID,
+		DestinationID:    transformStatus.DestinationID,
+		TransformationID: transformStatus.TransformationID,
+		Payload:          transformStatus,
+	}
+	if transformStatus.EventBefore != nil {
+		e.EventName = transformStatus.EventBefore.EventName
+		e.EventType = transformStatus.EventBefore.EventType
+	}
+	livestream.Default.Publish(e)
+}
+
+// processTransformationStatus builds the per event statuses of the transformation and passes them to record
+func (h *Handle) processTransformationStatus(tStatus *TransformationStatusT, tID string, record func(*TransformStatusT)) {
 	reportedMessageIDs := make(map[string]struct{})
 	eventBeforeMap := make(map[string]*EventBeforeTransform)
 	eventAfterMap := make(map[string]*EventsAfterTransform)
@@ -359,7 +383,7 @@ func (h *Handle) processRecordTransformationStatus(tStatus *TransformationStatus
 	}
 
 	for k := range eventBeforeMap {
-		h.RecordTransformationStatus(&TransformStatusT{
+		record(&TransformStatusT{
 			TransformationID: tID,
 			SourceID:         tStatus.SourceID,
 			DestinationID:    tStatus.DestID,
@@ -390,7 +414,7 @@ func (h *Handle) processRecordTransformationStatus(tStatus *TransformationStatus
 					isError = true
 				}
 
-				h.RecordTransformationStatus(&TransformStatusT{
+				record(&TransformStatusT{
 					TransformationID: tID,
 					SourceID:         tStatus.SourceID,
 					DestinationID:    tStatus.DestID,
@@ -411,7 +435,7 @@ func (h *Handle) processRecordTransformationStatus(tStatus *TransformationStatus
 				IsDropped:  true,
 			}
 
-			h.RecordTransformationStatus(&TransformStatusT{
+			record(&TransformStatusT{
 				TransformationID: tID,
 				SourceID:         tStatus.SourceID,
 				DestinationID:    tStatus.DestID,
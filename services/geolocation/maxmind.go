@@ -57,3 +57,51 @@ func (f *maxmindDBReader) Close() error {
 
 	return nil
 }
+
+type multiDBReader struct {
+	readers []*maxmindDBReader
+}
+
+// NewMultiDBReader returns a fetcher merging the lookups of several databases, e.g. a city database along with ASN and connection type ones.
+// Fields found in more than one database are taken from the last one having them.
+func NewMultiDBReader(dbLocs ...string) (GeoFetcher, error) {
+	if len(dbLocs) == 0 {
+		return nil, ErrInvalidDatabase
+	}
+	m := &multiDBReader{}
+	for _, dbLoc := range dbLocs {
+		reader, err := NewMaxmindDBReader(dbLoc)
+		if err != nil {
+			_ = m.Close()
+			return nil, fmt.Errorf("opening database %q: %w", dbLoc, err)
+		}
+		m.readers = append(m.readers, reader)
+	}
+	if len(m.readers) == 1 {
+		return m.readers[0], nil
+	}
+	return m, nil
+}
+
+func (m *multiDBReader) Locate(ip string) (GeoInfo, error) {
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil {
+		return GeoInfo{}, ErrInvalidIP
+	}
+
+	info := GeoInfo{}
+	for _, reader := range m.readers {
+		if err := reader.Lookup(parsedIP, &info); err != nil {
+			return GeoInfo{}, fmt.Errorf("reading geolocation for ip: %w", err)
+		}
+	}
+	return info, nil
+}
+
+func (m *multiDBReader) Close() error {
+	var errs []error
+	for _, reader := range m.readers {
+		errs = append(errs, reader.Close())
+	}
+	return errors.Join(errs...)
+}
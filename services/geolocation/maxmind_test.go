@@ -51,3 +51,66 @@ func TestGeolocationFetcher(t *testing.T) {
 		require.Empty(t, emptyLookup)
 	})
 }
+
+func TestMultiDBReader(t *testing.T) {
+	t.Run("reader errors out when any db is corrupted", func(t *testing.T) {
+		_, err := geolocation.NewMultiDBReader("./testdata/city_test.mmdb", "./testdata/corrupted_city_test.mmdb")
+		require.ErrorIs(t, err, geolocation.ErrInvalidDatabase)
+
+		_, err = geolocation.NewMultiDBReader()
+		require.ErrorIs(t, err, geolocation.ErrInvalidDatabase)
+	})
+
+	// isp_test.mmdb and connection_type_test.mmdb are minimal databases following the GeoIP2 ISP and Connection-Type schemas
+	f, err := geolocation.NewMultiDBReader("./testdata/city_test.mmdb", "./testdata/isp_test.mmdb", "./testdata/connection_type_test.mmdb")
+	require.NoError(t, err)
+	defer func() { require.NoError(t, f.Close()) }()
+
+	t.Run("lookups are merged from all databases", func(t *testing.T) {
+		lookup, err := f.Locate(`2.125.160.216`)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"en": "Boxford"}, lookup.City.Names)
+		require.EqualValues(t, 5607, lookup.ASN)
+		require.Equal(t, "Sky UK Limited", lookup.ASOrganization)
+		require.Equal(t, "Sky UK", lookup.ISP)
+		require.Equal(t, "Sky Broadband", lookup.Organization)
+		require.Equal(t, "Cable/DSL", lookup.ConnectionType)
+
+		lookup, err = f.Locate(`2a02:ff40::0`)
+		require.NoError(t, err)
+		require.Equal(t, `Europe`, lookup.Continent.Names["en"])
+		require.EqualValues(t, 5607, lookup.ASN)
+		require.Empty(t, lookup.ISP)
+		require.Equal(t, "Cellular", lookup.ConnectionType)
+	})
+
+	t.Run("invalid and missing ips", func(t *testing.T) {
+		_, err := f.Locate(`invalid-ip`)
+		require.ErrorIs(t, err, geolocation.ErrInvalidIP)
+
+		lookup, err := f.Locate(`1.1.1.1`)
+		require.NoError(t, err)
+		require.Empty(t, lookup)
+	})
+}
+
+func TestSwappableFetcher(t *testing.T) {
+	city, err := geolocation.NewMaxmindDBReader("./testdata/city_test.mmdb")
+	require.NoError(t, err)
+	f := geolocation.NewSwappableFetcher(city)
+
+	lookup, err := f.Locate(`2.125.160.216`)
+	require.NoError(t, err)
+	require.Zero(t, lookup.ASN)
+
+	withISP, err := geolocation.NewMultiDBReader("./testdata/city_test.mmdb", "./testdata/isp_test.mmdb")
+	require.NoError(t, err)
+	require.NoError(t, f.Swap(withISP))
+
+	lookup, err = f.Locate(`2.125.160.216`)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"en": "Boxford"}, lookup.City.Names)
+	require.EqualValues(t, 5607, lookup.ASN)
+
+	require.NoError(t, f.Close())
+}
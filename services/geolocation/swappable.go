@@ -0,0 +1,34 @@
+package geolocation
+
+import "sync"
+
+// SwappableFetcher is a fetcher whose underlying fetcher can be replaced while in use, e.g. once a newer database is available
+type SwappableFetcher struct {
+	mu      sync.RWMutex
+	fetcher GeoFetcher
+}
+
+func NewSwappableFetcher(fetcher GeoFetcher) *SwappableFetcher {
+	return &SwappableFetcher{fetcher: fetcher}
+}
+
+func (s *SwappableFetcher) Locate(ip string) (GeoInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fetcher.Locate(ip)
+}
+
+// Swap replaces the underlying fetcher, closing the previous one once no lookup is using it anymore
+func (s *SwappableFetcher) Swap(fetcher GeoFetcher) error {
+	s.mu.Lock()
+	previous := s.fetcher
+	s.fetcher = fetcher
+	s.mu.Unlock()
+	return previous.Close()
+}
+
+func (s *SwappableFetcher) Close() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.fetcher.Close()
+}
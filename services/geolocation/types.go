@@ -6,7 +6,9 @@ type GeoFetcher interface {
 }
 
 // The City struct corresponds to the data in the GeoIP2/GeoLite2 City
-// databases. Given we are using the native library to decode the information,
+// databases, or compatible ones such as DB-IP and IP2Location MMDB files,
+// along with the network traits of the ASN, ISP and connection type databases.
+// Given we are using the native library to decode the information,
 // we have modified some fields in it to contain the pointer values.
 type GeoInfo struct {
 	City         City          `maxminddb:"city"`
@@ -15,6 +17,13 @@ type GeoInfo struct {
 	Subdivisions []Subdivision `maxminddb:"subdivisions"`
 	Country      Country       `maxminddb:"country"`
 	Location     Location      `maxminddb:"location"`
+
+	// Network traits, found in ASN, ISP and connection type databases
+	ASN            uint   `maxminddb:"autonomous_system_number"`
+	ASOrganization string `maxminddb:"autonomous_system_organization"`
+	ISP            string `maxminddb:"isp"`
+	Organization   string `maxminddb:"organization"`
+	ConnectionType string `maxminddb:"connection_type"`
 }
 
 type City struct {
@@ -0,0 +1,84 @@
+package lineage
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+// defaultWatchTTL is the duration message and user ids are watched for, if not specified
+const defaultWatchTTL = time.Hour
+
+type Admin struct {
+	recorder *Recorder
+}
+
+// NewAdmin returns the admin handler of a recorder, to be registered with the admin server
+func NewAdmin(recorder *Recorder) *Admin {
+	return &Admin{recorder: recorder}
+}
+
+type TraceArg struct {
+	MessageID string
+	UserID    string
+}
+
+type WatchArg struct {
+	MessageID string
+	UserID    string
+	TTL       time.Duration
+}
+
+type messageTrace struct {
+	MessageID string   `json:"messageId"`
+	Records   []Record `json:"records"`
+}
+
+// Trace returns the recorded transitions of a message id, or of all the traced message ids of a user id
+func (a *Admin) Trace(arg TraceArg, reply *string) error {
+	var messageIDs []string
+	switch {
+	case arg.MessageID != "":
+		messageIDs = []string{arg.MessageID}
+	case arg.UserID != "":
+		messageIDs = a.recorder.TracedMessages(arg.UserID)
+		slices.Sort(messageIDs)
+	default:
+		return fmt.Errorf("either a message id or a user id is required")
+	}
+	traces := make([]messageTrace, 0, len(messageIDs))
+	for _, messageID := range messageIDs {
+		if records := a.recorder.Trace(messageID); len(records) > 0 {
+			traces = append(traces, messageTrace{MessageID: messageID, Records: records})
+		}
+	}
+	return marshalReply(traces, reply)
+}
+
+// Watch traces the events of a message id or user id from now on, replying with all the current watches
+func (a *Admin) Watch(arg WatchArg, reply *string) error {
+	ttl := arg.TTL
+	if ttl == 0 {
+		ttl = defaultWatchTTL
+	}
+	switch {
+	case arg.MessageID != "":
+		a.recorder.Watch(arg.MessageID, ttl)
+	case arg.UserID != "":
+		a.recorder.Watch(arg.UserID, ttl)
+	default:
+		return fmt.Errorf("either a message id or a user id is required")
+	}
+	return marshalReply(a.recorder.Watches(), reply)
+}
+
+func marshalReply(v any, reply *string) error {
+	formattedOutput, err := jsonrs.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	*reply = string(formattedOutput)
+	return nil
+}
@@ -0,0 +1,202 @@
+// Package lineage records the transitions of events across the stages of the pipeline, i.e. gateway, dedup, user
+// transformation, destination transformation and router delivery, so that the whereabouts of an event can be queried
+// by its message id.
+//
+// Events are traced either when sampled, deterministically by their message id so that all stages agree, or on-demand
+// while their message id or user id is being watched. Once an event is traced at a stage, it is traced at all the
+// following stages of the same process, even by stages which are not aware of its user id.
+// Traces are kept in memory for a limited time, thus they are lost on restarts and only cover the stages running in
+// the process being queried.
+package lineage
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+)
+
+type Stage string
+
+const (
+	StageGateway        Stage = "gateway"
+	StageDedup          Stage = "dedup"
+	StageUserTransform  Stage = "user_transform"
+	StageDestTransform  Stage = "dest_transform"
+	StageRouterDelivery Stage = "router_delivery"
+)
+
+// Record is the transition of an event at a stage
+type Record struct {
+	Stage         Stage     `json:"stage"`
+	Status        string    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+	MessageID     string    `json:"messageId"`
+	UserID        string    `json:"userId,omitempty"`
+	JobID         int64     `json:"jobId,omitempty"`
+	SourceID      string    `json:"sourceId,omitempty"`
+	DestinationID string    `json:"destinationId,omitempty"`
+	TraceParent   string    `json:"traceparent,omitempty"`
+	At            time.Time `json:"at"`
+}
+
+// Recorder records the transitions of traced events
+type Recorder struct {
+	sampleRate           config.ValueLoader[float64]
+	maxRecordsPerMessage config.ValueLoader[int]
+	maxErrorLength       config.ValueLoader[int]
+	now                  func() time.Time
+
+	traces *expirable.LRU[string, []Record] // message id => records
+
+	watchesMu sync.RWMutex
+	watches   map[string]time.Time // message id or user id => expiry of the watch
+	watching  atomic.Bool
+
+	mu sync.Mutex // serialises updates of traces
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultRecorder *Recorder
+)
+
+// Default returns the recorder shared by all the stages of the process
+func Default() *Recorder {
+	defaultOnce.Do(func() {
+		defaultRecorder = New(config.Default)
+	})
+	return defaultRecorder
+}
+
+// New returns a new recorder
+func New(conf *config.Config) *Recorder {
+	return &Recorder{
+		sampleRate:           conf.GetReloadableFloat64Var(0, "Lineage.sampleRate"),
+		maxRecordsPerMessage: conf.GetReloadableIntVar(100, 1, "Lineage.maxRecordsPerMessage"),
+		maxErrorLength:       conf.GetReloadableIntVar(1000, 1, "Lineage.maxErrorLength"),
+		now:                  time.Now,
+		traces:               expirable.NewLRU[string, []Record](conf.GetIntVar(10000, 1, "Lineage.maxMessages"), nil, conf.GetDurationVar(1, time.Hour, "Lineage.retention")),
+		watches:              make(map[string]time.Time),
+	}
+}
+
+// Active returns false if no event can currently be traced, allowing callers to skip extracting ids from payloads.
+// A nil recorder is never active.
+func (r *Recorder) Active() bool {
+	if r == nil {
+		return false
+	}
+	return r.sampleRate.Load() > 0 || r.watching.Load() || r.traces.Len() > 0
+}
+
+// Traced returns true if the event with the given message id, and optionally user ids, is traced
+func (r *Recorder) Traced(messageID string, userIDs ...string) bool {
+	if r == nil || messageID == "" {
+		return false
+	}
+	if r.traces.Contains(messageID) || r.sampled(messageID) {
+		return true
+	}
+	if !r.watching.Load() {
+		return false
+	}
+	r.watchesMu.RLock()
+	defer r.watchesMu.RUnlock()
+	now := r.now()
+	watched := func(id string) bool {
+		expiry, ok := r.watches[id]
+		return ok && now.Before(expiry)
+	}
+	if watched(messageID) {
+		return true
+	}
+	for _, userID := range userIDs {
+		if userID != "" && watched(userID) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recorder) sampled(messageID string) bool {
+	rate := r.sampleRate.Load()
+	if rate <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(messageID))
+	return float64(h.Sum32()%1_000_000) < rate*1_000_000
+}
+
+// Record records the transition of an event, if it is traced
+func (r *Recorder) Record(rec Record) {
+	if !r.Traced(rec.MessageID, rec.UserID) {
+		return
+	}
+	if rec.At.IsZero() {
+		rec.At = r.now()
+	}
+	if maxLength := r.maxErrorLength.Load(); len(rec.Error) > maxLength {
+		rec.Error = rec.Error[:maxLength]
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	records, _ := r.traces.Peek(rec.MessageID)
+	if len(records) >= r.maxRecordsPerMessage.Load() {
+		return
+	}
+	r.traces.Add(rec.MessageID, append(records, rec))
+}
+
+// Trace returns the transitions recorded for a message id, in the order they were recorded
+func (r *Recorder) Trace(messageID string) []Record {
+	records, _ := r.traces.Peek(messageID)
+	return records
+}
+
+// TracedMessages returns the message ids with transitions recorded for a user id
+func (r *Recorder) TracedMessages(userID string) []string {
+	var messageIDs []string
+	for _, records := range r.traces.Values() {
+		for _, rec := range records {
+			if rec.UserID == userID {
+				messageIDs = append(messageIDs, rec.MessageID)
+				break
+			}
+		}
+	}
+	return messageIDs
+}
+
+// Watch traces the events with the given message or user id for ttl, stopping watching it if ttl isn't positive
+func (r *Recorder) Watch(id string, ttl time.Duration) {
+	r.watchesMu.Lock()
+	defer r.watchesMu.Unlock()
+	now := r.now()
+	r.watches[id] = now.Add(ttl)
+	for id, expiry := range r.watches {
+		if !now.Before(expiry) {
+			delete(r.watches, id)
+		}
+	}
+	r.watching.Store(len(r.watches) > 0)
+}
+
+// Watches returns the message and user ids being watched, along with the expiry of their watch
+func (r *Recorder) Watches() map[string]time.Time {
+	r.watchesMu.RLock()
+	defer r.watchesMu.RUnlock()
+	now := r.now()
+	watches := make(map[string]time.Time, len(r.watches))
+	for id, expiry := range r.watches {
+		if now.Before(expiry) {
+			watches[id] = expiry
+		}
+	}
+	return watches
+}
@@ -0,0 +1,120 @@
+package lineage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Run("nothing is traced by default", func(t *testing.T) {
+		r := New(config.New())
+		require.False(t, r.Active())
+		r.Record(Record{Stage: StageGateway, Status: "stored", MessageID: "m1"})
+		require.Empty(t, r.Trace("m1"))
+	})
+
+	t.Run("nil recorder", func(t *testing.T) {
+		var r *Recorder
+		require.False(t, r.Active())
+		require.False(t, r.Traced("m1"))
+	})
+
+	t.Run("sampling", func(t *testing.T) {
+		c := config.New()
+		c.Set("Lineage.sampleRate", 1.0)
+		r := New(c)
+		require.True(t, r.Active())
+		r.Record(Record{Stage: StageGateway, Status: "stored", MessageID: "m1"})
+		r.Record(Record{Stage: StageDedup, Status: "passed", MessageID: "m1", JobID: 1})
+		records := r.Trace("m1")
+		require.Len(t, records, 2)
+		require.Equal(t, StageGateway, records[0].Stage)
+		require.Equal(t, StageDedup, records[1].Stage)
+		require.False(t, records[0].At.IsZero())
+
+		c.Set("Lineage.sampleRate", 0.5)
+		var sampled int
+		for i := range 1000 {
+			if r.sampled(time.Duration(i).String()) {
+				sampled++
+			}
+		}
+		require.InDelta(t, 500, sampled, 100)
+		require.Equal(t, r.sampled("m2"), r.sampled("m2"), "sampling should be deterministic")
+	})
+
+	t.Run("watching a user traces its events through later stages", func(t *testing.T) {
+		r := New(config.New())
+		now := time.Now()
+		r.now = func() time.Time { return now }
+		r.Watch("u1", time.Minute)
+		require.True(t, r.Active())
+		require.False(t, r.Traced("m1"))
+		require.True(t, r.Traced("m1", "u1"))
+
+		r.Record(Record{Stage: StageGateway, Status: "stored", MessageID: "m1", UserID: "u1"})
+		r.Record(Record{Stage: StageGateway, Status: "stored", MessageID: "m2", UserID: "u2"})
+		r.Record(Record{Stage: StageRouterDelivery, Status: "failed", Error: "timeout", MessageID: "m1", DestinationID: "d1"})
+		require.Len(t, r.Trace("m1"), 2)
+		require.Empty(t, r.Trace("m2"))
+		require.Equal(t, []string{"m1"}, r.TracedMessages("u1"))
+
+		now = now.Add(2 * time.Minute)
+		require.Empty(t, r.Watches())
+		require.False(t, r.Traced("m3", "u1"))
+		require.True(t, r.Traced("m1"), "events traced already should still be traced")
+	})
+
+	t.Run("limits", func(t *testing.T) {
+		c := config.New()
+		c.Set("Lineage.sampleRate", 1.0)
+		c.Set("Lineage.maxRecordsPerMessage", 2)
+		c.Set("Lineage.maxErrorLength", 3)
+		c.Set("Lineage.maxMessages", 2)
+		r := New(c)
+		for range 3 {
+			r.Record(Record{Stage: StageRouterDelivery, Status: "failed", Error: "timeout", MessageID: "m1"})
+		}
+		records := r.Trace("m1")
+		require.Len(t, records, 2)
+		require.Equal(t, "tim", records[0].Error)
+
+		r.Record(Record{Stage: StageGateway, Status: "stored", MessageID: "m2"})
+		r.Record(Record{Stage: StageGateway, Status: "stored", MessageID: "m3"})
+		require.Empty(t, r.Trace("m1"), "least recently traced message should be evicted")
+	})
+}
+
+func TestAdmin(t *testing.T) {
+	r := New(config.New())
+	a := NewAdmin(r)
+
+	var reply string
+	require.Error(t, a.Watch(WatchArg{}, &reply))
+	require.NoError(t, a.Watch(WatchArg{UserID: "u1"}, &reply))
+	var watches map[string]time.Time
+	require.NoError(t, jsonrs.Unmarshal([]byte(reply), &watches))
+	require.Contains(t, watches, "u1")
+
+	r.Record(Record{Stage: StageGateway, Status: "stored", MessageID: "m1", UserID: "u1"})
+	r.Record(Record{Stage: StageDedup, Status: "dropped", MessageID: "m1"})
+
+	for _, arg := range []TraceArg{{MessageID: "m1"}, {UserID: "u1"}} {
+		require.NoError(t, a.Trace(arg, &reply))
+		var traces []messageTrace
+		require.NoError(t, jsonrs.Unmarshal([]byte(reply), &traces))
+		require.Len(t, traces, 1)
+		require.Equal(t, "m1", traces[0].MessageID)
+		require.Len(t, traces[0].Records, 2)
+		require.Equal(t, "dropped", traces[0].Records[1].Status)
+	}
+
+	require.NoError(t, a.Trace(TraceArg{MessageID: "unknown"}, &reply))
+	require.JSONEq(t, `[]`, reply)
+	require.Error(t, a.Trace(TraceArg{}, &reply))
+}
@@ -0,0 +1,176 @@
+package v2
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Local token provider", func() {
+	Describe("ParseLocalOAuthConfig", func() {
+		It("parses client credentials settings", func() {
+			conf, err := ParseLocalOAuthConfig(map[string]interface{}{
+				"tokenUrl":         "https://idp.example.com/token",
+				"clientId":         "client",
+				"clientSecret":     "s3cr3t",
+				"scopes":           []interface{}{"a", "b"},
+				"clientAuthMethod": "client_secret_post",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conf.GrantType).To(Equal(GrantTypeClientCredentials))
+			Expect(conf.Scopes).To(Equal([]string{"a", "b"}))
+			Expect(conf.ClientAuthInBody).To(BeTrue())
+		})
+
+		DescribeTable("rejects invalid settings",
+			func(v interface{}, expectedErr string) {
+				_, err := ParseLocalOAuthConfig(v)
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+			},
+			Entry("not an object", "oauth", "expected an object"),
+			Entry("missing token url", map[string]interface{}{"clientId": "c", "clientSecret": "s"}, "tokenUrl"),
+			Entry("missing client secret", map[string]interface{}{"tokenUrl": "https://idp", "clientId": "c"}, "clientSecret"),
+			Entry("unsupported grant type", map[string]interface{}{"tokenUrl": "https://idp", "grantType": "password"}, "unsupported grantType"),
+			Entry("missing private key", map[string]interface{}{"tokenUrl": "https://idp", "grantType": GrantTypeJWTBearer, "clientId": "c"}, "privateKey"),
+			Entry("invalid private key", map[string]interface{}{"tokenUrl": "https://idp", "grantType": GrantTypeJWTBearer, "clientId": "c", "privateKey": "key"}, "PEM encoded"),
+		)
+	})
+
+	Describe("Token", func() {
+		var (
+			server       *httptest.Server
+			tokensIssued atomic.Int64
+			lastRequest  atomic.Pointer[http.Request]
+			provider     *LocalTokenProvider
+			now          time.Time
+		)
+		BeforeEach(func() {
+			tokensIssued.Store(0)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = r.ParseForm()
+				lastRequest.Store(r)
+				if r.PostForm.Get("grant_type") == "" {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"error":"invalid_request","error_description":"missing grant_type"}`))
+					return
+				}
+				_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":600}`, tokensIssued.Add(1))
+			}))
+			now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			provider = NewLocalTokenProvider(nil, server.Client(), time.Minute)
+			provider.now = func() time.Time { return now }
+		})
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("uses the client credentials grant, caching tokens until they are about to expire", func() {
+			conf, err := ParseLocalOAuthConfig(map[string]interface{}{
+				"tokenUrl":     server.URL,
+				"clientId":     "client",
+				"clientSecret": "s3cr3t",
+				"scopes":       "events:write",
+				"audience":     "https://api.example.com",
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			token, err := provider.Token(context.Background(), "dest-1", conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.AccessToken).To(Equal("token-1"))
+			Expect(token.AuthorizationHeader()).To(Equal("Bearer token-1"))
+			Expect(token.ExpiresAt).To(Equal(now.Add(10 * time.Minute)))
+			req := lastRequest.Load()
+			clientID, clientSecret, ok := req.BasicAuth()
+			Expect(ok).To(BeTrue())
+			Expect(clientID).To(Equal("client"))
+			Expect(clientSecret).To(Equal("s3cr3t"))
+			Expect(req.PostForm.Get("grant_type")).To(Equal(GrantTypeClientCredentials))
+			Expect(req.PostForm.Get("scope")).To(Equal("events:write"))
+			Expect(req.PostForm.Get("audience")).To(Equal("https://api.example.com"))
+
+			now = now.Add(8 * time.Minute)
+			token, err = provider.Token(context.Background(), "dest-1", conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.AccessToken).To(Equal("token-1"))
+
+			token, err = provider.Token(context.Background(), "dest-2", conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.AccessToken).To(Equal("token-2"), "tokens are cached per destination")
+
+			now = now.Add(time.Minute + time.Second)
+			token, err = provider.Token(context.Background(), "dest-1", conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.AccessToken).To(Equal("token-3"), "tokens about to expire should be refreshed")
+		})
+
+		It("requests a new token after the cached one gets invalidated", func() {
+			conf, err := ParseLocalOAuthConfig(map[string]interface{}{"tokenUrl": server.URL, "clientId": "client", "clientSecret": "s3cr3t"})
+			Expect(err).NotTo(HaveOccurred())
+			first, err := provider.Token(context.Background(), "dest-1", conf)
+			Expect(err).NotTo(HaveOccurred())
+			provider.Invalidate("dest-1", conf, &LocalToken{AccessToken: "other"})
+			token, err := provider.Token(context.Background(), "dest-1", conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal(first), "invalidating another token shouldn't evict the cached one")
+
+			provider.Invalidate("dest-1", conf, first)
+			token, err = provider.Token(context.Background(), "dest-1", conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.AccessToken).To(Equal("token-2"))
+		})
+
+		It("uses the jwt bearer grant", func() {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			Expect(err).NotTo(HaveOccurred())
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			Expect(err).NotTo(HaveOccurred())
+			conf, err := ParseLocalOAuthConfig(map[string]interface{}{
+				"grantType":  GrantTypeJWTBearer,
+				"tokenUrl":   server.URL,
+				"clientId":   "client",
+				"privateKey": string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+				"keyId":      "key-1",
+			})
+			Expect(err).NotTo(HaveOccurred())
+			provider.now = time.Now
+
+			token, err := provider.Token(context.Background(), "dest-1", conf)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token.AccessToken).To(Equal("token-1"))
+
+			req := lastRequest.Load()
+			_, _, ok := req.BasicAuth()
+			Expect(ok).To(BeFalse())
+			Expect(req.PostForm.Get("grant_type")).To(Equal(GrantTypeJWTBearer))
+			Expect(req.PostForm.Get("client_id")).To(Equal("client"))
+			claims := jwt.RegisteredClaims{}
+			assertion, err := jwt.ParseWithClaims(req.PostForm.Get("assertion"), &claims, func(t *jwt.Token) (interface{}, error) {
+				return &key.PublicKey, nil
+			}, jwt.WithValidMethods([]string{"ES256"}), jwt.WithAudience(server.URL), jwt.WithIssuer("client"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(assertion.Header["kid"]).To(Equal("key-1"))
+			Expect(claims.Subject).To(Equal("client"))
+			Expect(claims.ID).NotTo(BeEmpty())
+		})
+
+		It("returns token request errors", func() {
+			conf, err := ParseLocalOAuthConfig(map[string]interface{}{"tokenUrl": server.URL, "clientId": "client", "clientSecret": "s3cr3t"})
+			Expect(err).NotTo(HaveOccurred())
+			conf.GrantType = ""
+			_, err = provider.Token(context.Background(), "dest-1", conf)
+			Expect(err).To(MatchError("token request failed with 400: invalid_request missing grant_type"))
+		})
+	})
+})
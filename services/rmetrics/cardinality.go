@@ -0,0 +1,276 @@
+package rmetrics
+
+import (
+	"fmt"
+	"hash/maphash"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+)
+
+// OtherTagValue is the value of the workspace tags, and the tags scoped to workspaces, of the workspaces which are not
+// amongst the top ones
+const OtherTagValue = "other"
+
+// CardinalityLimiter is a [stats.Stats] bounding the cardinality of the metrics it creates, by
+//   - dropping the tags of a metric which are not in its allow-list, if it has one
+//   - keeping the workspace tags, and tags scoped to workspaces such as source and destination ids, of the top N
+//     workspaces only, replacing them with [OtherTagValue] for the rest of the workspaces. Gauges are never bucketed, since
+//     the gauges of different workspaces sharing the same tags would overwrite each other's values instead of adding up
+//
+// If Stats.cardinality.trackSeries is enabled, it also keeps track of up to Stats.cardinality.maxSeriesPerMetric series
+// per metric name, which can be listed with [SeriesHandler].
+type CardinalityLimiter struct {
+	stats.Stats
+
+	allowedTags        map[string][]string // metric name => tags allowed
+	topWorkspaces      config.ValueLoader[int]
+	rankingInterval    config.ValueLoader[time.Duration]
+	workspaceTags      []string
+	bucketedTags       []string // workspace tags along with tags scoped to workspaces
+	trackSeries        bool
+	maxSeriesPerMetric int
+	now                func() time.Time
+
+	rankingMu       sync.Mutex                          // guards ranking workspaces and admitting new ones
+	workspaceCounts atomic.Pointer[sync.Map]            // workspace => *atomic.Int64 of the metrics created since the last ranking
+	admitted        atomic.Pointer[map[string]struct{}] // workspaces keeping their tags, replaced on every change
+	rankedAt        atomic.Int64                        // unix nanoseconds of the last ranking
+
+	seed   maphash.Seed
+	series sync.Map // metric name => *metricSeries
+}
+
+// metricSeries holds the hashes of the tags of a metric's series
+type metricSeries struct {
+	mu     sync.Mutex
+	hashes map[uint64]struct{}
+}
+
+// NewCardinalityLimiter returns a [CardinalityLimiter] creating metrics with s
+func NewCardinalityLimiter(s stats.Stats, conf *config.Config) *CardinalityLimiter {
+	l := &CardinalityLimiter{
+		Stats:              s,
+		allowedTags:        make(map[string][]string),
+		topWorkspaces:      conf.GetReloadableIntVar(0, 1, "Stats.cardinality.topWorkspaces"),
+		rankingInterval:    conf.GetReloadableDurationVar(5, time.Minute, "Stats.cardinality.rankingInterval"),
+		workspaceTags:      conf.GetStringSliceVar([]string{"workspaceId", "workspace_id", "workspace"}, "Stats.cardinality.workspaceTags"),
+		trackSeries:        conf.GetBoolVar(false, "Stats.cardinality.trackSeries"),
+		maxSeriesPerMetric: conf.GetIntVar(10000, 1, "Stats.cardinality.maxSeriesPerMetric"),
+		now:                time.Now,
+		seed:               maphash.MakeSeed(),
+	}
+	l.workspaceCounts.Store(&sync.Map{})
+	l.admitted.Store(&map[string]struct{}{})
+	l.rankedAt.Store(l.now().UnixNano())
+	l.bucketedTags = append(slices.Clone(l.workspaceTags), conf.GetStringSliceVar([]string{"sourceID", "sourceId", "source_id", "destID", "destId", "destinationId", "destination_id"}, "Stats.cardinality.workspaceScopedTags")...)
+	// allow-lists are in the form of metric_name:tag1,tag2
+	for _, allowList := range conf.GetStringSliceVar(nil, "Stats.cardinality.allowedTags") {
+		name, tags, _ := strings.Cut(allowList, ":")
+		l.allowedTags[strings.TrimSpace(name)] = strings.Split(strings.ReplaceAll(tags, " ", ""), ",")
+	}
+	return l
+}
+
+func (l *CardinalityLimiter) NewStat(name, statType string) stats.Measurement {
+	l.track(name, nil)
+	return l.Stats.NewStat(name, statType)
+}
+
+func (l *CardinalityLimiter) NewTaggedStat(name, statType string, tags stats.Tags) stats.Measurement {
+	tags = l.limit(name, statType, tags)
+	l.track(name, tags)
+	return l.Stats.NewTaggedStat(name, statType, tags)
+}
+
+func (l *CardinalityLimiter) NewSampledTaggedStat(name, statType string, tags stats.Tags) stats.Measurement {
+	tags = l.limit(name, statType, tags)
+	l.track(name, tags)
+	return l.Stats.NewSampledTaggedStat(name, statType, tags)
+}
+
+// limit returns the tags to create a metric with, copying them if they need to be changed
+func (l *CardinalityLimiter) limit(name, statType string, tags stats.Tags) stats.Tags {
+	if len(tags) == 0 {
+		return tags
+	}
+	allowed, hasAllowList := l.allowedTags[name]
+	topWorkspaces := l.topWorkspaces.Load()
+	if !hasAllowList && topWorkspaces <= 0 {
+		return tags
+	}
+	copied := false
+	set := func(tag, value string) {
+		if !copied {
+			tags, copied = maps.Clone(tags), true
+		}
+		if value == "" {
+			delete(tags, tag)
+		} else {
+			tags[tag] = value
+		}
+	}
+	if hasAllowList {
+		for tag := range tags {
+			if !slices.Contains(allowed, tag) {
+				set(tag, "")
+			}
+		}
+	}
+	if topWorkspaces > 0 && statType != stats.GaugeType {
+		if workspace := l.workspace(tags); workspace != "" && !l.admit(workspace, topWorkspaces) {
+			for _, tag := range l.bucketedTags {
+				if _, ok := tags[tag]; ok {
+					set(tag, OtherTagValue)
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// workspace returns the value of the first workspace tag found in tags
+func (l *CardinalityLimiter) workspace(tags stats.Tags) string {
+	for _, tag := range l.workspaceTags {
+		if workspace := tags[tag]; workspace != "" {
+			return workspace
+		}
+	}
+	return ""
+}
+
+// admit returns true if the workspace is amongst the top ones, i.e. the ones which have created the most metrics
+// during the last ranking interval. Until the next ranking, new workspaces are admitted while there is room for them.
+// Only rankings and admissions of new workspaces are serialized, the rest being lock-free.
+func (l *CardinalityLimiter) admit(workspace string, top int) bool {
+	if now := l.now(); now.Sub(time.Unix(0, l.rankedAt.Load())) >= l.rankingInterval.Load() {
+		l.rank(now, top)
+	}
+	counts := l.workspaceCounts.Load()
+	count, ok := counts.Load(workspace)
+	if !ok {
+		count, _ = counts.LoadOrStore(workspace, new(atomic.Int64))
+	}
+	count.(*atomic.Int64).Add(1)
+
+	admitted := *l.admitted.Load()
+	if _, ok := admitted[workspace]; ok {
+		return true
+	}
+	if len(admitted) >= top {
+		return false
+	}
+	l.rankingMu.Lock()
+	defer l.rankingMu.Unlock()
+	admitted = *l.admitted.Load()
+	if _, ok := admitted[workspace]; ok {
+		return true
+	}
+	if len(admitted) >= top {
+		return false
+	}
+	updated := maps.Clone(admitted)
+	updated[workspace] = struct{}{}
+	l.admitted.Store(&updated)
+	return true
+}
+
+// rank admits the top workspaces by the number of metrics they created since the last ranking, starting a new ranking interval
+func (l *CardinalityLimiter) rank(now time.Time, top int) {
+	l.rankingMu.Lock()
+	defer l.rankingMu.Unlock()
+	if now.Sub(time.Unix(0, l.rankedAt.Load())) < l.rankingInterval.Load() {
+		return // ranked concurrently
+	}
+	counts := make(map[string]int64)
+	l.workspaceCounts.Swap(&sync.Map{}).Range(func(workspace, count any) bool {
+		counts[workspace.(string)] = count.(*atomic.Int64).Load()
+		return true
+	})
+	ranked := slices.Collect(maps.Keys(counts))
+	sort.Slice(ranked, func(i, j int) bool {
+		if counts[ranked[i]] != counts[ranked[j]] {
+			return counts[ranked[i]] > counts[ranked[j]]
+		}
+		return ranked[i] < ranked[j]
+	})
+	admitted := make(map[string]struct{}, top)
+	for _, w := range ranked[:min(top, len(ranked))] {
+		admitted[w] = struct{}{}
+	}
+	l.admitted.Store(&admitted)
+	l.rankedAt.Store(now.UnixNano())
+}
+
+// track records the series of the metric, if series are tracked and the metric hasn't reached the maximum number of series tracked
+func (l *CardinalityLimiter) track(name string, tags stats.Tags) {
+	if !l.trackSeries {
+		return
+	}
+	// the hashes of the tags are summed, so that the hash of the series doesn't depend on the order of the tags
+	var hash uint64
+	var h maphash.Hash
+	h.SetSeed(l.seed)
+	for tag, value := range tags {
+		h.Reset()
+		_, _ = h.WriteString(tag)
+		_ = h.WriteByte(0)
+		_, _ = h.WriteString(value)
+		hash += h.Sum64()
+	}
+	v, ok := l.series.Load(name)
+	if !ok {
+		v, _ = l.series.LoadOrStore(name, &metricSeries{hashes: make(map[uint64]struct{})})
+	}
+	series := v.(*metricSeries)
+	series.mu.Lock()
+	defer series.mu.Unlock()
+	if len(series.hashes) < l.maxSeriesPerMetric {
+		series.hashes[hash] = struct{}{}
+	}
+}
+
+// SeriesCount returns the number of series created per metric name, capped at Stats.cardinality.maxSeriesPerMetric
+func (l *CardinalityLimiter) SeriesCount() map[string]int {
+	counts := make(map[string]int)
+	l.series.Range(func(name, v any) bool {
+		series := v.(*metricSeries)
+		series.mu.Lock()
+		counts[name.(string)] = len(series.hashes)
+		series.mu.Unlock()
+		return true
+	})
+	return counts
+}
+
+// SeriesHandler returns a handler listing the number of series created per metric name in the Prometheus text format,
+// if s is a [CardinalityLimiter] tracking series. It is meant to be served on internal ports only.
+func SeriesHandler(s stats.Stats) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		l, ok := s.(*CardinalityLimiter)
+		if !ok || !l.trackSeries {
+			http.Error(w, "series are not tracked", http.StatusNotFound)
+			return
+		}
+		counts := l.SeriesCount()
+		names := make([]string, 0, len(counts))
+		for name := range counts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = fmt.Fprintln(w, "# HELP rudder_metric_series Number of series of a metric, i.e. of distinct combinations of its tag values, capped at Stats.cardinality.maxSeriesPerMetric.")
+		_, _ = fmt.Fprintln(w, "# TYPE rudder_metric_series gauge")
+		for _, name := range names {
+			_, _ = fmt.Fprintf(w, "rudder_metric_series{metric=%q} %d\n", name, counts[name])
+		}
+	}
+}
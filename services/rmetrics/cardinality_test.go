@@ -0,0 +1,136 @@
+package rmetrics_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+	"github.com/rudderlabs/rudder-server/services/rmetrics"
+)
+
+func TestCardinalityLimiter(t *testing.T) {
+	t.Run("tags are kept by default", func(t *testing.T) {
+		store, err := memstats.New()
+		require.NoError(t, err)
+		l := rmetrics.NewCardinalityLimiter(store, config.New())
+		tags := stats.Tags{"workspaceId": "w1", "destId": "d1"}
+		l.NewTaggedStat("events", stats.CountType, tags).Increment()
+		require.EqualValues(t, 1, store.Get("events", tags).LastValue())
+	})
+
+	t.Run("allow-list", func(t *testing.T) {
+		store, err := memstats.New()
+		require.NoError(t, err)
+		c := config.New()
+		c.Set("Stats.cardinality.allowedTags", []string{"events:workspaceId, reqType"})
+		l := rmetrics.NewCardinalityLimiter(store, c)
+
+		tags := stats.Tags{"workspaceId": "w1", "reqType": "track", "sourceID": "s1"}
+		l.NewTaggedStat("events", stats.CountType, tags).Increment()
+		l.NewTaggedStat("other_events", stats.CountType, tags).Increment()
+		require.EqualValues(t, 1, store.Get("events", stats.Tags{"workspaceId": "w1", "reqType": "track"}).LastValue())
+		require.EqualValues(t, 1, store.Get("other_events", tags).LastValue())
+		require.Len(t, tags, 3, "tags of callers should not be modified")
+	})
+
+	t.Run("top workspaces", func(t *testing.T) {
+		store, err := memstats.New()
+		require.NoError(t, err)
+		c := config.New()
+		c.Set("Stats.cardinality.topWorkspaces", 1)
+		c.Set("Stats.cardinality.rankingInterval", "1m")
+		l := rmetrics.NewCardinalityLimiter(store, c)
+
+		count := func(workspace string, n int) {
+			for range n {
+				l.NewTaggedStat("events", stats.CountType, stats.Tags{"workspaceId": workspace, "destId": workspace + "-d", "reqType": "track"}).Increment()
+			}
+		}
+		count("w1", 1)
+		count("w2", 3)
+		require.EqualValues(t, 1, store.Get("events", stats.Tags{"workspaceId": "w1", "destId": "w1-d", "reqType": "track"}).LastValue())
+		require.EqualValues(t, 3, store.Get("events", stats.Tags{"workspaceId": rmetrics.OtherTagValue, "destId": rmetrics.OtherTagValue, "reqType": "track"}).LastValue())
+
+		// gauges of bucketed workspaces would overwrite each other's values
+		l.NewTaggedStat("pending_events", stats.GaugeType, stats.Tags{"workspaceId": "w2", "destId": "w2-d"}).Gauge(5)
+		l.NewTaggedStat("pending_events", stats.GaugeType, stats.Tags{"workspaceId": "w3", "destId": "w3-d"}).Gauge(7)
+		require.EqualValues(t, 5, store.Get("pending_events", stats.Tags{"workspaceId": "w2", "destId": "w2-d"}).LastValue(), "gauges should never be bucketed")
+		require.EqualValues(t, 7, store.Get("pending_events", stats.Tags{"workspaceId": "w3", "destId": "w3-d"}).LastValue())
+
+		c.Set("Stats.cardinality.rankingInterval", "0s")
+		count("w2", 1)
+		require.EqualValues(t, 1, store.Get("events", stats.Tags{"workspaceId": "w2", "destId": "w2-d", "reqType": "track"}).LastValue(), "w2 should have replaced w1 as the top workspace")
+	})
+
+	t.Run("concurrent metrics", func(t *testing.T) {
+		store, err := memstats.New()
+		require.NoError(t, err)
+		c := config.New()
+		c.Set("Stats.cardinality.topWorkspaces", 2)
+		c.Set("Stats.cardinality.rankingInterval", "1ms")
+		c.Set("Stats.cardinality.trackSeries", true)
+		l := rmetrics.NewCardinalityLimiter(store, c)
+
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range 100 {
+					l.NewTaggedStat("events", stats.CountType, stats.Tags{"workspaceId": fmt.Sprintf("w%d", (i+j)%5), "reqType": "track"}).Increment()
+				}
+			}()
+		}
+		wg.Wait()
+		require.LessOrEqual(t, l.SeriesCount()["events"], 5+1, "at most the top workspaces and the other ones should have their own series")
+	})
+
+	t.Run("series are not tracked by default", func(t *testing.T) {
+		store, err := memstats.New()
+		require.NoError(t, err)
+		l := rmetrics.NewCardinalityLimiter(store, config.New())
+		l.NewTaggedStat("events", stats.CountType, stats.Tags{"workspaceId": "w1"})
+		require.Empty(t, l.SeriesCount())
+
+		rec := httptest.NewRecorder()
+		rmetrics.SeriesHandler(l)(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("series handler", func(t *testing.T) {
+		store, err := memstats.New()
+		require.NoError(t, err)
+		c := config.New()
+		c.Set("Stats.cardinality.trackSeries", true)
+		c.Set("Stats.cardinality.maxSeriesPerMetric", 2)
+		l := rmetrics.NewCardinalityLimiter(store, c)
+		l.NewStat("uptime", stats.GaugeType)
+		l.NewTaggedStat("events", stats.CountType, stats.Tags{"workspaceId": "w1", "reqType": "track"})
+		l.NewTaggedStat("events", stats.CountType, stats.Tags{"workspaceId": "w2", "reqType": "track"})
+		l.NewTaggedStat("events", stats.CountType, stats.Tags{"reqType": "track", "workspaceId": "w1"})
+		require.Equal(t, map[string]int{"uptime": 1, "events": 2}, l.SeriesCount())
+		l.NewTaggedStat("events", stats.CountType, stats.Tags{"workspaceId": "w3", "reqType": "track"})
+		require.Equal(t, 2, l.SeriesCount()["events"], "series tracked should be capped")
+
+		rec := httptest.NewRecorder()
+		rmetrics.SeriesHandler(l)(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		body, err := io.ReadAll(rec.Body)
+		require.NoError(t, err)
+		require.Contains(t, string(body), "# TYPE rudder_metric_series gauge\n")
+		require.Contains(t, string(body), `rudder_metric_series{metric="events"} 2`+"\n")
+		require.Contains(t, string(body), `rudder_metric_series{metric="uptime"} 1`+"\n")
+
+		rec = httptest.NewRecorder()
+		rmetrics.SeriesHandler(store)(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		require.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,39 @@
+---
+--- Local reports, the aggregated reports kept locally when the postgres reporting sink is enabled
+---
+
+CREATE TABLE IF NOT EXISTS local_reports (
+		id BIGSERIAL PRIMARY KEY,
+		reported_at TIMESTAMP WITH TIME ZONE NOT NULL,
+		workspace_id TEXT NOT NULL,
+		namespace TEXT NOT NULL DEFAULT '',
+		instance_id TEXT NOT NULL DEFAULT '',
+		source_definition_id TEXT DEFAULT '',
+		source_category TEXT DEFAULT '',
+		source_id TEXT DEFAULT '',
+		destination_definition_id TEXT DEFAULT '',
+		destination_id TEXT DEFAULT '',
+		source_task_run_id TEXT DEFAULT '',
+		source_job_id TEXT DEFAULT '',
+		source_job_run_id TEXT DEFAULT '',
+		transformation_id TEXT DEFAULT '',
+		transformation_version_id TEXT DEFAULT '',
+		tracking_plan_id TEXT DEFAULT '',
+		tracking_plan_version INT DEFAULT 0,
+		in_pu TEXT DEFAULT '',
+		pu TEXT DEFAULT '',
+		terminal_state BOOLEAN,
+		initial_state BOOLEAN,
+		status TEXT NOT NULL,
+		status_code INT,
+		event_name TEXT DEFAULT '',
+		event_type TEXT DEFAULT '',
+		error_type TEXT DEFAULT '',
+		count BIGINT,
+		violation_count BIGINT DEFAULT 0,
+		sample_response TEXT,
+		sample_event TEXT
+		);
+
+CREATE INDEX IF NOT EXISTS local_reports_reported_at_index ON local_reports (reported_at);
+CREATE INDEX IF NOT EXISTS local_reports_connection_index ON local_reports (workspace_id, source_id, destination_id, reported_at);
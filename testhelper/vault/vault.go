@@ -0,0 +1,90 @@
+// Package vault provides a local stand-in for the KV v2 secrets engine of a Vault-compatible HTTP API
+package vault
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+// Server is an in-memory KV v2 secrets engine mounted at Mount, serving reads of secrets at /v1/<mount>/data/<path>
+type Server struct {
+	Server *httptest.Server
+	Token  string
+	Mount  string
+
+	secretsMu sync.RWMutex
+	secrets   map[string]map[string]interface{}
+	requests  int
+}
+
+// NewServer starts a new stand-in server requiring the given token in the X-Vault-Token header
+func NewServer(token, mount string) *Server {
+	s := &Server{
+		Token:   token,
+		Mount:   strings.Trim(mount, "/"),
+		secrets: make(map[string]map[string]interface{}),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Put stores the secret's data at the given path, replacing any existing data
+func (s *Server) Put(path string, data map[string]interface{}) {
+	s.secretsMu.Lock()
+	defer s.secretsMu.Unlock()
+	s.secrets[strings.Trim(path, "/")] = data
+}
+
+// Requests returns the number of requests served so far
+func (s *Server) Requests() int {
+	s.secretsMu.RLock()
+	defer s.secretsMu.RUnlock()
+	return s.requests
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.Server.Close()
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.secretsMu.Lock()
+	s.requests++
+	s.secretsMu.Unlock()
+
+	if r.Header.Get("X-Vault-Token") != s.Token {
+		writeErrors(w, http.StatusForbidden, "permission denied")
+		return
+	}
+	path, ok := strings.CutPrefix(r.URL.Path, "/v1/"+s.Mount+"/data/")
+	if !ok || r.Method != http.MethodGet {
+		writeErrors(w, http.StatusNotFound)
+		return
+	}
+	s.secretsMu.RLock()
+	data, ok := s.secrets[path]
+	s.secretsMu.RUnlock()
+	if !ok {
+		writeErrors(w, http.StatusNotFound)
+		return
+	}
+	body, _ := jsonrs.Marshal(map[string]interface{}{
+		"data": map[string]interface{}{
+			"data":     data,
+			"metadata": map[string]interface{}{"version": 1},
+		},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func writeErrors(w http.ResponseWriter, statusCode int, errors ...string) {
+	body, _ := jsonrs.Marshal(map[string]interface{}{"errors": append([]string{}, errors...)})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(body)
+}